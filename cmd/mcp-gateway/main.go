@@ -10,9 +10,14 @@ import (
 	"time"
 
 	"github.com/amoylab/unla/internal/auth"
+	authstorage "github.com/amoylab/unla/internal/auth/storage"
+	"github.com/amoylab/unla/internal/auth/upstream"
 	"github.com/amoylab/unla/internal/common/cnst"
 	"github.com/amoylab/unla/internal/common/config"
 	"github.com/amoylab/unla/internal/core"
+	"github.com/amoylab/unla/internal/core/ratelimit"
+	"github.com/amoylab/unla/internal/core/toolcache"
+	"github.com/amoylab/unla/internal/i18n"
 	"github.com/amoylab/unla/internal/mcp/session"
 	"github.com/amoylab/unla/internal/mcp/storage"
 	"github.com/amoylab/unla/internal/mcp/storage/notifier"
@@ -175,12 +180,65 @@ func run() {
 			zap.Error(err))
 	}
 
+	// Initialize the replay buffer of streamable HTTP streams, resumption is disabled without max_events
+	eventStore, err := session.NewEventStore(logger, &cfg.Session)
+	if err != nil {
+		logger.Fatal("failed to initialize session event store",
+			zap.String("type", cfg.Session.Type),
+			zap.Error(err))
+	}
+
+	// Initialize tool result cache, caching is disabled without a type
+	var toolCache toolcache.Store
+	if cfg.ToolCache.Type != "" {
+		toolCache, err = toolcache.NewStore(logger, &cfg.ToolCache, cfg.Session.Redis)
+		if err != nil {
+			logger.Fatal("failed to initialize tool cache",
+				zap.String("type", cfg.ToolCache.Type),
+				zap.Error(err))
+		}
+	}
+
+	// Initialize translations used to localize tool argument errors
+	translationsPath := cfg.I18n.Path
+	if translationsPath == "" {
+		translationsPath = "configs/i18n"
+	}
+	if err := i18n.InitTranslator(translationsPath); err != nil {
+		logger.Warn("failed to load translations",
+			zap.String("path", translationsPath),
+			zap.Error(err))
+	}
+
+	// Initialize rate limiter, rate limiting is disabled without a type
+	var rateLimiter ratelimit.Limiter
+	if cfg.RateLimit.Type != "" {
+		rateLimiter, err = ratelimit.NewLimiter(logger, &cfg.RateLimit, cfg.Session.Redis)
+		if err != nil {
+			logger.Fatal("failed to initialize rate limiter",
+				zap.String("type", cfg.RateLimit.Type),
+				zap.Error(err))
+		}
+	}
+
 	// Initialize auth service
 	a, err := auth.NewAuth(logger, cfg.Auth)
 	if err != nil {
 		logger.Fatal("Failed to initialize auth service", zap.Error(err))
 	}
 
+	// Upstream OAuth is enabled by its callback URL
+	var upstreamOAuth *upstream.Client
+	if cfg.Auth.Upstream.CallbackURL != "" {
+		upstreamStore, err := authstorage.NewStore(logger, &cfg.Auth.Upstream.Storage)
+		if err != nil {
+			logger.Fatal("failed to initialize upstream OAuth storage",
+				zap.String("type", cfg.Auth.Upstream.Storage.Type),
+				zap.Error(err))
+		}
+		upstreamOAuth = upstream.NewClient(logger, upstreamStore, cfg.Auth.Upstream.CallbackURL)
+	}
+
 	// Initialize tracing BEFORE creating server if enabled
 	var tracingServiceName string
 	if cfg.Tracing.Enabled {
@@ -218,6 +276,14 @@ func run() {
 		a,
 		core.WithForwardConfig(cfg.Forward),
 		core.WithToolAccessConfig(cfg.ToolAccess),
+		core.WithCircuitBreakerConfig(cfg.CircuitBreaker),
+		core.WithTimeoutConfig(cfg.Timeout),
+		core.WithHTTPTransportConfig(cfg.HTTPTransport),
+		core.WithToolCache(toolCache),
+		core.WithRateLimiter(rateLimiter),
+		core.WithEventStore(eventStore),
+		core.WithSessionKeepAlive(cfg.Session.Heartbeat, cfg.Session.IdleTimeout),
+		core.WithUpstreamOAuth(upstreamOAuth),
 		core.WithTraceCapture(cfg.Tracing.Capture),
 		core.WithTracing(tracingServiceName), // Register OTel middleware early
 	)
@@ -226,6 +292,9 @@ func run() {
 	}
 
 	server.EnableMetrics(cfg.Metrics)
+	if err := server.EnableAdmin(cfg.Admin); err != nil {
+		logger.Fatal("failed to enable admin endpoints", zap.Error(err))
+	}
 
 	err = server.RegisterRoutes(ctx)
 	if err != nil {
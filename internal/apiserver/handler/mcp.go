@@ -3,7 +3,6 @@ package handler
 import (
 	"context"
 	"errors"
-	"fmt"
 	"net/http"
 	"sort"
 	"strconv"
@@ -497,6 +496,7 @@ func (h *MCP) HandleListMCPServers(c *gin.Context) {
 			McpServers: dto.FromMCPServerConfigs(server.McpServers),
 			Tools:      dto.FromToolConfigs(server.Tools),
 			Prompts:    dto.FromPromptConfigs(server.Prompts),
+			Resources:  dto.FromResourceConfigs(server.Resources),
 			Servers:    dto.FromServerConfigs(server.Servers),
 			Routers:    dto.FromRouterConfigs(server.Routers),
 			CreatedAt:  server.CreatedAt,
@@ -1065,6 +1065,27 @@ func (h *MCP) fetchCapabilities(ctx context.Context, cfg *config.MCPConfig) (*mc
 		ServerInfo:        mcp.ImplementationSchema{},
 	}
 
+	// Resources of HTTP servers are declared in the config
+	for _, res := range cfg.Resources {
+		if res.IsTemplate() {
+			capabilities.ResourceTemplates = append(capabilities.ResourceTemplates, mcp.MCPResourceTemplate{
+				UriTemplate: res.URITemplate,
+				Name:        res.Name,
+				Description: res.Description,
+				MimeType:    res.MimeType,
+				LastSynced:  capabilities.LastSynced,
+			})
+			continue
+		}
+		capabilities.Resources = append(capabilities.Resources, mcp.MCPResource{
+			Uri:         res.URI,
+			Name:        res.Name,
+			Description: res.Description,
+			MimeType:    res.MimeType,
+			LastSynced:  capabilities.LastSynced,
+		})
+	}
+
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	errChan := make(chan error, len(cfg.McpServers)*4) // 4 operations per server
@@ -1105,9 +1126,9 @@ func (h *MCP) fetchCapabilities(ctx context.Context, cfg *config.MCPConfig) (*mc
 				}()
 			}
 
-			// Fetch tools, prompts, resources, and resource templates concurrently
+			// Fetch tools and prompts concurrently
 			var serverWg sync.WaitGroup
-			serverWg.Add(4)
+			serverWg.Add(2)
 
 			// Fetch tools
 			go func() {
@@ -1167,24 +1188,51 @@ func (h *MCP) fetchCapabilities(ctx context.Context, cfg *config.MCPConfig) (*mc
 				mu.Unlock()
 			}()
 
-			// Resources functionality not yet implemented in transport layer
-			// Emit clear warnings to avoid silent failures and surface partial support
-			go func() {
-				defer serverWg.Done()
-				h.logger.Debug("resource fetching not implemented; skipping",
-					zap.String("server", serverCfg.Name))
-				// Push a non-fatal warning into the error channel for aggregation
-				errChan <- fmt.Errorf("resources fetching not implemented for server %s", serverCfg.Name)
-			}()
-			go func() {
-				defer serverWg.Done()
-				h.logger.Debug("resource template fetching not implemented; skipping",
-					zap.String("server", serverCfg.Name))
-				// Push a non-fatal warning into the error channel for aggregation
-				errChan <- fmt.Errorf("resource templates fetching not implemented for server %s", serverCfg.Name)
-			}()
-
 			serverWg.Wait()
+
+			// Resources are fetched after the other capabilities, transports that stop
+			// between requests must not be used concurrently
+			if resources, err := transport.FetchResources(ctx); err != nil {
+				h.logger.Debug("failed to fetch resources",
+					zap.String("server", serverCfg.Name),
+					zap.Error(err))
+				errChan <- err
+			} else {
+				mcpResources := make([]mcp.MCPResource, len(resources))
+				for i, resource := range resources {
+					mcpResources[i] = mcp.MCPResource{
+						Uri:         resource.URI,
+						Name:        resource.Name,
+						Description: resource.Description,
+						MimeType:    resource.MimeType,
+						LastSynced:  time.Now().UTC(),
+					}
+				}
+				mu.Lock()
+				capabilities.Resources = append(capabilities.Resources, mcpResources...)
+				mu.Unlock()
+			}
+
+			if templates, err := transport.FetchResourceTemplates(ctx); err != nil {
+				h.logger.Debug("failed to fetch resource templates",
+					zap.String("server", serverCfg.Name),
+					zap.Error(err))
+				errChan <- err
+			} else {
+				mcpTemplates := make([]mcp.MCPResourceTemplate, len(templates))
+				for i, tmpl := range templates {
+					mcpTemplates[i] = mcp.MCPResourceTemplate{
+						UriTemplate: tmpl.URITemplate,
+						Name:        tmpl.Name,
+						Description: tmpl.Description,
+						MimeType:    tmpl.MimeType,
+						LastSynced:  time.Now().UTC(),
+					}
+				}
+				mu.Lock()
+				capabilities.ResourceTemplates = append(capabilities.ResourceTemplates, mcpTemplates...)
+				mu.Unlock()
+			}
 		}(mcpServerCfg)
 	}
 
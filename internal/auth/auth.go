@@ -39,6 +39,9 @@ type OAuth2 interface {
 
 	// ValidateToken validates an access token
 	ValidateToken(ctx context.Context, token string) error
+
+	// TokenClientID returns the id of the client an access token was issued to
+	TokenClientID(ctx context.Context, token string) (string, error)
 }
 
 // AuthorizationResponse represents the response from the authorization endpoint
@@ -114,6 +117,15 @@ func (a *auth) ValidateToken(ctx context.Context, token string) error {
 	return a.OAuth2.ValidateToken(ctx, token)
 }
 
+// TokenClientID returns the id of the client an access token was issued to
+func (a *auth) TokenClientID(ctx context.Context, token string) (string, error) {
+	if a.OAuth2 == nil {
+		return "", errorx.ErrOAuth2NotEnabled
+	}
+
+	return a.OAuth2.TokenClientID(ctx, token)
+}
+
 // GetGoogleOAuth returns the Google OAuth provider
 func (a *auth) GetGoogleOAuth() ExternalOAuth {
 	return a.googleOAuth
@@ -448,3 +448,15 @@ func (s *oauth) ValidateToken(ctx context.Context, token string) error {
 
 	return nil
 }
+
+// TokenClientID returns the id of the client an access token was issued to
+func (s *oauth) TokenClientID(ctx context.Context, token string) (string, error) {
+	tokenInfo, err := s.store.GetToken(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	if tokenInfo.ExpiresAt < time.Now().Unix() {
+		return "", errorx.ErrTokenExpired
+	}
+	return tokenInfo.ClientID, nil
+}
@@ -199,6 +199,9 @@ func TestRegister_And_Revoke_And_ValidateToken(t *testing.T) {
 	}
 	assert.NoError(t, o.store.SaveToken(context.Background(), tok))
 	assert.NoError(t, o.ValidateToken(context.Background(), "atk"))
+	clientID, err := o.TokenClientID(context.Background(), "atk")
+	assert.NoError(t, err)
+	assert.Equal(t, tok.ClientID, clientID)
 
 	// Expired token
 	tok2 := &storage.Token{AccessToken: "exp", ExpiresAt: time.Now().Add(-1 * time.Second).Unix()}
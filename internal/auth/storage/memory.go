@@ -15,6 +15,16 @@ type MemoryStorage struct {
 	clients            map[string]*Client
 	authorizationCodes map[string]*AuthorizationCode
 	tokens             map[string]*Token
+
+	upstreamClients        map[string]*UpstreamClient
+	upstreamTokens         map[upstreamTokenKey]*UpstreamToken
+	upstreamAuthorizations map[string]*UpstreamAuthorization
+}
+
+// upstreamTokenKey identifies the token of a user to an upstream MCP server
+type upstreamTokenKey struct {
+	subject  string
+	resource string
 }
 
 // NewMemoryStorage creates a new memory storage instance
@@ -23,6 +33,10 @@ func NewMemoryStorage() *MemoryStorage {
 		clients:            make(map[string]*Client),
 		authorizationCodes: make(map[string]*AuthorizationCode),
 		tokens:             make(map[string]*Token),
+
+		upstreamClients:        make(map[string]*UpstreamClient),
+		upstreamTokens:         make(map[upstreamTokenKey]*UpstreamToken),
+		upstreamAuthorizations: make(map[string]*UpstreamAuthorization),
 	}
 }
 
@@ -188,3 +202,82 @@ func (s *MemoryStorage) DeleteTokensByClientID(ctx context.Context, clientID str
 	}
 	return nil
 }
+
+// SaveUpstreamClient saves the registration at an upstream authorization server
+func (s *MemoryStorage) SaveUpstreamClient(ctx context.Context, client *UpstreamClient) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	client.CreatedAt = time.Now().Unix()
+	s.upstreamClients[client.Issuer] = client
+	return nil
+}
+
+// GetUpstreamClient retrieves the registration at an upstream authorization server
+func (s *MemoryStorage) GetUpstreamClient(ctx context.Context, issuer string) (*UpstreamClient, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if client, ok := s.upstreamClients[issuer]; ok {
+		return client, nil
+	}
+	return nil, errorx.ErrInvalidClient
+}
+
+// SaveUpstreamToken saves the token of a user to an upstream MCP server
+func (s *MemoryStorage) SaveUpstreamToken(ctx context.Context, token *UpstreamToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token.CreatedAt = time.Now().Unix()
+	s.upstreamTokens[upstreamTokenKey{subject: token.Subject, resource: token.Resource}] = token
+	return nil
+}
+
+// GetUpstreamToken retrieves the token of a user to an upstream MCP server, an expired
+// token is returned as well so that it can be refreshed
+func (s *MemoryStorage) GetUpstreamToken(ctx context.Context, subject, resource string) (*UpstreamToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if token, ok := s.upstreamTokens[upstreamTokenKey{subject: subject, resource: resource}]; ok {
+		return token, nil
+	}
+	return nil, errorx.ErrTokenNotFound
+}
+
+// DeleteUpstreamToken deletes the token of a user to an upstream MCP server
+func (s *MemoryStorage) DeleteUpstreamToken(ctx context.Context, subject, resource string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.upstreamTokens, upstreamTokenKey{subject: subject, resource: resource})
+	return nil
+}
+
+// SaveUpstreamAuthorization saves an authorization awaiting its callback
+func (s *MemoryStorage) SaveUpstreamAuthorization(ctx context.Context, authorization *UpstreamAuthorization) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	authorization.CreatedAt = time.Now().Unix()
+	s.upstreamAuthorizations[authorization.State] = authorization
+	return nil
+}
+
+// TakeUpstreamAuthorization retrieves and deletes the authorization of a state
+func (s *MemoryStorage) TakeUpstreamAuthorization(ctx context.Context, state string) (*UpstreamAuthorization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	authorization, ok := s.upstreamAuthorizations[state]
+	if !ok {
+		return nil, errorx.ErrInvalidGrant
+	}
+	delete(s.upstreamAuthorizations, state)
+
+	if authorization.ExpiresAt < time.Now().Unix() {
+		return nil, errorx.ErrAuthorizationCodeExpired
+	}
+	return authorization, nil
+}
@@ -76,3 +76,36 @@ func TestMemoryStorage_Token_Flow(t *testing.T) {
 	_, err = s.GetToken(ctx, "t3")
 	assert.Error(t, err)
 }
+
+func TestMemoryStorage_Upstream_Flow(t *testing.T) {
+	s := NewMemoryStorage()
+	ctx := context.Background()
+
+	_, err := s.GetUpstreamClient(ctx, "https://as.example")
+	assert.ErrorIs(t, err, errorx.ErrInvalidClient)
+	assert.NoError(t, s.SaveUpstreamClient(ctx, &UpstreamClient{Issuer: "https://as.example", ClientID: "c1"}))
+	client, err := s.GetUpstreamClient(ctx, "https://as.example")
+	assert.NoError(t, err)
+	assert.Equal(t, "c1", client.ClientID)
+
+	// expired tokens are kept while they can be refreshed
+	tok := &UpstreamToken{Subject: "client:u1", Resource: "https://mcp.example/mcp", AccessToken: "a1", RefreshToken: "r1", ExpiresAt: time.Now().Add(-time.Minute).Unix()}
+	assert.NoError(t, s.SaveUpstreamToken(ctx, tok))
+	got, err := s.GetUpstreamToken(ctx, "client:u1", "https://mcp.example/mcp")
+	assert.NoError(t, err)
+	assert.Equal(t, "r1", got.RefreshToken)
+	_, err = s.GetUpstreamToken(ctx, "client:u2", "https://mcp.example/mcp")
+	assert.ErrorIs(t, err, errorx.ErrTokenNotFound)
+	assert.NoError(t, s.DeleteUpstreamToken(ctx, "client:u1", "https://mcp.example/mcp"))
+	_, err = s.GetUpstreamToken(ctx, "client:u1", "https://mcp.example/mcp")
+	assert.ErrorIs(t, err, errorx.ErrTokenNotFound)
+
+	// a state can only be used once
+	auth := &UpstreamAuthorization{State: "st1", Subject: "client:u1", CodeVerifier: "v1", ExpiresAt: time.Now().Add(time.Minute).Unix()}
+	assert.NoError(t, s.SaveUpstreamAuthorization(ctx, auth))
+	taken, err := s.TakeUpstreamAuthorization(ctx, "st1")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", taken.CodeVerifier)
+	_, err = s.TakeUpstreamAuthorization(ctx, "st1")
+	assert.ErrorIs(t, err, errorx.ErrInvalidGrant)
+}
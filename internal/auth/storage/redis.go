@@ -51,6 +51,10 @@ const (
 	clientPrefix            = "oauth:client:"
 	authorizationCodePrefix = "oauth:code:"
 	tokenPrefix             = "oauth:token:"
+
+	upstreamClientPrefix        = "oauth:upstream:client:"
+	upstreamTokenPrefix         = "oauth:upstream:token:"
+	upstreamAuthorizationPrefix = "oauth:upstream:authorization:"
 )
 
 // GetClient retrieves a client by ID
@@ -244,3 +248,111 @@ func (s *RedisStorage) DeleteTokensByClientID(ctx context.Context, clientID stri
 
 	return iter.Err()
 }
+
+// upstreamTokenRedisKey returns the key of the token of a user to an upstream MCP server
+func upstreamTokenRedisKey(subject, resource string) string {
+	return upstreamTokenPrefix + subject + ":" + resource
+}
+
+// SaveUpstreamClient saves the registration at an upstream authorization server
+func (s *RedisStorage) SaveUpstreamClient(ctx context.Context, client *UpstreamClient) error {
+	client.CreatedAt = time.Now().Unix()
+	data, err := json.Marshal(client)
+	if err != nil {
+		return err
+	}
+
+	return s.client.Set(ctx, upstreamClientPrefix+client.Issuer, data, 0).Err()
+}
+
+// GetUpstreamClient retrieves the registration at an upstream authorization server
+func (s *RedisStorage) GetUpstreamClient(ctx context.Context, issuer string) (*UpstreamClient, error) {
+	data, err := s.client.Get(ctx, upstreamClientPrefix+issuer).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, errorx.ErrInvalidClient
+		}
+		return nil, err
+	}
+
+	var client UpstreamClient
+	if err := json.Unmarshal(data, &client); err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+// SaveUpstreamToken saves the token of a user to an upstream MCP server. Tokens that can
+// be refreshed are kept until deleted, the others expire with their access token.
+func (s *RedisStorage) SaveUpstreamToken(ctx context.Context, token *UpstreamToken) error {
+	token.CreatedAt = time.Now().Unix()
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	var ttl time.Duration
+	if token.RefreshToken == "" && token.ExpiresAt > 0 {
+		ttl = time.Duration(token.ExpiresAt-token.CreatedAt) * time.Second
+		if ttl <= 0 {
+			return nil
+		}
+	}
+	return s.client.Set(ctx, upstreamTokenRedisKey(token.Subject, token.Resource), data, ttl).Err()
+}
+
+// GetUpstreamToken retrieves the token of a user to an upstream MCP server, an expired
+// token is returned as well so that it can be refreshed
+func (s *RedisStorage) GetUpstreamToken(ctx context.Context, subject, resource string) (*UpstreamToken, error) {
+	data, err := s.client.Get(ctx, upstreamTokenRedisKey(subject, resource)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, errorx.ErrTokenNotFound
+		}
+		return nil, err
+	}
+
+	var token UpstreamToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// DeleteUpstreamToken deletes the token of a user to an upstream MCP server
+func (s *RedisStorage) DeleteUpstreamToken(ctx context.Context, subject, resource string) error {
+	return s.client.Del(ctx, upstreamTokenRedisKey(subject, resource)).Err()
+}
+
+// SaveUpstreamAuthorization saves an authorization awaiting its callback
+func (s *RedisStorage) SaveUpstreamAuthorization(ctx context.Context, authorization *UpstreamAuthorization) error {
+	authorization.CreatedAt = time.Now().Unix()
+	data, err := json.Marshal(authorization)
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Duration(authorization.ExpiresAt-authorization.CreatedAt) * time.Second
+	return s.client.Set(ctx, upstreamAuthorizationPrefix+authorization.State, data, ttl).Err()
+}
+
+// TakeUpstreamAuthorization retrieves and deletes the authorization of a state
+func (s *RedisStorage) TakeUpstreamAuthorization(ctx context.Context, state string) (*UpstreamAuthorization, error) {
+	data, err := s.client.GetDel(ctx, upstreamAuthorizationPrefix+state).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, errorx.ErrInvalidGrant
+		}
+		return nil, err
+	}
+
+	var authorization UpstreamAuthorization
+	if err := json.Unmarshal(data, &authorization); err != nil {
+		return nil, err
+	}
+
+	if authorization.ExpiresAt < time.Now().Unix() {
+		return nil, errorx.ErrAuthorizationCodeExpired
+	}
+	return &authorization, nil
+}
@@ -19,6 +19,19 @@ type Store interface {
 	GetToken(ctx context.Context, accessToken string) (*Token, error)
 	DeleteToken(ctx context.Context, accessToken string) error
 	DeleteTokensByClientID(ctx context.Context, clientID string) error
+
+	// Upstream data is kept for the gateway acting as OAuth client of upstream MCP servers
+	SaveUpstreamClient(ctx context.Context, client *UpstreamClient) error
+	GetUpstreamClient(ctx context.Context, issuer string) (*UpstreamClient, error)
+
+	SaveUpstreamToken(ctx context.Context, token *UpstreamToken) error
+	GetUpstreamToken(ctx context.Context, subject, resource string) (*UpstreamToken, error)
+	DeleteUpstreamToken(ctx context.Context, subject, resource string) error
+
+	SaveUpstreamAuthorization(ctx context.Context, authorization *UpstreamAuthorization) error
+	// TakeUpstreamAuthorization returns the authorization of the state and deletes it, a
+	// state can only be used once
+	TakeUpstreamAuthorization(ctx context.Context, state string) (*UpstreamAuthorization, error)
 }
 
 // Client represents an OAuth2 client
@@ -56,3 +69,40 @@ type Token struct {
 	ExpiresAt    int64    `json:"expires_at"`
 	CreatedAt    int64    `json:"created_at"`
 }
+
+// UpstreamClient represents the registration of the gateway at the authorization server
+// of an upstream MCP server
+type UpstreamClient struct {
+	Issuer       string `json:"issuer"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	RedirectURI  string `json:"redirect_uri"`
+	CreatedAt    int64  `json:"created_at"`
+}
+
+// UpstreamToken represents the token of a gateway user to an upstream MCP server, it is
+// kept past the expiry of the access token while it can be refreshed
+type UpstreamToken struct {
+	Subject      string   `json:"subject"`
+	Resource     string   `json:"resource"`
+	Issuer       string   `json:"issuer"`
+	AccessToken  string   `json:"access_token"`
+	TokenType    string   `json:"token_type"`
+	RefreshToken string   `json:"refresh_token,omitempty"`
+	Scope        []string `json:"scope,omitempty"`
+	ExpiresAt    int64    `json:"expires_at,omitempty"` // 0 when the token does not expire
+	CreatedAt    int64    `json:"created_at"`
+}
+
+// UpstreamAuthorization represents an authorization of an upstream MCP server awaiting
+// its callback
+type UpstreamAuthorization struct {
+	State        string `json:"state"`
+	Subject      string `json:"subject"`
+	Resource     string `json:"resource"`
+	Issuer       string `json:"issuer"`
+	CodeVerifier string `json:"code_verifier"`
+	RedirectURI  string `json:"redirect_uri"`
+	ExpiresAt    int64  `json:"expires_at"`
+	CreatedAt    int64  `json:"created_at"`
+}
@@ -0,0 +1,153 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// metadataTTL is how long discovered metadata is used before it is fetched again
+const metadataTTL = time.Hour
+
+// resourceMetadata is the OAuth protected resource metadata of an MCP server (RFC 9728)
+type resourceMetadata struct {
+	Resource             string   `json:"resource"`
+	AuthorizationServers []string `json:"authorization_servers"`
+	ScopesSupported      []string `json:"scopes_supported,omitempty"`
+}
+
+// serverMetadata is the metadata of an authorization server (RFC 8414)
+type serverMetadata struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	RegistrationEndpoint          string   `json:"registration_endpoint,omitempty"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported,omitempty"`
+}
+
+// discovery is the authorization server of an MCP server with the metadata of both
+type discovery struct {
+	resource  resourceMetadata
+	server    serverMetadata
+	fetchedAt time.Time
+}
+
+// discover returns the authorization server of the MCP server at serverURL. Servers
+// without protected resource metadata are their own authorization server, as in earlier
+// revisions of the MCP authorization spec.
+func (c *Client) discover(ctx context.Context, serverURL string) (*discovery, error) {
+	c.mu.Lock()
+	cached, ok := c.discoveries[serverURL]
+	c.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < metadataTTL {
+		return cached, nil
+	}
+
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server url: %w", err)
+	}
+	origin := u.Scheme + "://" + u.Host
+
+	d := &discovery{fetchedAt: time.Now()}
+	found, err := c.fetchFirst(ctx, &d.resource, wellKnownURLs(u, "oauth-protected-resource")...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch protected resource metadata: %w", err)
+	}
+	issuer := origin
+	if found && len(d.resource.AuthorizationServers) > 0 {
+		issuer = strings.TrimSuffix(d.resource.AuthorizationServers[0], "/")
+	}
+
+	server, err := c.serverMetadata(ctx, issuer)
+	if err != nil {
+		return nil, err
+	}
+	d.server = *server
+
+	c.mu.Lock()
+	c.discoveries[serverURL] = d
+	c.mu.Unlock()
+	return d, nil
+}
+
+// serverMetadata fetches the metadata of the authorization server of issuer. Issuers
+// without metadata get the default endpoints under their URL.
+func (c *Client) serverMetadata(ctx context.Context, issuer string) (*serverMetadata, error) {
+	u, err := url.Parse(issuer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid issuer: %w", err)
+	}
+
+	var metadata serverMetadata
+	urls := append(wellKnownURLs(u, "oauth-authorization-server"), wellKnownURLs(u, "openid-configuration")...)
+	if u.Path != "" && u.Path != "/" {
+		urls = append(urls, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration")
+	}
+	found, err := c.fetchFirst(ctx, &metadata, urls...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch authorization server metadata: %w", err)
+	}
+	if !found {
+		base := strings.TrimSuffix(issuer, "/")
+		metadata = serverMetadata{
+			Issuer:                issuer,
+			AuthorizationEndpoint: base + "/authorize",
+			TokenEndpoint:         base + "/token",
+			RegistrationEndpoint:  base + "/register",
+		}
+	}
+	if metadata.Issuer == "" {
+		metadata.Issuer = issuer
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" {
+		return nil, fmt.Errorf("authorization server %s has no authorization or token endpoint", issuer)
+	}
+	return &metadata, nil
+}
+
+// wellKnownURLs returns the well-known URLs of a document for u, with the path of u
+// inserted after the well-known segment first and the document at the origin last
+func wellKnownURLs(u *url.URL, name string) []string {
+	origin := u.Scheme + "://" + u.Host
+	urls := make([]string, 0, 2)
+	if path := strings.TrimSuffix(u.Path, "/"); path != "" {
+		urls = append(urls, origin+"/.well-known/"+name+path)
+	}
+	return append(urls, origin+"/.well-known/"+name)
+}
+
+// fetchFirst decodes the first of the JSON documents that exists into v, it reports
+// whether any did
+func (c *Client) fetchFirst(ctx context.Context, v any, urls ...string) (bool, error) {
+	for _, u := range urls {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return false, err
+		}
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return false, err
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+		_ = resp.Body.Close()
+		if err != nil {
+			return false, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			continue
+		}
+		if err := json.Unmarshal(body, v); err != nil {
+			return false, fmt.Errorf("invalid metadata at %s: %w", u, err)
+		}
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,436 @@
+// Package upstream makes the gateway an OAuth client of the upstream MCP servers that
+// require the MCP authorization flow. Each gateway user authorizes the gateway at the
+// server once, their tokens are kept in the auth storage and refreshed when they expire.
+package upstream
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/amoylab/unla/internal/auth/storage"
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/common/errorx"
+)
+
+const (
+	// authorizationTTL is how long a user has to complete an authorization
+	authorizationTTL = 10 * time.Minute
+	// expiryLeeway refreshes access tokens shortly before they expire
+	expiryLeeway = 30 * time.Second
+	// maxResponseSize bounds the responses read from authorization servers
+	maxResponseSize = 1 << 20
+	requestTimeout  = 30 * time.Second
+)
+
+var (
+	// ErrNoSubject is returned for requests without a gateway user to authorize
+	ErrNoSubject = errors.New("no gateway user to authorize at the upstream server")
+	// errRejected wraps the error responses of authorization servers
+	errRejected = errors.New("rejected by the authorization server")
+)
+
+// AuthorizationRequiredError is returned when the user has not authorized the gateway at
+// an upstream server yet, or the authorization was revoked
+type AuthorizationRequiredError struct {
+	Server string
+	// URL is the page of the authorization server the user has to visit
+	URL string
+}
+
+func (e *AuthorizationRequiredError) Error() string {
+	return fmt.Sprintf("authorization required: open %s to authorize access to %s", e.URL, e.Server)
+}
+
+// Server is an upstream MCP server protected by OAuth
+type Server struct {
+	Name  string
+	URL   string
+	OAuth config.MCPServerOAuthConfig
+}
+
+// Client performs the MCP authorization flow for the users of the gateway
+type Client struct {
+	logger      *zap.Logger
+	store       storage.Store
+	callbackURL string
+	httpClient  *http.Client
+
+	mu sync.Mutex
+	// discoveries caches the authorization servers of MCP servers by server URL
+	discoveries map[string]*discovery
+	// refreshes makes concurrent requests of a user share a single refresh
+	refreshes singleflight.Group
+}
+
+// NewClient creates a client redirecting users to callbackURL once they authorized the
+// gateway, tokens are kept in store
+func NewClient(logger *zap.Logger, store storage.Store, callbackURL string) *Client {
+	return &Client{
+		logger:      logger.Named("auth.upstream"),
+		store:       store,
+		callbackURL: callbackURL,
+		httpClient:  &http.Client{Timeout: requestTimeout},
+		discoveries: make(map[string]*discovery),
+	}
+}
+
+// Token returns an access token of the user for the server, refreshing it when it
+// expired. Users without a token get an *AuthorizationRequiredError with the URL of a
+// new authorization.
+func (c *Client) Token(ctx context.Context, server Server, subject string) (string, error) {
+	if subject == "" {
+		return "", ErrNoSubject
+	}
+
+	token, err := c.store.GetUpstreamToken(ctx, subject, server.URL)
+	if err != nil && !errors.Is(err, errorx.ErrTokenNotFound) {
+		return "", fmt.Errorf("failed to get upstream token: %w", err)
+	}
+	if token != nil && !expired(token) {
+		return token.AccessToken, nil
+	}
+
+	if token != nil && token.RefreshToken != "" {
+		refreshed, err, _ := c.refreshes.Do(subject+"\x00"+server.URL, func() (any, error) {
+			return c.refresh(ctx, server, token)
+		})
+		if err == nil {
+			return refreshed.(*storage.UpstreamToken).AccessToken, nil
+		}
+		// the token is only dropped when the authorization server refused it, not when it
+		// could not be reached
+		if !errors.Is(err, errRejected) {
+			return "", fmt.Errorf("failed to refresh upstream token: %w", err)
+		}
+		c.logger.Info("failed to refresh upstream token, authorization required",
+			zap.String("server", server.Name),
+			zap.String("subject", subject),
+			zap.Error(err))
+		if err := c.store.DeleteUpstreamToken(ctx, subject, server.URL); err != nil {
+			return "", fmt.Errorf("failed to delete upstream token: %w", err)
+		}
+	}
+
+	authURL, err := c.Authorize(ctx, server, subject)
+	if err != nil {
+		return "", err
+	}
+	return "", &AuthorizationRequiredError{Server: server.Name, URL: authURL}
+}
+
+// Revoke forgets the token of the user for the server, its next request starts a new
+// authorization. It is called when the server rejected the token.
+func (c *Client) Revoke(ctx context.Context, server Server, subject string) error {
+	return c.store.DeleteUpstreamToken(ctx, subject, server.URL)
+}
+
+// Authorize starts an authorization of the server by the user and returns the URL of
+// the authorization server the user has to open
+func (c *Client) Authorize(ctx context.Context, server Server, subject string) (string, error) {
+	d, err := c.discover(ctx, server.URL)
+	if err != nil {
+		return "", err
+	}
+	client, err := c.client(ctx, server, d)
+	if err != nil {
+		return "", err
+	}
+
+	verifier := oauth2.GenerateVerifier()
+	state := randomString(24)
+	authorization := &storage.UpstreamAuthorization{
+		State:        state,
+		Subject:      subject,
+		Resource:     server.URL,
+		Issuer:       d.server.Issuer,
+		CodeVerifier: verifier,
+		RedirectURI:  c.callbackURL,
+		ExpiresAt:    time.Now().Add(authorizationTTL).Unix(),
+	}
+	if err := c.store.SaveUpstreamAuthorization(ctx, authorization); err != nil {
+		return "", fmt.Errorf("failed to save upstream authorization: %w", err)
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", client.ClientID)
+	params.Set("redirect_uri", c.callbackURL)
+	params.Set("state", state)
+	params.Set("code_challenge", oauth2.S256ChallengeFromVerifier(verifier))
+	params.Set("code_challenge_method", "S256")
+	params.Set("resource", resourceIndicator(server, d))
+	if scopes := requestedScopes(server, d); len(scopes) > 0 {
+		params.Set("scope", strings.Join(scopes, " "))
+	}
+
+	sep := "?"
+	if strings.Contains(d.server.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.server.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+// Callback completes the authorization of state by exchanging the code for a token of
+// the user, servers returns the server the authorization was started for by URL
+func (c *Client) Callback(ctx context.Context, state, code string, servers func(url string) (Server, bool)) (*storage.UpstreamToken, error) {
+	authorization, err := c.store.TakeUpstreamAuthorization(ctx, state)
+	if err != nil {
+		return nil, fmt.Errorf("unknown or expired authorization: %w", err)
+	}
+	server, ok := servers(authorization.Resource)
+	if !ok {
+		return nil, fmt.Errorf("upstream server %s is no longer configured", authorization.Resource)
+	}
+	d, err := c.discover(ctx, server.URL)
+	if err != nil {
+		return nil, err
+	}
+	if d.server.Issuer != authorization.Issuer {
+		return nil, fmt.Errorf("authorization server of %s changed during the authorization", server.Name)
+	}
+	client, err := c.client(ctx, server, d)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("grant_type", "authorization_code")
+	params.Set("code", code)
+	params.Set("redirect_uri", authorization.RedirectURI)
+	params.Set("code_verifier", authorization.CodeVerifier)
+	params.Set("resource", resourceIndicator(server, d))
+	token, err := c.requestToken(ctx, d.server.TokenEndpoint, client, params)
+	if err != nil {
+		return nil, err
+	}
+
+	token.Subject = authorization.Subject
+	token.Resource = server.URL
+	token.Issuer = d.server.Issuer
+	if err := c.store.SaveUpstreamToken(ctx, token); err != nil {
+		return nil, fmt.Errorf("failed to save upstream token: %w", err)
+	}
+	c.logger.Info("authorized upstream server",
+		zap.String("server", server.Name),
+		zap.String("subject", token.Subject))
+	return token, nil
+}
+
+// refresh exchanges the refresh token of an expired token for a new one
+func (c *Client) refresh(ctx context.Context, server Server, token *storage.UpstreamToken) (*storage.UpstreamToken, error) {
+	d, err := c.discover(ctx, server.URL)
+	if err != nil {
+		return nil, err
+	}
+	client, err := c.client(ctx, server, d)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("grant_type", "refresh_token")
+	params.Set("refresh_token", token.RefreshToken)
+	params.Set("resource", resourceIndicator(server, d))
+	refreshed, err := c.requestToken(ctx, d.server.TokenEndpoint, client, params)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshed.Subject = token.Subject
+	refreshed.Resource = token.Resource
+	refreshed.Issuer = d.server.Issuer
+	// authorization servers that do not rotate refresh tokens omit them
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = token.RefreshToken
+	}
+	if err := c.store.SaveUpstreamToken(ctx, refreshed); err != nil {
+		return nil, fmt.Errorf("failed to save upstream token: %w", err)
+	}
+	return refreshed, nil
+}
+
+// client returns the client of the gateway at the authorization server, a configured
+// client or one registered dynamically. Registrations are kept for each issuer and made
+// again when the callback URL changed.
+func (c *Client) client(ctx context.Context, server Server, d *discovery) (*storage.UpstreamClient, error) {
+	if server.OAuth.ClientID != "" {
+		return &storage.UpstreamClient{
+			Issuer:       d.server.Issuer,
+			ClientID:     server.OAuth.ClientID,
+			ClientSecret: server.OAuth.ClientSecret,
+			RedirectURI:  c.callbackURL,
+		}, nil
+	}
+
+	client, err := c.store.GetUpstreamClient(ctx, d.server.Issuer)
+	if err == nil && client.RedirectURI == c.callbackURL {
+		return client, nil
+	}
+	if err != nil && !errors.Is(err, errorx.ErrInvalidClient) {
+		return nil, fmt.Errorf("failed to get upstream client: %w", err)
+	}
+	if d.server.RegistrationEndpoint == "" {
+		return nil, fmt.Errorf("authorization server %s does not support dynamic client registration, configure a clientId for %s", d.server.Issuer, server.Name)
+	}
+
+	client, err = c.register(ctx, d.server)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.store.SaveUpstreamClient(ctx, client); err != nil {
+		return nil, fmt.Errorf("failed to save upstream client: %w", err)
+	}
+	c.logger.Info("registered at upstream authorization server",
+		zap.String("issuer", client.Issuer),
+		zap.String("client_id", client.ClientID))
+	return client, nil
+}
+
+// register registers the gateway at the authorization server (RFC 7591)
+func (c *Client) register(ctx context.Context, server serverMetadata) (*storage.UpstreamClient, error) {
+	body, err := json.Marshal(map[string]any{
+		"client_name":                cnst.AppName,
+		"redirect_uris":              []string{c.callbackURL},
+		"grant_types":                []string{"authorization_code", "refresh_token"},
+		"response_types":             []string{"code"},
+		"token_endpoint_auth_method": "none",
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.RegistrationEndpoint, strings.NewReader(string(body)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	var registration struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+	}
+	if err := c.do(req, &registration); err != nil {
+		return nil, fmt.Errorf("client registration failed: %w", err)
+	}
+	if registration.ClientID == "" {
+		return nil, fmt.Errorf("client registration failed: no client_id returned")
+	}
+	return &storage.UpstreamClient{
+		Issuer:       server.Issuer,
+		ClientID:     registration.ClientID,
+		ClientSecret: registration.ClientSecret,
+		RedirectURI:  c.callbackURL,
+	}, nil
+}
+
+// requestToken requests a token at the token endpoint, confidential clients authenticate
+// with HTTP basic authentication
+func (c *Client) requestToken(ctx context.Context, endpoint string, client *storage.UpstreamClient, params url.Values) (*storage.UpstreamToken, error) {
+	if client.ClientSecret == "" {
+		params.Set("client_id", client.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if client.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(client.ClientID), url.QueryEscape(client.ClientSecret))
+	}
+
+	var resp struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+		Scope        string `json:"scope"`
+	}
+	if err := c.do(req, &resp); err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	if resp.AccessToken == "" {
+		return nil, fmt.Errorf("token request failed: no access_token returned")
+	}
+
+	token := &storage.UpstreamToken{
+		AccessToken:  resp.AccessToken,
+		TokenType:    resp.TokenType,
+		RefreshToken: resp.RefreshToken,
+		Scope:        strings.Fields(resp.Scope),
+	}
+	if resp.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Unix() + resp.ExpiresIn
+	}
+	return token, nil
+}
+
+// do sends a request to the authorization server and decodes its JSON response into v,
+// OAuth errors are returned with their code and description
+func (c *Client) do(req *http.Request, v any) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var oauthErr errorx.OAuth2Error
+		if json.Unmarshal(body, &oauthErr) == nil && oauthErr.ErrorType != "" {
+			return fmt.Errorf("%w: status %d: %s %s", errRejected, resp.StatusCode, oauthErr.ErrorType, oauthErr.ErrorDescription)
+		}
+		return fmt.Errorf("%w: status %d", errRejected, resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+// expired reports whether the access token expired or is about to
+func expired(token *storage.UpstreamToken) bool {
+	return token.ExpiresAt > 0 && time.Now().Add(expiryLeeway).Unix() >= token.ExpiresAt
+}
+
+// resourceIndicator is the resource tokens are requested for (RFC 8707), the one of the
+// protected resource metadata or else the URL of the server
+func resourceIndicator(server Server, d *discovery) string {
+	if d.resource.Resource != "" {
+		return d.resource.Resource
+	}
+	return server.URL
+}
+
+// requestedScopes are the configured scopes, or those the server supports
+func requestedScopes(server Server, d *discovery) []string {
+	if len(server.OAuth.Scopes) > 0 {
+		return server.OAuth.Scopes
+	}
+	return d.resource.ScopesSupported
+}
+
+// randomString returns n random bytes encoded for URLs
+func randomString(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,208 @@
+package upstream
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/auth/storage"
+	"github.com/amoylab/unla/internal/common/errorx"
+)
+
+const testCallbackURL = "https://gateway.example/oauth/upstream/callback"
+
+// testAuthServer is an MCP server at /mcp with its authorization server at /as
+type testAuthServer struct {
+	*httptest.Server
+	registrations atomic.Int32
+	tokenRequests atomic.Int32
+	// challenge is the PKCE challenge of the last authorization
+	challenge string
+	// refresh answers refresh token grants, it rejects them when false
+	refresh bool
+}
+
+func newTestAuthServer(t *testing.T) *testAuthServer {
+	t.Helper()
+	as := &testAuthServer{refresh: true}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/oauth-protected-resource/mcp", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"resource":              as.URL + "/mcp",
+			"authorization_servers": []string{as.URL + "/as"},
+			"scopes_supported":      []string{"read"},
+		})
+	})
+	mux.HandleFunc("/.well-known/oauth-authorization-server/as", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"issuer":                 as.URL + "/as",
+			"authorization_endpoint": as.URL + "/as/authorize",
+			"token_endpoint":         as.URL + "/as/token",
+			"registration_endpoint":  as.URL + "/as/register",
+		})
+	})
+	mux.HandleFunc("/as/register", func(w http.ResponseWriter, r *http.Request) {
+		as.registrations.Add(1)
+		var req struct {
+			RedirectURIs []string `json:"redirect_uris"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		assert.Equal(t, []string{testCallbackURL}, req.RedirectURIs)
+		writeJSON(w, http.StatusCreated, map[string]any{"client_id": "registered"})
+	})
+	mux.HandleFunc("/as/token", func(w http.ResponseWriter, r *http.Request) {
+		as.tokenRequests.Add(1)
+		_ = r.ParseForm()
+		assert.Equal(t, "registered", r.PostForm.Get("client_id"))
+		assert.Equal(t, as.URL+"/mcp", r.PostForm.Get("resource"))
+		switch r.PostForm.Get("grant_type") {
+		case "authorization_code":
+			sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+			if r.PostForm.Get("code") != "code1" || base64.RawURLEncoding.EncodeToString(sum[:]) != as.challenge {
+				writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_grant"})
+				return
+			}
+			// expires within the leeway, the next use refreshes it
+			writeJSON(w, http.StatusOK, map[string]any{"access_token": "access1", "token_type": "Bearer", "refresh_token": "refresh1", "expires_in": 1})
+		case "refresh_token":
+			if !as.refresh || r.PostForm.Get("refresh_token") != "refresh1" {
+				writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_grant"})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"access_token": "access2", "token_type": "Bearer", "expires_in": 3600})
+		}
+	})
+	as.Server = httptest.NewServer(mux)
+	t.Cleanup(as.Close)
+	return as
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// requireAuthorization asserts that err asks for an authorization and returns its URL
+func requireAuthorization(t *testing.T, err error) *url.URL {
+	t.Helper()
+	var authErr *AuthorizationRequiredError
+	require.True(t, errors.As(err, &authErr), "expected an authorization to be required, got %v", err)
+	u, err := url.Parse(authErr.URL)
+	require.NoError(t, err)
+	return u
+}
+
+func TestClient_AuthorizationFlow(t *testing.T) {
+	as := newTestAuthServer(t)
+	store := storage.NewMemoryStorage()
+	c := NewClient(zap.NewNop(), store, testCallbackURL)
+	server := Server{Name: "remote", URL: as.URL + "/mcp"}
+	ctx := context.Background()
+
+	_, err := c.Token(ctx, server, "")
+	assert.ErrorIs(t, err, ErrNoSubject)
+
+	_, err = c.Token(ctx, server, "client:u1")
+	authURL := requireAuthorization(t, err)
+	query := authURL.Query()
+	assert.Equal(t, as.URL+"/as/authorize", authURL.Scheme+"://"+authURL.Host+authURL.Path)
+	assert.Equal(t, "registered", query.Get("client_id"))
+	assert.Equal(t, testCallbackURL, query.Get("redirect_uri"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.Equal(t, as.URL+"/mcp", query.Get("resource"))
+	assert.Equal(t, "read", query.Get("scope"))
+	as.challenge = query.Get("code_challenge")
+
+	// the registration is kept for the next authorizations
+	_, err = c.Token(ctx, server, "client:u2")
+	requireAuthorization(t, err)
+	assert.Equal(t, int32(1), as.registrations.Load())
+
+	servers := func(u string) (Server, bool) { return server, u == server.URL }
+	token, err := c.Callback(ctx, query.Get("state"), "code1", servers)
+	require.NoError(t, err)
+	assert.Equal(t, "client:u1", token.Subject)
+	assert.Equal(t, "access1", token.AccessToken)
+
+	// states are single use
+	_, err = c.Callback(ctx, query.Get("state"), "code1", servers)
+	assert.Error(t, err)
+
+	// the expired token is refreshed, the refresh token is kept when none is returned
+	access, err := c.Token(ctx, server, "client:u1")
+	require.NoError(t, err)
+	assert.Equal(t, "access2", access)
+	stored, err := store.GetUpstreamToken(ctx, "client:u1", server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "refresh1", stored.RefreshToken)
+
+	requests := as.tokenRequests.Load()
+	access, err = c.Token(ctx, server, "client:u1")
+	require.NoError(t, err)
+	assert.Equal(t, "access2", access)
+	assert.Equal(t, requests, as.tokenRequests.Load())
+
+	// other users have their own tokens
+	_, err = c.Token(ctx, server, "client:u2")
+	requireAuthorization(t, err)
+}
+
+func TestClient_RefreshRejected(t *testing.T) {
+	as := newTestAuthServer(t)
+	as.refresh = false
+	store := storage.NewMemoryStorage()
+	c := NewClient(zap.NewNop(), store, testCallbackURL)
+	server := Server{Name: "remote", URL: as.URL + "/mcp"}
+	ctx := context.Background()
+
+	require.NoError(t, store.SaveUpstreamToken(ctx, &storage.UpstreamToken{
+		Subject:      "client:u1",
+		Resource:     server.URL,
+		AccessToken:  "access1",
+		RefreshToken: "refresh1",
+		ExpiresAt:    time.Now().Add(-time.Minute).Unix(),
+	}))
+
+	_, err := c.Token(ctx, server, "client:u1")
+	requireAuthorization(t, err)
+	_, err = store.GetUpstreamToken(ctx, "client:u1", server.URL)
+	assert.ErrorIs(t, err, errorx.ErrTokenNotFound)
+}
+
+func TestClient_ServerMetadataDefaults(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+	c := NewClient(zap.NewNop(), storage.NewMemoryStorage(), testCallbackURL)
+
+	// servers without metadata are their own authorization server
+	d, err := c.discover(context.Background(), srv.URL+"/mcp")
+	require.NoError(t, err)
+	assert.Equal(t, srv.URL, d.server.Issuer)
+	assert.Equal(t, srv.URL+"/authorize", d.server.AuthorizationEndpoint)
+	assert.Equal(t, srv.URL+"/token", d.server.TokenEndpoint)
+	assert.Equal(t, srv.URL+"/register", d.server.RegistrationEndpoint)
+}
+
+func TestWellKnownURLs(t *testing.T) {
+	u, _ := url.Parse("https://mcp.example/tenant/mcp")
+	assert.Equal(t, []string{
+		"https://mcp.example/.well-known/oauth-protected-resource/tenant/mcp",
+		"https://mcp.example/.well-known/oauth-protected-resource",
+	}, wellKnownURLs(u, "oauth-protected-resource"))
+
+	u, _ = url.Parse("https://mcp.example")
+	assert.Equal(t, []string{"https://mcp.example/.well-known/oauth-authorization-server"}, wellKnownURLs(u, "oauth-authorization-server"))
+}
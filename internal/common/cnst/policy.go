@@ -8,4 +8,6 @@ const (
 	PolicyOnStart MCPStartupPolicy = "onStart"
 	// PolicyOnDemand represents the policy to connect when needed
 	PolicyOnDemand MCPStartupPolicy = "onDemand"
+	// PolicyPerSession represents the policy to start a dedicated stdio process for every session
+	PolicyPerSession MCPStartupPolicy = "perSession"
 )
@@ -9,6 +9,7 @@ import (
 func TestMCPStartupPolicy_Constants(t *testing.T) {
 	assert.Equal(t, MCPStartupPolicy("onStart"), PolicyOnStart)
 	assert.Equal(t, MCPStartupPolicy("onDemand"), PolicyOnDemand)
+	assert.Equal(t, MCPStartupPolicy("perSession"), PolicyPerSession)
 }
 
 func TestMCPStartupPolicy_String(t *testing.T) {
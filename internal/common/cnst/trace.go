@@ -13,6 +13,12 @@ const (
 	// SpanHTTPToolExecute represents executing an HTTP tool
 	SpanHTTPToolExecute = "mcp.http_tool.execute"
 
+	// SpanHTTPResourceRead represents reading an HTTP resource
+	SpanHTTPResourceRead = "mcp.http_resource.read"
+
+	// SpanHTTPCompletion represents looking up completion values at an HTTP endpoint
+	SpanHTTPCompletion = "mcp.http_completion.lookup"
+
 	// SpanSSEConnect represents establishing SSE connection on server
 	SpanSSEConnect = "mcp.sse.connect"
 
@@ -26,23 +32,48 @@ const (
 	SpanTransportSSECallTool          = "mcp.transport.sse.call_tool"
 	SpanTransportStreamableFetchTools = "mcp.transport.streamable.fetch_tools"
 	SpanTransportStreamableCallTool   = "mcp.transport.streamable.call_tool"
+
+	SpanTransportStdIOReadResource      = "mcp.transport.stdio.read_resource"
+	SpanTransportSSEReadResource        = "mcp.transport.sse.read_resource"
+	SpanTransportStreamableReadResource = "mcp.transport.streamable.read_resource"
+)
+
+// Common span event names
+const (
+	// EventHTTPToolAttempt records a single downstream request attempt of an HTTP tool
+	EventHTTPToolAttempt = "http.tool.attempt"
+	// EventCircuitBreakerRejected records a call rejected by an open circuit breaker
+	EventCircuitBreakerRejected = "circuit_breaker.rejected"
+	// EventRateLimitRejected records a request rejected by a rate limit
+	EventRateLimitRejected = "rate_limit.rejected"
+	// EventAsyncJobPoll records a poll of the status of an async tool job
+	EventAsyncJobPoll = "async_job.poll"
 )
 
 // Common attribute keys
 const (
-	AttrTransportType       = "transport.type"
-	AttrMCPTool             = "mcp.tool"
-	AttrMCPSessionID        = "mcp.session_id"
-	AttrMCPPrefix           = "mcp.prefix"
-	AttrClientAddr          = "client.remote_addr"
-	AttrClientUserAgent     = "client.user_agent"
-	AttrErrorReason         = "error.reason"
-	AttrMCPErrorCode        = "mcp.error_code"
-	AttrHTTPStatusCode      = "http.status_code"
-	AttrHTTPRespType        = "http.response.content_type"
-	AttrHTTPRespSize        = "http.response.size"
-	AttrHTTPErrorPreview    = "http.response.error_preview"
-	AttrHTTPRespBody        = "http.response.body"
-	AttrDownstreamArgPrefix = "downstream.arg."
-	AttrDownstreamReqBody   = "downstream.request.body"
+	AttrTransportType         = "transport.type"
+	AttrMCPTool               = "mcp.tool"
+	AttrMCPResourceURI        = "mcp.resource.uri"
+	AttrMCPCompletionArgument = "mcp.completion.argument"
+	AttrMCPSessionID          = "mcp.session_id"
+	AttrMCPPrefix             = "mcp.prefix"
+	AttrClientAddr            = "client.remote_addr"
+	AttrClientUserAgent       = "client.user_agent"
+	AttrErrorReason           = "error.reason"
+	AttrMCPErrorCode          = "mcp.error_code"
+	AttrHTTPStatusCode        = "http.status_code"
+	AttrHTTPRespType          = "http.response.content_type"
+	AttrHTTPRespSize          = "http.response.size"
+	AttrHTTPErrorPreview      = "http.response.error_preview"
+	AttrHTTPRespBody          = "http.response.body"
+	AttrDownstreamArgPrefix   = "downstream.arg."
+	AttrDownstreamReqBody     = "downstream.request.body"
+	AttrHTTPAttempt           = "http.attempt"
+	AttrHTTPAttemptDuration   = "http.attempt.duration_ms"
+	AttrHTTPRetryBackoff      = "http.retry.backoff_ms"
+	AttrCircuitBreakerKey     = "circuit_breaker.key"
+	AttrRateLimitLevel        = "rate_limit.level"
+	AttrRateLimitKeyBy        = "rate_limit.key_by"
+	AttrAsyncPoll             = "async_job.poll"
 )
@@ -35,21 +35,57 @@ type (
 
 	// MCPGatewayConfig represents the MCP gateway configuration
 	MCPGatewayConfig struct {
-		Port           int              `yaml:"port"`
-		ReloadPort     int              `yaml:"reload_port"`
-		ReloadInterval time.Duration    `yaml:"reload_interval"`
-		ReloadSwitch   bool             `yaml:"reload_switch"`
-		Forward        ForwardConfig    `yaml:"forward"`
-		ToolAccess     ToolAccessConfig `yaml:"tool_access"`
-		PID            string           `yaml:"pid"`
-		SuperAdmin     SuperAdminConfig `yaml:"super_admin"`
-		Logger         LoggerConfig     `yaml:"logger"`
-		Tracing        trace.Config     `yaml:"tracing"`
-		Storage        StorageConfig    `yaml:"storage"`
-		Notifier       NotifierConfig   `yaml:"notifier"`
-		Session        SessionConfig    `yaml:"session"`
-		Auth           AuthConfig       `yaml:"auth"`
-		Metrics        MetricsConfig    `yaml:"metrics"`
+		Port           int                  `yaml:"port"`
+		ReloadPort     int                  `yaml:"reload_port"`
+		ReloadInterval time.Duration        `yaml:"reload_interval"`
+		ReloadSwitch   bool                 `yaml:"reload_switch"`
+		Forward        ForwardConfig        `yaml:"forward"`
+		ToolAccess     ToolAccessConfig     `yaml:"tool_access"`
+		CircuitBreaker CircuitBreakerConfig `yaml:"circuit_breaker"`
+		Timeout        TimeoutConfig        `yaml:"timeout"`
+		HTTPTransport  HTTPTransportConfig  `yaml:"http_transport"`
+		ToolCache      ToolCacheConfig      `yaml:"tool_cache"`
+		RateLimit      RateLimitStoreConfig `yaml:"rate_limit"`
+		PID            string               `yaml:"pid"`
+		SuperAdmin     SuperAdminConfig     `yaml:"super_admin"`
+		Logger         LoggerConfig         `yaml:"logger"`
+		Tracing        trace.Config         `yaml:"tracing"`
+		Storage        StorageConfig        `yaml:"storage"`
+		Notifier       NotifierConfig       `yaml:"notifier"`
+		Session        SessionConfig        `yaml:"session"`
+		Auth           AuthConfig           `yaml:"auth"`
+		Metrics        MetricsConfig        `yaml:"metrics"`
+		Admin          AdminConfig          `yaml:"admin"`
+		I18n           I18nConfig           `yaml:"i18n"`
+	}
+
+	// HTTPTransportConfig tunes the connection pools shared by HTTP tools
+	HTTPTransportConfig struct {
+		MaxIdleConns        int           `yaml:"max_idle_conns"`
+		MaxIdleConnsPerHost int           `yaml:"max_idle_conns_per_host"`
+		MaxConnsPerHost     int           `yaml:"max_conns_per_host"`
+		IdleConnTimeout     time.Duration `yaml:"idle_conn_timeout"`
+		HTTP2               HTTP2Config   `yaml:"http2"`
+	}
+
+	// HTTP2Config controls HTTP/2 usage of the shared transports
+	HTTP2Config struct {
+		Disabled         bool          `yaml:"disabled"`            // only speak HTTP/1.1 to upstreams
+		ReadIdleTimeout  time.Duration `yaml:"read_idle_timeout"`   // send a ping after a connection was idle this long
+		PingTimeout      time.Duration `yaml:"ping_timeout"`        // close the connection when a ping is not answered in time
+		MaxReadFrameSize int           `yaml:"max_read_frame_size"` // largest frame the upstream may send
+	}
+
+	// ToolCacheConfig selects the backend of the tool result cache
+	ToolCacheConfig struct {
+		Type   string `yaml:"type"`   // "memory" or "redis", redis uses the session redis connection settings
+		Prefix string `yaml:"prefix"` // key prefix in redis
+	}
+
+	// RateLimitStoreConfig selects the backend holding the token buckets of rate limits
+	RateLimitStoreConfig struct {
+		Type   string `yaml:"type"`   // "memory" or "redis", redis uses the session redis connection settings
+		Prefix string `yaml:"prefix"` // key prefix in redis
 	}
 
 	// MetricsConfig controls Prometheus metrics exposure
@@ -60,6 +96,13 @@ type (
 		Buckets   []float64 `yaml:"buckets"`
 	}
 
+	// AdminConfig controls the admin endpoints of the gateway
+	AdminConfig struct {
+		Enabled bool   `yaml:"enabled"`
+		Path    string `yaml:"path"`
+		Token   string `yaml:"token"` // bearer token required by the endpoints, they cannot be enabled without one
+	}
+
 	// ToolAccessConfig controls access policies for tool endpoints.
 	ToolAccessConfig struct {
 		InternalNetwork InternalNetworkAccessConfig `yaml:"internal_network"`
@@ -72,8 +115,27 @@ type (
 
 	// SessionConfig represents the session storage configuration
 	SessionConfig struct {
-		Type  string             `yaml:"type"`  // "memory" or "redis"
-		Redis SessionRedisConfig `yaml:"redis"` // Redis configuration
+		Type   string              `yaml:"type"`   // "memory" or "redis"
+		Redis  SessionRedisConfig  `yaml:"redis"`  // Redis configuration
+		Events SessionEventsConfig `yaml:"events"` // Replay buffer of streamable HTTP streams
+		// Heartbeat keeps idle SSE and streamable HTTP GET streams from being cut by proxies
+		Heartbeat SessionHeartbeatConfig `yaml:"heartbeat"`
+		// IdleTimeout is how long a session may go without requests or an open stream
+		// before it is reaped, 0 keeps sessions until they are deleted
+		IdleTimeout time.Duration `yaml:"idle_timeout"`
+	}
+
+	// SessionHeartbeatConfig represents the heartbeats written to idle event streams
+	SessionHeartbeatConfig struct {
+		Interval time.Duration `yaml:"interval"` // 0 disables heartbeats
+		Mode     string        `yaml:"mode"`     // "comment" (default) or "ping"
+	}
+
+	// SessionEventsConfig represents the buffer of recent stream messages replayed to
+	// streamable HTTP clients resuming with Last-Event-ID. It uses the session store type.
+	SessionEventsConfig struct {
+		MaxEvents int           `yaml:"max_events"` // messages kept per session, 0 disables resumption
+		TTL       time.Duration `yaml:"ttl"`        // how long messages are kept after the last one
 	}
 
 	// SessionRedisConfig represents the Redis configuration for session storage
@@ -111,6 +173,8 @@ type (
 		CORS   *CORSConfig        `yaml:"cors,omitempty"`
 		Google *GoogleOAuthConfig `yaml:"google,omitempty"`
 		GitHub *GitHubOAuthConfig `yaml:"github,omitempty"`
+		// Upstream configures the gateway as OAuth client of protected upstream MCP servers
+		Upstream UpstreamOAuthConfig `yaml:"upstream"`
 	}
 	OAuth2Config struct {
 		Issuer  string              `yaml:"issuer"`
@@ -129,6 +193,13 @@ type (
 		DB          int    `yaml:"db"`
 	}
 
+	// UpstreamOAuthConfig defines how the gateway authorizes its users at upstream MCP
+	// servers with an oauth block
+	UpstreamOAuthConfig struct {
+		CallbackURL string              `yaml:"callback_url"` // public URL of /oauth/upstream/callback, registered at the upstream authorization servers
+		Storage     OAuth2StorageConfig `yaml:"storage"`      // where the tokens of the users are kept
+	}
+
 	// GoogleOAuthConfig defines Google OAuth configuration
 	GoogleOAuthConfig struct {
 		ClientID     string `yaml:"client_id"`
@@ -214,6 +285,9 @@ func LoadConfig[T Type](filename string) (*T, string, error) {
 				mcpCfg.Metrics.Buckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 0.75, 1.0, 2.5, 5.0, 7.5, 10}
 			}
 		}
+		if mcpCfg.Admin.Enabled && mcpCfg.Admin.Path == "" {
+			mcpCfg.Admin.Path = "/admin"
+		}
 
 	}
 
@@ -230,6 +304,14 @@ func LoadConfig[T Type](filename string) (*T, string, error) {
 	return &cfg, cfgPath, nil
 }
 
+// Heartbeats written to idle event streams
+const (
+	// HeartbeatModeComment writes an SSE comment, which clients ignore
+	HeartbeatModeComment = "comment"
+	// HeartbeatModePing writes a ping event
+	HeartbeatModePing = "ping"
+)
+
 // resolveEnv replaces environment variable placeholders in YAML content
 func resolveEnv(content []byte) []byte {
 	regex := regexp.MustCompile(`\$\{(\w+)(?::([^}]*))?\}`)
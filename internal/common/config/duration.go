@@ -0,0 +1,82 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Duration wraps time.Duration so MCP configs can use human readable values
+// such as "500ms" or "2s" in both YAML and JSON. Numeric JSON values are
+// treated as nanoseconds to stay compatible with time.Duration's encoding.
+type Duration time.Duration
+
+// Std returns the value as a time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// String returns the formatted duration, e.g. "1.5s"
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch val := v.(type) {
+	case nil:
+		*d = 0
+	case float64:
+		*d = Duration(time.Duration(val))
+	case string:
+		parsed, err := parseDuration(val)
+		if err != nil {
+			return err
+		}
+		*d = parsed
+	default:
+		return fmt.Errorf("invalid duration: %s", string(data))
+	}
+	return nil
+}
+
+func (d Duration) MarshalYAML() (any, error) {
+	return time.Duration(d).String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	var raw string
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+	parsed, err := parseDuration(raw)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func parseDuration(s string) (Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		if n, convErr := strconv.ParseInt(s, 10, 64); convErr == nil {
+			return Duration(time.Duration(n)), nil
+		}
+		return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	return Duration(parsed), nil
+}
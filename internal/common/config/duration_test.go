@@ -0,0 +1,42 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDuration_JSON(t *testing.T) {
+	var v struct {
+		D Duration `json:"d"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"d":"1.5s"}`), &v))
+	assert.Equal(t, 1500*time.Millisecond, v.D.Std())
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"d":1000}`), &v))
+	assert.Equal(t, time.Microsecond, v.D.Std())
+
+	assert.Error(t, json.Unmarshal([]byte(`{"d":"soon"}`), &v))
+
+	v.D = Duration(2 * time.Second)
+	b, err := json.Marshal(v)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"d":"2s"}`, string(b))
+}
+
+func TestDuration_YAML(t *testing.T) {
+	var v struct {
+		D Duration `yaml:"d"`
+	}
+	assert.NoError(t, yaml.Unmarshal([]byte("d: 250ms"), &v))
+	assert.Equal(t, 250*time.Millisecond, v.D.Std())
+
+	assert.Error(t, yaml.Unmarshal([]byte("d: soon"), &v))
+
+	b, err := yaml.Marshal(v)
+	assert.NoError(t, err)
+	assert.Equal(t, "d: 250ms\n", string(b))
+}
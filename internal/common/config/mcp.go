@@ -1,6 +1,7 @@
 package config
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/ifuryst/lol"
@@ -29,15 +30,25 @@ type (
 		Servers    []ServerConfig    `json:"servers,omitempty" yaml:"servers,omitempty"`
 		Tools      []ToolConfig      `json:"tools,omitempty" yaml:"tools,omitempty"`
 		Prompts    []PromptConfig    `json:"prompts,omitempty" yaml:"prompts,omitempty"`
+		Resources  []ResourceConfig  `json:"resources,omitempty" yaml:"resources,omitempty"`
 		McpServers []MCPServerConfig `json:"mcpServers,omitempty" yaml:"mcpServers,omitempty"` // proxy mcp servers
 	}
 
 	RouterConfig struct {
-		Server    string      `json:"server" yaml:"server"`
-		Prefix    string      `json:"prefix" yaml:"prefix"`
-		SSEPrefix string      `json:"ssePrefix" yaml:"ssePrefix"`
-		CORS      *CORSConfig `json:"cors,omitempty" yaml:"cors,omitempty"`
-		Auth      *Auth       `json:"auth,omitempty" yaml:"auth,omitempty"`
+		Server     string            `json:"server" yaml:"server"`
+		Prefix     string            `json:"prefix" yaml:"prefix"`
+		SSEPrefix  string            `json:"ssePrefix" yaml:"ssePrefix"`
+		CORS       *CORSConfig       `json:"cors,omitempty" yaml:"cors,omitempty"`
+		Auth       *Auth             `json:"auth,omitempty" yaml:"auth,omitempty"`
+		RateLimits []RateLimitConfig `json:"rateLimits,omitempty" yaml:"rateLimits,omitempty"` // apply to every request under the prefix
+	}
+
+	// RateLimitConfig is a token bucket holding Burst tokens, refilled with Requests tokens every Per
+	RateLimitConfig struct {
+		Requests int      `json:"requests" yaml:"requests"`
+		Per      Duration `json:"per,omitempty" yaml:"per,omitempty"`     // refill period, default is 1s
+		Burst    int      `json:"burst,omitempty" yaml:"burst,omitempty"` // bucket size, defaults to Requests
+		KeyBy    string   `json:"keyBy,omitempty" yaml:"keyBy,omitempty"` // tenant, prefix, client or session, default is prefix
 	}
 
 	CORSConfig struct {
@@ -55,15 +66,37 @@ type (
 	}
 
 	ServerConfig struct {
-		Name         string            `json:"name" yaml:"name"`
-		Description  string            `json:"description" yaml:"description"`
-		AllowedTools []string          `json:"allowedTools,omitempty" yaml:"allowedTools,omitempty"`
-		Config       map[string]string `json:"config,omitempty" yaml:"config,omitempty"`
+		Name             string                `json:"name" yaml:"name"`
+		Description      string                `json:"description" yaml:"description"`
+		AllowedTools     []string              `json:"allowedTools,omitempty" yaml:"allowedTools,omitempty"`
+		AllowedResources []string              `json:"allowedResources,omitempty" yaml:"allowedResources,omitempty"`
+		Config           map[string]string     `json:"config,omitempty" yaml:"config,omitempty"`
+		CircuitBreaker   *CircuitBreakerConfig `json:"circuitBreaker,omitempty" yaml:"circuitBreaker,omitempty"` // overrides the gateway default
+		Timeout          *TimeoutConfig        `json:"timeout,omitempty" yaml:"timeout,omitempty"`               // overrides the gateway default
+	}
+
+	// TimeoutConfig bounds an upstream HTTP call, empty fields mean no limit
+	TimeoutConfig struct {
+		Connect        Duration `json:"connect,omitempty" yaml:"connect,omitempty"`               // establishing the TCP connection
+		TLSHandshake   Duration `json:"tlsHandshake,omitempty" yaml:"tlsHandshake,omitempty"`     // completing the TLS handshake
+		ResponseHeader Duration `json:"responseHeader,omitempty" yaml:"responseHeader,omitempty"` // waiting for response headers once the request is sent
+		Total          Duration `json:"total,omitempty" yaml:"total,omitempty"`                   // the whole call, including retries and reading the body
+	}
+
+	// CircuitBreakerConfig controls failing fast when an upstream API keeps failing
+	CircuitBreakerConfig struct {
+		Enabled          bool     `json:"enabled" yaml:"enabled"`
+		KeyBy            string   `json:"keyBy,omitempty" yaml:"keyBy,omitempty"`                       // host (per server) or server, default is host
+		FailureThreshold int      `json:"failureThreshold,omitempty" yaml:"failureThreshold,omitempty"` // consecutive failures before opening
+		OpenDuration     Duration `json:"openDuration,omitempty" yaml:"openDuration,omitempty"`         // how long to reject calls before probing
+		HalfOpenRequests int      `json:"halfOpenRequests,omitempty" yaml:"halfOpenRequests,omitempty"` // probes that must succeed to close again
 	}
 
 	ToolConfig struct {
 		Name         string            `json:"name" yaml:"name"`
+		Title        string            `json:"title,omitempty" yaml:"title,omitempty"`
 		Description  string            `json:"description,omitempty" yaml:"description,omitempty"`
+		Icons        []IconConfig      `json:"icons,omitempty" yaml:"icons,omitempty"`
 		Method       string            `json:"method" yaml:"method"`
 		Endpoint     string            `json:"endpoint" yaml:"endpoint"`
 		Proxy        *ProxyConfig      `json:"proxy,omitempty" yaml:"proxy,omitempty"`
@@ -72,8 +105,76 @@ type (
 		RequestBody  string            `json:"requestBody"  yaml:"requestBody"`
 		ResponseBody string            `json:"responseBody" yaml:"responseBody"`
 		InputSchema  map[string]any    `json:"inputSchema,omitempty" yaml:"inputSchema,omitempty"`
+		OutputSchema map[string]any    `json:"outputSchema,omitempty" yaml:"outputSchema,omitempty"` // JSON Schema of the object a JSON response is returned as in structuredContent
 		Annotations  map[string]any    `json:"annotations,omitempty" yaml:"annotations,omitempty"`
 		Meta         map[string]any    `json:"meta,omitempty" yaml:"meta,omitempty"`
+		Retry        *RetryConfig      `json:"retry,omitempty" yaml:"retry,omitempty"`
+		Timeout      *TimeoutConfig    `json:"timeout,omitempty" yaml:"timeout,omitempty"` // overrides the server timeout
+		Cache        *CacheConfig      `json:"cache,omitempty" yaml:"cache,omitempty"`
+		RateLimits   []RateLimitConfig `json:"rateLimits,omitempty" yaml:"rateLimits,omitempty"`
+		CoerceArgs   bool              `json:"coerceArgs,omitempty" yaml:"coerceArgs,omitempty"` // convert string args like "42" to the declared scalar type before validation
+		Async        *AsyncConfig      `json:"async,omitempty" yaml:"async,omitempty"`           // the endpoint submits a job whose status is polled until it finishes
+	}
+
+	// AsyncConfig polls the status of a job submitted by a tool until it finishes. The
+	// status endpoint and headers are rendered with the submit response as response, the
+	// other templates with the latest status response. CompletedWhen and FailedWhen render
+	// "true" once the job is done, Progress, Total and Message are sent to the client as
+	// notifications/progress while it runs.
+	AsyncConfig struct {
+		StatusMethod   string            `json:"statusMethod,omitempty" yaml:"statusMethod,omitempty"` // defaults to GET
+		StatusEndpoint string            `json:"statusEndpoint" yaml:"statusEndpoint"`
+		StatusHeaders  map[string]string `json:"statusHeaders,omitempty" yaml:"statusHeaders,omitempty"`
+		Interval       Duration          `json:"interval,omitempty" yaml:"interval,omitempty"` // delay between polls, defaults to 2s
+		Timeout        Duration          `json:"timeout,omitempty" yaml:"timeout,omitempty"`   // bounds the whole job, defaults to 10m
+		CompletedWhen  string            `json:"completedWhen" yaml:"completedWhen"`           // e.g. {{eq .Response.Data.state "done"}}
+		FailedWhen     string            `json:"failedWhen,omitempty" yaml:"failedWhen,omitempty"`
+		Progress       string            `json:"progress,omitempty" yaml:"progress,omitempty"` // number, defaults to the poll count
+		Total          string            `json:"total,omitempty" yaml:"total,omitempty"`
+		Message        string            `json:"message,omitempty" yaml:"message,omitempty"`
+		ResultBody     string            `json:"resultBody,omitempty" yaml:"resultBody,omitempty"` // defaults to the status response
+	}
+
+	// ResourceConfig exposes an HTTP endpoint as an MCP resource. Either URI or URITemplate
+	// is set, the variables of a URI template are passed to the templates as args.
+	ResourceConfig struct {
+		Name         string            `json:"name" yaml:"name"`
+		Description  string            `json:"description,omitempty" yaml:"description,omitempty"`
+		URI          string            `json:"uri,omitempty" yaml:"uri,omitempty"`
+		URITemplate  string            `json:"uriTemplate,omitempty" yaml:"uriTemplate,omitempty"` // e.g. weather://cities/{city}
+		MimeType     string            `json:"mimeType,omitempty" yaml:"mimeType,omitempty"`       // defaults to the Content-Type of the response
+		Method       string            `json:"method" yaml:"method"`
+		Endpoint     string            `json:"endpoint" yaml:"endpoint"`
+		Proxy        *ProxyConfig      `json:"proxy,omitempty" yaml:"proxy,omitempty"`
+		Headers      map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+		RequestBody  string            `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+		ResponseBody string            `json:"responseBody,omitempty" yaml:"responseBody,omitempty"`
+		Timeout      *TimeoutConfig    `json:"timeout,omitempty" yaml:"timeout,omitempty"` // overrides the server timeout
+
+		Completions map[string]*CompletionConfig `json:"completions,omitempty" yaml:"completions,omitempty"` // keyed by URI template variable
+	}
+
+	// CacheConfig caches successful results of a tool. Only tools annotated with
+	// readOnlyHint are cached. Results are only served to the user they were fetched
+	// for, the OAuth client of the request or else its session, unless shared is set.
+	// The default key covers the arguments, a key template narrows it down.
+	CacheConfig struct {
+		TTL        Duration `json:"ttl" yaml:"ttl"`
+		Key        string   `json:"key,omitempty" yaml:"key,omitempty"`               // template over args and request, e.g. {{.Args.id}}
+		MaxEntries int      `json:"maxEntries,omitempty" yaml:"maxEntries,omitempty"` // entries kept per tool, oldest are evicted first
+		Shared     bool     `json:"shared,omitempty" yaml:"shared,omitempty"`         // serve results to every user, only for responses that do not depend on the caller
+	}
+
+	// RetryConfig controls automatic retries of an HTTP tool call. Retries are only
+	// performed when the tool is annotated with readOnlyHint or idempotentHint.
+	RetryConfig struct {
+		MaxAttempts      int      `json:"maxAttempts" yaml:"maxAttempts"`                               // total attempts including the first one
+		InitialBackoff   Duration `json:"initialBackoff,omitempty" yaml:"initialBackoff,omitempty"`     // delay before the first retry
+		MaxBackoff       Duration `json:"maxBackoff,omitempty" yaml:"maxBackoff,omitempty"`             // upper bound of a single delay
+		Multiplier       float64  `json:"multiplier,omitempty" yaml:"multiplier,omitempty"`             // growth factor between retries
+		Jitter           float64  `json:"jitter,omitempty" yaml:"jitter,omitempty"`                     // random spread applied to each delay, 0.0 ~ 1.0
+		RetryOn          []int    `json:"retryOn,omitempty" yaml:"retryOn,omitempty"`                   // retryable HTTP status codes
+		IgnoreRetryAfter bool     `json:"ignoreRetryAfter,omitempty" yaml:"ignoreRetryAfter,omitempty"` // do not honor the Retry-After header
 	}
 
 	MCPServerConfig struct {
@@ -83,8 +184,59 @@ type (
 		Args         []string              `json:"args,omitempty" yaml:"args,omitempty"`       // for stdio
 		Env          map[string]string     `json:"env,omitempty" yaml:"env,omitempty"`         // for stdio
 		URL          string                `json:"url,omitempty" yaml:"url,omitempty"`         // for sse and streamable-http
-		Policy       cnst.MCPStartupPolicy `json:"policy" yaml:"policy"`                       // onStart or onDemand
+		Headers      map[string]string     `json:"headers,omitempty" yaml:"headers,omitempty"` // for sse and streamable-http, templates using .Request or .Args are rendered per request, the others once per connection
+		Policy       cnst.MCPStartupPolicy `json:"policy" yaml:"policy"`                       // onStart, onDemand or perSession (stdio only)
 		Preinstalled bool                  `json:"preinstalled" yaml:"preinstalled"`           // whether to install this MCP server when mcp-gateway starts
+		// Supervisor tunes the health checks and restarts of stdio servers
+		Supervisor *SupervisorConfig `json:"supervisor,omitempty" yaml:"supervisor,omitempty"`
+		// PerSession tunes the processes of stdio servers with the perSession policy
+		PerSession *PerSessionConfig `json:"perSession,omitempty" yaml:"perSession,omitempty"`
+		// Sandbox confines the processes of stdio servers
+		Sandbox *SandboxConfig `json:"sandbox,omitempty" yaml:"sandbox,omitempty"`
+		// OAuth makes the gateway authorize each of its users at sse and streamable-http
+		// servers with the MCP authorization flow
+		OAuth *MCPServerOAuthConfig `json:"oauth,omitempty" yaml:"oauth,omitempty"`
+	}
+
+	// MCPServerOAuthConfig tunes the authorization of the gateway users at an upstream MCP
+	// server. The authorization server is discovered from the protected resource metadata of
+	// the server, the gateway registers itself there unless clientId is set.
+	MCPServerOAuthConfig struct {
+		Scopes       []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`             // requested scopes, those of the resource metadata by default
+		ClientID     string   `json:"clientId,omitempty" yaml:"clientId,omitempty"`         // pre-registered client, skips dynamic client registration
+		ClientSecret string   `json:"clientSecret,omitempty" yaml:"clientSecret,omitempty"` // secret of clientId, empty for public clients
+	}
+
+	// SandboxConfig confines the process of a stdio MCP server. A sandboxed process does not
+	// inherit the environment of the gateway, it only gets the variables of envAllowlist plus
+	// Env, so PATH usually has to be listed. Resource limits and the uid/gid drop are only
+	// supported on Linux, zero values leave a limit unset.
+	SandboxConfig struct {
+		WorkDir      string   `json:"workDir,omitempty" yaml:"workDir,omitempty"`
+		EnvAllowlist []string `json:"envAllowlist,omitempty" yaml:"envAllowlist,omitempty"` // gateway variables passed on, a trailing * matches a prefix
+		MaxCPUTime   Duration `json:"maxCpuTime,omitempty" yaml:"maxCpuTime,omitempty"`     // CPU time of the process, rounded up to seconds
+		MaxMemoryMB  int      `json:"maxMemoryMB,omitempty" yaml:"maxMemoryMB,omitempty"`   // address space of the process
+		MaxOpenFiles int      `json:"maxOpenFiles,omitempty" yaml:"maxOpenFiles,omitempty"`
+		MaxRuntime   Duration `json:"maxRuntime,omitempty" yaml:"maxRuntime,omitempty"` // wall time after which the process is killed
+		UID          *int     `json:"uid,omitempty" yaml:"uid,omitempty"`               // user the process runs as, the gateway needs CAP_SETUID
+		GID          *int     `json:"gid,omitempty" yaml:"gid,omitempty"`
+	}
+
+	// PerSessionConfig tunes the dedicated processes of a perSession stdio server, zero values
+	// use the defaults
+	PerSessionConfig struct {
+		MaxProcesses int      `json:"maxProcesses,omitempty" yaml:"maxProcesses,omitempty"` // processes running at once, sessions beyond are rejected
+		IdleTimeout  Duration `json:"idleTimeout,omitempty" yaml:"idleTimeout,omitempty"`   // a process without requests for this long is stopped
+	}
+
+	// SupervisorConfig tunes the supervision of a stdio MCP server, zero values use the defaults
+	SupervisorConfig struct {
+		HealthCheckInterval Duration `json:"healthCheckInterval,omitempty" yaml:"healthCheckInterval,omitempty"` // ping interval, negative disables health checks
+		HealthCheckTimeout  Duration `json:"healthCheckTimeout,omitempty" yaml:"healthCheckTimeout,omitempty"`
+		InitialBackoff      Duration `json:"initialBackoff,omitempty" yaml:"initialBackoff,omitempty"` // delay of the first restart, doubled after every crash
+		MaxBackoff          Duration `json:"maxBackoff,omitempty" yaml:"maxBackoff,omitempty"`
+		MaxRestarts         int      `json:"maxRestarts,omitempty" yaml:"maxRestarts,omitempty"` // crashes within restartWindow before the server is marked failed
+		RestartWindow       Duration `json:"restartWindow,omitempty" yaml:"restartWindow,omitempty"`
 	}
 
 	ArgConfig struct {
@@ -117,6 +269,7 @@ type (
 		Servers    string          `json:"servers" yaml:"servers"`
 		Tools      string          `json:"tools" yaml:"tools"`
 		Prompts    string          `json:"prompts" yaml:"prompts"`
+		Resources  string          `json:"resources" yaml:"resources"`
 		McpServers string          `json:"mcp_servers" yaml:"mcp_servers"`
 		IsActive   bool            `json:"is_active" yaml:"is_active"` // indicates if this version is currently active
 		Hash       string          `json:"hash" yaml:"hash"`           // hash of the configuration content
@@ -129,15 +282,40 @@ type (
 
 	PromptConfig struct {
 		Name           string           `json:"name" yaml:"name"`
+		Title          string           `json:"title,omitempty" yaml:"title,omitempty"`
 		Description    string           `json:"description" yaml:"description"`
+		Icons          []IconConfig     `json:"icons,omitempty" yaml:"icons,omitempty"`
 		Arguments      []PromptArgument `json:"arguments" yaml:"arguments"`
 		PromptResponse []PromptResponse `json:"promptResponse,omitempty" yaml:"promptResponse,omitempty"`
 	}
 
+	// IconConfig is an icon shown by clients next to a tool or prompt
+	IconConfig struct {
+		Src      string   `json:"src" yaml:"src"`                               // URL or data URI of the image
+		MimeType string   `json:"mimeType,omitempty" yaml:"mimeType,omitempty"` // e.g. image/png
+		Sizes    []string `json:"sizes,omitempty" yaml:"sizes,omitempty"`       // e.g. 48x48, any
+	}
+
 	PromptArgument struct {
-		Name        string `json:"name" yaml:"name"`
-		Description string `json:"description" yaml:"description"`
-		Required    bool   `json:"required" yaml:"required"`
+		Name        string            `json:"name" yaml:"name"`
+		Description string            `json:"description" yaml:"description"`
+		Required    bool              `json:"required" yaml:"required"`
+		Enum        []string          `json:"enum,omitempty" yaml:"enum,omitempty"`             // static completion values
+		Completion  *CompletionConfig `json:"completion,omitempty" yaml:"completion,omitempty"` // looks completion values up at an HTTP endpoint
+	}
+
+	// CompletionConfig provides the values offered by completion/complete for an argument.
+	// Enum values are filtered by the typed prefix. When Endpoint is set the values are
+	// looked up there instead, with the argument being completed and the arguments already
+	// resolved by the client passed as args. The response, or the rendered ResponseBody,
+	// is a JSON array of strings or one value per line.
+	CompletionConfig struct {
+		Enum         []string          `json:"enum,omitempty" yaml:"enum,omitempty"`
+		Method       string            `json:"method,omitempty" yaml:"method,omitempty"` // defaults to GET
+		Endpoint     string            `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+		Headers      map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+		RequestBody  string            `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+		ResponseBody string            `json:"responseBody,omitempty" yaml:"responseBody,omitempty"`
 	}
 
 	PromptResponse struct {
@@ -145,9 +323,22 @@ type (
 		Content PromptResponseContent `json:"content" yaml:"content"`
 	}
 
+	// PromptResponseContent is the content of a prompt message. Text, Data and the
+	// resource fields are templates rendered with the prompt arguments.
 	PromptResponseContent struct {
-		Type string `json:"type" yaml:"type"`
-		Text string `json:"text" yaml:"text"`
+		Type     string                 `json:"type" yaml:"type"`                             // text, image, audio or resource
+		Text     string                 `json:"text,omitempty" yaml:"text,omitempty"`         // text content
+		Data     string                 `json:"data,omitempty" yaml:"data,omitempty"`         // base64 image or audio data
+		MimeType string                 `json:"mimeType,omitempty" yaml:"mimeType,omitempty"` // MIME type of image or audio data
+		Resource *PromptResourceContent `json:"resource,omitempty" yaml:"resource,omitempty"` // embedded resource
+	}
+
+	// PromptResourceContent is a resource embedded in a prompt message
+	PromptResourceContent struct {
+		URI      string `json:"uri" yaml:"uri"`
+		MimeType string `json:"mimeType,omitempty" yaml:"mimeType,omitempty"`
+		Text     string `json:"text,omitempty" yaml:"text,omitempty"`
+		Blob     string `json:"blob,omitempty" yaml:"blob,omitempty"` // base64 encoded
 	}
 )
 
@@ -201,15 +392,171 @@ func (t *ToolConfig) ToToolSchema() mcp.ToolSchema {
 
 	return mcp.ToolSchema{
 		Name:        t.Name,
+		Title:       t.Title,
 		Description: t.Description,
+		Icons:       toIcons(t.Icons),
 		InputSchema: mcp.ToolInputSchema{
 			Type:       "object",
 			Properties: properties,
 			Required:   required,
 		},
-		Annotations: annotations,
-		Meta:        t.Meta,
+		OutputSchema: t.toOutputSchema(),
+		Annotations:  annotations,
+		Meta:         t.Meta,
+	}
+}
+
+// toOutputSchema converts the declared output schema, nil if the tool has none
+func (t *ToolConfig) toOutputSchema() *mcp.ToolOutputSchema {
+	if t.OutputSchema == nil {
+		return nil
+	}
+	schema := &mcp.ToolOutputSchema{Type: "object"}
+	if properties, ok := t.OutputSchema["properties"].(map[string]any); ok {
+		schema.Properties = properties
+	}
+	switch required := t.OutputSchema["required"].(type) {
+	case []string:
+		schema.Required = required
+	case []any:
+		for _, r := range required {
+			if name, ok := r.(string); ok {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+	}
+	return schema
+}
+
+// IsTemplate reports whether the resource is addressed by a URI template
+func (r *ResourceConfig) IsTemplate() bool {
+	return r.URITemplate != ""
+}
+
+// ToResourceSchema converts a ResourceConfig with a fixed URI to a ResourceSchema
+func (r *ResourceConfig) ToResourceSchema() mcp.ResourceSchema {
+	return mcp.ResourceSchema{
+		URI:         r.URI,
+		Name:        r.Name,
+		Description: r.Description,
+		MimeType:    r.MimeType,
+	}
+}
+
+// ToResourceTemplateSchema converts a ResourceConfig with a URI template to a ResourceTemplateSchema
+func (r *ResourceConfig) ToResourceTemplateSchema() mcp.ResourceTemplateSchema {
+	return mcp.ResourceTemplateSchema{
+		URITemplate: r.URITemplate,
+		Name:        r.Name,
+		Description: r.Description,
+		MimeType:    r.MimeType,
+	}
+}
+
+// ToToolConfig returns the request settings of the resource as a read-only tool,
+// so that resources are read the same way HTTP tools are called
+func (r *ResourceConfig) ToToolConfig() *ToolConfig {
+	return &ToolConfig{
+		Name:         r.Name,
+		Description:  r.Description,
+		Method:       r.Method,
+		Endpoint:     r.Endpoint,
+		Proxy:        r.Proxy,
+		Headers:      r.Headers,
+		RequestBody:  r.RequestBody,
+		ResponseBody: r.ResponseBody,
+		Timeout:      r.Timeout,
+		Annotations:  map[string]any{"readOnlyHint": true},
+	}
+}
+
+// ToToolConfig converts a CompletionConfig to a read-only ToolConfig so that the lookup
+// is executed like a tool call
+func (c *CompletionConfig) ToToolConfig(name string) *ToolConfig {
+	method := c.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	return &ToolConfig{
+		Name:         name,
+		Method:       method,
+		Endpoint:     c.Endpoint,
+		Headers:      c.Headers,
+		RequestBody:  c.RequestBody,
+		ResponseBody: c.ResponseBody,
+		Annotations:  map[string]any{"readOnlyHint": true},
+	}
+}
+
+// StatusToolConfig converts an AsyncConfig to the ToolConfig polling the job status
+func (a *AsyncConfig) StatusToolConfig(name string) *ToolConfig {
+	method := a.StatusMethod
+	if method == "" {
+		method = http.MethodGet
+	}
+	return &ToolConfig{
+		Name:        name,
+		Method:      method,
+		Endpoint:    a.StatusEndpoint,
+		Headers:     a.StatusHeaders,
+		Annotations: map[string]any{"readOnlyHint": true},
+	}
+}
+
+// ToCompletionConfig returns how the argument is completed, or nil when it is not. The
+// enum of the argument applies unless the completion declares its own.
+func (a *PromptArgument) ToCompletionConfig() *CompletionConfig {
+	if a.Completion == nil && len(a.Enum) == 0 {
+		return nil
+	}
+	completion := CompletionConfig{}
+	if a.Completion != nil {
+		completion = *a.Completion
+	}
+	if len(completion.Enum) == 0 {
+		completion.Enum = a.Enum
 	}
+	return &completion
+}
+
+// IsRetrySafe reports whether the tool may be called again automatically,
+// which is the case for read-only or idempotent tools
+func (t *ToolConfig) IsRetrySafe() bool {
+	return utils.GetBool(t.Annotations, "readOnlyHint", false) ||
+		utils.GetBool(t.Annotations, "idempotentHint", false)
+}
+
+// Keys a rate limit bucket can be shared by
+const (
+	RateLimitKeyByTenant  = "tenant"
+	RateLimitKeyByPrefix  = "prefix"
+	RateLimitKeyByClient  = "client"
+	RateLimitKeyBySession = "session"
+)
+
+// IsCacheable reports whether results of the tool may be cached
+func (t *ToolConfig) IsCacheable() bool {
+	return t.Cache != nil && t.Cache.TTL > 0 && utils.GetBool(t.Annotations, "readOnlyHint", false)
+}
+
+// Merge returns a copy of t where every field set in override takes precedence
+func (t TimeoutConfig) Merge(override *TimeoutConfig) TimeoutConfig {
+	if override == nil {
+		return t
+	}
+	if override.Connect > 0 {
+		t.Connect = override.Connect
+	}
+	if override.TLSHandshake > 0 {
+		t.TLSHandshake = override.TLSHandshake
+	}
+	if override.ResponseHeader > 0 {
+		t.ResponseHeader = override.ResponseHeader
+	}
+	if override.Total > 0 {
+		t.Total = override.Total
+	}
+	return t
 }
 
 // ToPromptSchema converts a PromptConfig to a PromptSchema
@@ -225,17 +572,45 @@ func (t *PromptConfig) ToPromptSchema() mcp.PromptSchema {
 	var responses []mcp.PromptResponseSchema
 	for _, r := range t.PromptResponse {
 		responses = append(responses, mcp.PromptResponseSchema{
-			Role: r.Role,
-			Content: mcp.PromptResponseContentSchema{
-				Type: r.Content.Type,
-				Text: r.Content.Text,
-			},
+			Role:    r.Role,
+			Content: r.Content.toSchema(),
 		})
 	}
 	return mcp.PromptSchema{
 		Name:           t.Name,
+		Title:          t.Title,
 		Description:    t.Description,
+		Icons:          toIcons(t.Icons),
 		Arguments:      args,
 		PromptResponse: responses,
 	}
 }
+
+func (c PromptResponseContent) toSchema() mcp.PromptResponseContentSchema {
+	content := mcp.PromptResponseContentSchema{
+		Type:     c.Type,
+		Text:     c.Text,
+		Data:     c.Data,
+		MimeType: c.MimeType,
+	}
+	if c.Resource != nil {
+		content.Resource = &mcp.ResourceContents{
+			URI:      c.Resource.URI,
+			MimeType: c.Resource.MimeType,
+			Text:     c.Resource.Text,
+			Blob:     c.Resource.Blob,
+		}
+	}
+	return content
+}
+
+func toIcons(icons []IconConfig) []mcp.Icon {
+	if len(icons) == 0 {
+		return nil
+	}
+	result := make([]mcp.Icon, len(icons))
+	for i, icon := range icons {
+		result[i] = mcp.Icon{Src: icon.Src, MimeType: icon.MimeType, Sizes: icon.Sizes}
+	}
+	return result
+}
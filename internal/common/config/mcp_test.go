@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/amoylab/unla/pkg/mcp"
 	"github.com/stretchr/testify/assert"
@@ -40,6 +41,19 @@ func TestToolConfig_ToToolSchema(t *testing.T) {
 	if assert.NotNil(t, schema.Annotations) {
 		assert.Equal(t, "T", schema.Annotations.Title)
 	}
+	assert.Nil(t, schema.OutputSchema)
+
+	tcfg.OutputSchema = map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"temp": map[string]any{"type": "number"}},
+		"required":   []any{"temp"},
+	}
+	schema = tcfg.ToToolSchema()
+	assert.Equal(t, &mcp.ToolOutputSchema{
+		Type:       "object",
+		Properties: map[string]any{"temp": map[string]any{"type": "number"}},
+		Required:   []string{"temp"},
+	}, schema.OutputSchema)
 }
 
 func TestPromptConfig_ToPromptSchema(t *testing.T) {
@@ -61,3 +75,14 @@ func TestPromptConfig_ToPromptSchema(t *testing.T) {
 		assert.Equal(t, "hello", ps.PromptResponse[0].Content.Text)
 	}
 }
+
+func TestTimeoutConfig_Merge(t *testing.T) {
+	base := TimeoutConfig{Connect: Duration(time.Second), Total: Duration(time.Minute)}
+	assert.Equal(t, base, base.Merge(nil))
+
+	merged := base.Merge(&TimeoutConfig{Total: Duration(5 * time.Second), ResponseHeader: Duration(2 * time.Second)})
+	assert.Equal(t, Duration(time.Second), merged.Connect)
+	assert.Equal(t, Duration(2*time.Second), merged.ResponseHeader)
+	assert.Equal(t, Duration(5*time.Second), merged.Total)
+	assert.Zero(t, merged.TLSHandshake)
+}
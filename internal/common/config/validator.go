@@ -3,6 +3,9 @@ package config
 import (
 	"fmt"
 	"strings"
+
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/pkg/mcp"
 )
 
 // Location represents a configuration location
@@ -107,9 +110,245 @@ func validateSingleConfig(cfg *MCPConfig) []*ValidationError {
 		}
 	}
 
+	// Check resources and the references to them
+	resourceNameMap := make(map[string]bool)
+	resourceURIMap := make(map[string]bool)
+	for _, resource := range cfg.Resources {
+		if resourceNameMap[resource.Name] {
+			errors = append(errors, &ValidationError{
+				Message:   fmt.Sprintf("duplicate resource name %q found in resource configurations", resource.Name),
+				Locations: []Location{{File: cfg.Name}},
+			})
+		}
+		resourceNameMap[resource.Name] = true
+		for _, msg := range validateResource(&resource) {
+			errors = append(errors, &ValidationError{
+				Message:   fmt.Sprintf("resource %q: %s", resource.Name, msg),
+				Locations: []Location{{File: cfg.Name}},
+			})
+		}
+		uri := resource.URI + resource.URITemplate
+		if uri != "" && resourceURIMap[uri] {
+			errors = append(errors, &ValidationError{
+				Message:   fmt.Sprintf("duplicate resource URI %q found in resource configurations", uri),
+				Locations: []Location{{File: cfg.Name}},
+			})
+		}
+		resourceURIMap[uri] = true
+	}
+	for _, server := range cfg.Servers {
+		for _, resourceName := range server.AllowedResources {
+			if !resourceNameMap[resourceName] {
+				errors = append(errors, &ValidationError{
+					Message:   fmt.Sprintf("resource %q referenced in server %q does not exist", resourceName, server.Name),
+					Locations: []Location{{File: cfg.Name}},
+				})
+			}
+		}
+	}
+
+	// Check prompts
+	for _, prompt := range cfg.Prompts {
+		for _, msg := range validatePrompt(&prompt) {
+			errors = append(errors, &ValidationError{
+				Message:   fmt.Sprintf("prompt %q: %s", prompt.Name, msg),
+				Locations: []Location{{File: cfg.Name}},
+			})
+		}
+	}
+
+	// Check MCP servers
+	for _, mcpServer := range cfg.McpServers {
+		for _, msg := range validateMCPServer(&mcpServer) {
+			errors = append(errors, &ValidationError{
+				Message:   fmt.Sprintf("mcp server %q: %s", mcpServer.Name, msg),
+				Locations: []Location{{File: cfg.Name}},
+			})
+		}
+	}
+
+	// Check rate limits
+	for _, router := range cfg.Routers {
+		for _, msg := range validateRateLimits(router.RateLimits) {
+			errors = append(errors, &ValidationError{
+				Message:   fmt.Sprintf("router %q: %s", router.Prefix, msg),
+				Locations: []Location{{File: cfg.Name}},
+			})
+		}
+	}
+	for _, tool := range cfg.Tools {
+		for _, msg := range append(validateRateLimits(tool.RateLimits), validateAsync(tool.Async)...) {
+			errors = append(errors, &ValidationError{
+				Message:   fmt.Sprintf("tool %q: %s", tool.Name, msg),
+				Locations: []Location{{File: cfg.Name}},
+			})
+		}
+	}
+
 	return errors
 }
 
+func validateRateLimits(limits []RateLimitConfig) []string {
+	var msgs []string
+	for i, limit := range limits {
+		if limit.Requests <= 0 {
+			msgs = append(msgs, fmt.Sprintf("rate limit #%d must allow at least one request", i+1))
+		}
+		if limit.Per < 0 || limit.Burst < 0 {
+			msgs = append(msgs, fmt.Sprintf("rate limit #%d must not be negative", i+1))
+		}
+		switch limit.KeyBy {
+		case "", RateLimitKeyByTenant, RateLimitKeyByPrefix, RateLimitKeyByClient, RateLimitKeyBySession:
+		default:
+			msgs = append(msgs, fmt.Sprintf("rate limit #%d has unknown keyBy %q", i+1, limit.KeyBy))
+		}
+	}
+	return msgs
+}
+
+func validateMCPServer(server *MCPServerConfig) []string {
+	var msgs []string
+	if server.Policy == cnst.PolicyPerSession && server.Type != cnst.BackendProtoStdio.String() {
+		msgs = append(msgs, "perSession policy is only supported by stdio servers")
+	}
+	if len(server.Headers) > 0 && server.Type == cnst.BackendProtoStdio.String() {
+		msgs = append(msgs, "headers are only supported by sse and streamable-http servers")
+	}
+	if ps := server.PerSession; ps != nil && (ps.MaxProcesses < 0 || ps.IdleTimeout < 0) {
+		msgs = append(msgs, "perSession maxProcesses and idleTimeout must not be negative")
+	}
+	if sup := server.Supervisor; sup != nil {
+		if sup.HealthCheckTimeout < 0 || sup.InitialBackoff < 0 || sup.MaxBackoff < 0 || sup.RestartWindow < 0 || sup.MaxRestarts < 0 {
+			msgs = append(msgs, "supervisor timeouts, backoffs and restarts must not be negative")
+		}
+		if sup.MaxBackoff > 0 && sup.InitialBackoff > sup.MaxBackoff {
+			msgs = append(msgs, "supervisor initialBackoff must not exceed maxBackoff")
+		}
+	}
+	if sb := server.Sandbox; sb != nil {
+		if server.Type != cnst.BackendProtoStdio.String() {
+			msgs = append(msgs, "sandbox is only supported by stdio servers")
+		}
+		if sb.MaxCPUTime < 0 || sb.MaxMemoryMB < 0 || sb.MaxOpenFiles < 0 || sb.MaxRuntime < 0 {
+			msgs = append(msgs, "sandbox limits must not be negative")
+		}
+		if (sb.UID != nil && *sb.UID < 0) || (sb.GID != nil && *sb.GID < 0) {
+			msgs = append(msgs, "sandbox uid and gid must not be negative")
+		}
+		for _, name := range sb.EnvAllowlist {
+			if name == "" || strings.Contains(name, "=") {
+				msgs = append(msgs, fmt.Sprintf("sandbox envAllowlist has an invalid variable name %q", name))
+			}
+		}
+	}
+	if oauth := server.OAuth; oauth != nil {
+		if server.Type == cnst.BackendProtoStdio.String() {
+			msgs = append(msgs, "oauth is only supported by sse and streamable-http servers")
+		}
+		if server.Policy == cnst.PolicyOnStart || server.Preinstalled {
+			msgs = append(msgs, "oauth servers connect with the token of a user, they cannot use the onStart policy or be preinstalled")
+		}
+		if oauth.ClientSecret != "" && oauth.ClientID == "" {
+			msgs = append(msgs, "oauth clientSecret requires clientId")
+		}
+		for name := range server.Headers {
+			if strings.EqualFold(name, "Authorization") {
+				msgs = append(msgs, "oauth servers must not set the Authorization header")
+			}
+		}
+	}
+	return msgs
+}
+
+func validateAsync(async *AsyncConfig) []string {
+	if async == nil {
+		return nil
+	}
+	var msgs []string
+	if async.StatusEndpoint == "" {
+		msgs = append(msgs, "async statusEndpoint is required")
+	}
+	if async.CompletedWhen == "" {
+		msgs = append(msgs, "async completedWhen is required")
+	}
+	if async.Interval < 0 || async.Timeout < 0 {
+		msgs = append(msgs, "async interval and timeout must not be negative")
+	}
+	return msgs
+}
+
+func validateResource(resource *ResourceConfig) []string {
+	var msgs []string
+	switch {
+	case resource.URI == "" && resource.URITemplate == "":
+		msgs = append(msgs, "either uri or uriTemplate is required")
+	case resource.URI != "" && resource.URITemplate != "":
+		msgs = append(msgs, "uri and uriTemplate are mutually exclusive")
+	case resource.URITemplate != "":
+		if _, err := mcp.CompileURITemplate(resource.URITemplate); err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	if resource.Endpoint == "" {
+		msgs = append(msgs, "endpoint is required")
+	}
+	if len(resource.Completions) > 0 {
+		vars := make(map[string]bool)
+		if resource.URITemplate != "" {
+			if tmpl, err := mcp.CompileURITemplate(resource.URITemplate); err == nil {
+				for _, v := range tmpl.Variables() {
+					vars[v] = true
+				}
+			}
+		}
+		for name, completion := range resource.Completions {
+			if !vars[name] {
+				msgs = append(msgs, fmt.Sprintf("completion %q is not a variable of the URI template", name))
+			}
+			if msg := validateCompletion(completion); msg != "" {
+				msgs = append(msgs, fmt.Sprintf("completion %q %s", name, msg))
+			}
+		}
+	}
+	return msgs
+}
+
+func validateCompletion(completion *CompletionConfig) string {
+	if completion == nil || (completion.Endpoint == "" && len(completion.Enum) == 0) {
+		return "requires enum or endpoint"
+	}
+	return ""
+}
+
+func validatePrompt(prompt *PromptConfig) []string {
+	var msgs []string
+	for _, arg := range prompt.Arguments {
+		if arg.Completion == nil {
+			continue
+		}
+		if msg := validateCompletion(arg.ToCompletionConfig()); msg != "" {
+			msgs = append(msgs, fmt.Sprintf("completion of argument %q %s", arg.Name, msg))
+		}
+	}
+	for i, resp := range prompt.PromptResponse {
+		content := resp.Content
+		switch content.Type {
+		case "", mcp.TextContentType:
+		case mcp.ImageContentType, mcp.AudioContentType:
+			if content.Data == "" || content.MimeType == "" {
+				msgs = append(msgs, fmt.Sprintf("message #%d of type %s requires data and mimeType", i+1, content.Type))
+			}
+		case mcp.ResourceContentType:
+			if content.Resource == nil || content.Resource.URI == "" {
+				msgs = append(msgs, fmt.Sprintf("message #%d of type resource requires resource.uri", i+1))
+			}
+		default:
+			msgs = append(msgs, fmt.Sprintf("message #%d has unknown content type %q", i+1, content.Type))
+		}
+	}
+	return msgs
+}
+
 // formatValidationErrors formats a slice of validation errors into a single error
 func formatValidationErrors(errors []*ValidationError) error {
 	if len(errors) == 0 {
@@ -49,6 +49,91 @@ func TestValidateSingleConfig_Errors(t *testing.T) {
 	assert.NotContains(t, msg, "server \"mcp1\"")
 }
 
+func TestValidateSingleConfig_RateLimits(t *testing.T) {
+	cfg := &MCPConfig{
+		Name:    "rl",
+		Servers: []ServerConfig{{Name: "srv"}},
+		Routers: []RouterConfig{{Server: "srv", Prefix: "/rl", RateLimits: []RateLimitConfig{
+			{Requests: 10, KeyBy: RateLimitKeyByClient},
+			{Requests: 0},
+		}}},
+		Tools: []ToolConfig{{Name: "t", RateLimits: []RateLimitConfig{
+			{Requests: 1, KeyBy: "user"},
+			{Requests: 1, Burst: -1},
+		}}},
+	}
+
+	errs := validateSingleConfig(cfg)
+	var msg string
+	for _, e := range errs {
+		msg += e.Message + "\n"
+	}
+	assert.Len(t, errs, 3)
+	assert.Contains(t, msg, "router \"/rl\": rate limit #2 must allow at least one request")
+	assert.Contains(t, msg, "tool \"t\": rate limit #1 has unknown keyBy \"user\"")
+	assert.Contains(t, msg, "tool \"t\": rate limit #2 must not be negative")
+}
+
+func TestValidateSingleConfig_Resources(t *testing.T) {
+	cfg := &MCPConfig{
+		Name:    "res",
+		Servers: []ServerConfig{{Name: "srv", AllowedResources: []string{"readme", "missing"}}},
+		Routers: []RouterConfig{{Server: "srv", Prefix: "/res"}},
+		Resources: []ResourceConfig{
+			{Name: "readme", URI: "docs://readme", Endpoint: "http://localhost/readme"},
+			{Name: "readme", URI: "docs://readme", Endpoint: "http://localhost/readme"},
+			{Name: "both", URI: "docs://a", URITemplate: "docs://{a}", Endpoint: "http://localhost/a"},
+			{Name: "bad", URITemplate: "docs://{?q}", Endpoint: "http://localhost/q"},
+			{Name: "none"},
+		},
+	}
+
+	errs := validateSingleConfig(cfg)
+	var msg string
+	for _, e := range errs {
+		msg += e.Message + "\n"
+	}
+	assert.Len(t, errs, 7)
+	assert.Contains(t, msg, "duplicate resource name \"readme\"")
+	assert.Contains(t, msg, "duplicate resource URI \"docs://readme\"")
+	assert.Contains(t, msg, "resource \"both\": uri and uriTemplate are mutually exclusive")
+	assert.Contains(t, msg, "resource \"bad\": unsupported expression {?q}")
+	assert.Contains(t, msg, "resource \"none\": either uri or uriTemplate is required")
+	assert.Contains(t, msg, "resource \"none\": endpoint is required")
+	assert.Contains(t, msg, "resource \"missing\" referenced in server \"srv\" does not exist")
+}
+
+func TestValidateSingleConfig_Prompts(t *testing.T) {
+	cfg := &MCPConfig{
+		Name: "prompts",
+		Prompts: []PromptConfig{{
+			Name: "review",
+			PromptResponse: []PromptResponse{
+				{Role: "user", Content: PromptResponseContent{Type: "text", Text: "Review {{.Args.code}}"}},
+				{Role: "user", Content: PromptResponseContent{Type: "image", Data: "{{.Args.image}}", MimeType: "image/png"}},
+				{Role: "user", Content: PromptResponseContent{Type: "resource", Resource: &PromptResourceContent{URI: "docs://guide"}}},
+			},
+		}, {
+			Name: "broken",
+			PromptResponse: []PromptResponse{
+				{Role: "user", Content: PromptResponseContent{Type: "image", Data: "iVBORw=="}},
+				{Role: "user", Content: PromptResponseContent{Type: "resource"}},
+				{Role: "user", Content: PromptResponseContent{Type: "video"}},
+			},
+		}},
+	}
+
+	errs := validateSingleConfig(cfg)
+	var msg string
+	for _, e := range errs {
+		msg += e.Message + "\n"
+	}
+	assert.Len(t, errs, 3)
+	assert.Contains(t, msg, "prompt \"broken\": message #1 of type image requires data and mimeType")
+	assert.Contains(t, msg, "prompt \"broken\": message #2 of type resource requires resource.uri")
+	assert.Contains(t, msg, "prompt \"broken\": message #3 has unknown content type \"video\"")
+}
+
 func TestFormatAndValidateConfigs_DuplicatePrefixes(t *testing.T) {
 	cfg1 := &MCPConfig{
 		Name:    "cfg1",
@@ -93,3 +178,152 @@ func TestMergeConfigs_UpdateAppendDelete(t *testing.T) {
 	names := []string{got[0].Name, got[1].Name}
 	assert.NotContains(t, names, "n2")
 }
+
+func TestValidateSingleConfig_Completions(t *testing.T) {
+	cfg := &MCPConfig{
+		Name: "completions",
+		Prompts: []PromptConfig{{
+			Name: "review",
+			Arguments: []PromptArgument{
+				{Name: "language", Enum: []string{"Go"}, Completion: &CompletionConfig{Method: "GET"}},
+				{Name: "framework", Completion: &CompletionConfig{}},
+			},
+		}},
+		Resources: []ResourceConfig{{
+			Name:        "city",
+			URITemplate: "weather://cities/{city}",
+			Endpoint:    "http://localhost/cities",
+			Completions: map[string]*CompletionConfig{
+				"city":    {Enum: []string{"Xiamen"}},
+				"country": {Endpoint: "http://localhost/countries"},
+			},
+		}},
+	}
+
+	errs := validateSingleConfig(cfg)
+	var msg string
+	for _, e := range errs {
+		msg += e.Message + "\n"
+	}
+	assert.Len(t, errs, 2)
+	assert.Contains(t, msg, "prompt \"review\": completion of argument \"framework\" requires enum or endpoint")
+	assert.Contains(t, msg, "resource \"city\": completion \"country\" is not a variable of the URI template")
+}
+
+func TestValidateSingleConfig_Async(t *testing.T) {
+	cfg := &MCPConfig{
+		Name: "async",
+		Tools: []ToolConfig{
+			{Name: "report", Async: &AsyncConfig{StatusEndpoint: "http://localhost/jobs/{{.Response.Data.id}}", CompletedWhen: "{{.Response.Data.done}}"}},
+			{Name: "broken", Async: &AsyncConfig{Interval: -1}},
+		},
+	}
+
+	errs := validateSingleConfig(cfg)
+	var msg string
+	for _, e := range errs {
+		msg += e.Message + "\n"
+	}
+	assert.Len(t, errs, 3)
+	assert.Contains(t, msg, "tool \"broken\": async statusEndpoint is required")
+	assert.Contains(t, msg, "tool \"broken\": async completedWhen is required")
+	assert.Contains(t, msg, "tool \"broken\": async interval and timeout must not be negative")
+}
+
+func TestValidateSingleConfig_Supervisor(t *testing.T) {
+	cfg := &MCPConfig{
+		Name: "stdio",
+		McpServers: []MCPServerConfig{
+			{Name: "ok", Type: "stdio", Command: "server", Supervisor: &SupervisorConfig{HealthCheckInterval: -1, InitialBackoff: Duration(time.Second), MaxBackoff: Duration(time.Minute)}},
+			{Name: "broken", Type: "stdio", Command: "server", Supervisor: &SupervisorConfig{InitialBackoff: Duration(time.Minute), MaxBackoff: Duration(time.Second), MaxRestarts: -1}},
+		},
+	}
+
+	errs := validateSingleConfig(cfg)
+	var msg string
+	for _, e := range errs {
+		msg += e.Message + "\n"
+	}
+	assert.Len(t, errs, 2)
+	assert.Contains(t, msg, "mcp server \"broken\": supervisor timeouts, backoffs and restarts must not be negative")
+	assert.Contains(t, msg, "mcp server \"broken\": supervisor initialBackoff must not exceed maxBackoff")
+}
+
+func TestValidateSingleConfig_PerSession(t *testing.T) {
+	cfg := &MCPConfig{
+		Name: "per-session",
+		McpServers: []MCPServerConfig{
+			{Name: "ok", Type: "stdio", Command: "server", Policy: "perSession", PerSession: &PerSessionConfig{MaxProcesses: 10}},
+			{Name: "remote", Type: "sse", URL: "http://localhost", Policy: "perSession"},
+			{Name: "broken", Type: "stdio", Command: "server", Policy: "perSession", PerSession: &PerSessionConfig{IdleTimeout: -1}},
+		},
+	}
+
+	errs := validateSingleConfig(cfg)
+	var msg string
+	for _, e := range errs {
+		msg += e.Message + "\n"
+	}
+	assert.Len(t, errs, 2)
+	assert.Contains(t, msg, "mcp server \"remote\": perSession policy is only supported by stdio servers")
+	assert.Contains(t, msg, "mcp server \"broken\": perSession maxProcesses and idleTimeout must not be negative")
+}
+
+func TestValidateSingleConfig_Sandbox(t *testing.T) {
+	uid := -1
+	cfg := &MCPConfig{
+		Name: "sandbox",
+		McpServers: []MCPServerConfig{
+			{Name: "ok", Type: "stdio", Command: "server", Sandbox: &SandboxConfig{EnvAllowlist: []string{"PATH", "LC_*"}, MaxMemoryMB: 512, MaxRuntime: Duration(time.Hour)}},
+			{Name: "remote", Type: "sse", URL: "http://localhost", Sandbox: &SandboxConfig{}},
+			{Name: "broken", Type: "stdio", Command: "server", Sandbox: &SandboxConfig{EnvAllowlist: []string{"A=B"}, MaxOpenFiles: -1, UID: &uid}},
+		},
+	}
+
+	errs := validateSingleConfig(cfg)
+	var msg string
+	for _, e := range errs {
+		msg += e.Message + "\n"
+	}
+	assert.Len(t, errs, 4)
+	assert.Contains(t, msg, "mcp server \"remote\": sandbox is only supported by stdio servers")
+	assert.Contains(t, msg, "mcp server \"broken\": sandbox limits must not be negative")
+	assert.Contains(t, msg, "mcp server \"broken\": sandbox uid and gid must not be negative")
+	assert.Contains(t, msg, "mcp server \"broken\": sandbox envAllowlist has an invalid variable name \"A=B\"")
+}
+
+func TestValidateSingleConfig_Headers(t *testing.T) {
+	cfg := &MCPConfig{
+		Name: "headers",
+		McpServers: []MCPServerConfig{
+			{Name: "remote", Type: "streamable-http", URL: "http://localhost", Headers: map[string]string{"Authorization": "Bearer {{ env \"TOKEN\" }}"}},
+			{Name: "local", Type: "stdio", Command: "server", Headers: map[string]string{"Authorization": "Bearer token"}},
+		},
+	}
+
+	errs := validateSingleConfig(cfg)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "mcp server \"local\": headers are only supported by sse and streamable-http servers", errs[0].Message)
+}
+
+func TestValidateSingleConfig_OAuth(t *testing.T) {
+	cfg := &MCPConfig{
+		Name: "oauth",
+		McpServers: []MCPServerConfig{
+			{Name: "remote", Type: "streamable-http", URL: "http://localhost", OAuth: &MCPServerOAuthConfig{Scopes: []string{"read"}}},
+			{Name: "local", Type: "stdio", Command: "server", OAuth: &MCPServerOAuthConfig{}},
+			{Name: "broken", Type: "sse", URL: "http://localhost", Policy: "onStart", Headers: map[string]string{"authorization": "Bearer token"}, OAuth: &MCPServerOAuthConfig{ClientSecret: "secret"}},
+		},
+	}
+
+	errs := validateSingleConfig(cfg)
+	var msg string
+	for _, e := range errs {
+		msg += e.Message + "\n"
+	}
+	assert.Len(t, errs, 4)
+	assert.Contains(t, msg, "mcp server \"broken\": oauth servers connect with the token of a user, they cannot use the onStart policy or be preinstalled")
+	assert.Contains(t, msg, "mcp server \"local\": oauth is only supported by sse and streamable-http servers")
+	assert.Contains(t, msg, "mcp server \"broken\": oauth clientSecret requires clientId")
+	assert.Contains(t, msg, "mcp server \"broken\": oauth servers must not set the Authorization header")
+}
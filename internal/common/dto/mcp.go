@@ -12,6 +12,7 @@ type MCPServer struct {
 	McpServers []MCPServerConfig `json:"mcpServers,omitempty"`
 	Tools      []ToolConfig      `json:"tools,omitempty"`
 	Prompts    []PromptConfig    `json:"prompts,omitempty"`
+	Resources  []ResourceConfig  `json:"resources,omitempty"`
 	Servers    []ServerConfig    `json:"servers,omitempty"`
 	Routers    []RouterConfig    `json:"routers,omitempty"`
 	CreatedAt  time.Time         `json:"createdAt"`
@@ -28,6 +29,7 @@ type MCPConfig struct {
 	Servers    []ServerConfig    `json:"servers,omitempty"`
 	Tools      []ToolConfig      `json:"tools,omitempty"`
 	Prompts    []PromptConfig    `json:"prompts,omitempty"`
+	Resources  []ResourceConfig  `json:"resources,omitempty"`
 	McpServers []MCPServerConfig `json:"mcpServers,omitempty"`
 }
 
@@ -52,10 +54,11 @@ type Auth struct {
 }
 
 type ServerConfig struct {
-	Name         string            `json:"name"`
-	Description  string            `json:"description"`
-	AllowedTools []string          `json:"allowedTools,omitempty"`
-	Config       map[string]string `json:"config,omitempty"`
+	Name             string            `json:"name"`
+	Description      string            `json:"description"`
+	AllowedTools     []string          `json:"allowedTools,omitempty"`
+	AllowedResources []string          `json:"allowedResources,omitempty"`
+	Config           map[string]string `json:"config,omitempty"`
 }
 
 type ToolConfig struct {
@@ -71,6 +74,31 @@ type ToolConfig struct {
 	InputSchema  map[string]any    `json:"inputSchema,omitempty"`
 }
 
+type ResourceConfig struct {
+	Name         string            `json:"name"`
+	Description  string            `json:"description,omitempty"`
+	URI          string            `json:"uri,omitempty"`
+	URITemplate  string            `json:"uriTemplate,omitempty"`
+	MimeType     string            `json:"mimeType,omitempty"`
+	Method       string            `json:"method"`
+	Endpoint     string            `json:"endpoint"`
+	Proxy        *ProxyConfig      `json:"proxy,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	RequestBody  string            `json:"requestBody,omitempty"`
+	ResponseBody string            `json:"responseBody,omitempty"`
+
+	Completions map[string]*CompletionConfig `json:"completions,omitempty"`
+}
+
+type CompletionConfig struct {
+	Enum         []string          `json:"enum,omitempty"`
+	Method       string            `json:"method,omitempty"`
+	Endpoint     string            `json:"endpoint,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	RequestBody  string            `json:"requestBody,omitempty"`
+	ResponseBody string            `json:"responseBody,omitempty"`
+}
+
 type MCPServerConfig struct {
 	Type         string            `json:"type"`              // sse, stdio and streamable-http
 	Name         string            `json:"name"`              // server name
@@ -78,7 +106,7 @@ type MCPServerConfig struct {
 	Args         []string          `json:"args,omitempty"`    // for stdio
 	Env          map[string]string `json:"env,omitempty"`     // for stdio
 	URL          string            `json:"url,omitempty"`     // for sse and streamable-http
-	Policy       string            `json:"policy"`            // onStart or onDemand
+	Policy       string            `json:"policy"`            // onStart, onDemand or perSession
 	Preinstalled bool              `json:"preinstalled"`      // whether to install this MCP server when mcp-gateway starts
 }
 
@@ -114,9 +142,11 @@ type PromptConfig struct {
 }
 
 type PromptArgument struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Required    bool   `json:"required"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Required    bool              `json:"required"`
+	Enum        []string          `json:"enum,omitempty"`
+	Completion  *CompletionConfig `json:"completion,omitempty"`
 }
 
 type PromptResponse struct {
@@ -125,8 +155,18 @@ type PromptResponse struct {
 }
 
 type PromptResponseContent struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type     string                 `json:"type"`
+	Text     string                 `json:"text,omitempty"`
+	Data     string                 `json:"data,omitempty"`
+	MimeType string                 `json:"mimeType,omitempty"`
+	Resource *PromptResourceContent `json:"resource,omitempty"`
+}
+
+type PromptResourceContent struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
 }
 
 // FromConfig converts a config.MCPConfig to dto.MCPServer
@@ -137,6 +177,7 @@ func FromConfig(cfg *config.MCPConfig) MCPServer {
 		McpServers: FromMCPServerConfigs(cfg.McpServers),
 		Tools:      FromToolConfigs(cfg.Tools),
 		Prompts:    FromPromptConfigs(cfg.Prompts),
+		Resources:  FromResourceConfigs(cfg.Resources),
 		Servers:    FromServerConfigs(cfg.Servers),
 		Routers:    FromRouterConfigs(cfg.Routers),
 		CreatedAt:  cfg.CreatedAt,
@@ -184,10 +225,11 @@ func FromServerConfigs(cfgs []config.ServerConfig) []ServerConfig {
 	result := make([]ServerConfig, len(cfgs))
 	for i, cfg := range cfgs {
 		result[i] = ServerConfig{
-			Name:         cfg.Name,
-			Description:  cfg.Description,
-			AllowedTools: cfg.AllowedTools,
-			Config:       cfg.Config,
+			Name:             cfg.Name,
+			Description:      cfg.Description,
+			AllowedTools:     cfg.AllowedTools,
+			AllowedResources: cfg.AllowedResources,
+			Config:           cfg.Config,
 		}
 	}
 	return result
@@ -216,6 +258,51 @@ func FromToolConfigs(cfgs []config.ToolConfig) []ToolConfig {
 	return result
 }
 
+// FromResourceConfigs converts a slice of config.ResourceConfig to dto.ResourceConfig
+func FromResourceConfigs(cfgs []config.ResourceConfig) []ResourceConfig {
+	if cfgs == nil {
+		return nil
+	}
+	result := make([]ResourceConfig, len(cfgs))
+	for i, cfg := range cfgs {
+		result[i] = ResourceConfig{
+			Name:         cfg.Name,
+			Description:  cfg.Description,
+			URI:          cfg.URI,
+			URITemplate:  cfg.URITemplate,
+			MimeType:     cfg.MimeType,
+			Method:       cfg.Method,
+			Endpoint:     cfg.Endpoint,
+			Proxy:        FromProxyConfig(cfg.Proxy),
+			Headers:      cfg.Headers,
+			RequestBody:  cfg.RequestBody,
+			ResponseBody: cfg.ResponseBody,
+		}
+		if len(cfg.Completions) > 0 {
+			result[i].Completions = make(map[string]*CompletionConfig, len(cfg.Completions))
+			for name, completion := range cfg.Completions {
+				result[i].Completions[name] = FromCompletionConfig(completion)
+			}
+		}
+	}
+	return result
+}
+
+// FromCompletionConfig converts a config.CompletionConfig to dto.CompletionConfig
+func FromCompletionConfig(cfg *config.CompletionConfig) *CompletionConfig {
+	if cfg == nil {
+		return nil
+	}
+	return &CompletionConfig{
+		Enum:         cfg.Enum,
+		Method:       cfg.Method,
+		Endpoint:     cfg.Endpoint,
+		Headers:      cfg.Headers,
+		RequestBody:  cfg.RequestBody,
+		ResponseBody: cfg.ResponseBody,
+	}
+}
+
 // FromProxyConfig converts a config.ProxyConfig to dto.ProxyConfig
 func FromProxyConfig(cfg *config.ProxyConfig) *ProxyConfig {
 	if cfg == nil {
@@ -334,6 +421,8 @@ func FromPromptArguments(cfgs []config.PromptArgument) []PromptArgument {
 			Name:        cfg.Name,
 			Description: cfg.Description,
 			Required:    cfg.Required,
+			Enum:        cfg.Enum,
+			Completion:  FromCompletionConfig(cfg.Completion),
 		}
 	}
 	return result
@@ -354,8 +443,19 @@ func FromPromptResponses(cfgs []config.PromptResponse) []PromptResponse {
 }
 
 func FromPromptResponseContent(cfg config.PromptResponseContent) PromptResponseContent {
-	return PromptResponseContent{
-		Type: cfg.Type,
-		Text: cfg.Text,
+	content := PromptResponseContent{
+		Type:     cfg.Type,
+		Text:     cfg.Text,
+		Data:     cfg.Data,
+		MimeType: cfg.MimeType,
+	}
+	if cfg.Resource != nil {
+		content.Resource = &PromptResourceContent{
+			URI:      cfg.Resource.URI,
+			MimeType: cfg.Resource.MimeType,
+			Text:     cfg.Resource.Text,
+			Blob:     cfg.Resource.Blob,
+		}
 	}
+	return content
 }
@@ -0,0 +1,143 @@
+package core
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/core/mcpproxy"
+)
+
+// stdioServerStatus is the status of a supervised stdio server behind a router prefix
+type stdioServerStatus struct {
+	Prefix string `json:"prefix"`
+	mcpproxy.ServerStatus
+}
+
+// stdioServerStderr is the recent standard error of a stdio server behind a router prefix
+type stdioServerStderr struct {
+	Prefix string                `json:"prefix"`
+	Server string                `json:"server"`
+	Lines  []mcpproxy.StderrLine `json:"lines"`
+}
+
+// errAdminTokenRequired is returned when the admin endpoints are enabled without a token
+var errAdminTokenRequired = errors.New("admin endpoints require a token")
+
+// EnableAdmin registers the admin endpoints of the gateway. They restart servers and
+// expose their standard error, so they are never served without a token.
+func (s *Server) EnableAdmin(cfg config.AdminConfig) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Token == "" {
+		return errAdminTokenRequired
+	}
+	group := s.router.Group(cfg.Path)
+	group.Use(adminAuth(cfg.Token))
+	group.GET("/stdio-servers", s.handleListStdioServers)
+	group.POST("/stdio-servers/:name/restart", s.handleRestartStdioServer)
+	group.GET("/stdio-servers/:name/stderr", s.handleStdioServerStderr)
+	s.logger.Info("admin endpoints enabled", zap.String("path", cfg.Path))
+	return nil
+}
+
+// adminAuth rejects the requests without the bearer token of the admin endpoints
+func adminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// stdioServers returns the supervised stdio servers of the current state by prefix, only
+// those named name unless it is empty
+func (s *Server) stdioServers(name string) map[string]mcpproxy.Supervised {
+	servers := make(map[string]mcpproxy.Supervised)
+	for prefix, transport := range s.state.GetTransports() {
+		supervised, ok := transport.(mcpproxy.Supervised)
+		if !ok {
+			continue
+		}
+		if name != "" && supervised.Status().Name != name {
+			continue
+		}
+		servers[prefix] = supervised
+	}
+	return servers
+}
+
+func stdioServerStatuses(servers map[string]mcpproxy.Supervised) []stdioServerStatus {
+	statuses := make([]stdioServerStatus, 0, len(servers))
+	for prefix, server := range servers {
+		statuses = append(statuses, stdioServerStatus{Prefix: prefix, ServerStatus: server.Status()})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Prefix < statuses[j].Prefix })
+	return statuses
+}
+
+// handleListStdioServers returns the status of every supervised stdio server
+func (s *Server) handleListStdioServers(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"servers": stdioServerStatuses(s.stdioServers(""))})
+}
+
+// handleRestartStdioServer restarts a stdio server under every prefix it serves, which
+// also brings back a server that failed after crashing repeatedly
+func (s *Server) handleRestartStdioServer(c *gin.Context) {
+	name := c.Param("name")
+	servers := s.stdioServers(name)
+	if len(servers) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "stdio server not found"})
+		return
+	}
+
+	for prefix, server := range servers {
+		s.logger.Info("restarting stdio server",
+			zap.String("prefix", prefix),
+			zap.String("server", name))
+		if err := server.Restart(c.Request.Context()); err != nil {
+			s.logger.Error("failed to restart stdio server",
+				zap.String("prefix", prefix),
+				zap.String("server", name),
+				zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   err.Error(),
+				"servers": stdioServerStatuses(servers),
+			})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"servers": stdioServerStatuses(servers)})
+}
+
+// handleStdioServerStderr returns the recent standard error of a stdio server under every
+// prefix it serves
+func (s *Server) handleStdioServerStderr(c *gin.Context) {
+	name := c.Param("name")
+	servers := make([]stdioServerStderr, 0)
+	for prefix, transport := range s.state.GetTransports() {
+		capturer, ok := transport.(mcpproxy.StderrCapturer)
+		if !ok {
+			continue
+		}
+		if server, lines := capturer.Stderr(); server == name {
+			servers = append(servers, stdioServerStderr{Prefix: prefix, Server: server, Lines: lines})
+		}
+	}
+	if len(servers) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "stdio server not found"})
+		return
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Prefix < servers[j].Prefix })
+	c.JSON(http.StatusOK, gin.H{"servers": servers})
+}
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/core/mcpproxy"
+	"github.com/amoylab/unla/internal/core/state"
+)
+
+func newAdminTestServer(t *testing.T) *Server {
+	t.Helper()
+	cfg := &config.MCPConfig{
+		Name:    "stdio",
+		Routers: []config.RouterConfig{{Prefix: "/fs", Server: "fs"}},
+		McpServers: []config.MCPServerConfig{{
+			Name:    "fs",
+			Type:    cnst.BackendProtoStdio.String(),
+			Command: "__nonexistent_command__",
+			Policy:  cnst.PolicyOnDemand,
+		}},
+	}
+	st, err := state.BuildStateFromConfig(context.Background(), []*config.MCPConfig{cfg}, nil, zap.NewNop())
+	require.NoError(t, err)
+
+	s := &Server{logger: zap.NewNop(), router: gin.New(), state: st}
+	s.superviseStdioServers(st)
+	require.NoError(t, s.EnableAdmin(config.AdminConfig{Enabled: true, Path: "/admin", Token: "secret"}))
+	return s
+}
+
+func serveAdmin(s *Server, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestEnableAdmin_RequiresToken(t *testing.T) {
+	s := &Server{logger: zap.NewNop(), router: gin.New()}
+	assert.ErrorIs(t, s.EnableAdmin(config.AdminConfig{Enabled: true, Path: "/admin"}), errAdminTokenRequired)
+	assert.Empty(t, s.router.Routes())
+
+	// disabled endpoints need no token
+	assert.NoError(t, s.EnableAdmin(config.AdminConfig{Path: "/admin"}))
+}
+
+func TestAdmin_ListStdioServers(t *testing.T) {
+	s := newAdminTestServer(t)
+
+	w := serveAdmin(s, http.MethodGet, "/admin/stdio-servers", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = serveAdmin(s, http.MethodGet, "/admin/stdio-servers", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = serveAdmin(s, http.MethodGet, "/admin/stdio-servers", "secret")
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Servers []stdioServerStatus `json:"servers"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Servers, 1)
+	assert.Equal(t, "/fs", resp.Servers[0].Prefix)
+	assert.Equal(t, "fs", resp.Servers[0].Name)
+	assert.Equal(t, mcpproxy.ServerStateStopped, resp.Servers[0].State)
+}
+
+func TestAdmin_RestartStdioServer(t *testing.T) {
+	s := newAdminTestServer(t)
+
+	w := serveAdmin(s, http.MethodPost, "/admin/stdio-servers/unknown/restart", "secret")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// an on-demand server is only reset, the next request starts it
+	w = serveAdmin(s, http.MethodPost, "/admin/stdio-servers/fs/restart", "secret")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"state":"stopped"`)
+}
+
+func TestAdmin_StdioServerStderr(t *testing.T) {
+	s := newAdminTestServer(t)
+
+	w := serveAdmin(s, http.MethodGet, "/admin/stdio-servers/unknown/stderr", "secret")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = serveAdmin(s, http.MethodGet, "/admin/stdio-servers/fs/stderr", "secret")
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Servers []stdioServerStderr `json:"servers"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Servers, 1)
+	assert.Equal(t, "/fs", resp.Servers[0].Prefix)
+	assert.Equal(t, "fs", resp.Servers[0].Server)
+	assert.NotNil(t, resp.Servers[0].Lines)
+	assert.Empty(t, resp.Servers[0].Lines)
+}
@@ -0,0 +1,234 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/internal/template"
+	"github.com/amoylab/unla/pkg/mcp"
+)
+
+const (
+	defaultAsyncInterval = 2 * time.Second
+	defaultAsyncTimeout  = 10 * time.Minute
+
+	// progressKey is the gin context key of the progress reporter of a tools/call request
+	progressKey = "mcp.progress"
+)
+
+// AsyncJobError is returned when the job submitted by an async tool fails
+type AsyncJobError struct {
+	Tool    string
+	Message string
+}
+
+func (e *AsyncJobError) Error() string {
+	return fmt.Sprintf("job of tool %s failed: %s", e.Tool, e.Message)
+}
+
+// progressReporter sends notifications/progress for the request that asked for them
+// with a progress token
+type progressReporter struct {
+	token any
+	conn  session.Connection
+	isSSE bool
+	last  float64
+}
+
+// setProgressToken enables progress notifications for the request being handled
+func setProgressToken(c *gin.Context, conn session.Connection, token any, isSSE bool) {
+	if token != nil {
+		c.Set(progressKey, &progressReporter{token: token, conn: conn, isSSE: isSSE})
+	}
+}
+
+func getProgressReporter(c *gin.Context) *progressReporter {
+	v, ok := c.Get(progressKey)
+	if !ok {
+		return nil
+	}
+	reporter, _ := v.(*progressReporter)
+	return reporter
+}
+
+// reportProgress notifies the client of the progress of its request. Progress that does
+// not increase is dropped, as clients expect it to grow with every notification.
+func (s *Server) reportProgress(c *gin.Context, progress, total float64, message string) {
+	reporter := getProgressReporter(c)
+	if reporter == nil || progress <= reporter.last {
+		return
+	}
+	reporter.last = progress
+	params := mcp.ProgressNotificationParams{
+		ProgressToken: reporter.token,
+		Progress:      progress,
+		Total:         total,
+		Message:       message,
+	}
+	if err := s.sendRequestNotification(c, reporter.conn, mcp.NotificationProgress, params, reporter.isSSE); err != nil {
+		s.getLogger(c).Warn("failed to send progress notification",
+			zap.String("session_id", reporter.conn.Meta().ID),
+			zap.Error(err))
+	}
+}
+
+// pollAsyncJob polls the status endpoint of the job submitted by an async tool until the
+// job completes, and returns the final status response. The total timeout of the tool
+// bounds every poll on its own, the timeout of the job bounds them all.
+func (s *Server) pollAsyncJob(ctx context.Context, c *gin.Context, tool *config.ToolConfig, tmplCtx *template.Context,
+	cli *http.Client, timeouts config.TimeoutConfig, submitBody []byte) (*http.Response, error) {
+	async := tool.Async
+	interval := async.Interval.Std()
+	if interval <= 0 {
+		interval = defaultAsyncInterval
+	}
+	timeout := async.Timeout.Std()
+	if timeout <= 0 {
+		timeout = defaultAsyncTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// The status request is rendered with the submit response, e.g. to pick up the job id
+	submitCtx := *tmplCtx
+	submitCtx.Response = asyncResponse(submitBody)
+	statusTool := async.StatusToolConfig(tool.Name)
+
+	for poll := 1; ; poll++ {
+		oteltrace.SpanFromContext(ctx).AddEvent(cnst.EventAsyncJobPoll,
+			oteltrace.WithAttributes(attribute.Int(cnst.AttrAsyncPoll, poll)))
+		resp, body, err := s.fetchJobStatus(ctx, c, statusTool, &submitCtx, cli, timeouts.Total.Std())
+		if err != nil {
+			if isTimeoutError(err) {
+				return nil, &TimeoutError{Tool: tool.Name, Err: err}
+			}
+			return nil, err
+		}
+		tmplCtx.Response = asyncResponse(body)
+
+		failed, err := renderCondition(async.FailedWhen, tmplCtx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render failedWhen template: %w", err)
+		}
+		if failed {
+			message, err := template.RenderTemplate(async.Message, tmplCtx)
+			if err != nil || message == "" {
+				message = string(body)
+			}
+			return nil, &AsyncJobError{Tool: tool.Name, Message: message}
+		}
+		completed, err := renderCondition(async.CompletedWhen, tmplCtx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render completedWhen template: %w", err)
+		}
+		if completed {
+			return resp, nil
+		}
+
+		if getProgressReporter(c) != nil {
+			progress, total, message := asyncProgress(async, tmplCtx, poll)
+			s.reportProgress(c, progress, total, message)
+		}
+
+		select {
+		case <-ctx.Done():
+			if isTimeoutError(ctx.Err()) {
+				return nil, &TimeoutError{Tool: tool.Name, Err: ctx.Err()}
+			}
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// fetchJobStatus requests the job status and returns the response with its body restored
+func (s *Server) fetchJobStatus(ctx context.Context, c *gin.Context, statusTool *config.ToolConfig,
+	tmplCtx *template.Context, cli *http.Client, timeout time.Duration) (*http.Response, []byte, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	req, _, err := s.prepareRequest(statusTool, tmplCtx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.validateToolEndpoint(ctx, req.URL); err != nil {
+		return nil, nil, err
+	}
+	resp, err := s.doHTTPRequest(ctx, cli, req.WithContext(ctx), statusTool, oteltrace.SpanFromContext(ctx), s.getLogger(c))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch job status: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read job status: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, nil, fmt.Errorf("status endpoint returned HTTP %d", resp.StatusCode)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, body, nil
+}
+
+// asyncProgress renders the progress of a running job, the poll count stands in for
+// progress that is not configured or not a number
+func asyncProgress(async *config.AsyncConfig, tmplCtx *template.Context, poll int) (progress, total float64, message string) {
+	progress = float64(poll)
+	if v, ok := renderNumber(async.Progress, tmplCtx); ok {
+		progress = v
+	}
+	total, _ = renderNumber(async.Total, tmplCtx)
+	if async.Message != "" {
+		message, _ = template.RenderTemplate(async.Message, tmplCtx)
+	}
+	return progress, total, message
+}
+
+func asyncResponse(body []byte) template.ResponseWrapper {
+	var data map[string]any
+	_ = json.Unmarshal(body, &data)
+	return template.ResponseWrapper{Data: preprocessResponseData(data), Body: string(body)}
+}
+
+// renderCondition reports whether the template renders a true boolean
+func renderCondition(tmpl string, tmplCtx *template.Context) (bool, error) {
+	if tmpl == "" {
+		return false, nil
+	}
+	rendered, err := template.RenderTemplate(tmpl, tmplCtx)
+	if err != nil {
+		return false, err
+	}
+	ok, _ := strconv.ParseBool(strings.TrimSpace(rendered))
+	return ok, nil
+}
+
+func renderNumber(tmpl string, tmplCtx *template.Context) (float64, bool) {
+	if tmpl == "" {
+		return 0, false
+	}
+	rendered, err := template.RenderTemplate(tmpl, tmplCtx)
+	if err != nil {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(rendered), 64)
+	return v, err == nil
+}
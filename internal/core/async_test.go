@@ -0,0 +1,145 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/pkg/mcp"
+)
+
+func newAsyncJobServer(t *testing.T, states ...string) *httptest.Server {
+	t.Helper()
+	var polls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/jobs":
+			_, _ = w.Write([]byte(`{"id":"j1"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/jobs/j1":
+			n := int(polls.Add(1))
+			state := states[min(n, len(states))-1]
+			_, _ = fmt.Fprintf(w, `{"state":%q,"percent":%d,"output":"report.pdf"}`, state, n*40)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func asyncTool(endpoint string) *config.ToolConfig {
+	return &config.ToolConfig{
+		Name:     "report",
+		Method:   http.MethodPost,
+		Endpoint: endpoint + "/jobs",
+		Async: &config.AsyncConfig{
+			StatusEndpoint: endpoint + "/jobs/{{.Response.Data.id}}",
+			Interval:       config.Duration(time.Millisecond),
+			CompletedWhen:  `{{eq .Response.Data.state "done"}}`,
+			FailedWhen:     `{{eq .Response.Data.state "failed"}}`,
+			Progress:       "{{.Response.Data.percent}}",
+			Total:          "100",
+			Message:        "job is {{.Response.Data.state}}",
+			ResultBody:     "generated {{.Response.Data.output}}",
+		},
+	}
+}
+
+func newAsyncTestServer() *Server {
+	allowlist, _ := parseInternalNetworkAllowlist([]string{"127.0.0.0/8", "::1/128"})
+	return &Server{logger: zap.NewNop(), toolRespHandler: CreateResponseHandlerChain(), internalNetACL: allowlist}
+}
+
+func TestExecuteHTTPTool_AsyncJob(t *testing.T) {
+	srv := newAsyncJobServer(t, "queued", "running", "done")
+	s := newAsyncTestServer()
+	conn := &fakeConn{meta: &session.Meta{ID: "sid", Request: &session.RequestInfo{}}}
+	c, w := newGin()
+	setProgressToken(c, conn, "tok-1", false)
+
+	result, err := s.executeHTTPTool(c, conn, asyncTool(srv.URL), map[string]any{}, &config.ServerConfig{})
+	require.NoError(t, err)
+	require.Len(t, result.Content, 1)
+	assert.Equal(t, "generated report.pdf", result.Content[0].(*mcp.TextContent).Text)
+
+	// progress was streamed ahead of the response, once per running poll
+	var progress []mcp.ProgressNotificationParams
+	for _, event := range strings.Split(w.Body.String(), "\n\n") {
+		data, ok := strings.CutPrefix(event, "event: message\ndata: ")
+		if !ok {
+			continue
+		}
+		var notification struct {
+			Method string                         `json:"method"`
+			Params mcp.ProgressNotificationParams `json:"params"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(data), &notification))
+		assert.Equal(t, mcp.NotificationProgress, notification.Method)
+		progress = append(progress, notification.Params)
+	}
+	assert.Equal(t, []mcp.ProgressNotificationParams{
+		{ProgressToken: "tok-1", Progress: 40, Total: 100, Message: "job is queued"},
+		{ProgressToken: "tok-1", Progress: 80, Total: 100, Message: "job is running"},
+	}, progress)
+}
+
+func TestExecuteHTTPTool_AsyncJobFailsAndTimesOut(t *testing.T) {
+	s := newAsyncTestServer()
+	conn := &fakeConn{meta: &session.Meta{ID: "sid", Request: &session.RequestInfo{}}}
+
+	srv := newAsyncJobServer(t, "running", "failed")
+	c, w := newGin()
+	_, err := s.executeHTTPTool(c, conn, asyncTool(srv.URL), map[string]any{}, &config.ServerConfig{})
+	var jobErr *AsyncJobError
+	if assert.ErrorAs(t, err, &jobErr) {
+		assert.Equal(t, "job is failed", jobErr.Message)
+	}
+	// no progress token, no notifications
+	assert.Empty(t, w.Body.String())
+
+	srv = newAsyncJobServer(t, "running")
+	tool := asyncTool(srv.URL)
+	tool.Async.Timeout = config.Duration(20 * time.Millisecond)
+	c, _ = newGin()
+	_, err = s.executeHTTPTool(c, conn, tool, map[string]any{}, &config.ServerConfig{})
+	var timeoutErr *TimeoutError
+	assert.ErrorAs(t, err, &timeoutErr)
+}
+
+func TestExecuteHTTPTool_AsyncJobPollTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			_, _ = w.Write([]byte(`{"id":"j1"}`))
+			return
+		}
+		// the status endpoint hangs
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+	s := newAsyncTestServer()
+	conn := &fakeConn{meta: &session.Meta{ID: "sid", Request: &session.RequestInfo{}}}
+
+	// every poll is bounded by the total timeout of the tool, not only by the job timeout
+	tool := asyncTool(srv.URL)
+	tool.Timeout = &config.TimeoutConfig{Total: config.Duration(50 * time.Millisecond)}
+	c, _ := newGin()
+	start := time.Now()
+	_, err := s.executeHTTPTool(c, conn, tool, map[string]any{}, &config.ServerConfig{})
+	var timeoutErr *TimeoutError
+	assert.ErrorAs(t, err, &timeoutErr)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
@@ -124,16 +124,21 @@ func (s *Server) sendOAuthError(c *gin.Context, err error) {
 
 // isValidAccessToken checks if the request has a valid Bearer token
 func (s *Server) isValidAccessToken(r *http.Request) bool {
-	auth := r.Header.Get("Authorization")
-	if auth == "" {
-		return false
-	}
-	parts := strings.Split(auth, " ")
-	if len(parts) != 2 || parts[0] != "Bearer" || parts[1] == "" {
+	token := bearerToken(r.Header.Get("Authorization"))
+	if token == "" {
 		return false
 	}
 
 	// Validate token using auth service
-	err := s.auth.ValidateToken(r.Context(), parts[1])
+	err := s.auth.ValidateToken(r.Context(), token)
 	return err == nil
 }
+
+// bearerToken extracts the token of a Bearer authorization header
+func bearerToken(header string) string {
+	parts := strings.Split(header, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return ""
+	}
+	return parts[1]
+}
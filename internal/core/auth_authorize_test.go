@@ -42,6 +42,9 @@ func (fakeOAuth2) Register(_ context.Context, _ *http.Request) (*inta.ClientRegi
 }
 func (fakeOAuth2) Revoke(_ context.Context, _ *http.Request) error { return nil }
 func (fakeOAuth2) ValidateToken(_ context.Context, _ string) error { return nil }
+func (fakeOAuth2) TokenClientID(_ context.Context, _ string) (string, error) {
+	return "id", nil
+}
 
 func TestHandleOAuthAuthorize_GET_RendersPage(t *testing.T) {
 	// Ensure template exists for rendering
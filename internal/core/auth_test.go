@@ -21,6 +21,7 @@ type mockAuthService struct {
 	register       func(ctx context.Context, r *http.Request) (*auth.ClientRegistrationResponse, error)
 	revoke         func(ctx context.Context, r *http.Request) error
 	validateToken  func(ctx context.Context, token string) error
+	tokenClientID  func(ctx context.Context, token string) (string, error)
 }
 
 func (m *mockAuthService) ServerMetadata(r *http.Request) map[string]interface{} {
@@ -68,6 +69,16 @@ func (m *mockAuthService) ValidateToken(ctx context.Context, token string) error
 	return errorx.ErrTokenNotFound
 }
 
+func (m *mockAuthService) TokenClientID(ctx context.Context, token string) (string, error) {
+	if m.tokenClientID != nil {
+		return m.tokenClientID(ctx, token)
+	}
+	if err := m.ValidateToken(ctx, token); err != nil {
+		return "", err
+	}
+	return "test_client", nil
+}
+
 // Additional methods to implement the full Auth interface
 func (m *mockAuthService) IsOAuth2Enabled() bool {
 	return true
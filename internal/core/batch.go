@@ -0,0 +1,107 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/pkg/mcp"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// batchKey is the gin context key of the responses collected for a JSON-RPC batch
+const batchKey = "mcp.batch"
+
+// batchResponses collects the responses to the messages of a JSON-RPC batch, which are
+// answered together once every message is handled
+type batchResponses struct {
+	items []json.RawMessage
+}
+
+func (b *batchResponses) add(data []byte) {
+	b.items = append(b.items, data)
+}
+
+// getBatch returns the responses collected for the batch being handled, nil outside of a batch
+func getBatch(c *gin.Context) *batchResponses {
+	v, ok := c.Get(batchKey)
+	if !ok {
+		return nil
+	}
+	batch, _ := v.(*batchResponses)
+	return batch
+}
+
+// isBatch reports whether a request body holds a JSON-RPC batch rather than a single message
+func isBatch(body []byte) bool {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// handleBatch handles the messages of a JSON-RPC batch one after another and answers with
+// the array of their responses. Batching was removed in protocol version 2025-06-18, so
+// sessions that negotiated it are answered with an Invalid Request error instead.
+func (s *Server) handleBatch(c *gin.Context, conn session.Connection, body []byte,
+	handle func(*gin.Context, mcp.JSONRPCRequest, session.Connection), isSSE bool) {
+	version := protocolVersion(conn)
+	if mcp.ProtocolVersionAtLeast(version, mcp.ProtocolVersion20250618) {
+		s.sendProtocolError(c, nil,
+			fmt.Sprintf("Invalid Request: JSON-RPC batching is not supported in protocol version %s", version),
+			http.StatusBadRequest, mcp.ErrorCodeInvalidRequest)
+		return
+	}
+
+	var reqs []mcp.JSONRPCRequest
+	if err := json.Unmarshal(body, &reqs); err != nil {
+		s.sendProtocolError(c, nil, "Invalid JSON-RPC batch", http.StatusBadRequest, mcp.ErrorCodeParseError)
+		return
+	}
+	if len(reqs) == 0 {
+		s.sendProtocolError(c, nil, "Invalid Request: empty batch", http.StatusBadRequest, mcp.ErrorCodeInvalidRequest)
+		return
+	}
+
+	batch := &batchResponses{}
+	c.Set(batchKey, batch)
+	request := c.Request
+	for _, req := range reqs {
+		if req.Method == mcp.Initialize {
+			s.sendProtocolError(c, req.Id, "Invalid Request: initialize must not be part of a batch",
+				http.StatusBadRequest, mcp.ErrorCodeInvalidRequest)
+			continue
+		}
+		handle(c, req, conn)
+		// every message gets its own span, do not nest them
+		c.Request = request
+	}
+	c.Set(batchKey, nil)
+
+	// a batch of notifications only is accepted without a response
+	if len(batch.items) == 0 {
+		c.String(http.StatusAccepted, mcp.Accepted)
+		return
+	}
+
+	data, err := json.Marshal(batch.items)
+	if err != nil {
+		s.sendProtocolError(c, nil, "Failed to marshal response", http.StatusInternalServerError, mcp.ErrorCodeInternalError)
+		return
+	}
+	if isSSE {
+		err = conn.Send(c.Request.Context(), &session.Message{Event: "message", Data: data})
+		if err != nil {
+			s.getLogger(c).Error("failed to send SSE message",
+				zap.String("session_id", conn.Meta().ID),
+				zap.Error(err))
+			s.sendProtocolError(c, nil, fmt.Sprintf("failed to send SSE message: %v", err), http.StatusInternalServerError, mcp.ErrorCodeInternalError)
+			return
+		}
+		c.String(http.StatusAccepted, mcp.Accepted)
+		return
+	}
+	c.Header(mcp.HeaderMcpSessionID, conn.Meta().ID)
+	c.Data(http.StatusOK, "application/json", data)
+}
@@ -0,0 +1,225 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/amoylab/unla/internal/common/config"
+)
+
+// Defaults applied to a circuit breaker config when a field is left empty
+const (
+	defaultBreakerFailureThreshold = 5
+	defaultBreakerOpenDuration     = 30 * time.Second
+	defaultBreakerHalfOpenRequests = 1
+
+	// breakerKeyByServer shares one breaker between all tools of a server
+	// instead of one per endpoint host
+	breakerKeyByServer = "server"
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerHalfOpen
+	breakerOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerClosed:
+		return "closed"
+	case breakerHalfOpen:
+		return "half-open"
+	case breakerOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitOpenError is returned when a call is rejected by an open circuit breaker
+type CircuitOpenError struct {
+	Key     string
+	RetryIn time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("upstream %s is unavailable (circuit open), retry in %s",
+		e.Key, e.RetryIn.Round(time.Second))
+}
+
+// circuitBreaker tracks consecutive failures of a single upstream
+type circuitBreaker struct {
+	mu               sync.Mutex
+	key              string
+	failureThreshold int
+	openDuration     time.Duration
+	halfOpenRequests int
+
+	state     breakerState
+	failures  int
+	openedAt  time.Time
+	probingAt time.Time // when the circuit turned half-open
+	probes    int       // in-flight half-open probes
+	successes int       // successful half-open probes
+
+	onStateChange func(key string, state breakerState)
+}
+
+// configure applies the (possibly reloaded) config to the breaker
+func (b *circuitBreaker) configure(cfg config.CircuitBreakerConfig) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failureThreshold = cfg.FailureThreshold
+	if b.failureThreshold <= 0 {
+		b.failureThreshold = defaultBreakerFailureThreshold
+	}
+	b.openDuration = cfg.OpenDuration.Std()
+	if b.openDuration <= 0 {
+		b.openDuration = defaultBreakerOpenDuration
+	}
+	b.halfOpenRequests = cfg.HalfOpenRequests
+	if b.halfOpenRequests <= 0 {
+		b.halfOpenRequests = defaultBreakerHalfOpenRequests
+	}
+}
+
+// allow reports whether a call may proceed. Callers that were allowed must
+// report the outcome through done.
+func (b *circuitBreaker) allow(now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		elapsed := now.Sub(b.openedAt)
+		if elapsed < b.openDuration {
+			return &CircuitOpenError{Key: b.key, RetryIn: b.openDuration - elapsed}
+		}
+		b.setState(breakerHalfOpen)
+		b.probingAt = now
+		b.probes = 0
+		b.successes = 0
+		fallthrough
+	case breakerHalfOpen:
+		if b.probes >= b.halfOpenRequests {
+			// the probes in flight get another open duration to settle the circuit
+			return &CircuitOpenError{Key: b.key, RetryIn: max(b.openDuration-now.Sub(b.probingAt), time.Second)}
+		}
+		b.probes++
+	}
+	return nil
+}
+
+// done records the outcome of an allowed call
+func (b *circuitBreaker) done(success bool, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerClosed:
+		if success {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.failureThreshold {
+			b.open(now)
+		}
+	case breakerHalfOpen:
+		if b.probes > 0 {
+			b.probes--
+		}
+		if !success {
+			b.open(now)
+			return
+		}
+		b.successes++
+		if b.successes >= b.halfOpenRequests {
+			b.failures = 0
+			b.setState(breakerClosed)
+		}
+	}
+}
+
+// release frees a half-open probe slot without counting the call either way,
+// e.g. when the client went away before the upstream answered
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == breakerHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+func (b *circuitBreaker) open(now time.Time) {
+	b.openedAt = now
+	b.probes = 0
+	b.successes = 0
+	b.setState(breakerOpen)
+}
+
+func (b *circuitBreaker) setState(state breakerState) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	if b.onStateChange != nil {
+		b.onStateChange(b.key, state)
+	}
+}
+
+// breakerRegistry holds one circuit breaker per upstream key
+type breakerRegistry struct {
+	mu            sync.Mutex
+	breakers      map[string]*circuitBreaker
+	onStateChange func(key string, state breakerState)
+}
+
+func newBreakerRegistry(onStateChange func(key string, state breakerState)) *breakerRegistry {
+	return &breakerRegistry{
+		breakers:      make(map[string]*circuitBreaker),
+		onStateChange: onStateChange,
+	}
+}
+
+// get returns the breaker for key, creating it on first use
+func (r *breakerRegistry) get(key string, cfg config.CircuitBreakerConfig) *circuitBreaker {
+	r.mu.Lock()
+	b, ok := r.breakers[key]
+	if !ok {
+		b = &circuitBreaker{key: key, onStateChange: r.onStateChange}
+		r.breakers[key] = b
+	}
+	r.mu.Unlock()
+
+	b.configure(cfg)
+	return b
+}
+
+// circuitBreakerFor resolves the breaker guarding a tool call, or nil when disabled
+func (s *Server) circuitBreakerFor(serverCfg *config.ServerConfig, host string) *circuitBreaker {
+	if s.breakers == nil {
+		return nil
+	}
+	cfg := s.circuitBreaker
+	if serverCfg != nil && serverCfg.CircuitBreaker != nil {
+		cfg = *serverCfg.CircuitBreaker
+	}
+	if !cfg.Enabled {
+		return nil
+	}
+
+	// servers sharing a host keep breakers of their own, they may configure them differently
+	key := host
+	if serverCfg != nil && serverCfg.Name != "" {
+		key = serverCfg.Name + "/" + host
+		if cfg.KeyBy == breakerKeyByServer {
+			key = serverCfg.Name
+		}
+	}
+	return s.breakers.get(key, cfg)
+}
@@ -0,0 +1,162 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestCircuitBreaker_Transitions(t *testing.T) {
+	var states []breakerState
+	r := newBreakerRegistry(func(_ string, state breakerState) { states = append(states, state) })
+	b := r.get("host", config.CircuitBreakerConfig{
+		Enabled:          true,
+		FailureThreshold: 2,
+		OpenDuration:     config.Duration(time.Minute),
+	})
+	now := time.Now()
+
+	// failures below the threshold keep the circuit closed
+	assert.NoError(t, b.allow(now))
+	b.done(false, now)
+	assert.NoError(t, b.allow(now))
+	b.done(true, now)
+	assert.NoError(t, b.allow(now))
+	b.done(false, now)
+	assert.Equal(t, breakerClosed, b.state)
+
+	// reaching the threshold opens the circuit
+	assert.NoError(t, b.allow(now))
+	b.done(false, now)
+	assert.Equal(t, breakerOpen, b.state)
+	err := b.allow(now.Add(10 * time.Second))
+	var openErr *CircuitOpenError
+	if assert.ErrorAs(t, err, &openErr) {
+		assert.Equal(t, "host", openErr.Key)
+		assert.Equal(t, 50*time.Second, openErr.RetryIn)
+	}
+
+	// after the open duration a single probe is let through
+	later := now.Add(time.Minute)
+	assert.NoError(t, b.allow(later))
+	assert.Equal(t, breakerHalfOpen, b.state)
+	err = b.allow(later.Add(15 * time.Second))
+	if assert.ErrorAs(t, err, &openErr) {
+		// until the probe settles the circuit
+		assert.Equal(t, 45*time.Second, openErr.RetryIn)
+	}
+
+	// a failed probe reopens the circuit
+	b.done(false, later)
+	assert.Equal(t, breakerOpen, b.state)
+
+	// a successful probe closes it again
+	later = later.Add(time.Minute)
+	assert.NoError(t, b.allow(later))
+	b.done(true, later)
+	assert.Equal(t, breakerClosed, b.state)
+
+	assert.Equal(t, []breakerState{breakerOpen, breakerHalfOpen, breakerOpen, breakerHalfOpen, breakerClosed}, states)
+}
+
+func TestCircuitBreaker_Release(t *testing.T) {
+	b := newBreakerRegistry(nil).get("host", config.CircuitBreakerConfig{Enabled: true, FailureThreshold: 1})
+	now := time.Now()
+	assert.NoError(t, b.allow(now))
+	b.done(false, now)
+
+	later := now.Add(defaultBreakerOpenDuration)
+	assert.NoError(t, b.allow(later))
+	b.release()
+	// the released slot can be used by the next probe
+	assert.NoError(t, b.allow(later))
+	assert.Equal(t, breakerHalfOpen, b.state)
+}
+
+func TestCircuitBreakerFor(t *testing.T) {
+	s := &Server{
+		breakers:       newBreakerRegistry(nil),
+		circuitBreaker: config.CircuitBreakerConfig{Enabled: true},
+	}
+
+	b := s.circuitBreakerFor(&config.ServerConfig{Name: "srv"}, "example.com")
+	if assert.NotNil(t, b) {
+		assert.Equal(t, "srv/example.com", b.key)
+		assert.Equal(t, defaultBreakerFailureThreshold, b.failureThreshold)
+	}
+
+	// another server on the same host does not change the thresholds of the first
+	other := s.circuitBreakerFor(&config.ServerConfig{
+		Name:           "other",
+		CircuitBreaker: &config.CircuitBreakerConfig{Enabled: true, FailureThreshold: 2},
+	}, "example.com")
+	if assert.NotNil(t, other) {
+		assert.NotSame(t, b, other)
+		assert.Equal(t, 2, other.failureThreshold)
+		assert.Equal(t, defaultBreakerFailureThreshold, b.failureThreshold)
+	}
+
+	// server override keyed by server name
+	b = s.circuitBreakerFor(&config.ServerConfig{
+		Name:           "srv",
+		CircuitBreaker: &config.CircuitBreakerConfig{Enabled: true, KeyBy: "server", FailureThreshold: 7},
+	}, "example.com")
+	if assert.NotNil(t, b) {
+		assert.Equal(t, "srv", b.key)
+		assert.Equal(t, 7, b.failureThreshold)
+	}
+
+	// server override disables the gateway default
+	assert.Nil(t, s.circuitBreakerFor(&config.ServerConfig{
+		CircuitBreaker: &config.CircuitBreakerConfig{Enabled: false},
+	}, "example.com"))
+
+	// servers built without a registry never break
+	assert.Nil(t, (&Server{circuitBreaker: config.CircuitBreakerConfig{Enabled: true}}).circuitBreakerFor(nil, "example.com"))
+}
+
+func TestExecuteHTTPTool_CircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	allowlist, _ := parseInternalNetworkAllowlist([]string{"127.0.0.0/8", "::1/128"})
+	s := &Server{
+		logger:          zap.NewNop(),
+		toolRespHandler: CreateResponseHandlerChain(),
+		internalNetACL:  allowlist,
+		breakers:        newBreakerRegistry(nil),
+	}
+	tool := &config.ToolConfig{Name: "t", Method: http.MethodGet, Endpoint: srv.URL}
+	serverCfg := &config.ServerConfig{
+		Name:           "srv",
+		CircuitBreaker: &config.CircuitBreakerConfig{Enabled: true, FailureThreshold: 2},
+	}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	conn := &fakeConnExec{meta: &session.Meta{ID: "sid", Request: &session.RequestInfo{}}}
+	c, _ := gin.CreateTestContext(nil)
+	c.Request = req
+
+	for i := 0; i < 2; i++ {
+		_, err := s.executeHTTPTool(c, conn, tool, map[string]any{}, serverCfg)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), calls.Load())
+
+	// the open circuit fails fast without reaching the upstream
+	_, err := s.executeHTTPTool(c, conn, tool, map[string]any{}, serverCfg)
+	var openErr *CircuitOpenError
+	assert.ErrorAs(t, err, &openErr)
+	assert.Equal(t, int32(2), calls.Load())
+}
@@ -0,0 +1,165 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/core/toolcache"
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/internal/template"
+	"github.com/amoylab/unla/pkg/mcp"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// executeHTTPToolCached serves read-only tools from the tool cache when possible and
+// executes them otherwise, caching successful results
+func (s *Server) executeHTTPToolCached(c *gin.Context, conn session.Connection, tool *config.ToolConfig,
+	args map[string]any, serverCfg *config.ServerConfig) (*mcp.CallToolResult, error) {
+	if s.toolCache == nil || !tool.IsCacheable() {
+		return s.executeHTTPTool(c, conn, tool, args, serverCfg)
+	}
+
+	logger := s.getLogger(c)
+	key, err := s.toolCacheKey(c, conn, tool, args, serverCfg)
+	if err != nil {
+		// A broken key template should not break the tool itself
+		logger.Warn("failed to render tool cache key",
+			zap.String("tool", tool.Name),
+			zap.Error(err))
+		return s.executeHTTPTool(c, conn, tool, args, serverCfg)
+	}
+
+	ctx := c.Request.Context()
+	if data, ok, err := s.toolCache.Get(ctx, key); err != nil {
+		logger.Warn("failed to read tool cache", zap.String("tool", tool.Name), zap.Error(err))
+	} else if ok {
+		var result mcp.CallToolResult
+		if err := json.Unmarshal(data, &result); err == nil {
+			logger.Debug("tool cache hit", zap.String("tool", tool.Name))
+			oteltrace.SpanFromContext(ctx).AddEvent("tool_cache.hit")
+			if s.metrics != nil {
+				s.metrics.ToolCacheRequest(tool.Name, true)
+			}
+			return &result, nil
+		}
+	}
+	if s.metrics != nil {
+		s.metrics.ToolCacheRequest(tool.Name, false)
+	}
+
+	result, err := s.executeHTTPTool(c, conn, tool, args, serverCfg)
+	if err != nil || result == nil || result.IsError {
+		return result, err
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return result, nil
+	}
+	if err := s.toolCache.Set(ctx, key, data, tool.Cache.TTL.Std(), tool.Cache.MaxEntries); err != nil {
+		logger.Warn("failed to write tool cache", zap.String("tool", tool.Name), zap.Error(err))
+	}
+	return result, nil
+}
+
+// toolCacheKey renders the cache key of a call. Without a key template the key is
+// the arguments, including defaults, encoded as JSON. Unless the cache is shared the
+// key is scoped to the user of the call.
+func (s *Server) toolCacheKey(c *gin.Context, conn session.Connection, tool *config.ToolConfig,
+	args map[string]any, serverCfg *config.ServerConfig) (toolcache.Key, error) {
+	key, err := s.renderToolCacheKey(c, conn, tool, args, serverCfg)
+	if err != nil || tool.Cache.Shared {
+		return key, err
+	}
+	key.Value = s.toolCacheScope(c, conn) + "\x00" + key.Value
+	return key, nil
+}
+
+// toolCacheScope is the user cached results are served to, the OAuth client of the
+// request or else its session
+func (s *Server) toolCacheScope(c *gin.Context, conn session.Connection) string {
+	if clientID := s.requestClientID(c, conn); clientID != "" {
+		return "client:" + clientID
+	}
+	return "session:" + conn.Meta().ID
+}
+
+func (s *Server) renderToolCacheKey(c *gin.Context, conn session.Connection, tool *config.ToolConfig,
+	args map[string]any, serverCfg *config.ServerConfig) (toolcache.Key, error) {
+	key := toolcache.Key{Namespace: conn.Meta().Prefix, Tool: tool.Name}
+
+	keyArgs := make(map[string]any, len(args))
+	for k, v := range args {
+		keyArgs[k] = v
+	}
+	fillDefaultArgs(tool, keyArgs)
+
+	if tool.Cache.Key == "" {
+		data, err := json.Marshal(keyArgs)
+		if err != nil {
+			return key, err
+		}
+		key.Value = string(data)
+		return key, nil
+	}
+
+	tmplCtx, err := template.PrepareTemplateContext(conn.Meta().Request, keyArgs, c.Request, serverCfg.Config)
+	if err != nil {
+		return key, err
+	}
+	key.Value, err = template.RenderTemplate(tool.Cache.Key, tmplCtx)
+	return key, err
+}
+
+// invalidateToolCache drops the cached results served under the router prefixes of
+// the given configs, called when a config version changes
+func (s *Server) invalidateToolCache(ctx context.Context, cfgs ...*config.MCPConfig) {
+	if s.toolCache == nil {
+		return
+	}
+	seen := make(map[string]struct{})
+	for _, cfg := range cfgs {
+		if cfg == nil {
+			continue
+		}
+		for _, router := range cfg.Routers {
+			if _, ok := seen[router.Prefix]; ok {
+				continue
+			}
+			seen[router.Prefix] = struct{}{}
+			if err := s.toolCache.Invalidate(ctx, router.Prefix); err != nil {
+				s.logger.Warn("failed to invalidate tool cache",
+					zap.String("prefix", router.Prefix),
+					zap.Error(err))
+			}
+		}
+	}
+}
+
+// changedConfigs returns the old and new versions of every config that was added,
+// removed or updated between two sets of configs
+func changedConfigs(oldCfgs, newCfgs []*config.MCPConfig) []*config.MCPConfig {
+	type cfgKey struct{ tenant, name string }
+	oldByKey := make(map[cfgKey]*config.MCPConfig, len(oldCfgs))
+	for _, cfg := range oldCfgs {
+		oldByKey[cfgKey{cfg.Tenant, cfg.Name}] = cfg
+	}
+
+	var changed []*config.MCPConfig
+	for _, cfg := range newCfgs {
+		k := cfgKey{cfg.Tenant, cfg.Name}
+		old, ok := oldByKey[k]
+		delete(oldByKey, k)
+		if ok && old.UpdatedAt.Equal(cfg.UpdatedAt) {
+			continue
+		}
+		changed = append(changed, old, cfg)
+	}
+	for _, old := range oldByKey {
+		changed = append(changed, old)
+	}
+	return changed
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/core/toolcache"
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/pkg/mcp"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestExecuteHTTPToolCached(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	allowlist, _ := parseInternalNetworkAllowlist([]string{"127.0.0.0/8", "::1/128"})
+	cache := toolcache.NewMemoryStore(zap.NewNop())
+	s := &Server{logger: zap.NewNop(), toolRespHandler: CreateResponseHandlerChain(), internalNetACL: allowlist, toolCache: cache}
+	tool := &config.ToolConfig{
+		Name:        "lookup",
+		Method:      http.MethodGet,
+		Endpoint:    srv.URL,
+		Annotations: map[string]any{"readOnlyHint": true},
+		Cache:       &config.CacheConfig{TTL: config.Duration(time.Minute)},
+	}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	conn := &fakeConnExec{meta: &session.Meta{ID: "sid", Prefix: "/p", Request: &session.RequestInfo{}}}
+	c, _ := gin.CreateTestContext(nil)
+	c.Request = req
+
+	call := func(args map[string]any) *mcp.CallToolResult {
+		res, err := s.executeHTTPToolCached(c, conn, tool, args, &config.ServerConfig{})
+		assert.NoError(t, err)
+		return res
+	}
+
+	res := call(map[string]any{"q": "a"})
+	if assert.NotNil(t, res) {
+		assert.Equal(t, "ok", res.Content[0].(*mcp.TextContent).Text)
+	}
+	res = call(map[string]any{"q": "a"})
+	if assert.NotNil(t, res) {
+		assert.Equal(t, "ok", res.Content[0].(*mcp.TextContent).Text)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+
+	// different arguments miss the cache
+	call(map[string]any{"q": "b"})
+	assert.Equal(t, int32(2), calls.Load())
+
+	// a key template only keeps what it references
+	tool.Cache.Key = "{{.Args.q}}"
+	call(map[string]any{"q": "a", "page": 1})
+	call(map[string]any{"q": "a", "page": 2})
+	assert.Equal(t, int32(3), calls.Load())
+
+	// results are not served to other sessions unless the cache is shared
+	other := &fakeConnExec{meta: &session.Meta{ID: "sid2", Prefix: "/p", Request: &session.RequestInfo{}}}
+	_, err := s.executeHTTPToolCached(c, other, tool, map[string]any{"q": "a"}, &config.ServerConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(4), calls.Load())
+	tool.Cache.Shared = true
+	call(map[string]any{"q": "a"})
+	_, err = s.executeHTTPToolCached(c, other, tool, map[string]any{"q": "a"}, &config.ServerConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(5), calls.Load())
+
+	// invalidation drops the entries of the prefix
+	s.invalidateToolCache(context.Background(), &config.MCPConfig{Routers: []config.RouterConfig{{Prefix: "/p"}}})
+	call(map[string]any{"q": "a"})
+	assert.Equal(t, int32(6), calls.Load())
+
+	// tools not annotated as read-only are never cached
+	tool.Annotations = nil
+	call(map[string]any{"q": "a"})
+	assert.Equal(t, int32(7), calls.Load())
+}
+
+func TestChangedConfigs(t *testing.T) {
+	now := time.Now()
+	a := &config.MCPConfig{Tenant: "t", Name: "a", UpdatedAt: now}
+	b := &config.MCPConfig{Tenant: "t", Name: "b", UpdatedAt: now}
+	c := &config.MCPConfig{Tenant: "t", Name: "c", UpdatedAt: now}
+	b2 := &config.MCPConfig{Tenant: "t", Name: "b", UpdatedAt: now.Add(time.Second)}
+	d := &config.MCPConfig{Tenant: "t", Name: "d", UpdatedAt: now}
+
+	changed := changedConfigs([]*config.MCPConfig{a, b, c}, []*config.MCPConfig{a, b2, d})
+	assert.ElementsMatch(t, []*config.MCPConfig{b, b2, nil, d, c}, changed)
+	assert.Empty(t, changedConfigs([]*config.MCPConfig{a}, []*config.MCPConfig{a}))
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/pkg/mcp"
+)
+
+// requestCancelledError is the cause of the context of a request cancelled by the client
+type requestCancelledError struct {
+	reason string
+}
+
+func (e *requestCancelledError) Error() string {
+	if e.reason == "" {
+		return "request cancelled by client"
+	}
+	return "request cancelled by client: " + e.reason
+}
+
+type inflightKey struct {
+	sessionID string
+	requestID string
+}
+
+// inflightRequests tracks the in-flight requests of the sessions of this instance by
+// session and JSON-RPC id, so that a cancellation can abort them
+type inflightRequests struct {
+	mu       sync.Mutex
+	requests map[inflightKey]context.CancelCauseFunc
+}
+
+func newInflightRequests() *inflightRequests {
+	return &inflightRequests{requests: make(map[inflightKey]context.CancelCauseFunc)}
+}
+
+// requestKey returns the JSON encoding of a JSON-RPC id, so that 1 and "1" stay distinct
+func requestKey(id any) string {
+	data, err := json.Marshal(id)
+	if err != nil {
+		return fmt.Sprint(id)
+	}
+	return string(data)
+}
+
+// track returns a context cancelled when the request is cancelled, and a func to call
+// once the request is done
+func (r *inflightRequests) track(ctx context.Context, sessionID string, id any) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	key := inflightKey{sessionID: sessionID, requestID: requestKey(id)}
+
+	r.mu.Lock()
+	r.requests[key] = cancel
+	r.mu.Unlock()
+
+	return ctx, func() {
+		r.mu.Lock()
+		delete(r.requests, key)
+		r.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// cancel aborts the request if it is in flight on this instance and reports whether it was
+func (r *inflightRequests) cancel(cancellation *session.Cancellation) bool {
+	key := inflightKey{sessionID: cancellation.SessionID, requestID: cancellation.RequestID}
+	r.mu.Lock()
+	cancel, ok := r.requests[key]
+	delete(r.requests, key)
+	r.mu.Unlock()
+	if ok {
+		cancel(&requestCancelledError{reason: cancellation.Reason})
+	}
+	return ok
+}
+
+// isRequestCancelled reports whether the request was cancelled by the client, in which
+// case no response is sent for it
+func isRequestCancelled(c *gin.Context) bool {
+	var cancelled *requestCancelledError
+	return errors.As(context.Cause(c.Request.Context()), &cancelled)
+}
+
+// handleCancelled handles notifications/cancelled. The request is aborted right away when it
+// is in flight on this instance, otherwise the cancellation is published to the session
+// store so that the instance serving it aborts it.
+func (s *Server) handleCancelled(c *gin.Context, conn session.Connection, req mcp.JSONRPCRequest) {
+	var params mcp.CancelledNotificationParams
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.RequestID == nil {
+		s.sendProtocolError(c, req.Id, "Invalid cancellation parameters", http.StatusBadRequest, mcp.ErrorCodeInvalidParams)
+		return
+	}
+
+	cancellation := &session.Cancellation{
+		SessionID: conn.Meta().ID,
+		RequestID: requestKey(params.RequestID),
+		Reason:    params.Reason,
+	}
+	if !s.inflight.cancel(cancellation) {
+		if err := s.sessions.PublishCancel(c.Request.Context(), cancellation); err != nil {
+			s.getLogger(c).Warn("failed to publish cancellation",
+				zap.String("session_id", cancellation.SessionID),
+				zap.String("request_id", cancellation.RequestID),
+				zap.Error(err))
+		}
+	}
+	s.sendAcceptedResponse(c)
+}
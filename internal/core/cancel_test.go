@@ -0,0 +1,88 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/pkg/mcp"
+)
+
+func cancelRequest(t *testing.T, s *Server, conn session.Connection, id any, reason string) int {
+	t.Helper()
+	params, err := json.Marshal(mcp.CancelledNotificationParams{RequestID: id, Reason: reason})
+	require.NoError(t, err)
+	c, w := newGin()
+	s.handleCancelled(c, conn, mcp.JSONRPCRequest{JSONRPC: mcp.JSPNRPCVersion, Method: mcp.NotificationCancelled, Params: params})
+	return w.Code
+}
+
+func TestExecuteHTTPTool_Cancelled(t *testing.T) {
+	arrived := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(arrived)
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	allowlist, _ := parseInternalNetworkAllowlist([]string{"127.0.0.0/8", "::1/128"})
+	s := &Server{
+		logger:          zap.NewNop(),
+		toolRespHandler: CreateResponseHandlerChain(),
+		internalNetACL:  allowlist,
+		sessions:        session.NewMemoryStore(zap.NewNop()),
+		inflight:        newInflightRequests(),
+	}
+	conn := &fakeConn{meta: &session.Meta{ID: "sid", Request: &session.RequestInfo{}}}
+	c, w := newGin()
+	ctx, done := s.inflight.track(c.Request.Context(), "sid", 7)
+	defer done()
+	c.Request = c.Request.WithContext(ctx)
+
+	go func() {
+		<-arrived
+		// the same id as a string belongs to another request
+		assert.Equal(t, http.StatusAccepted, cancelRequest(t, s, conn, "7", ""))
+		assert.Equal(t, http.StatusAccepted, cancelRequest(t, s, conn, 7, "user aborted"))
+	}()
+
+	tool := &config.ToolConfig{Name: "slow", Method: http.MethodGet, Endpoint: srv.URL}
+	start := time.Now()
+	_, err := s.executeHTTPTool(c, conn, tool, map[string]any{}, &config.ServerConfig{})
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.EqualError(t, context.Cause(c.Request.Context()), "request cancelled by client: user aborted")
+
+	// no response is sent for a cancelled request
+	s.sendToolExecutionError(c, conn, mcp.JSONRPCRequest{JSONRPC: mcp.JSPNRPCVersion, Id: 7, Method: mcp.ToolsCall}, err, false)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, mcp.Accepted, w.Body.String())
+}
+
+func TestHandleCancelled_PublishesToOtherInstances(t *testing.T) {
+	store := session.NewMemoryStore(zap.NewNop())
+	s := &Server{logger: zap.NewNop(), sessions: store, inflight: newInflightRequests()}
+
+	// the request is in flight on another instance sharing the store
+	remote := newInflightRequests()
+	store.OnCancel(func(cancellation *session.Cancellation) { remote.cancel(cancellation) })
+	ctx, done := remote.track(context.Background(), "sid", "req-1")
+	defer done()
+
+	conn := &fakeConn{meta: &session.Meta{ID: "sid"}}
+	assert.Equal(t, http.StatusAccepted, cancelRequest(t, s, conn, "req-1", ""))
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	var cancelled *requestCancelledError
+	assert.ErrorAs(t, context.Cause(ctx), &cancelled)
+
+	assert.Equal(t, http.StatusBadRequest, cancelRequest(t, s, conn, nil, ""))
+}
@@ -0,0 +1,275 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/core/mcpproxy"
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/internal/template"
+	"github.com/amoylab/unla/pkg/mcp"
+	apptrace "github.com/amoylab/unla/pkg/trace"
+)
+
+// errCompletionRefNotFound is returned when the prompt or resource template to complete does not exist
+var errCompletionRefNotFound = errors.New("completion reference not found")
+
+// handleCompletionComplete answers completion/complete. Arguments of configured prompts and
+// resource templates are completed from their enum or HTTP lookup, arguments of proxied MCP
+// servers by the upstream server.
+func (s *Server) handleCompletionComplete(c *gin.Context, conn session.Connection, req mcp.JSONRPCRequest, isSSE bool) {
+	var params mcp.CompleteParams
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.Argument.Name == "" {
+		s.sendProtocolError(c, req.Id, "Invalid completion parameters", http.StatusBadRequest, mcp.ErrorCodeInvalidParams)
+		return
+	}
+
+	var (
+		result *mcp.CompleteResult
+		err    error
+	)
+	prefix := conn.Meta().Prefix
+	switch s.state.GetProtoType(prefix) {
+	case cnst.BackendProtoHttp:
+		var completion *config.CompletionConfig
+		if completion, err = s.completionConfig(prefix, params); err == nil {
+			result, err = s.completeArgument(c, conn, completion, params)
+		}
+	case cnst.BackendProtoStdio, cnst.BackendProtoSSE, cnst.BackendProtoStreamable:
+		transport := s.state.GetTransport(prefix)
+		if transport == nil {
+			err = fmt.Errorf("transport not found")
+			break
+		}
+		result, err = transport.Complete(s.upstreamContext(c, conn), params, mergeRequestInfo(conn.Meta().Request, c.Request))
+	default:
+		result = completionResult(nil)
+	}
+	if err != nil {
+		if errors.Is(err, errCompletionRefNotFound) {
+			s.sendJSONRPCErrorWithData(c, conn, req, "Completion reference not found", mcp.ErrorCodeInvalidParams,
+				map[string]any{"ref": params.Ref}, isSSE)
+			return
+		}
+		s.getLogger(c).Error("failed to complete argument",
+			zap.String("ref", params.Ref.Type),
+			zap.String("argument", params.Argument.Name),
+			zap.String("session_id", conn.Meta().ID),
+			zap.Error(err))
+		message := fmt.Sprintf("Failed to complete argument: %v", err)
+		var stderrErr *mcpproxy.StderrError
+		if errors.As(err, &stderrErr) {
+			message = "Failed to complete argument"
+		}
+		s.sendJSONRPCError(c, conn, req, message, mcp.ErrorCodeInternalError, isSSE)
+		return
+	}
+	s.sendSuccessResponse(c, conn, req, result, isSSE)
+}
+
+// completionConfig returns how the argument of the referenced prompt or resource template is
+// completed, or nil when it has no completions
+func (s *Server) completionConfig(prefix string, params mcp.CompleteParams) (*config.CompletionConfig, error) {
+	switch params.Ref.Type {
+	case mcp.RefPrompt:
+		prompt := s.state.GetPrompt(prefix, params.Ref.Name)
+		if prompt == nil {
+			return nil, errCompletionRefNotFound
+		}
+		for i := range prompt.Arguments {
+			if prompt.Arguments[i].Name == params.Argument.Name {
+				return prompt.Arguments[i].ToCompletionConfig(), nil
+			}
+		}
+		return nil, nil
+	case mcp.RefResource:
+		res := s.state.GetResourceTemplate(prefix, params.Ref.URI)
+		if res == nil {
+			return nil, errCompletionRefNotFound
+		}
+		return res.Completions[params.Argument.Name], nil
+	default:
+		return nil, errCompletionRefNotFound
+	}
+}
+
+// completeArgument returns the enum values starting with the typed value, or the values
+// looked up at the completion endpoint
+func (s *Server) completeArgument(c *gin.Context, conn session.Connection, completion *config.CompletionConfig,
+	params mcp.CompleteParams) (*mcp.CompleteResult, error) {
+	if completion == nil {
+		return completionResult(nil), nil
+	}
+	if completion.Endpoint == "" {
+		prefix := strings.ToLower(params.Argument.Value)
+		var values []string
+		for _, v := range completion.Enum {
+			if strings.HasPrefix(strings.ToLower(v), prefix) {
+				values = append(values, v)
+			}
+		}
+		return completionResult(values), nil
+	}
+
+	values, err := s.lookupCompletion(c, conn, completion, params)
+	if err != nil {
+		return nil, err
+	}
+	return completionResult(values), nil
+}
+
+// lookupCompletion calls the completion endpoint. The arguments resolved by the client and
+// the argument being completed are passed to the templates as args.
+func (s *Server) lookupCompletion(c *gin.Context, conn session.Connection, completion *config.CompletionConfig,
+	params mcp.CompleteParams) ([]string, error) {
+	prefix := conn.Meta().Prefix
+	serverCfg := s.state.GetServerConfig(prefix)
+	if serverCfg == nil {
+		return nil, fmt.Errorf("server configuration not found")
+	}
+
+	scope := apptrace.Tracer(cnst.TraceCore).
+		Start(c.Request.Context(), cnst.SpanHTTPCompletion, oteltrace.WithSpanKind(oteltrace.SpanKindInternal)).
+		WithAttrs(
+			attribute.String(cnst.AttrMCPSessionID, conn.Meta().ID),
+			attribute.String(cnst.AttrMCPPrefix, prefix),
+			attribute.String(cnst.AttrMCPCompletionArgument, params.Argument.Name),
+		)
+	ctx := scope.Ctx
+	defer scope.End()
+
+	args := make(map[string]any)
+	if params.Context != nil {
+		for k, v := range params.Context.Arguments {
+			args[k] = v
+		}
+	}
+	args[params.Argument.Name] = params.Argument.Value
+	tmplCtx, err := template.PrepareTemplateContext(conn.Meta().Request, args, c.Request, serverCfg.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	tool := completion.ToToolConfig(params.Argument.Name)
+	req, _, err := s.prepareRequest(tool, tmplCtx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateToolEndpoint(ctx, req.URL); err != nil {
+		s.getLogger(c).Warn("blocked completion endpoint",
+			zap.String("argument", params.Argument.Name),
+			zap.String("endpoint", req.URL.String()),
+			zap.Error(err))
+		return nil, err
+	}
+
+	timeouts := s.toolTimeouts(serverCfg, tool)
+	cli := s.state.GetCompletionHTTPClient(prefix)
+	if cli == nil {
+		if cli, err = createHTTPClient(tool, timeouts); err != nil {
+			return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+		}
+	}
+
+	breaker := s.circuitBreakerFor(serverCfg, req.URL.Host)
+	if breaker != nil {
+		if err := breaker.allow(time.Now()); err != nil {
+			scope.Span.AddEvent(cnst.EventCircuitBreakerRejected,
+				oteltrace.WithAttributes(attribute.String(cnst.AttrCircuitBreakerKey, breaker.key)))
+			if s.metrics != nil {
+				s.metrics.CircuitBreakerRejected(breaker.key)
+			}
+			return nil, err
+		}
+	}
+
+	reqCtx := ctx
+	if timeouts.Total > 0 {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeout(ctx, timeouts.Total.Std())
+		defer cancel()
+	}
+	resp, err := s.doHTTPRequest(reqCtx, cli, req.WithContext(reqCtx), tool, scope.Span, s.getLogger(c))
+	if breaker != nil {
+		if err != nil && ctx.Err() != nil {
+			breaker.release()
+		} else {
+			breaker.done(err == nil && resp.StatusCode < http.StatusInternalServerError, time.Now())
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	scope.Span.SetAttributes(attribute.Int(cnst.AttrHTTPStatusCode, resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("upstream returned HTTP %d", resp.StatusCode)
+	}
+
+	text := string(body)
+	if completion.ResponseBody != "" {
+		var data map[string]any
+		_ = json.Unmarshal(body, &data)
+		tmplCtx.Response.Data = preprocessResponseData(data)
+		tmplCtx.Response.Body = text
+		if text, err = template.RenderTemplate(completion.ResponseBody, tmplCtx); err != nil {
+			return nil, fmt.Errorf("failed to render response body template: %w", err)
+		}
+	}
+	return parseCompletionValues(text), nil
+}
+
+// parseCompletionValues reads a JSON array of values, falling back to one value per line
+func parseCompletionValues(text string) []string {
+	var items []any
+	if err := json.Unmarshal([]byte(text), &items); err == nil {
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			} else if item != nil {
+				values = append(values, fmt.Sprint(item))
+			}
+		}
+		return values
+	}
+
+	var values []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			values = append(values, line)
+		}
+	}
+	return values
+}
+
+// completionResult caps the values at the number a completion result may contain
+func completionResult(values []string) *mcp.CompleteResult {
+	result := &mcp.CompleteResult{Completion: mcp.CompletionValues{Values: values, Total: len(values)}}
+	if result.Completion.Values == nil {
+		result.Completion.Values = []string{}
+	}
+	if len(values) > mcp.MaxCompletionValues {
+		result.Completion.Values = values[:mcp.MaxCompletionValues]
+		result.Completion.HasMore = true
+	}
+	return result
+}
@@ -0,0 +1,172 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/core/state"
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/pkg/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newCompletionTestServer(t *testing.T, upstream string) *Server {
+	t.Helper()
+	cfg := &config.MCPConfig{
+		Name:    "completion",
+		Routers: []config.RouterConfig{{Prefix: "/c", Server: "srv"}},
+		Servers: []config.ServerConfig{{
+			Name:             "srv",
+			AllowedResources: []string{"city"},
+			Config:           map[string]string{"country": "cn"},
+		}},
+		Prompts: []config.PromptConfig{{
+			Name: "review",
+			Arguments: []config.PromptArgument{
+				{Name: "language", Enum: []string{"Go", "Python", "PHP", "Rust"}},
+				{Name: "framework", Completion: &config.CompletionConfig{
+					Endpoint: upstream + "/frameworks?lang={{.Args.language}}&q={{.Args.framework}}",
+				}},
+				{Name: "code"},
+			},
+		}},
+		Resources: []config.ResourceConfig{{
+			Name:        "city",
+			URITemplate: "weather://cities/{city}",
+			Method:      http.MethodGet,
+			Endpoint:    upstream + "/cities/{{.Args.city}}",
+			Completions: map[string]*config.CompletionConfig{"city": {
+				Endpoint:     upstream + "/cities?country={{.Config.country}}&q={{.Args.city}}",
+				ResponseBody: "{{.Response.Data.data.names}}",
+			}},
+		}},
+	}
+	st, err := state.BuildStateFromConfig(context.Background(), []*config.MCPConfig{cfg}, nil, zap.NewNop())
+	require.NoError(t, err)
+
+	allowlist, _ := parseInternalNetworkAllowlist([]string{"127.0.0.0/8", "::1/128"})
+	return &Server{
+		logger:         zap.NewNop(),
+		state:          st,
+		internalNetACL: allowlist,
+	}
+}
+
+func complete(t *testing.T, s *Server, params mcp.CompleteParams) map[string]any {
+	t.Helper()
+	raw, err := json.Marshal(params)
+	require.NoError(t, err)
+	conn := &fakeConn{meta: &session.Meta{ID: "sid", Prefix: "/c"}}
+	c, w := newGin()
+	s.handleCompletionComplete(c, conn, mcp.JSONRPCRequest{JSONRPC: mcp.JSPNRPCVersion, Id: 1,
+		Method: mcp.CompletionComplete, Params: raw}, false)
+
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal([]byte(sseData(w.Body.String())), &resp))
+	return resp
+}
+
+func completionValues(t *testing.T, resp map[string]any) []any {
+	t.Helper()
+	require.Nil(t, resp["error"])
+	return resp["result"].(map[string]any)["completion"].(map[string]any)["values"].([]any)
+}
+
+func TestCompletion_Enum(t *testing.T) {
+	s := newCompletionTestServer(t, "http://127.0.0.1")
+	ref := mcp.CompleteReference{Type: mcp.RefPrompt, Name: "review"}
+
+	resp := complete(t, s, mcp.CompleteParams{Ref: ref, Argument: mcp.CompleteArgument{Name: "language", Value: "p"}})
+	assert.Equal(t, []any{"Python", "PHP"}, completionValues(t, resp))
+
+	// arguments without completions have no values
+	resp = complete(t, s, mcp.CompleteParams{Ref: ref, Argument: mcp.CompleteArgument{Name: "code", Value: "x"}})
+	assert.Empty(t, completionValues(t, resp))
+
+	resp = complete(t, s, mcp.CompleteParams{Ref: mcp.CompleteReference{Type: mcp.RefPrompt, Name: "missing"},
+		Argument: mcp.CompleteArgument{Name: "language"}})
+	assert.Equal(t, float64(mcp.ErrorCodeInvalidParams), resp["error"].(map[string]any)["code"])
+}
+
+func TestCompletion_HTTPLookup(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/frameworks":
+			assert.Equal(t, "Go", r.URL.Query().Get("lang"))
+			assert.Equal(t, "g", r.URL.Query().Get("q"))
+			_, _ = w.Write([]byte(`["gin","gorm"]`))
+		case "/cities":
+			assert.Equal(t, "cn", r.URL.Query().Get("country"))
+			assert.Equal(t, "x", r.URL.Query().Get("q"))
+			_, _ = w.Write([]byte(`{"data":{"names":["Xiamen","Xi'an"]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+	s := newCompletionTestServer(t, upstream.URL)
+
+	resp := complete(t, s, mcp.CompleteParams{
+		Ref:      mcp.CompleteReference{Type: mcp.RefPrompt, Name: "review"},
+		Argument: mcp.CompleteArgument{Name: "framework", Value: "g"},
+		Context:  &mcp.CompleteContext{Arguments: map[string]string{"language": "Go"}},
+	})
+	assert.Equal(t, []any{"gin", "gorm"}, completionValues(t, resp))
+
+	resp = complete(t, s, mcp.CompleteParams{
+		Ref:      mcp.CompleteReference{Type: mcp.RefResource, URI: "weather://cities/{city}"},
+		Argument: mcp.CompleteArgument{Name: "city", Value: "x"},
+	})
+	assert.Equal(t, []any{"Xiamen", "Xi'an"}, completionValues(t, resp))
+}
+
+func TestLookupCompletion_PooledClientAndCircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer upstream.Close()
+	s := newCompletionTestServer(t, upstream.URL)
+	s.breakers = newBreakerRegistry(nil)
+	s.circuitBreaker = config.CircuitBreakerConfig{Enabled: true, FailureThreshold: 2}
+
+	// lookups share the pooled client of the server
+	assert.NotNil(t, s.state.GetCompletionHTTPClient("/c"))
+
+	completion := &config.CompletionConfig{Endpoint: upstream.URL + "/frameworks"}
+	params := mcp.CompleteParams{Argument: mcp.CompleteArgument{Name: "framework", Value: "g"}}
+	conn := &fakeConn{meta: &session.Meta{ID: "sid", Prefix: "/c"}}
+	c, _ := newGin()
+	for i := 0; i < 2; i++ {
+		_, err := s.lookupCompletion(c, conn, completion, params)
+		assert.Error(t, err)
+	}
+	assert.Equal(t, int32(2), calls.Load())
+
+	// the open circuit fails fast without reaching the upstream
+	_, err := s.lookupCompletion(c, conn, completion, params)
+	var openErr *CircuitOpenError
+	assert.ErrorAs(t, err, &openErr)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestCompletionResult_Caps(t *testing.T) {
+	values := strings.Split(strings.Repeat("v,", mcp.MaxCompletionValues+10), ",")[:mcp.MaxCompletionValues+10]
+	result := completionResult(values)
+	assert.Len(t, result.Completion.Values, mcp.MaxCompletionValues)
+	assert.Equal(t, mcp.MaxCompletionValues+10, result.Completion.Total)
+	assert.True(t, result.Completion.HasMore)
+
+	assert.Equal(t, []string{}, completionResult(nil).Completion.Values)
+	assert.Equal(t, []string{"a", "b"}, parseCompletionValues("a\n\n b \n"))
+	assert.Equal(t, []string{"a", "1"}, parseCompletionValues(`["a", 1, null]`))
+}
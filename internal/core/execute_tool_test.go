@@ -41,7 +41,7 @@ func TestExecuteHTTPTool_Success(t *testing.T) {
 	conn := &fakeConnExec{meta: &session.Meta{ID: "sid", Request: &session.RequestInfo{Headers: map[string]string{"X-Req": "v"}}}}
 	c, _ := gin.CreateTestContext(nil)
 	c.Request = req
-	res, err := s.executeHTTPTool(c, conn, tool, map[string]any{}, map[string]string{})
+	res, err := s.executeHTTPTool(c, conn, tool, map[string]any{}, &config.ServerConfig{})
 	assert.NoError(t, err)
 	if assert.NotNil(t, res) {
 		if tc, ok := res.Content[0].(*mcp.TextContent); ok {
@@ -70,7 +70,7 @@ func TestExecuteHTTPTool_ForwardHeadersAndRequestError(t *testing.T) {
 		"_hdr": map[string]any{"X-A": "B"},
 	}
 
-	res, err := s.executeHTTPTool(c, conn, tool, args, map[string]string{})
+	res, err := s.executeHTTPTool(c, conn, tool, args, &config.ServerConfig{})
 	assert.Error(t, err)
 	assert.Nil(t, res)
 }
@@ -92,7 +92,15 @@ func (h *TextHandler) Handle(resp *http.Response, tool *config.ToolConfig, tmplC
 			return nil, fmt.Errorf("failed to render response body template: %w", err)
 		}
 	}
-	return mcp.NewCallToolResultText(rendered), nil
+	result := mcp.NewCallToolResultText(rendered)
+	if tool != nil && tool.OutputSchema != nil && resp.StatusCode < http.StatusBadRequest {
+		// JSON objects are also returned as structured content, the text keeps the rendering
+		var structured map[string]any
+		if err := json.Unmarshal(respBody, &structured); err == nil {
+			result.StructuredContent = structured
+		}
+	}
+	return result, nil
 }
 
 // ImageHandler is a handler for image responses
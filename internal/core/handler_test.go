@@ -104,6 +104,35 @@ func TestTextHandler_Handle(t *testing.T) {
 	assert.Len(t, result.Content, 1)
 }
 
+func TestTextHandler_Handle_StructuredContent(t *testing.T) {
+	handler := &TextHandler{}
+	tool := &config.ToolConfig{OutputSchema: map[string]any{"type": "object"}}
+
+	newResp := func(status int, body string) *http.Response {
+		resp := &http.Response{
+			StatusCode: status,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+		}
+		resp.Header.Set("Content-Type", "application/json")
+		return resp
+	}
+
+	result, err := handler.Handle(newResp(http.StatusOK, `{"temp":21}`), tool, template.NewContext())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"temp": 21.0}, result.StructuredContent)
+	assert.Equal(t, `{"temp":21}`, result.Content[0].(*mcp.TextContent).Text)
+
+	// arrays and error responses only return text
+	result, err = handler.Handle(newResp(http.StatusOK, `[1]`), tool, template.NewContext())
+	require.NoError(t, err)
+	assert.Nil(t, result.StructuredContent)
+
+	result, err = handler.Handle(newResp(http.StatusBadGateway, `{"error":"down"}`), tool, template.NewContext())
+	require.NoError(t, err)
+	assert.Nil(t, result.StructuredContent)
+}
+
 func TestTextHandler_Handle_WithResponseBodyTemplate(t *testing.T) {
 	handler := &TextHandler{}
 
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/mcp/session"
+)
+
+// keepAlive ticks while an event stream is idle, to renew its session and write heartbeats
+type keepAlive struct {
+	ticker   *time.Ticker
+	interval time.Duration
+}
+
+// newKeepAlive returns the keep-alive of an event stream. It ticks at the heartbeat
+// interval, and often enough for the open stream to keep its session from being reaped.
+func (s *Server) newKeepAlive() *keepAlive {
+	interval := s.heartbeat.Interval
+	if idle := s.sessionIdleTimeout / 2; idle > 0 && (interval <= 0 || idle < interval) {
+		interval = idle
+	}
+	if interval <= 0 {
+		return &keepAlive{}
+	}
+	return &keepAlive{ticker: time.NewTicker(interval), interval: interval}
+}
+
+// C returns the channel of the ticks, nil when keep-alive is disabled
+func (k *keepAlive) C() <-chan time.Time {
+	if k.ticker == nil {
+		return nil
+	}
+	return k.ticker.C
+}
+
+// reset restarts the idle period after the stream wrote a message
+func (k *keepAlive) reset() {
+	if k.ticker != nil {
+		k.ticker.Reset(k.interval)
+	}
+}
+
+func (k *keepAlive) stop() {
+	if k.ticker != nil {
+		k.ticker.Stop()
+	}
+}
+
+// keepSessionAlive renews the session of an idle event stream and writes a heartbeat. It
+// returns false when the session is gone and the stream should end.
+func (s *Server) keepSessionAlive(c *gin.Context, conn session.Connection) bool {
+	logger := s.getLogger(c)
+	if err := s.sessions.Touch(c.Request.Context(), conn.Meta().ID); err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			logger.Info("event stream closing, session is gone",
+				zap.String("session_id", conn.Meta().ID))
+			return false
+		}
+		logger.Warn("failed to renew session",
+			zap.String("session_id", conn.Meta().ID),
+			zap.Error(err))
+	}
+
+	if s.heartbeat.Interval <= 0 {
+		return true
+	}
+	heartbeat := ": ping\n\n"
+	if s.heartbeat.Mode == config.HeartbeatModePing {
+		heartbeat = "event: ping\ndata: \n\n"
+	}
+	if _, err := fmt.Fprint(c.Writer, heartbeat); err != nil {
+		logger.Debug("failed to write heartbeat",
+			zap.String("session_id", conn.Meta().ID),
+			zap.Error(err))
+		return true
+	}
+	c.Writer.Flush()
+	return true
+}
+
+// onSessionSweep exports the sessions of the instance as metrics and drops the events and
+// the per-session processes of the sessions reaped for being idle
+func (s *Server) onSessionSweep(active int, reaped []*session.Meta) {
+	if s.metrics != nil {
+		s.metrics.SessionsActive(active)
+		for _, meta := range reaped {
+			s.metrics.SessionReaped(meta.Type)
+		}
+	}
+	for _, meta := range reaped {
+		s.closeSessionTransports(meta.ID)
+	}
+	if s.events == nil {
+		return
+	}
+	for _, meta := range reaped {
+		if err := s.events.Delete(context.Background(), meta.ID); err != nil {
+			s.logger.Warn("failed to delete session events",
+				zap.String("session_id", meta.ID),
+				zap.Error(err))
+		}
+	}
+}
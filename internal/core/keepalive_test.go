@@ -0,0 +1,56 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/pkg/mcp"
+)
+
+func TestHandleGet_WritesHeartbeats(t *testing.T) {
+	s := newVersionTestServer(t)
+	s.heartbeat = config.SessionHeartbeatConfig{Interval: 10 * time.Millisecond, Mode: config.HeartbeatModePing}
+	sessionID, _ := initializeSession(t, s, mcp.LatestProtocolVersion)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	w := getMCP(ctx, s, sessionID, "")
+	assert.Contains(t, w.Body.String(), "event: ping\ndata: \n\n")
+}
+
+func TestHandleGet_EndsWhenSessionIsGone(t *testing.T) {
+	s := newVersionTestServer(t)
+	s.sessionIdleTimeout = 20 * time.Millisecond
+	sessionID, _ := initializeSession(t, s, mcp.LatestProtocolVersion)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		getMCP(t.Context(), s, sessionID, "")
+	}()
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, s.sessions.Unregister(t.Context(), sessionID))
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("event stream still open after the session was removed")
+	}
+}
+
+func TestKeepAlive_Interval(t *testing.T) {
+	s := &Server{}
+	assert.Nil(t, s.newKeepAlive().C())
+
+	s.heartbeat.Interval = time.Minute
+	assert.Equal(t, time.Minute, s.newKeepAlive().interval)
+
+	// open streams renew their session before it goes idle
+	s.sessionIdleTimeout = 30 * time.Second
+	assert.Equal(t, 15*time.Second, s.newKeepAlive().interval)
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/core/state"
+	"github.com/amoylab/unla/pkg/mcp"
+)
+
+// notifyListChanged sends notifications/tools/list_changed and notifications/prompts/list_changed
+// to the sessions whose prefix serves a different list after a reload. Only sessions registered
+// on this instance are notified, every replica reloads and notifies its own sessions.
+func (s *Server) notifyListChanged(ctx context.Context, prev, next *state.State) {
+	if s.sessions == nil {
+		return
+	}
+	changes := state.DiffLists(prev, next)
+	if len(changes.Tools) == 0 && len(changes.Prompts) == 0 {
+		return
+	}
+
+	conns, err := s.sessions.ListLocal(ctx)
+	if err != nil {
+		s.logger.Warn("failed to list sessions for list changed notifications", zap.Error(err))
+		return
+	}
+
+	notifications := []struct {
+		method   string
+		prefixes map[string]bool
+	}{
+		{mcp.NotificationToolListChanged, toSet(changes.Tools)},
+		{mcp.NotificationPromptListChanged, toSet(changes.Prompts)},
+	}
+	notified := 0
+	for _, conn := range conns {
+		prefix := conn.Meta().Prefix
+		for _, n := range notifications {
+			if !n.prefixes[prefix] {
+				continue
+			}
+			if err := s.sendNotification(ctx, conn, n.method, nil); err != nil {
+				s.logger.Debug("failed to send list changed notification",
+					zap.String("method", n.method),
+					zap.String("prefix", prefix),
+					zap.String("session_id", conn.Meta().ID),
+					zap.Error(err))
+				continue
+			}
+			notified++
+		}
+	}
+	s.logger.Info("sent list changed notifications",
+		zap.Strings("tool_prefixes", changes.Tools),
+		zap.Strings("prompt_prefixes", changes.Prompts),
+		zap.Int("notifications", notified))
+}
+
+func toSet(items []string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
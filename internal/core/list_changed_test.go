@@ -0,0 +1,52 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/core/state"
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestUpdateConfig_NotifiesListChanged(t *testing.T) {
+	ctx := context.Background()
+	cfg := func(desc string) *config.MCPConfig {
+		return &config.MCPConfig{
+			Name:   "lc",
+			Tenant: "t",
+			Tools: []config.ToolConfig{
+				{Name: "a", Description: desc, Method: "GET", Endpoint: "http://localhost/a"},
+				{Name: "b", Method: "GET", Endpoint: "http://localhost/b"},
+			},
+			Servers: []config.ServerConfig{
+				{Name: "sa", AllowedTools: []string{"a"}},
+				{Name: "sb", AllowedTools: []string{"b"}},
+			},
+			Routers: []config.RouterConfig{{Server: "sa", Prefix: "/a"}, {Server: "sb", Prefix: "/b"}},
+		}
+	}
+	st, err := state.BuildStateFromConfig(ctx, []*config.MCPConfig{cfg("v1")}, nil, zap.NewNop())
+	require.NoError(t, err)
+
+	store := session.NewMemoryStore(zap.NewNop())
+	connA, err := store.Register(ctx, &session.Meta{ID: "sa", Prefix: "/a"})
+	require.NoError(t, err)
+	connB, err := store.Register(ctx, &session.Meta{ID: "sb", Prefix: "/b"})
+	require.NoError(t, err)
+	s := &Server{logger: zap.NewNop(), state: st, sessions: store}
+
+	s.UpdateConfig(ctx, cfg("v2"))
+
+	select {
+	case msg := <-connA.EventQueue():
+		assert.JSONEq(t, `{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}`, string(msg.Data))
+	default:
+		t.Fatal("expected a tools list changed notification")
+	}
+	assert.Empty(t, connA.EventQueue())
+	assert.Empty(t, connB.EventQueue())
+}
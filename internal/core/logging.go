@@ -0,0 +1,77 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/core/mcpproxy"
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/pkg/mcp"
+)
+
+// Loggers of the log messages the gateway sends to sessions
+const (
+	logSourceTool     = "tool"
+	logSourceUpstream = "upstream"
+	logSourceTemplate = "template"
+)
+
+// handleLoggingSetLevel stores the minimum level of the log messages sent to the session
+func (s *Server) handleLoggingSetLevel(c *gin.Context, conn session.Connection, req mcp.JSONRPCRequest, isSSE bool) {
+	var params mcp.SetLevelParams
+	if err := json.Unmarshal(req.Params, &params); err != nil || !mcp.IsValidLoggingLevel(params.Level) {
+		s.sendProtocolError(c, req.Id, "Invalid logging level", http.StatusBadRequest, mcp.ErrorCodeInvalidParams)
+		return
+	}
+
+	meta := *conn.Meta()
+	meta.LogLevel = params.Level
+	if err := s.sessions.Update(c.Request.Context(), &meta); err != nil {
+		s.getLogger(c).Error("failed to store logging level",
+			zap.String("session_id", meta.ID),
+			zap.String("level", params.Level),
+			zap.Error(err))
+		s.sendJSONRPCError(c, conn, req, "Failed to set logging level", mcp.ErrorCodeInternalError, isSSE)
+		return
+	}
+	s.sendSuccessResponse(c, conn, req, struct{}{}, isSSE)
+}
+
+// logToSession sends a log message about the request being handled to the session, if
+// the session asked for messages of that level
+func (s *Server) logToSession(c *gin.Context, conn session.Connection, level, logger string, data any) {
+	if !mcp.LoggingLevelEnabled(conn.Meta().LogLevel, level) {
+		return
+	}
+	params := mcp.LoggingMessageNotificationParams{Level: level, Logger: logger, Data: data}
+	if err := s.sendRequestNotification(c, conn, mcp.NotificationMessage, params, conn.Meta().Type == "sse"); err != nil {
+		s.getLogger(c).Warn("failed to send log message",
+			zap.String("session_id", conn.Meta().ID),
+			zap.Error(err))
+	}
+}
+
+// relayUpstreamLogs returns the request context, set up to relay the log messages the
+// upstream server sends for the request to the session. Messages of a shared upstream
+// connection that cannot be tied to the request are only logged by the gateway.
+func (s *Server) relayUpstreamLogs(c *gin.Context, conn session.Connection) context.Context {
+	return mcpproxy.WithLogHandler(s.upstreamContext(c, conn), func(params mcp.LoggingMessageNotificationParams) {
+		if params.Logger == "" {
+			params.Logger = logSourceUpstream
+		}
+		s.logToSession(c, conn, params.Level, params.Logger, params.Data)
+	})
+}
+
+// toolLogData is the data of a log message about a tool call
+func toolLogData(tool, message string, err error) map[string]any {
+	data := map[string]any{"message": message, "tool": tool}
+	if err != nil {
+		data["error"] = err.Error()
+	}
+	return data
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/pkg/mcp"
+)
+
+// sseMessages decodes every event of a streamed response
+func sseMessages(t *testing.T, body string) []map[string]any {
+	t.Helper()
+	var messages []map[string]any
+	for _, event := range strings.Split(body, "\n\n") {
+		if data := sseData(event); data != "" {
+			var msg map[string]any
+			require.NoError(t, json.Unmarshal([]byte(data), &msg))
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+func TestLoggingSetLevel_StreamsToolLogs(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer upstream.Close()
+	s := newVersionTestServer(t)
+	s.state.GetTool("/rl", "limited").Endpoint = upstream.URL
+	sessionID, _ := initializeSession(t, s, mcp.LatestProtocolVersion)
+
+	w := postMCP(s, sessionID, nil, `{"jsonrpc":"2.0","id":2,"method":"logging/setLevel","params":{"level":"verbose"}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// nothing is logged before the client sets a level
+	w = postMCP(s, sessionID, nil, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"limited","arguments":{}}}`)
+	require.Len(t, sseMessages(t, w.Body.String()), 1)
+
+	w = postMCP(s, sessionID, nil, `{"jsonrpc":"2.0","id":4,"method":"logging/setLevel","params":{"level":"warning"}}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	conn, err := s.sessions.Get(t.Context(), sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, mcp.LoggingLevelWarning, conn.Meta().LogLevel)
+
+	w = postMCP(s, sessionID, nil, `{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"limited","arguments":{}}}`)
+	messages := sseMessages(t, w.Body.String())
+	require.Len(t, messages, 2)
+	assert.Equal(t, mcp.NotificationMessage, messages[0]["method"])
+	assert.Equal(t, map[string]any{
+		"level":  "warning",
+		"logger": "upstream",
+		"data":   map[string]any{"message": "upstream returned an error status", "tool": "limited", "status": float64(http.StatusBadGateway)},
+	}, messages[0]["params"])
+	assert.Equal(t, float64(5), messages[1]["id"])
+}
+
+func TestLogToSession_SSE(t *testing.T) {
+	s := &Server{logger: zap.NewNop()}
+	conn := &fakeConn{meta: &session.Meta{ID: "sid", Type: "sse", LogLevel: mcp.LoggingLevelInfo}}
+	c, w := newGin()
+
+	s.logToSession(c, conn, mcp.LoggingLevelDebug, logSourceUpstream, "dropped")
+	s.logToSession(c, conn, mcp.LoggingLevelError, logSourceUpstream, "disk full")
+
+	// SSE sessions get log messages through their event stream
+	assert.Empty(t, w.Body.String())
+	require.Len(t, conn.sent, 1)
+	var notification struct {
+		Method string                               `json:"method"`
+		Params mcp.LoggingMessageNotificationParams `json:"params"`
+	}
+	require.NoError(t, json.Unmarshal(conn.sent[0].Data, &notification))
+	assert.Equal(t, mcp.NotificationMessage, notification.Method)
+	assert.Equal(t, mcp.LoggingMessageNotificationParams{Level: "error", Logger: "upstream", Data: "disk full"}, notification.Params)
+}
@@ -1,12 +1,94 @@
 package mcpproxy
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
 
 	"github.com/amoylab/unla/pkg/mcp"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
 	mcpgo "github.com/mark3labs/mcp-go/mcp"
 )
 
+// cancelNotificationTimeout bounds the delivery of notifications/cancelled to the upstream server
+const cancelNotificationTimeout = 5 * time.Second
+
+// rawRequestID numbers the requests sent by sendRawRequest, they use string ids so
+// they never collide with the numeric ids client.Client assigns itself
+var rawRequestID atomic.Int64
+
+// sendRawRequest sends a request through the transport of an initialized client and
+// returns the undecoded result. client.Client decodes results into the types mcp-go
+// knows, which drops newer fields such as structuredContent.
+func sendRawRequest(ctx context.Context, cli *client.Client, method string, params any) (json.RawMessage, error) {
+	request := transport.JSONRPCRequest{
+		JSONRPC: mcpgo.JSONRPC_VERSION,
+		ID:      mcpgo.NewRequestId(fmt.Sprintf("unla-%d", rawRequestID.Add(1))),
+		Method:  method,
+		Params:  params,
+	}
+
+	response, err := cli.GetTransport().SendRequest(ctx, request)
+	if err != nil {
+		if ctx.Err() != nil {
+			cancelRequest(ctx, cli, request.ID)
+		}
+		return nil, fmt.Errorf("transport error: %w", err)
+	}
+	if response.Error != nil {
+		return nil, errors.New(response.Error.Message)
+	}
+	return response.Result, nil
+}
+
+// cancelRequest tells the upstream server that the request was abandoned, so that it can
+// stop working on it. Delivery is best effort.
+func cancelRequest(ctx context.Context, cli *client.Client, id mcpgo.RequestId) {
+	notification := mcpgo.JSONRPCNotification{
+		JSONRPC: mcpgo.JSONRPC_VERSION,
+		Notification: mcpgo.Notification{
+			Method: mcp.NotificationCancelled,
+			Params: mcpgo.NotificationParams{AdditionalFields: map[string]any{
+				"requestId": id,
+				"reason":    context.Cause(ctx).Error(),
+			}},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cancelNotificationTimeout)
+	defer cancel()
+	_ = cli.GetTransport().SendNotification(ctx, notification)
+}
+
+// callTool calls a tool of the upstream server, keeping the structured content of the result
+func callTool(ctx context.Context, cli *client.Client, request mcpgo.CallToolRequest) (*mcp.CallToolResult, error) {
+	raw, err := sendRawRequest(ctx, cli, string(mcpgo.MethodToolsCall), request.Params)
+	if err != nil {
+		return nil, err
+	}
+	return convertMCPGoRawResult(raw)
+}
+
+// convertMCPGoRawResult converts a raw tools/call result to local mcp format
+func convertMCPGoRawResult(raw json.RawMessage) (*mcp.CallToolResult, error) {
+	res, err := mcpgo.ParseCallToolResult(&raw)
+	if err != nil {
+		return nil, err
+	}
+	result := convertMCPGoResult(res)
+
+	var structured struct {
+		StructuredContent map[string]any `json:"structuredContent"`
+	}
+	if err := json.Unmarshal(raw, &structured); err == nil {
+		result.StructuredContent = structured.StructuredContent
+	}
+	return result, nil
+}
+
 // convertMCPGoResult converts mcp-go result to local mcp format
 func convertMCPGoResult(res *mcpgo.CallToolResult) *mcp.CallToolResult {
 	result := &mcp.CallToolResult{
@@ -1,9 +1,14 @@
 package mcpproxy
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/amoylab/unla/pkg/mcp"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
 	mcpgo "github.com/mark3labs/mcp-go/mcp"
 	"github.com/stretchr/testify/assert"
 )
@@ -104,3 +109,80 @@ func TestConvertMCPGoResult_AudioContentDetails(t *testing.T) {
 		}
 	}
 }
+
+type fakeRawTransport struct {
+	request  transport.JSONRPCRequest
+	response *transport.JSONRPCResponse
+}
+
+func (f *fakeRawTransport) Start(context.Context) error { return nil }
+func (f *fakeRawTransport) SendRequest(_ context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	f.request = request
+	return f.response, nil
+}
+func (f *fakeRawTransport) SendNotification(context.Context, mcpgo.JSONRPCNotification) error {
+	return nil
+}
+func (f *fakeRawTransport) SetNotificationHandler(func(mcpgo.JSONRPCNotification)) {}
+func (f *fakeRawTransport) Close() error                                           { return nil }
+
+func TestCallTool_PreservesStructuredContent(t *testing.T) {
+	tr := &fakeRawTransport{response: &transport.JSONRPCResponse{
+		Result: json.RawMessage(`{"content":[{"type":"text","text":"{\"temp\":21}"}],"structuredContent":{"temp":21}}`),
+	}}
+	cli := client.NewClient(tr)
+
+	req := mcpgo.CallToolRequest{}
+	req.Params.Name = "weather"
+	out, err := callTool(context.Background(), cli, req)
+	assert.NoError(t, err)
+	assert.Equal(t, "tools/call", tr.request.Method)
+	assert.Contains(t, tr.request.ID.String(), "unla-")
+	if assert.Len(t, out.Content, 1) {
+		assert.Equal(t, `{"temp":21}`, out.Content[0].(*mcp.TextContent).Text)
+	}
+	assert.Equal(t, map[string]any{"temp": 21.0}, out.StructuredContent)
+
+	tr.response = &transport.JSONRPCResponse{}
+	tr.response.Error = &struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}{Code: -32602, Message: "unknown tool"}
+	_, err = callTool(context.Background(), cli, req)
+	assert.EqualError(t, err, "unknown tool")
+}
+
+type blockingTransport struct {
+	fakeRawTransport
+	notifications []mcpgo.JSONRPCNotification
+}
+
+func (f *blockingTransport) SendRequest(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	f.request = request
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+func (f *blockingTransport) SendNotification(_ context.Context, notification mcpgo.JSONRPCNotification) error {
+	f.notifications = append(f.notifications, notification)
+	return nil
+}
+
+func TestCallTool_ForwardsCancellation(t *testing.T) {
+	tr := &blockingTransport{}
+	cli := client.NewClient(tr)
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errors.New("user aborted"))
+	req := mcpgo.CallToolRequest{}
+	req.Params.Name = "slow"
+	_, err := callTool(ctx, cli, req)
+	assert.Error(t, err)
+
+	if assert.Len(t, tr.notifications, 1) {
+		n := tr.notifications[0]
+		assert.Equal(t, mcp.NotificationCancelled, n.Method)
+		assert.Equal(t, tr.request.ID, n.Params.AdditionalFields["requestId"])
+		assert.Equal(t, "user aborted", n.Params.AdditionalFields["reason"])
+	}
+}
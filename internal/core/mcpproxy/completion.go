@@ -0,0 +1,26 @@
+package mcpproxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/amoylab/unla/pkg/mcp"
+	"github.com/mark3labs/mcp-go/client"
+)
+
+// complete asks the upstream server for completion values of a prompt or resource argument
+func complete(ctx context.Context, cli *client.Client, params mcp.CompleteParams) (*mcp.CompleteResult, error) {
+	raw, err := sendRawRequest(ctx, cli, mcp.CompletionComplete, params)
+	if err != nil {
+		return nil, err
+	}
+	var result mcp.CompleteResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("invalid %s result: %w", mcp.CompletionComplete, err)
+	}
+	if result.Completion.Values == nil {
+		result.Completion.Values = []string{}
+	}
+	return &result, nil
+}
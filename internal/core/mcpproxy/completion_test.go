@@ -0,0 +1,33 @@
+package mcpproxy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/amoylab/unla/pkg/mcp"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComplete(t *testing.T) {
+	tr := &scriptedTransport{handle: func(transport.JSONRPCRequest) string {
+		return `{"completion":{"values":["python","pytorch"],"total":2}}`
+	}}
+	params := mcp.CompleteParams{
+		Ref:      mcp.CompleteReference{Type: mcp.RefPrompt, Name: "review"},
+		Argument: mcp.CompleteArgument{Name: "language", Value: "py"},
+	}
+	result, err := complete(context.Background(), client.NewClient(tr), params)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"python", "pytorch"}, result.Completion.Values)
+	assert.Equal(t, 2, result.Completion.Total)
+	assert.Equal(t, mcp.CompletionComplete, tr.requests[0].Method)
+	assert.Equal(t, params, tr.requests[0].Params)
+
+	tr.handle = func(transport.JSONRPCRequest) string { return `{"completion":{}}` }
+	result, err = complete(context.Background(), client.NewClient(tr), params)
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, result.Completion.Values)
+}
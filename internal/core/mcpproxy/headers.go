@@ -0,0 +1,70 @@
+package mcpproxy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/amoylab/unla/internal/template"
+)
+
+type headersKey struct{}
+
+// renderHeaders renders the header templates of an HTTP based server, a nil template
+// context renders them without request information
+func renderHeaders(headers map[string]string, tmplCtx *template.Context) (map[string]string, error) {
+	if len(headers) == 0 {
+		return nil, nil
+	}
+	if tmplCtx == nil {
+		tmplCtx = template.NewContext()
+	}
+	rendered := make(map[string]string, len(headers))
+	for k, v := range headers {
+		value, err := template.RenderTemplate(v, tmplCtx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render header %s: %w", k, err)
+		}
+		rendered[k] = value
+	}
+	return rendered, nil
+}
+
+// isRequestTemplate reports whether a header template uses the request or the arguments
+func isRequestTemplate(tmpl string) bool {
+	return strings.Contains(tmpl, ".Request") || strings.Contains(tmpl, ".Args")
+}
+
+// filterHeaders returns the headers whose templates do or do not use the request
+func filterHeaders(headers map[string]string, request bool) map[string]string {
+	filtered := make(map[string]string)
+	for k, v := range headers {
+		if isRequestTemplate(v) == request {
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
+
+// connectHeaders renders the headers of a connection, which is shared by the requests of
+// every user. Only the headers using nothing but the environment are sent with all of its
+// requests, the others are rendered for every request by withRequestHeaders.
+func connectHeaders(headers map[string]string) (map[string]string, error) {
+	return renderHeaders(filterHeaders(headers, false), nil)
+}
+
+// withRequestHeaders returns a context whose requests to the upstream server carry the
+// headers using the request, rendered with its template context
+func withRequestHeaders(ctx context.Context, headers map[string]string, tmplCtx *template.Context) (context.Context, error) {
+	rendered, err := renderHeaders(filterHeaders(headers, true), tmplCtx)
+	if err != nil || rendered == nil {
+		return ctx, err
+	}
+	return context.WithValue(ctx, headersKey{}, rendered), nil
+}
+
+// requestHeaders implements transport.HTTPHeaderFunc with the headers of withRequestHeaders
+func requestHeaders(ctx context.Context) map[string]string {
+	headers, _ := ctx.Value(headersKey{}).(map[string]string)
+	return headers
+}
@@ -0,0 +1,138 @@
+package mcpproxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/template"
+	"github.com/amoylab/unla/pkg/mcp"
+)
+
+func TestRenderHeaders(t *testing.T) {
+	t.Setenv("UNLA_TEST_TOKEN", "secret")
+	headers := map[string]string{
+		"Authorization": `Bearer {{ env "UNLA_TEST_TOKEN" }}`,
+		"X-User":        `{{ index .Request.Headers "X-User" }}`,
+	}
+
+	rendered, err := renderHeaders(headers, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer secret", "X-User": ""}, rendered)
+
+	// connections only carry the headers not using the request
+	rendered, err = connectHeaders(headers)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer secret"}, rendered)
+
+	tmplCtx, err := template.AssembleTemplateContext(&template.RequestWrapper{Headers: map[string]string{"X-User": "alice"}}, nil, nil)
+	require.NoError(t, err)
+	ctx, err := withRequestHeaders(t.Context(), headers, tmplCtx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"X-User": "alice"}, requestHeaders(ctx))
+
+	rendered, err = renderHeaders(nil, tmplCtx)
+	require.NoError(t, err)
+	assert.Nil(t, rendered)
+	_, err = renderHeaders(map[string]string{"X-Broken": "{{"}, nil)
+	assert.Error(t, err)
+}
+
+// newHeaderRecordingServer is a minimal streamable HTTP MCP server recording the headers
+// of every request by method
+func newHeaderRecordingServer(t *testing.T) (*httptest.Server, func(method string) http.Header) {
+	t.Helper()
+	var mu sync.Mutex
+	received := make(map[string]http.Header)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		received[req.Method] = r.Header.Clone()
+		mu.Unlock()
+
+		var result string
+		switch req.Method {
+		case "initialize":
+			result = `{"protocolVersion":"2025-03-26","capabilities":{"tools":{}},"serverInfo":{"name":"test","version":"1.0.0"}}`
+		case "tools/call":
+			result = `{"content":[{"type":"text","text":"ok"}]}`
+		default:
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, result)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func(method string) http.Header {
+		mu.Lock()
+		defer mu.Unlock()
+		return received[method]
+	}
+}
+
+func TestStreamableTransport_Headers(t *testing.T) {
+	t.Setenv("UNLA_TEST_TOKEN", "secret")
+	srv, received := newHeaderRecordingServer(t)
+	tr := &StreamableTransport{cfg: config.MCPServerConfig{
+		Name:   "remote",
+		Type:   cnst.BackendProtoStreamable.String(),
+		URL:    srv.URL,
+		Policy: cnst.PolicyOnDemand,
+		Headers: map[string]string{
+			"Authorization": `Bearer {{ env "UNLA_TEST_TOKEN" }}`,
+			"X-User":        `{{ index .Request.Headers "X-User" }}`,
+		},
+	}}
+
+	// connected without request information, the call renders the headers of its request
+	require.NoError(t, tr.Start(t.Context(), nil))
+	assert.Equal(t, "Bearer secret", received("initialize").Get("Authorization"))
+	assert.Empty(t, received("initialize").Get("X-User"))
+
+	req := &template.RequestWrapper{Headers: map[string]string{"X-User": "alice"}}
+	_, err := tr.CallTool(t.Context(), mcp.CallToolParams{Name: "echo", Arguments: []byte(`{}`)}, req)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer secret", received("tools/call").Get("Authorization"))
+	assert.Equal(t, "alice", received("tools/call").Get("X-User"))
+}
+
+func TestStreamableTransport_HeadersOfConnectingRequest(t *testing.T) {
+	srv, received := newHeaderRecordingServer(t)
+	tr := &StreamableTransport{cfg: config.MCPServerConfig{
+		Name:    "remote",
+		Type:    cnst.BackendProtoStreamable.String(),
+		URL:     srv.URL,
+		Policy:  cnst.PolicyOnStart,
+		Headers: map[string]string{"X-User": `{{ index .Request.Headers "X-User" }}`},
+	}}
+	t.Cleanup(func() { _ = tr.Stop(t.Context()) })
+
+	// the request opening the connection does not leave its headers on it
+	alice := &template.RequestWrapper{Headers: map[string]string{"X-User": "alice"}}
+	_, err := tr.CallTool(t.Context(), mcp.CallToolParams{Name: "echo", Arguments: []byte(`{}`)}, alice)
+	require.NoError(t, err)
+	assert.Empty(t, received("initialize").Get("X-User"))
+	assert.Equal(t, "alice", received("tools/call").Get("X-User"))
+	require.True(t, tr.IsRunning())
+
+	bob := &template.RequestWrapper{Headers: map[string]string{"X-User": "bob"}}
+	_, err = tr.CallTool(t.Context(), mcp.CallToolParams{Name: "echo", Arguments: []byte(`{}`)}, bob)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bob"}, received("tools/call").Values("X-User"))
+}
@@ -0,0 +1,111 @@
+package mcpproxy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/amoylab/unla/pkg/mcp"
+	mcpgo "github.com/mark3labs/mcp-go/mcp"
+)
+
+// LogHandler is called with the notifications/message of the upstream server
+type LogHandler func(params mcp.LoggingMessageNotificationParams)
+
+// UpstreamLogger is implemented by the transports relaying the log messages of their
+// upstream server
+type UpstreamLogger interface {
+	// OnUpstreamLog sets the handler of the log messages that cannot be attributed to a
+	// request, they are not relayed to any client
+	OnUpstreamLog(handler LogHandler)
+}
+
+type logHandlerKey struct{}
+
+// WithLogHandler returns a context whose requests relay the log messages of the upstream
+// server to the handler while they are in flight
+func WithLogHandler(ctx context.Context, handler LogHandler) context.Context {
+	return context.WithValue(ctx, logHandlerKey{}, handler)
+}
+
+// logRelay relays the log messages of the upstream server to the handlers of the requests
+// in flight. Every request is sent upstream with a progress token of its own, a message
+// carrying that token in its _meta goes to the handler of the request. On a connection
+// serving a single session the other messages go to one of its requests as well, on a
+// shared connection they cannot be attributed and only go to the local handler.
+type logRelay struct {
+	mu       sync.RWMutex
+	handlers map[string]LogHandler
+	next     int
+	// dedicated is set when the connection serves the requests of a single session
+	dedicated bool
+	local     LogHandler
+}
+
+// relay registers the log handler of the context until the returned func is called. The
+// token is the progress token to send upstream with the request, empty if the context has
+// no handler.
+func (r *logRelay) relay(ctx context.Context) (string, func()) {
+	handler, _ := ctx.Value(logHandlerKey{}).(LogHandler)
+	if handler == nil {
+		return "", func() {}
+	}
+	r.mu.Lock()
+	if r.handlers == nil {
+		r.handlers = make(map[string]LogHandler)
+	}
+	token := fmt.Sprintf("unla-%d", r.next)
+	r.next++
+	r.handlers[token] = handler
+	r.mu.Unlock()
+
+	return token, func() {
+		r.mu.Lock()
+		delete(r.handlers, token)
+		r.mu.Unlock()
+	}
+}
+
+func (r *logRelay) setLocal(handler LogHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.local = handler
+}
+
+// handleNotification dispatches notifications/message to the handler of the request it
+// belongs to
+func (r *logRelay) handleNotification(notification mcpgo.JSONRPCNotification) {
+	if notification.Method != mcp.NotificationMessage {
+		return
+	}
+	fields := notification.Params.AdditionalFields
+	params := mcp.LoggingMessageNotificationParams{Data: fields["data"]}
+	params.Level, _ = fields["level"].(string)
+	params.Logger, _ = fields["logger"].(string)
+	token, _ := notification.Params.Meta["progressToken"].(string)
+
+	r.mu.RLock()
+	handler, ok := r.handlers[token]
+	if !ok && r.dedicated {
+		for _, h := range r.handlers {
+			handler, ok = h, true
+			break
+		}
+	}
+	if !ok {
+		handler = r.local
+	}
+	r.mu.RUnlock()
+	if handler != nil {
+		handler(params)
+	}
+}
+
+// progressMeta returns the _meta of an upstream request carrying the progress token, nil
+// without token
+func progressMeta(token string) *mcpgo.Meta {
+	if token == "" {
+		return nil
+	}
+	return &mcpgo.Meta{ProgressToken: token}
+}
@@ -0,0 +1,76 @@
+package mcpproxy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/amoylab/unla/pkg/mcp"
+	mcpgo "github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogRelay(t *testing.T) {
+	var relay logRelay
+	var got, local []mcp.LoggingMessageNotificationParams
+	relay.setLocal(func(params mcp.LoggingMessageNotificationParams) {
+		local = append(local, params)
+	})
+	ctx := WithLogHandler(context.Background(), func(params mcp.LoggingMessageNotificationParams) {
+		got = append(got, params)
+	})
+
+	message := func(token string) mcpgo.JSONRPCNotification {
+		notification := mcpgo.JSONRPCNotification{Notification: mcpgo.Notification{
+			Method: mcp.NotificationMessage,
+			Params: mcpgo.NotificationParams{AdditionalFields: map[string]any{
+				"level":  "error",
+				"logger": "db",
+				"data":   map[string]any{"error": "connection refused"},
+			}},
+		}}
+		if token != "" {
+			notification.Params.Meta = map[string]any{"progressToken": token}
+		}
+		return notification
+	}
+	want := mcp.LoggingMessageNotificationParams{
+		Level:  "error",
+		Logger: "db",
+		Data:   map[string]any{"error": "connection refused"},
+	}
+
+	token, done := relay.relay(ctx)
+	assert.NotEmpty(t, token)
+	other, _ := relay.relay(WithLogHandler(context.Background(), func(mcp.LoggingMessageNotificationParams) {
+		t.Error("message relayed to another request")
+	}))
+	assert.NotEqual(t, token, other)
+	none, _ := relay.relay(context.Background()) // requests without a handler are not relayed
+	assert.Empty(t, none)
+
+	relay.handleNotification(mcpgo.JSONRPCNotification{Notification: mcpgo.Notification{Method: mcp.NotificationProgress}})
+	relay.handleNotification(message(token))
+	// messages of a shared connection without the token of a request are only logged
+	relay.handleNotification(message(""))
+	done()
+	relay.handleNotification(message(token))
+
+	assert.Equal(t, []mcp.LoggingMessageNotificationParams{want}, got)
+	assert.Equal(t, []mcp.LoggingMessageNotificationParams{want, want}, local)
+}
+
+func TestLogRelay_Dedicated(t *testing.T) {
+	relay := logRelay{dedicated: true}
+	var got []mcp.LoggingMessageNotificationParams
+	_, done := relay.relay(WithLogHandler(context.Background(), func(params mcp.LoggingMessageNotificationParams) {
+		got = append(got, params)
+	}))
+	defer done()
+
+	// the connection of a single session relays its messages to its requests
+	relay.handleNotification(mcpgo.JSONRPCNotification{Notification: mcpgo.Notification{
+		Method: mcp.NotificationMessage,
+		Params: mcpgo.NotificationParams{AdditionalFields: map[string]any{"level": "info", "data": "started"}},
+	}})
+	assert.Equal(t, []mcp.LoggingMessageNotificationParams{{Level: "info", Data: "started"}}, got)
+}
@@ -0,0 +1,142 @@
+package mcpproxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/amoylab/unla/internal/auth/upstream"
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/pkg/mcp"
+)
+
+// ErrUpstreamOAuthDisabled is returned for servers with an oauth block while the gateway
+// has no upstream OAuth configured
+var ErrUpstreamOAuthDisabled = errors.New("the upstream server requires OAuth, but upstream OAuth is not configured")
+
+// Authorizer provides the access tokens of gateway users to upstream servers protected by
+// OAuth, upstream.Client implements it
+type Authorizer interface {
+	// Token returns the access token of the user, or an *upstream.AuthorizationRequiredError
+	Token(ctx context.Context, server upstream.Server, subject string) (string, error)
+	// Revoke forgets the token of the user once the server rejected it
+	Revoke(ctx context.Context, server upstream.Server, subject string) error
+}
+
+// OAuthClient is implemented by the transports of servers that may be protected by OAuth
+type OAuthClient interface {
+	// SetAuthorizer sets the authorizer providing the tokens of the gateway users
+	SetAuthorizer(authorizer Authorizer)
+}
+
+type (
+	subjectKey    struct{}
+	oauthGrantKey struct{}
+)
+
+// WithSubject returns a context whose requests to upstream servers protected by OAuth are
+// made with the tokens of the gateway user subject
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// oauthGrant is the access token requests to an upstream server are made with
+type oauthGrant struct {
+	token string
+	// revoke forgets the token, it is called once the server rejected it
+	revoke  func()
+	revoked atomic.Bool
+}
+
+// upstreamOAuth authorizes the requests of a transport to a server protected by OAuth
+type upstreamOAuth struct {
+	mu         sync.Mutex
+	authorizer Authorizer
+}
+
+// SetAuthorizer implements OAuthClient.SetAuthorizer
+func (o *upstreamOAuth) SetAuthorizer(authorizer Authorizer) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.authorizer = authorizer
+}
+
+// authorize returns a context whose requests to the server carry the access token of the
+// user of ctx. Servers without an oauth block are requested as they are.
+func (o *upstreamOAuth) authorize(ctx context.Context, cfg config.MCPServerConfig) (context.Context, error) {
+	if cfg.OAuth == nil {
+		return ctx, nil
+	}
+	o.mu.Lock()
+	authorizer := o.authorizer
+	o.mu.Unlock()
+	if authorizer == nil {
+		return ctx, ErrUpstreamOAuthDisabled
+	}
+
+	server := upstream.Server{Name: cfg.Name, URL: cfg.URL, OAuth: *cfg.OAuth}
+	subject, _ := ctx.Value(subjectKey{}).(string)
+	token, err := authorizer.Token(ctx, server, subject)
+	if err != nil {
+		return ctx, err
+	}
+	grant := &oauthGrant{token: token}
+	grant.revoke = func() {
+		if grant.revoked.CompareAndSwap(false, true) {
+			_ = authorizer.Revoke(context.WithoutCancel(ctx), server, subject)
+		}
+	}
+	return context.WithValue(ctx, oauthGrantKey{}, grant), nil
+}
+
+// reauthorize turns the error of a request whose token the server rejected into the
+// authorization the user has to make again, other errors are returned as they are
+func (o *upstreamOAuth) reauthorize(ctx context.Context, cfg config.MCPServerConfig, err error) error {
+	grant, ok := ctx.Value(oauthGrantKey{}).(*oauthGrant)
+	if !ok || !grant.revoked.Load() {
+		return err
+	}
+	if _, authErr := o.authorize(ctx, cfg); authErr != nil {
+		return authErr
+	}
+	return err
+}
+
+// oauthHTTPClient returns the client of the requests to servers protected by OAuth, it
+// adds the access token of the request context and revokes it when the server rejects it
+func oauthHTTPClient() *http.Client {
+	return &http.Client{Transport: oauthRoundTripper{base: http.DefaultTransport}}
+}
+
+type oauthRoundTripper struct {
+	base http.RoundTripper
+}
+
+func (rt oauthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	grant, ok := req.Context().Value(oauthGrantKey{}).(*oauthGrant)
+	if !ok {
+		return rt.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+grant.token)
+	resp, err := rt.base.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		grant.revoke()
+	}
+	return resp, err
+}
+
+// toolCallError returns the result of a tool call that failed with err. Users who have
+// not authorized the gateway at the server, or whose token it rejected, get a result
+// telling them where to authorize it rather than an error.
+func (o *upstreamOAuth) toolCallError(ctx context.Context, cfg config.MCPServerConfig, err error) (*mcp.CallToolResult, error) {
+	err = o.reauthorize(ctx, cfg, err)
+	var authErr *upstream.AuthorizationRequiredError
+	if !errors.As(err, &authErr) {
+		return nil, err
+	}
+	return mcp.NewCallToolResultError("Authorization required: open " + authErr.URL +
+		" to authorize access to " + authErr.Server + ", then call the tool again."), nil
+}
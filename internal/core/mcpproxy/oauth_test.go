@@ -0,0 +1,190 @@
+package mcpproxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amoylab/unla/internal/auth/upstream"
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/pkg/mcp"
+)
+
+// testAuthorizer hands out the tokens of its users, others have to authorize
+type testAuthorizer struct {
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+func (a *testAuthorizer) Token(_ context.Context, server upstream.Server, subject string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if token, ok := a.tokens[subject]; ok {
+		return token, nil
+	}
+	return "", &upstream.AuthorizationRequiredError{Server: server.Name, URL: "https://as.example/authorize?user=" + subject}
+}
+
+func (a *testAuthorizer) Revoke(_ context.Context, _ upstream.Server, subject string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.tokens, subject)
+	return nil
+}
+
+// newOAuthProtectedServer is a minimal streamable HTTP MCP server only accepting the
+// bearer token "good"
+func newOAuthProtectedServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var result string
+		switch req.Method {
+		case "initialize":
+			result = `{"protocolVersion":"2025-03-26","capabilities":{"tools":{}},"serverInfo":{"name":"test","version":"1.0.0"}}`
+		case "tools/call":
+			result = `{"content":[{"type":"text","text":"ok"}]}`
+		default:
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, result)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestStreamableTransport_OAuth(t *testing.T) {
+	srv := newOAuthProtectedServer(t)
+	tr := &StreamableTransport{cfg: config.MCPServerConfig{
+		Name:  "remote",
+		Type:  cnst.BackendProtoStreamable.String(),
+		URL:   srv.URL,
+		OAuth: &config.MCPServerOAuthConfig{},
+	}}
+	params := mcp.CallToolParams{Name: "echo", Arguments: []byte(`{}`)}
+
+	_, err := tr.CallTool(WithSubject(t.Context(), "bob"), params, nil)
+	assert.ErrorIs(t, err, ErrUpstreamOAuthDisabled)
+
+	authorizer := &testAuthorizer{tokens: map[string]string{"bob": "good", "carol": "revoked"}}
+	tr.SetAuthorizer(authorizer)
+
+	result, err := tr.CallTool(WithSubject(t.Context(), "bob"), params, nil)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	// sessions of users are not kept
+	assert.False(t, tr.IsRunning())
+
+	// users who have not authorized the gateway get the URL to do so
+	result, err = tr.CallTool(WithSubject(t.Context(), "alice"), params, nil)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	require.Len(t, result.Content, 1)
+	assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, "https://as.example/authorize?user=alice")
+
+	// a token the server rejects is revoked and the user authorizes again
+	result, err = tr.CallTool(WithSubject(t.Context(), "carol"), params, nil)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, "https://as.example/authorize?user=carol")
+	authorizer.mu.Lock()
+	assert.NotContains(t, authorizer.tokens, "carol")
+	authorizer.mu.Unlock()
+
+	// listing tools asks for the authorization as an error
+	_, err = tr.FetchTools(WithSubject(t.Context(), "alice"))
+	var authErr *upstream.AuthorizationRequiredError
+	assert.ErrorAs(t, err, &authErr)
+}
+
+func TestStreamableTransport_OAuthSessionPerUser(t *testing.T) {
+	// the server opens a session per token, and rejects requests made in the session of
+	// another token. The calls of bob are held until dave called the tool.
+	arrived, release := make(chan struct{}), make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("Authorization")
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var result string
+		switch req.Method {
+		case "initialize":
+			w.Header().Set("Mcp-Session-Id", token)
+			result = `{"protocolVersion":"2025-03-26","capabilities":{"tools":{}},"serverInfo":{"name":"test","version":"1.0.0"}}`
+		case "tools/call":
+			if r.Header.Get("Mcp-Session-Id") != token {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if token == "Bearer bob" {
+				close(arrived)
+				<-release
+			}
+			result = `{"content":[{"type":"text","text":"ok"}]}`
+		default:
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, result)
+	}))
+	t.Cleanup(srv.Close)
+
+	tr := &StreamableTransport{cfg: config.MCPServerConfig{
+		Name:  "remote",
+		Type:  cnst.BackendProtoStreamable.String(),
+		URL:   srv.URL,
+		OAuth: &config.MCPServerOAuthConfig{},
+	}}
+	authorizer := &testAuthorizer{tokens: map[string]string{"bob": "bob", "dave": "dave"}}
+	tr.SetAuthorizer(authorizer)
+	params := mcp.CallToolParams{Name: "echo", Arguments: []byte(`{}`)}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		result, err := tr.CallTool(WithSubject(t.Context(), "bob"), params, nil)
+		assert.NoError(t, err)
+		assert.False(t, result.IsError)
+	}()
+	<-arrived
+
+	// dave does not get the session bob opened while bob's call is in flight
+	result, err := tr.CallTool(WithSubject(t.Context(), "dave"), params, nil)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	close(release)
+	wg.Wait()
+
+	authorizer.mu.Lock()
+	assert.Len(t, authorizer.tokens, 2)
+	authorizer.mu.Unlock()
+	assert.False(t, tr.IsRunning())
+}
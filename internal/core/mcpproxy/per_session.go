@@ -0,0 +1,391 @@
+package mcpproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/template"
+	"github.com/amoylab/unla/pkg/mcp"
+)
+
+var (
+	// ErrTooManySessionProcesses is returned when a per-session server already runs its
+	// maximum number of processes
+	ErrTooManySessionProcesses = errors.New("too many per-session processes")
+	// ErrPerSessionSubscriptions is returned for resource subscriptions, which are shared by
+	// the sessions of a prefix and cannot follow per-session processes
+	ErrPerSessionSubscriptions = errors.New("resource subscriptions are not supported by per-session servers")
+)
+
+const (
+	defaultSessionMaxProcesses = 50
+	defaultSessionIdleTimeout  = 10 * time.Minute
+)
+
+// SessionScoped is implemented by the transports holding resources for each MCP session
+type SessionScoped interface {
+	// CloseSession releases the resources of the session, it is called once the session ended
+	CloseSession(ctx context.Context, sessionID string) error
+}
+
+type sessionKey struct{}
+
+// sessionInfo is the MCP session a request to the upstream server is made for
+type sessionInfo struct {
+	id  string
+	req *template.RequestWrapper
+}
+
+// WithSession returns a context whose requests are made for the MCP session, req is the
+// request information templates of the session are rendered from
+func WithSession(ctx context.Context, sessionID string, req *template.RequestWrapper) context.Context {
+	return context.WithValue(ctx, sessionKey{}, sessionInfo{id: sessionID, req: req})
+}
+
+// PerSessionTransport implements Transport with a dedicated stdio process for every MCP
+// session. The Env and Args of a process are rendered from the request information of its
+// session, so each user may run the server with their own credentials. Processes stop once
+// they were idle for the idle timeout or their session ended.
+type PerSessionTransport struct {
+	mu        sync.Mutex
+	cfg       config.MCPServerConfig
+	processes map[string]*sessionProcess
+	// stopReaper stops the reaping of idle processes, nil while no process runs
+	stopReaper  context.CancelFunc
+	onViolation ViolationHandler
+	onLog       LogHandler
+	// stderr is shared by the processes of every session, it is created on first use
+	stderr *stderrLog
+}
+
+// sessionProcess is the stdio process of a session
+type sessionProcess struct {
+	transport *StdioTransport
+	// active counts the requests in flight, busy processes are not reaped
+	active   int
+	lastUsed time.Time
+}
+
+var (
+	_ Transport      = (*PerSessionTransport)(nil)
+	_ SessionScoped  = (*PerSessionTransport)(nil)
+	_ Sandboxed      = (*PerSessionTransport)(nil)
+	_ StderrCapturer = (*PerSessionTransport)(nil)
+	_ UpstreamLogger = (*PerSessionTransport)(nil)
+)
+
+// perSessionSettings is a PerSessionConfig with the defaults applied
+type perSessionSettings struct {
+	maxProcesses int
+	idleTimeout  time.Duration
+}
+
+func newPerSessionSettings(cfg *config.PerSessionConfig) perSessionSettings {
+	s := perSessionSettings{
+		maxProcesses: defaultSessionMaxProcesses,
+		idleTimeout:  defaultSessionIdleTimeout,
+	}
+	if cfg == nil {
+		return s
+	}
+	if cfg.MaxProcesses > 0 {
+		s.maxProcesses = cfg.MaxProcesses
+	}
+	if cfg.IdleTimeout > 0 {
+		s.idleTimeout = cfg.IdleTimeout.Std()
+	}
+	return s
+}
+
+// Start does nothing, processes start with the first request of their session
+func (t *PerSessionTransport) Start(_ context.Context, _ *template.Context) error {
+	return nil
+}
+
+// Stop stops the processes of every session
+func (t *PerSessionTransport) Stop(ctx context.Context) error {
+	t.mu.Lock()
+	processes := t.processes
+	t.processes = nil
+	if t.stopReaper != nil {
+		t.stopReaper()
+		t.stopReaper = nil
+	}
+	t.mu.Unlock()
+
+	var errs []error
+	for _, p := range processes {
+		if err := p.transport.Stop(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// IsRunning reports whether a process of any session is running
+func (t *PerSessionTransport) IsRunning() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.processes) > 0
+}
+
+// OnViolation implements Sandboxed.OnViolation for the processes of every session
+func (t *PerSessionTransport) OnViolation(handler ViolationHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onViolation = handler
+	for _, p := range t.processes {
+		p.transport.OnViolation(handler)
+	}
+}
+
+// OnUpstreamLog implements UpstreamLogger.OnUpstreamLog for the processes of every session
+func (t *PerSessionTransport) OnUpstreamLog(handler LogHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onLog = handler
+	for _, p := range t.processes {
+		p.transport.OnUpstreamLog(handler)
+	}
+}
+
+// OnStderr implements StderrCapturer.OnStderr for the processes of every session
+func (t *PerSessionTransport) OnStderr(handler StderrHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stderrLog().setHandler(handler)
+}
+
+// Stderr implements StderrCapturer.Stderr with the lines of the processes of every session
+func (t *PerSessionTransport) Stderr() (string, []StderrLine) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cfg.Name, t.stderrLog().recent()
+}
+
+// stderrLog returns the log of standard error, t.mu must be held
+func (t *PerSessionTransport) stderrLog() *stderrLog {
+	if t.stderr == nil {
+		t.stderr = newStderrLog(t.cfg.Name)
+	}
+	return t.stderr
+}
+
+// CloseSession implements SessionScoped.CloseSession by stopping the process of the session
+func (t *PerSessionTransport) CloseSession(ctx context.Context, sessionID string) error {
+	t.mu.Lock()
+	p, ok := t.processes[sessionID]
+	if ok {
+		delete(t.processes, sessionID)
+	}
+	t.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return p.transport.Stop(ctx)
+}
+
+// acquire returns the transport of the session of the context, starting its process
+// unless it runs. done must be called once the requests completed. Requests made outside
+// of a session get a process of their own, stopped right after them.
+func (t *PerSessionTransport) acquire(ctx context.Context) (*StdioTransport, func(), error) {
+	info, ok := ctx.Value(sessionKey{}).(sessionInfo)
+	if !ok {
+		cfg := t.cfg
+		cfg.Policy = cnst.PolicyOnDemand
+		t.mu.Lock()
+		tr := t.newProcessTransport(cfg)
+		t.mu.Unlock()
+		return tr, func() {}, nil
+	}
+	settings := newPerSessionSettings(t.cfg.PerSession)
+
+	t.mu.Lock()
+	p, ok := t.processes[info.id]
+	if !ok {
+		if len(t.processes) >= settings.maxProcesses {
+			t.mu.Unlock()
+			return nil, nil, fmt.Errorf("%w: %s runs %d processes", ErrTooManySessionProcesses, t.cfg.Name, settings.maxProcesses)
+		}
+		if t.processes == nil {
+			t.processes = make(map[string]*sessionProcess)
+		}
+		// the policy is kept, the process keeps running between the requests of the session
+		p = &sessionProcess{transport: t.newProcessTransport(t.cfg)}
+		t.processes[info.id] = p
+		t.startReaper(settings.idleTimeout)
+	}
+	p.active++
+	p.lastUsed = time.Now()
+	t.mu.Unlock()
+
+	done := func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		p.active--
+		p.lastUsed = time.Now()
+		// a process that never started does not hold a slot
+		if p.active == 0 && !p.transport.IsRunning() && t.processes[info.id] == p {
+			delete(t.processes, info.id)
+		}
+	}
+
+	if !p.transport.IsRunning() {
+		tmplCtx, err := template.AssembleTemplateContext(info.req, nil, nil)
+		if err == nil {
+			err = p.transport.Start(ctx, tmplCtx)
+		}
+		if err != nil {
+			done()
+			return nil, nil, err
+		}
+	}
+	return p.transport, done, nil
+}
+
+// newProcessTransport returns the transport of a process serving a single session, t.mu
+// must be held
+func (t *PerSessionTransport) newProcessTransport(cfg config.MCPServerConfig) *StdioTransport {
+	return &StdioTransport{
+		cfg:         cfg,
+		onViolation: t.onViolation,
+		stderr:      t.stderrLog(),
+		logs:        logRelay{dedicated: true, local: t.onLog},
+	}
+}
+
+// startReaper starts reaping idle processes unless it runs already, t.mu must be held
+func (t *PerSessionTransport) startReaper(idleTimeout time.Duration) {
+	if t.stopReaper != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.stopReaper = cancel
+	go func() {
+		ticker := time.NewTicker(reapInterval(idleTimeout))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				t.reapIdle(idleTimeout)
+			}
+		}
+	}()
+}
+
+// reapInterval checks for idle processes often enough to stop them close to the timeout
+func reapInterval(idleTimeout time.Duration) time.Duration {
+	return min(max(idleTimeout/2, time.Second), time.Minute)
+}
+
+// reapIdle stops the processes without requests for longer than the idle timeout
+func (t *PerSessionTransport) reapIdle(idleTimeout time.Duration) {
+	now := time.Now()
+	var idle []*StdioTransport
+
+	t.mu.Lock()
+	for id, p := range t.processes {
+		if p.active == 0 && now.Sub(p.lastUsed) > idleTimeout {
+			delete(t.processes, id)
+			idle = append(idle, p.transport)
+		}
+	}
+	if len(t.processes) == 0 && t.stopReaper != nil {
+		t.stopReaper()
+		t.stopReaper = nil
+	}
+	t.mu.Unlock()
+
+	for _, tr := range idle {
+		_ = tr.Stop(context.Background())
+	}
+}
+
+func (t *PerSessionTransport) FetchTools(ctx context.Context) ([]mcp.ToolSchema, error) {
+	tr, done, err := t.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	return tr.FetchTools(ctx)
+}
+
+func (t *PerSessionTransport) CallTool(ctx context.Context, params mcp.CallToolParams, req *template.RequestWrapper) (*mcp.CallToolResult, error) {
+	tr, done, err := t.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	return tr.CallTool(ctx, params, req)
+}
+
+// FetchPrompts returns all prompts
+func (t *PerSessionTransport) FetchPrompts(ctx context.Context) ([]mcp.PromptSchema, error) {
+	return []mcp.PromptSchema{}, nil
+}
+
+// FetchPrompt returns a specific prompt by name
+func (t *PerSessionTransport) FetchPrompt(ctx context.Context, name string) (*mcp.PromptSchema, error) {
+	return nil, &HTTPError{
+		StatusCode: http.StatusNotFound,
+		Message:    "Prompt not found",
+	}
+}
+
+// FetchResources returns all resources of the process of the session
+func (t *PerSessionTransport) FetchResources(ctx context.Context) ([]mcp.ResourceSchema, error) {
+	tr, done, err := t.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	return tr.FetchResources(ctx)
+}
+
+// FetchResourceTemplates returns all resource templates of the process of the session
+func (t *PerSessionTransport) FetchResourceTemplates(ctx context.Context) ([]mcp.ResourceTemplateSchema, error) {
+	tr, done, err := t.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	return tr.FetchResourceTemplates(ctx)
+}
+
+// ReadResource reads a resource of the process of the session
+func (t *PerSessionTransport) ReadResource(ctx context.Context, uri string, req *template.RequestWrapper) (*mcp.ReadResourceResult, error) {
+	tr, done, err := t.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	return tr.ReadResource(ctx, uri, req)
+}
+
+// SubscribeResource is not supported, see ErrPerSessionSubscriptions
+func (t *PerSessionTransport) SubscribeResource(_ context.Context, _ string, _ ResourceUpdateHandler) error {
+	return ErrPerSessionSubscriptions
+}
+
+// UnsubscribeResource does nothing, nothing can be subscribed
+func (t *PerSessionTransport) UnsubscribeResource(_ context.Context, _ string) error {
+	return nil
+}
+
+// Complete fetches completion values of an argument from the process of the session
+func (t *PerSessionTransport) Complete(ctx context.Context, params mcp.CompleteParams, req *template.RequestWrapper) (*mcp.CompleteResult, error) {
+	tr, done, err := t.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	return tr.Complete(ctx, params, req)
+}
@@ -0,0 +1,116 @@
+package mcpproxy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/template"
+	"github.com/amoylab/unla/pkg/mcp"
+)
+
+func newTestPerSessionTransport(t *testing.T, perSession *config.PerSessionConfig) *PerSessionTransport {
+	t.Helper()
+	tr := &PerSessionTransport{cfg: config.MCPServerConfig{
+		Name:    "test",
+		Type:    cnst.BackendProtoStdio.String(),
+		Command: os.Args[0],
+		Args:    []string{`--team={{index .Request.Query "team"}}`},
+		Env: map[string]string{
+			testServerEnv:    "serve",
+			"UNLA_TEST_USER": `{{index .Request.Headers "X-User"}}`,
+		},
+		Policy:     cnst.PolicyPerSession,
+		PerSession: perSession,
+	}}
+	t.Cleanup(func() { _ = tr.Stop(context.Background()) })
+	return tr
+}
+
+func sessionContext(ctx context.Context, sessionID, user string) context.Context {
+	return WithSession(ctx, sessionID, &template.RequestWrapper{
+		Headers: map[string]string{"X-User": user},
+		Query:   map[string]string{"team": "t-" + user},
+	})
+}
+
+func whoami(ctx context.Context, tr Transport) (string, error) {
+	result, err := tr.CallTool(ctx, mcp.CallToolParams{Name: "whoami", Arguments: []byte(`{}`)}, nil)
+	if err != nil {
+		return "", err
+	}
+	return result.Content[0].(*mcp.TextContent).Text, nil
+}
+
+func TestPerSessionTransport_ProcessPerSession(t *testing.T) {
+	tr := newTestPerSessionTransport(t, nil)
+	alice := sessionContext(t.Context(), "a", "alice")
+	bob := sessionContext(t.Context(), "b", "bob")
+
+	got, err := whoami(alice, tr)
+	require.NoError(t, err)
+	assert.Equal(t, "alice --team=t-alice", got)
+	got, err = whoami(bob, tr)
+	require.NoError(t, err)
+	assert.Equal(t, "bob --team=t-bob", got)
+
+	// processes keep running between the requests of their session
+	first := tr.processes["a"].transport.Status().PID
+	_, err = whoami(alice, tr)
+	require.NoError(t, err)
+	assert.Equal(t, first, tr.processes["a"].transport.Status().PID)
+	assert.Len(t, tr.processes, 2)
+
+	// ending a session stops its process
+	require.NoError(t, tr.CloseSession(t.Context(), "a"))
+	assert.Len(t, tr.processes, 1)
+	assert.True(t, tr.IsRunning())
+	require.NoError(t, tr.Stop(t.Context()))
+	assert.False(t, tr.IsRunning())
+}
+
+func TestPerSessionTransport_MaxProcesses(t *testing.T) {
+	tr := newTestPerSessionTransport(t, &config.PerSessionConfig{MaxProcesses: 1})
+
+	_, err := whoami(sessionContext(t.Context(), "a", "alice"), tr)
+	require.NoError(t, err)
+	_, err = whoami(sessionContext(t.Context(), "b", "bob"), tr)
+	assert.True(t, errors.Is(err, ErrTooManySessionProcesses))
+
+	require.NoError(t, tr.CloseSession(t.Context(), "a"))
+	_, err = whoami(sessionContext(t.Context(), "b", "bob"), tr)
+	assert.NoError(t, err)
+}
+
+func TestPerSessionTransport_ReapsIdleProcesses(t *testing.T) {
+	tr := newTestPerSessionTransport(t, nil)
+	_, err := whoami(sessionContext(t.Context(), "a", "alice"), tr)
+	require.NoError(t, err)
+	process := tr.processes["a"].transport
+
+	tr.reapIdle(defaultSessionIdleTimeout)
+	assert.Len(t, tr.processes, 1)
+
+	tr.reapIdle(0)
+	assert.Empty(t, tr.processes)
+	assert.False(t, process.IsRunning())
+	assert.Nil(t, tr.stopReaper)
+}
+
+func TestPerSessionTransport_WithoutSession(t *testing.T) {
+	tr := newTestPerSessionTransport(t, nil)
+
+	// requests outside of a session run on a process of their own
+	tools, err := tr.FetchTools(t.Context())
+	require.NoError(t, err)
+	assert.NotEmpty(t, tools)
+	assert.False(t, tr.IsRunning())
+
+	assert.ErrorIs(t, tr.SubscribeResource(t.Context(), "file:///a", func(string) {}), ErrPerSessionSubscriptions)
+}
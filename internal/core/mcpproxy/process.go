@@ -0,0 +1,186 @@
+package mcpproxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+
+	"github.com/amoylab/unla/internal/common/config"
+)
+
+// stdioProcess is a child process serving MCP over its standard input and output. The
+// gateway owns the process rather than mcp-go, so that it notices when the process exits.
+type stdioProcess struct {
+	cmd       *exec.Cmd
+	transport *transport.Stdio
+	stdout    *os.File
+	stderr    *os.File
+	// exited is closed once the process exited, err is its exit error
+	exited chan struct{}
+	err    error
+	// stderrDone is closed once standard error was read to its end
+	stderrDone chan struct{}
+	// killedFor is why the gateway killed the process, if it did
+	killedFor atomic.Pointer[error]
+	sandbox   *config.SandboxConfig
+}
+
+// startStdioProcess starts the command with the environment of the gateway plus env, in a
+// process group of its own. A sandboxed command only gets the allowed variables of the
+// gateway, and its resource limits are set before it executes. The lines of standard error
+// go to stderr.
+func startStdioProcess(command string, args, env []string, sandbox *config.SandboxConfig, stderr *stderrLog) (*stdioProcess, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Env = append(os.Environ(), env...)
+	if sandbox != nil {
+		cmd.Dir = sandbox.WorkDir
+		cmd.Env = append(sandboxEnv(os.Environ(), sandbox.EnvAllowlist), env...)
+		if err := confine(cmd, sandbox); err != nil {
+			return nil, err
+		}
+		if err := limitResources(cmd, sandbox); err != nil {
+			return nil, fmt.Errorf("failed to apply sandbox limits: %w", err)
+		}
+	}
+	setProcessGroup(cmd)
+
+	// Pipes are created by hand, exec.Cmd would close them on Wait while they are read
+	stdinR, stdinW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		closeFiles(stdinR, stdinW)
+		return nil, err
+	}
+	stderrR, stderrW, err := os.Pipe()
+	if err != nil {
+		closeFiles(stdinR, stdinW, stdoutR, stdoutW)
+		return nil, err
+	}
+	cmd.Stdin = stdinR
+	cmd.Stdout = stdoutW
+	cmd.Stderr = stderrW
+
+	if err := cmd.Start(); err != nil {
+		closeFiles(stdinR, stdinW, stdoutR, stdoutW, stderrR, stderrW)
+		return nil, err
+	}
+	// The child holds its ends now, stdout reaches EOF once it exits
+	closeFiles(stdinR, stdoutW, stderrW)
+
+	p := &stdioProcess{
+		cmd: cmd,
+		// stderr is closed by stop rather than mcp-go, once its last lines were read
+		transport:  transport.NewIO(stdoutR, stdinW, io.NopCloser(stderrR)),
+		stdout:     stdoutR,
+		stderr:     stderrR,
+		exited:     make(chan struct{}),
+		stderrDone: make(chan struct{}),
+		sandbox:    sandbox,
+	}
+	// stderr is read until its end, a chatty server would block once the pipe is full
+	go func() {
+		stderr.read(cmd.Process.Pid, stderrR)
+		close(p.stderrDone)
+	}()
+	go func() {
+		p.err = cmd.Wait()
+		close(p.exited)
+	}()
+	if sandbox != nil && sandbox.MaxRuntime > 0 {
+		go p.enforceRuntime(sandbox.MaxRuntime.Std())
+	}
+	return p, nil
+}
+
+// enforceRuntime kills the process once it ran for longer than max
+func (p *stdioProcess) enforceRuntime(max time.Duration) {
+	timer := time.NewTimer(max)
+	defer timer.Stop()
+	select {
+	case <-p.exited:
+	case <-timer.C:
+		p.killFor(&SandboxViolation{PID: p.pid(), Limit: SandboxLimitRuntime, Max: max})
+	}
+}
+
+// pid returns the process id
+func (p *stdioProcess) pid() int {
+	return p.cmd.Process.Pid
+}
+
+// kill terminates the process and the processes it spawned right away
+func (p *stdioProcess) kill() {
+	_ = killProcessGroup(p.cmd.Process)
+}
+
+// killFor kills the process, reason tells why in exitError
+func (p *stdioProcess) killFor(reason error) {
+	p.killedFor.Store(&reason)
+	p.kill()
+}
+
+// exitError describes why the process exited, it must be called once exited is closed
+func (p *stdioProcess) exitError() error {
+	if reason := p.killedFor.Load(); reason != nil {
+		return *reason
+	}
+	if p.sandbox != nil && p.sandbox.MaxCPUTime > 0 {
+		limit := time.Duration(cpuSeconds(p.sandbox.MaxCPUTime.Std())) * time.Second
+		if cpuLimitExceeded(p.cmd.ProcessState, limit) {
+			return &SandboxViolation{PID: p.pid(), Limit: SandboxLimitCPU, Max: limit}
+		}
+	}
+	if p.err != nil {
+		return fmt.Errorf("process exited: %w", p.err)
+	}
+	return fmt.Errorf("process exited")
+}
+
+// bind returns a context that is cancelled with ErrServerExited once the process exited,
+// requests pending on a dead process would never complete otherwise
+func (p *stdioProcess) bind(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	go func() {
+		select {
+		case <-p.exited:
+			cancel(ErrServerExited)
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() { cancel(nil) }
+}
+
+// stop closes the standard input of the process, which tells an MCP server to exit, and
+// kills the process if it did not exit within the grace period. Standard error is read
+// to its end before stop returns, unless children of the process keep it open.
+func (p *stdioProcess) stop(grace time.Duration) error {
+	_ = p.transport.Close()
+	select {
+	case <-p.exited:
+	case <-time.After(grace):
+		p.kill()
+		<-p.exited
+	}
+	select {
+	case <-p.stderrDone:
+	case <-time.After(stderrDrainTimeout):
+	}
+	_ = p.stdout.Close()
+	_ = p.stderr.Close()
+	return p.err
+}
+
+func closeFiles(files ...*os.File) {
+	for _, f := range files {
+		_ = f.Close()
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package mcpproxy
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup does nothing, process groups are only supported on Unix
+func setProcessGroup(_ *exec.Cmd) {}
+
+// killProcessGroup kills the process, the processes it spawned keep running
+func killProcessGroup(process *os.Process) error {
+	return process.Kill()
+}
@@ -0,0 +1,183 @@
+package mcpproxy
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/mark3labs/mcp-go/client"
+)
+
+// connectFunc starts and initializes a client of the upstream server
+type connectFunc func(ctx context.Context) (*client.Client, error)
+
+// remoteClient is the client of an SSE or streamable HTTP transport with the requests in
+// flight on it. Concurrent requests share the client, it is only closed once the last of
+// them completed, or when the transport stops.
+type remoteClient struct {
+	mu     sync.Mutex
+	client *client.Client
+	// active counts the requests in flight
+	active int
+}
+
+// start starts the client unless it is running
+func (r *remoteClient) start(ctx context.Context, connect connectFunc) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.client != nil {
+		return nil
+	}
+	c, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	r.client = c
+	return nil
+}
+
+// acquire returns the client, starting it unless it is running. done must be called once
+// the request completed, an idle client is closed then unless keep reports otherwise.
+func (r *remoteClient) acquire(ctx context.Context, connect connectFunc, keep func() bool) (*client.Client, func(), error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.client == nil {
+		c, err := connect(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		r.client = c
+	}
+	c := r.client
+	r.active++
+	return c, func() { r.release(c, keep) }, nil
+}
+
+func (r *remoteClient) release(c *client.Client, keep func() bool) {
+	r.mu.Lock()
+	r.active--
+	if r.active > 0 || r.client != c || keep() {
+		r.mu.Unlock()
+		return
+	}
+	r.client = nil
+	r.mu.Unlock()
+	_ = c.Close()
+}
+
+// stop closes the client, requests still in flight on it fail
+func (r *remoteClient) stop() error {
+	r.mu.Lock()
+	c := r.client
+	r.client = nil
+	r.mu.Unlock()
+	if c == nil {
+		return nil
+	}
+	return c.Close()
+}
+
+func (r *remoteClient) running() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.client != nil
+}
+
+// remoteClients are the clients of a transport, one per gateway user of a server
+// protected by OAuth. A connection carries the token of the request opening it, it must
+// not serve the requests of other users.
+type remoteClients struct {
+	mu      sync.Mutex
+	clients map[string]*userClient
+}
+
+type userClient struct {
+	remoteClient
+	// users counts the requests holding the client, guarded by remoteClients.mu
+	users int
+}
+
+// hold returns the client of the subject, creating it unless it exists. unhold must be
+// called once done with it.
+func (r *remoteClients) hold(subject string) *userClient {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.clients == nil {
+		r.clients = make(map[string]*userClient)
+	}
+	c, ok := r.clients[subject]
+	if !ok {
+		c = &userClient{}
+		r.clients[subject] = c
+	}
+	c.users++
+	return c
+}
+
+// unhold drops the client of the subject once nobody holds it and it is not running
+func (r *remoteClients) unhold(subject string, c *userClient) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c.users--
+	if c.users == 0 && !c.running() && r.clients[subject] == c {
+		delete(r.clients, subject)
+	}
+}
+
+// start starts the client of the subject unless it is running
+func (r *remoteClients) start(ctx context.Context, subject string, connect connectFunc) error {
+	c := r.hold(subject)
+	defer r.unhold(subject, c)
+	return c.start(ctx, connect)
+}
+
+// acquire returns the client of the subject, see remoteClient.acquire
+func (r *remoteClients) acquire(ctx context.Context, subject string, connect connectFunc, keep func() bool) (*client.Client, func(), error) {
+	c := r.hold(subject)
+	cli, done, err := c.acquire(ctx, connect, keep)
+	if err != nil {
+		r.unhold(subject, c)
+		return nil, nil, err
+	}
+	return cli, func() {
+		done()
+		r.unhold(subject, c)
+	}, nil
+}
+
+// stop closes the clients of every user
+func (r *remoteClients) stop() error {
+	r.mu.Lock()
+	clients := r.clients
+	r.clients = nil
+	r.mu.Unlock()
+
+	var errs []error
+	for _, c := range clients {
+		errs = append(errs, c.stop())
+	}
+	return errors.Join(errs...)
+}
+
+// running reports whether the client of any user is running
+func (r *remoteClients) running() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.clients {
+		if c.running() {
+			return true
+		}
+	}
+	return false
+}
+
+// clientSubject returns the user whose client serves the requests of ctx. Servers without
+// an oauth block are requested with a single client.
+func clientSubject(ctx context.Context, cfg config.MCPServerConfig) string {
+	if cfg.OAuth == nil {
+		return ""
+	}
+	subject, _ := ctx.Value(subjectKey{}).(string)
+	return subject
+}
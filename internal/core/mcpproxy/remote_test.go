@@ -0,0 +1,66 @@
+package mcpproxy
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/common/config"
+	mcpgo "github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSSEServer(t *testing.T) string {
+	t.Helper()
+	mcpServer := server.NewMCPServer("test", "1.0.0")
+	mcpServer.AddTool(mcpgo.NewTool("echo"), func(context.Context, mcpgo.CallToolRequest) (*mcpgo.CallToolResult, error) {
+		return mcpgo.NewToolResultText("ok"), nil
+	})
+	srv := server.NewTestServer(mcpServer)
+	t.Cleanup(srv.Close)
+	return srv.URL + "/sse"
+}
+
+func TestSSETransport_OutlivesStartingRequest(t *testing.T) {
+	tr := &SSETransport{cfg: config.MCPServerConfig{Name: "sse", Type: cnst.BackendProtoSSE.String(), URL: newTestSSEServer(t)}}
+	// a subscription keeps the transport running after its requests
+	tr.subs.add("docs://a", "", func(string) {})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, tr.Start(ctx, nil))
+	cancel()
+
+	// responses keep arriving on the stream of the request that started it
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tools, err := tr.FetchTools(ctx)
+	require.NoError(t, err)
+	require.Len(t, tools, 1)
+	assert.Equal(t, "echo", tools[0].Name)
+	assert.True(t, tr.IsRunning())
+	assert.NoError(t, tr.Stop(ctx))
+	assert.False(t, tr.IsRunning())
+}
+
+func TestSSETransport_ConcurrentRequests(t *testing.T) {
+	tr := &SSETransport{cfg: config.MCPServerConfig{Name: "sse", Type: cnst.BackendProtoSSE.String(), URL: newTestSSEServer(t)}}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// the client is shared and only closed after the last request
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := tr.FetchTools(ctx)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.False(t, tr.IsRunning())
+}
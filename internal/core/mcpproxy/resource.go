@@ -0,0 +1,180 @@
+package mcpproxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/amoylab/unla/pkg/mcp"
+	"github.com/mark3labs/mcp-go/client"
+	mcpgo "github.com/mark3labs/mcp-go/mcp"
+)
+
+// ResourceUpdateHandler is called with the URI of a subscribed resource the upstream
+// server reported as updated
+type ResourceUpdateHandler func(uri string)
+
+// resourceSubscriptions keeps the resources a transport subscribed to upstream. They are
+// renewed when the transport restarts, and a transport with subscriptions is kept running
+// so that update notifications keep arriving. Subscriptions to servers protected by OAuth
+// are held by the client of the user who made them.
+type resourceSubscriptions struct {
+	mu       sync.RWMutex
+	handlers map[string]ResourceUpdateHandler
+	subjects map[string]string
+}
+
+func (s *resourceSubscriptions) add(uri, subject string, handler ResourceUpdateHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.handlers == nil {
+		s.handlers = make(map[string]ResourceUpdateHandler)
+		s.subjects = make(map[string]string)
+	}
+	s.handlers[uri] = handler
+	s.subjects[uri] = subject
+}
+
+// remove drops the subscription and returns the user whose client holds it
+func (s *resourceSubscriptions) remove(uri string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	subject := s.subjects[uri]
+	delete(s.handlers, uri)
+	delete(s.subjects, uri)
+	return subject
+}
+
+// active reports whether any resource is subscribed
+func (s *resourceSubscriptions) active() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.handlers) > 0
+}
+
+// held reports whether the client of the user holds any subscription
+func (s *resourceSubscriptions) held(subject string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, sub := range s.subjects {
+		if sub == subject {
+			return true
+		}
+	}
+	return false
+}
+
+// uris returns the resources the client of the user subscribed to
+func (s *resourceSubscriptions) uris(subject string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	uris := make([]string, 0, len(s.handlers))
+	for uri, sub := range s.subjects {
+		if sub == subject {
+			uris = append(uris, uri)
+		}
+	}
+	return uris
+}
+
+// handleNotification dispatches notifications/resources/updated to the handler of the resource
+func (s *resourceSubscriptions) handleNotification(notification mcpgo.JSONRPCNotification) {
+	if notification.Method != mcp.NotificationResourceUpdated {
+		return
+	}
+	uri, _ := notification.Params.AdditionalFields["uri"].(string)
+	s.mu.RLock()
+	handler := s.handlers[uri]
+	s.mu.RUnlock()
+	if handler != nil {
+		handler(uri)
+	}
+}
+
+// watch registers the subscriptions on a newly started client of the user and renews the
+// upstream subscriptions made before a restart
+func (s *resourceSubscriptions) watch(ctx context.Context, cli *client.Client, subject string) {
+	cli.OnNotification(s.handleNotification)
+	for _, uri := range s.uris(subject) {
+		_ = subscribeResource(ctx, cli, uri)
+	}
+}
+
+// listResources fetches every page of the resources of the upstream server
+func listResources(ctx context.Context, cli *client.Client) ([]mcp.ResourceSchema, error) {
+	resources := make([]mcp.ResourceSchema, 0)
+	cursor := ""
+	for {
+		var page struct {
+			Resources  []mcp.ResourceSchema `json:"resources"`
+			NextCursor string               `json:"nextCursor"`
+		}
+		if err := listPage(ctx, cli, mcp.ResourcesList, cursor, &page); err != nil {
+			return nil, err
+		}
+		resources = append(resources, page.Resources...)
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			return resources, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// listResourceTemplates fetches every page of the resource templates of the upstream server
+func listResourceTemplates(ctx context.Context, cli *client.Client) ([]mcp.ResourceTemplateSchema, error) {
+	templates := make([]mcp.ResourceTemplateSchema, 0)
+	cursor := ""
+	for {
+		var page struct {
+			ResourceTemplates []mcp.ResourceTemplateSchema `json:"resourceTemplates"`
+			NextCursor        string                       `json:"nextCursor"`
+		}
+		if err := listPage(ctx, cli, mcp.ResourcesTemplatesList, cursor, &page); err != nil {
+			return nil, err
+		}
+		templates = append(templates, page.ResourceTemplates...)
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			return templates, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+func listPage(ctx context.Context, cli *client.Client, method, cursor string, page any) error {
+	params := map[string]any{}
+	if cursor != "" {
+		params["cursor"] = cursor
+	}
+	raw, err := sendRawRequest(ctx, cli, method, params)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, page); err != nil {
+		return fmt.Errorf("invalid %s result: %w", method, err)
+	}
+	return nil
+}
+
+// readResource reads a resource of the upstream server
+func readResource(ctx context.Context, cli *client.Client, uri string) (*mcp.ReadResourceResult, error) {
+	raw, err := sendRawRequest(ctx, cli, mcp.ResourcesRead, mcp.ReadResourceParams{URI: uri})
+	if err != nil {
+		return nil, err
+	}
+	var result mcp.ReadResourceResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("invalid %s result: %w", mcp.ResourcesRead, err)
+	}
+	return &result, nil
+}
+
+func subscribeResource(ctx context.Context, cli *client.Client, uri string) error {
+	_, err := sendRawRequest(ctx, cli, mcp.ResourcesSubscribe, mcp.SubscribeResourceParams{URI: uri})
+	return err
+}
+
+func unsubscribeResource(ctx context.Context, cli *client.Client, uri string) error {
+	_, err := sendRawRequest(ctx, cli, mcp.ResourcesUnsubscribe, mcp.SubscribeResourceParams{URI: uri})
+	return err
+}
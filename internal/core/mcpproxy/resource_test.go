@@ -0,0 +1,107 @@
+package mcpproxy
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/amoylab/unla/pkg/mcp"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	mcpgo "github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedTransport answers every request with the result returned by handle
+type scriptedTransport struct {
+	fakeRawTransport
+	requests []transport.JSONRPCRequest
+	handle   func(request transport.JSONRPCRequest) string
+}
+
+func (f *scriptedTransport) SendRequest(_ context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	f.requests = append(f.requests, request)
+	return &transport.JSONRPCResponse{Result: json.RawMessage(f.handle(request))}, nil
+}
+
+func TestListResources_FollowsCursor(t *testing.T) {
+	tr := &scriptedTransport{handle: func(request transport.JSONRPCRequest) string {
+		params := request.Params.(map[string]any)
+		if params["cursor"] == "page2" {
+			return `{"resources":[{"uri":"docs://b","name":"b"}]}`
+		}
+		return `{"resources":[{"uri":"docs://a","name":"a","mimeType":"text/plain"}],"nextCursor":"page2"}`
+	}}
+	cli := client.NewClient(tr)
+
+	resources, err := listResources(context.Background(), cli)
+	require.NoError(t, err)
+	assert.Equal(t, []mcp.ResourceSchema{
+		{URI: "docs://a", Name: "a", MimeType: "text/plain"},
+		{URI: "docs://b", Name: "b"},
+	}, resources)
+	require.Len(t, tr.requests, 2)
+	assert.Equal(t, mcp.ResourcesList, tr.requests[0].Method)
+}
+
+func TestListResourceTemplates(t *testing.T) {
+	tr := &scriptedTransport{handle: func(transport.JSONRPCRequest) string {
+		return `{"resourceTemplates":[{"uriTemplate":"docs://{name}","name":"doc"}]}`
+	}}
+	templates, err := listResourceTemplates(context.Background(), client.NewClient(tr))
+	require.NoError(t, err)
+	assert.Equal(t, []mcp.ResourceTemplateSchema{{URITemplate: "docs://{name}", Name: "doc"}}, templates)
+	assert.Equal(t, mcp.ResourcesTemplatesList, tr.requests[0].Method)
+}
+
+func TestReadResource(t *testing.T) {
+	tr := &scriptedTransport{handle: func(transport.JSONRPCRequest) string {
+		return `{"contents":[{"uri":"docs://a","mimeType":"text/plain","text":"hello"},{"uri":"docs://a.png","blob":"iVBORw=="}]}`
+	}}
+	result, err := readResource(context.Background(), client.NewClient(tr), "docs://a")
+	require.NoError(t, err)
+	assert.Equal(t, []mcp.ResourceContents{
+		{URI: "docs://a", MimeType: "text/plain", Text: "hello"},
+		{URI: "docs://a.png", Blob: "iVBORw=="},
+	}, result.Contents)
+	assert.Equal(t, mcp.ResourcesRead, tr.requests[0].Method)
+	assert.Equal(t, mcp.ReadResourceParams{URI: "docs://a"}, tr.requests[0].Params)
+}
+
+func TestResourceSubscriptions(t *testing.T) {
+	var subs resourceSubscriptions
+	assert.False(t, subs.active())
+
+	var updated []string
+	subs.add("docs://a", "", func(uri string) { updated = append(updated, uri) })
+	assert.True(t, subs.active())
+
+	notify := func(method, uri string) {
+		n := mcpgo.JSONRPCNotification{}
+		n.Method = method
+		n.Params.AdditionalFields = map[string]any{"uri": uri}
+		subs.handleNotification(n)
+	}
+	notify(mcp.NotificationResourceUpdated, "docs://a")
+	notify(mcp.NotificationResourceUpdated, "docs://other")
+	notify(mcp.NotificationToolListChanged, "docs://a")
+	assert.Equal(t, []string{"docs://a"}, updated)
+
+	// subscriptions are renewed on a restarted client
+	tr := &scriptedTransport{handle: func(transport.JSONRPCRequest) string { return `{}` }}
+	subs.watch(context.Background(), client.NewClient(tr), "")
+	require.Len(t, tr.requests, 1)
+	assert.Equal(t, mcp.ResourcesSubscribe, tr.requests[0].Method)
+
+	// subscriptions to servers protected by OAuth are held by the client of their user
+	subs.add("docs://b", "bob", func(string) {})
+	assert.True(t, subs.held("bob"))
+	assert.False(t, subs.held("carol"))
+	assert.Equal(t, []string{"docs://b"}, subs.uris("bob"))
+	assert.Equal(t, "bob", subs.remove("docs://b"))
+	assert.False(t, subs.held("bob"))
+
+	assert.Empty(t, subs.remove("docs://a"))
+	assert.False(t, subs.active())
+}
@@ -0,0 +1,69 @@
+package mcpproxy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SandboxLimit is a limit of the sandbox of a stdio server
+type SandboxLimit string
+
+const (
+	// SandboxLimitCPU is the CPU time of the process, the kernel kills a process exceeding it
+	SandboxLimitCPU SandboxLimit = "cpu"
+	// SandboxLimitRuntime is the wall time of the process, the gateway kills a process
+	// exceeding it
+	SandboxLimitRuntime SandboxLimit = "runtime"
+)
+
+// ErrSandboxUnsupported is returned when a sandbox sets resource limits or credentials on
+// a platform other than Linux
+var ErrSandboxUnsupported = errors.New("sandbox resource limits and credentials are only supported on Linux")
+
+// SandboxViolation is a stdio server process killed for exceeding a limit of its sandbox.
+// Processes exceeding their memory or open files limit see allocations and opens fail,
+// which cannot be told apart from other failures, so only cpu and runtime are reported.
+type SandboxViolation struct {
+	Server string        `json:"server"`
+	PID    int           `json:"pid"`
+	Limit  SandboxLimit  `json:"limit"`
+	Max    time.Duration `json:"max"`
+}
+
+func (v *SandboxViolation) Error() string {
+	return fmt.Sprintf("sandbox violation: %s limit of %s exceeded", v.Limit, v.Max)
+}
+
+// ViolationHandler is called when a process of a stdio server violated its sandbox. It
+// must not call back into the transport.
+type ViolationHandler func(violation SandboxViolation)
+
+// Sandboxed is implemented by the transports confining their server processes
+type Sandboxed interface {
+	// OnViolation sets the handler of sandbox violations
+	OnViolation(handler ViolationHandler)
+}
+
+// sandboxEnv returns the variables of environ whose names are in the allowlist, an entry
+// ending with * allows every name with its prefix. The result is never nil, exec.Cmd would
+// pass on the environment of the gateway otherwise.
+func sandboxEnv(environ, allowlist []string) []string {
+	env := []string{}
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		for _, allowed := range allowlist {
+			if prefix, ok := strings.CutSuffix(allowed, "*"); (ok && strings.HasPrefix(name, prefix)) || name == allowed {
+				env = append(env, kv)
+				break
+			}
+		}
+	}
+	return env
+}
+
+// cpuSeconds rounds a CPU time limit up to the seconds of RLIMIT_CPU
+func cpuSeconds(limit time.Duration) uint64 {
+	return uint64((limit + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,129 @@
+//go:build linux
+
+package mcpproxy
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/amoylab/unla/internal/common/config"
+)
+
+// confine makes the command run as the user and group of the sandbox
+func confine(cmd *exec.Cmd, sandbox *config.SandboxConfig) error {
+	if sandbox.UID == nil && sandbox.GID == nil {
+		return nil
+	}
+	cred := &syscall.Credential{Uid: uint32(os.Getuid()), Gid: uint32(os.Getgid())}
+	if sandbox.UID != nil {
+		cred.Uid = uint32(*sandbox.UID)
+	}
+	if sandbox.GID != nil {
+		cred.Gid = uint32(*sandbox.GID)
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Credential: cred}
+	return nil
+}
+
+const (
+	// sandboxHelperArg0 is the argv[0] the gateway executes itself with to set the resource
+	// limits of a sandbox, the helper then executes the command in its place
+	sandboxHelperArg0 = "unla-sandbox"
+	// sandboxLimitsEnv passes the limits to the helper as resource:soft:hard triples, it is
+	// removed from the environment of the command
+	sandboxLimitsEnv = "UNLA_SANDBOX_RLIMITS"
+)
+
+func init() {
+	if len(os.Args) > 1 && os.Args[0] == sandboxHelperArg0 {
+		runSandboxHelper()
+	}
+}
+
+// limitResources makes the command start through the sandbox helper, which sets the
+// resource limits of the sandbox before it executes the command. The limits thus apply
+// from the first instruction of the command on, and children it spawns inherit them.
+func limitResources(cmd *exec.Cmd, sandbox *config.SandboxConfig) error {
+	var limits []string
+	if sandbox.MaxCPUTime > 0 {
+		// the kernel sends SIGXCPU at the soft limit and SIGKILL at the hard one
+		secs := cpuSeconds(sandbox.MaxCPUTime.Std())
+		limits = append(limits, rlimit(syscall.RLIMIT_CPU, secs, secs+1))
+	}
+	if sandbox.MaxOpenFiles > 0 {
+		files := uint64(sandbox.MaxOpenFiles)
+		limits = append(limits, rlimit(syscall.RLIMIT_NOFILE, files, files))
+	}
+	if sandbox.MaxMemoryMB > 0 {
+		// last, the helper allocates nothing once it is set
+		bytes := uint64(sandbox.MaxMemoryMB) << 20
+		limits = append(limits, rlimit(syscall.RLIMIT_AS, bytes, bytes))
+	}
+	if len(limits) == 0 || cmd.Err != nil {
+		return nil
+	}
+	// /proc/self/exe resolves to the gateway in the child, even once its binary was replaced
+	cmd.Args = append([]string{sandboxHelperArg0, cmd.Path}, cmd.Args...)
+	cmd.Path = "/proc/self/exe"
+	cmd.Env = append(cmd.Env, sandboxLimitsEnv+"="+strings.Join(limits, ","))
+	return nil
+}
+
+func rlimit(resource int, soft, hard uint64) string {
+	return fmt.Sprintf("%d:%d:%d", resource, soft, hard)
+}
+
+// runSandboxHelper sets the resource limits passed by limitResources and executes the
+// command, os.Args[1] is its path and the rest its arguments. It never returns.
+func runSandboxHelper() {
+	env := make([]string, 0, len(os.Environ()))
+	var limits string
+	for _, kv := range os.Environ() {
+		if v, ok := strings.CutPrefix(kv, sandboxLimitsEnv+"="); ok {
+			limits = v
+			continue
+		}
+		env = append(env, kv)
+	}
+	err := setRlimits(limits)
+	if err == nil {
+		err = syscall.Exec(os.Args[1], os.Args[2:], env)
+	}
+	fmt.Fprintf(os.Stderr, "failed to start sandboxed command: %v\n", err)
+	os.Exit(127)
+}
+
+func setRlimits(limits string) error {
+	for _, limit := range strings.Split(limits, ",") {
+		var resource int
+		var rlim syscall.Rlimit
+		if _, err := fmt.Sscanf(limit, "%d:%d:%d", &resource, &rlim.Cur, &rlim.Max); err != nil {
+			return fmt.Errorf("invalid resource limit %q: %w", limit, err)
+		}
+		if err := syscall.Setrlimit(resource, &rlim); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cpuLimitExceeded reports whether the process was killed for exceeding its CPU time
+func cpuLimitExceeded(state *os.ProcessState, limit time.Duration) bool {
+	ws, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !ws.Signaled() {
+		return false
+	}
+	switch ws.Signal() {
+	case syscall.SIGXCPU:
+		return true
+	case syscall.SIGKILL:
+		// SIGKILL is sent at the hard limit to processes ignoring SIGXCPU
+		return state.UserTime()+state.SystemTime() >= limit
+	default:
+		return false
+	}
+}
@@ -0,0 +1,29 @@
+//go:build !linux
+
+package mcpproxy
+
+import (
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/amoylab/unla/internal/common/config"
+)
+
+// confine rejects the sandboxes that cannot be enforced on this platform
+func confine(_ *exec.Cmd, sandbox *config.SandboxConfig) error {
+	if sandbox.UID != nil || sandbox.GID != nil ||
+		sandbox.MaxCPUTime > 0 || sandbox.MaxMemoryMB > 0 || sandbox.MaxOpenFiles > 0 {
+		return ErrSandboxUnsupported
+	}
+	return nil
+}
+
+// limitResources does nothing, confine rejected the sandboxes with limits
+func limitResources(_ *exec.Cmd, _ *config.SandboxConfig) error {
+	return nil
+}
+
+func cpuLimitExceeded(_ *os.ProcessState, _ time.Duration) bool {
+	return false
+}
@@ -0,0 +1,130 @@
+package mcpproxy
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/template"
+	"github.com/amoylab/unla/pkg/mcp"
+)
+
+func TestSandboxEnv(t *testing.T) {
+	environ := []string{"PATH=/bin", "HOME=/root", "LC_ALL=C", "LC_TIME=C", "SECRET=x"}
+	assert.Equal(t, []string{"PATH=/bin", "LC_ALL=C", "LC_TIME=C"}, sandboxEnv(environ, []string{"PATH", "LC_*"}))
+	assert.NotNil(t, sandboxEnv(environ, nil))
+	assert.Empty(t, sandboxEnv(environ, nil))
+}
+
+func TestStdioTransport_SandboxEnvAndWorkDir(t *testing.T) {
+	t.Setenv("UNLA_TEST_ALLOWED", "yes")
+	t.Setenv("UNLA_TEST_SECRET", "no")
+	dir := t.TempDir()
+	tr := newTestStdioTransport(t, "serve", cnst.PolicyOnDemand, nil)
+	tr.cfg.Sandbox = &config.SandboxConfig{WorkDir: dir, EnvAllowlist: []string{"UNLA_TEST_ALLOWED"}}
+
+	result, err := tr.CallTool(t.Context(), mcp.CallToolParams{Name: "environ", Arguments: []byte(`{}`)}, nil)
+	require.NoError(t, err)
+	lines := strings.Split(result.Content[0].(*mcp.TextContent).Text, "\n")
+
+	wd, err := os.Stat(lines[0])
+	require.NoError(t, err)
+	want, err := os.Stat(dir)
+	require.NoError(t, err)
+	assert.True(t, os.SameFile(want, wd))
+	assert.ElementsMatch(t, []string{"UNLA_TEST_ALLOWED=yes", testServerEnv + "=serve"}, lines[1:])
+}
+
+func TestStdioTransport_SandboxMaxRuntime(t *testing.T) {
+	tr := newTestStdioTransport(t, "serve", cnst.PolicyOnStart, &config.SupervisorConfig{
+		HealthCheckInterval: -1,
+		InitialBackoff:      config.Duration(time.Hour),
+	})
+	tr.cfg.Sandbox = &config.SandboxConfig{MaxRuntime: config.Duration(100 * time.Millisecond)}
+	violations := make(chan SandboxViolation, 1)
+	tr.OnViolation(func(violation SandboxViolation) { violations <- violation })
+
+	require.NoError(t, tr.Start(t.Context(), template.NewContext()))
+	pid := tr.Status().PID
+
+	status := waitForState(t, tr, ServerStateRestarting)
+	assert.Equal(t, "sandbox violation: runtime limit of 100ms exceeded", status.LastError)
+	violation := <-violations
+	assert.Equal(t, SandboxViolation{Server: "test", PID: pid, Limit: SandboxLimitRuntime, Max: 100 * time.Millisecond}, violation)
+}
+
+func TestStdioTransport_SandboxMaxCPUTime(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("resource limits are only supported on Linux")
+	}
+	tr := newTestStdioTransport(t, "serve", cnst.PolicyOnDemand, nil)
+	tr.cfg.Sandbox = &config.SandboxConfig{MaxCPUTime: config.Duration(time.Second)}
+	violations := make(chan SandboxViolation, 1)
+	tr.OnViolation(func(violation SandboxViolation) { violations <- violation })
+
+	_, err := tr.CallTool(t.Context(), mcp.CallToolParams{Name: "spin", Arguments: []byte(`{}`)}, nil)
+	require.Error(t, err)
+
+	select {
+	case violation := <-violations:
+		assert.Equal(t, SandboxLimitCPU, violation.Limit)
+		assert.Equal(t, time.Second, violation.Max)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the violation was not reported")
+	}
+}
+
+func TestStdioTransport_SandboxLimitsBeforeExec(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("resource limits are only supported on Linux")
+	}
+	tr := newTestStdioTransport(t, "serve", cnst.PolicyOnStart, nil)
+	tr.cfg.Sandbox = &config.SandboxConfig{MaxOpenFiles: 64, EnvAllowlist: []string{"PATH"}}
+	require.NoError(t, tr.Start(t.Context(), template.NewContext()))
+
+	// the process started with the limits already set
+	result, err := tr.CallTool(t.Context(), mcp.CallToolParams{Name: "limits", Arguments: []byte(`{}`)}, nil)
+	require.NoError(t, err)
+	assert.Regexp(t, `Max open files\s+64\s+64`, result.Content[0].(*mcp.TextContent).Text)
+
+	// the helper does not pass the limits on in the environment
+	result, err = tr.CallTool(t.Context(), mcp.CallToolParams{Name: "environ", Arguments: []byte(`{}`)}, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, result.Content[0].(*mcp.TextContent).Text, sandboxLimitsEnv)
+}
+
+func TestStdioTransport_SandboxKillsProcessGroup(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process groups are only checked on Linux")
+	}
+	tr := newTestStdioTransport(t, "serve", cnst.PolicyOnStart, &config.SupervisorConfig{
+		HealthCheckInterval: -1,
+		InitialBackoff:      config.Duration(time.Hour),
+	})
+	tr.cfg.Sandbox = &config.SandboxConfig{MaxRuntime: config.Duration(500 * time.Millisecond)}
+	require.NoError(t, tr.Start(t.Context(), template.NewContext()))
+
+	result, err := tr.CallTool(t.Context(), mcp.CallToolParams{Name: "spawn", Arguments: []byte(`{}`)}, nil)
+	require.NoError(t, err)
+	child := result.Content[0].(*mcp.TextContent).Text
+	require.NotEqual(t, "0", child)
+
+	// the children of a server exceeding its runtime are killed with it
+	waitForState(t, tr, ServerStateRestarting)
+	require.Eventually(t, func() bool {
+		stat, err := os.ReadFile("/proc/" + child + "/stat")
+		if err != nil {
+			return true
+		}
+		// a killed child may linger as a zombie until it is reaped
+		_, fields, _ := strings.Cut(string(stat), ") ")
+		return strings.HasPrefix(fields, "Z")
+	}, 5*time.Second, 20*time.Millisecond)
+}
@@ -0,0 +1,145 @@
+package mcpproxy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// stderrBufferLines is how many recent lines of standard error are kept per server
+	stderrBufferLines = 200
+	// maxStderrLineLength is the length lines of standard error are truncated to
+	maxStderrLineLength = 4096
+	// stderrErrorLines is how many lines of standard error initialization errors quote
+	stderrErrorLines = 20
+	// stderrDrainTimeout bounds the wait for the last lines of an exited process, children
+	// it left behind may keep its standard error open
+	stderrDrainTimeout = 500 * time.Millisecond
+)
+
+// StderrLine is a line a stdio server process wrote to its standard error
+type StderrLine struct {
+	Time time.Time `json:"time"`
+	PID  int       `json:"pid"`
+	Text string    `json:"text"`
+}
+
+// StderrHandler is called with every line the processes of a server write to their
+// standard error. It must not call back into the transport.
+type StderrHandler func(server string, line StderrLine)
+
+// StderrCapturer is implemented by the transports capturing the standard error of their
+// server processes
+type StderrCapturer interface {
+	// OnStderr sets the handler of the lines written to standard error
+	OnStderr(handler StderrHandler)
+	// Stderr returns the name of the server and its recent lines of standard error, oldest
+	// first
+	Stderr() (server string, lines []StderrLine)
+}
+
+// stderrLog keeps the recent lines of standard error of the processes of a server in a
+// ring buffer and passes every line on to its handler
+type stderrLog struct {
+	mu     sync.Mutex
+	server string
+	lines  []StderrLine
+	// next is where the following line goes once the buffer is full, the oldest line
+	next    int
+	handler StderrHandler
+}
+
+func newStderrLog(server string) *stderrLog {
+	return &stderrLog{server: server}
+}
+
+func (l *stderrLog) setHandler(handler StderrHandler) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.handler = handler
+}
+
+func (l *stderrLog) add(pid int, text string) {
+	line := StderrLine{Time: time.Now(), PID: pid, Text: text}
+	l.mu.Lock()
+	if len(l.lines) < stderrBufferLines {
+		l.lines = append(l.lines, line)
+	} else {
+		l.lines[l.next] = line
+		l.next = (l.next + 1) % stderrBufferLines
+	}
+	handler := l.handler
+	l.mu.Unlock()
+
+	if handler != nil {
+		handler(l.server, line)
+	}
+}
+
+// recent returns the buffered lines, oldest first
+func (l *stderrLog) recent() []StderrLine {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lines := make([]StderrLine, 0, len(l.lines))
+	lines = append(lines, l.lines[l.next:]...)
+	return append(lines, l.lines[:l.next]...)
+}
+
+// tail returns the text of the last n buffered lines of a process
+func (l *stderrLog) tail(pid, n int) []string {
+	var texts []string
+	for _, line := range l.recent() {
+		if line.PID == pid {
+			texts = append(texts, line.Text)
+		}
+	}
+	return texts[max(len(texts)-n, 0):]
+}
+
+// read adds the lines of r until it is exhausted or closed, blank lines are skipped
+func (l *stderrLog) read(pid int, r io.Reader) {
+	br := bufio.NewReaderSize(r, maxStderrLineLength)
+	for {
+		line, isPrefix, err := br.ReadLine()
+		if err != nil {
+			return
+		}
+		if text := string(line); strings.TrimSpace(text) != "" {
+			l.add(pid, text)
+		}
+		// the rest of a truncated line is dropped
+		for isPrefix {
+			if _, isPrefix, err = br.ReadLine(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// StderrError is the error of a stdio server quoting the last lines of its standard error.
+// It is meant for the gateway logs and the admin endpoints, the lines may hold secrets and
+// must not reach clients.
+type StderrError struct {
+	Err    error
+	Stderr []string
+}
+
+func (e *StderrError) Error() string {
+	return fmt.Sprintf("%v\nstderr:\n%s", e.Err, strings.Join(e.Stderr, "\n"))
+}
+
+func (e *StderrError) Unwrap() error {
+	return e.Err
+}
+
+// withStderr appends lines of standard error to an error
+func withStderr(err error, lines []string) error {
+	if len(lines) == 0 {
+		return err
+	}
+	return &StderrError{Err: err, Stderr: lines}
+}
@@ -0,0 +1,65 @@
+package mcpproxy
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/template"
+)
+
+func TestStderrLog_KeepsRecentLines(t *testing.T) {
+	log := newStderrLog("test")
+	for i := range stderrBufferLines + 50 {
+		log.add(i%2, fmt.Sprintf("line %d", i))
+	}
+
+	lines := log.recent()
+	require.Len(t, lines, stderrBufferLines)
+	assert.Equal(t, "line 50", lines[0].Text)
+	assert.Equal(t, fmt.Sprintf("line %d", stderrBufferLines+49), lines[len(lines)-1].Text)
+	assert.Equal(t, []string{"line 245", "line 247", "line 249"}, log.tail(1, 3))
+	assert.Empty(t, log.tail(2, 3))
+}
+
+func TestStderrLog_Read(t *testing.T) {
+	var handled []string
+	log := newStderrLog("test")
+	log.setHandler(func(server string, line StderrLine) {
+		assert.Equal(t, "test", server)
+		handled = append(handled, line.Text)
+	})
+
+	long := strings.Repeat("x", maxStderrLineLength+100)
+	log.read(7, strings.NewReader("first\n\n  \r\n"+long+"\nlast"))
+
+	require.Len(t, handled, 3)
+	assert.Equal(t, "first", handled[0])
+	assert.Len(t, handled[1], maxStderrLineLength)
+	assert.Equal(t, "last", handled[2])
+	assert.Equal(t, 7, log.recent()[0].PID)
+}
+
+func TestStdioTransport_InitializeErrorQuotesStderr(t *testing.T) {
+	tr := newTestStdioTransport(t, "fail", cnst.PolicyOnDemand, nil)
+	var handled []string
+	tr.OnStderr(func(_ string, line StderrLine) { handled = append(handled, line.Text) })
+
+	err := tr.Start(t.Context(), template.NewContext())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stderr:\nstarting test server\nerror: missing API key")
+	assert.Contains(t, tr.Status().LastError, "error: missing API key")
+	var stderrErr *StderrError
+	require.ErrorAs(t, err, &stderrErr)
+	assert.Equal(t, []string{"starting test server", "error: missing API key"}, stderrErr.Stderr)
+	assert.Equal(t, []string{"starting test server", "error: missing API key"}, handled)
+
+	server, lines := tr.Stderr()
+	assert.Equal(t, "test", server)
+	require.Len(t, lines, 2)
+	assert.NotZero(t, lines[0].PID)
+}
@@ -3,8 +3,11 @@ package mcpproxy
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/amoylab/unla/internal/template"
 
@@ -15,24 +18,51 @@ import (
 	"github.com/amoylab/unla/pkg/utils"
 	"github.com/amoylab/unla/pkg/version"
 	"github.com/mark3labs/mcp-go/client"
-	"github.com/mark3labs/mcp-go/client/transport"
 	mcpgo "github.com/mark3labs/mcp-go/mcp"
 	"go.opentelemetry.io/otel/attribute"
 	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
-// StdioTransport implements Transport using standard input/output
+// StdioTransport implements Transport using standard input/output. The transport
+// supervises the server process: it notices when the process exits or stops answering
+// pings, and restarts a server that must keep running with exponential backoff until it
+// crashes too often.
 type StdioTransport struct {
-	client *client.Client
+	mu      sync.Mutex
+	client  *client.Client
+	process *stdioProcess
+	// env and args are rendered at the last start, restarts reuse them
+	env  []string
+	args []string
+	// active counts the requests in flight, an on-demand server stops after the last one
+	active int
+	sup    supervision
 	cfg    config.MCPServerConfig
+	subs   resourceSubscriptions
+	logs   logRelay
+	// onViolation is called when the process violated its sandbox
+	onViolation ViolationHandler
+	// stderr keeps the standard error of the processes, it is created on first use
+	stderr *stderrLog
 }
 
-var _ Transport = (*StdioTransport)(nil)
+var (
+	_ Transport      = (*StdioTransport)(nil)
+	_ Supervised     = (*StdioTransport)(nil)
+	_ Sandboxed      = (*StdioTransport)(nil)
+	_ StderrCapturer = (*StdioTransport)(nil)
+	_ UpstreamLogger = (*StdioTransport)(nil)
+)
 
 func (t *StdioTransport) Start(ctx context.Context, tmplCtx *template.Context) error {
-	if t.IsRunning() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.client != nil {
 		return nil
 	}
+	if t.sup.state == ServerStateFailed {
+		return fmt.Errorf("%w: %s", ErrServerFailed, t.sup.lastError)
+	}
 
 	renderedClientEnv := make(map[string]string)
 	for k, v := range t.cfg.Env {
@@ -42,21 +72,44 @@ func (t *StdioTransport) Start(ctx context.Context, tmplCtx *template.Context) e
 		}
 		renderedClientEnv[k] = rendered
 	}
+	t.env = utils.MapToEnvList(renderedClientEnv)
+
+	t.args = make([]string, len(t.cfg.Args))
+	for i, arg := range t.cfg.Args {
+		rendered, err := template.RenderTemplate(arg, tmplCtx)
+		if err != nil {
+			return fmt.Errorf("failed to render args template: %w", err)
+		}
+		t.args[i] = rendered
+	}
+
+	if err := t.startLocked(ctx); err != nil {
+		// a server started with the gateway is retried in the background
+		if t.cfg.Policy == cnst.PolicyOnStart {
+			t.crashed(err)
+		}
+		return err
+	}
+	return nil
+}
 
-	// Create stdio transport
-	stdioTransport := transport.NewStdio(
-		t.cfg.Command,
-		utils.MapToEnvList(renderedClientEnv),
-		t.cfg.Args...,
-	)
+// startLocked starts and initializes the server process, t.mu must be held
+func (t *StdioTransport) startLocked(ctx context.Context) error {
+	t.sup.cancelRestart()
 
-	// Start the transport
-	if err := stdioTransport.Start(ctx); err != nil {
+	p, err := startStdioProcess(t.cfg.Command, t.args, t.env, t.cfg.Sandbox, t.stderrLog())
+	if err != nil {
+		t.sup.lastError = err.Error()
 		return fmt.Errorf("failed to start stdio transport: %w", err)
 	}
 
 	// Create client with the transport
-	c := client.NewClient(stdioTransport)
+	c := client.NewClient(p.transport)
+	if err := c.Start(ctx); err != nil {
+		_ = p.stop(stopGracePeriod)
+		t.sup.lastError = err.Error()
+		return fmt.Errorf("failed to start stdio transport: %w", err)
+	}
 
 	// Initialize the client
 	initRequest := mcpgo.InitializeRequest{}
@@ -66,34 +119,319 @@ func (t *StdioTransport) Start(ctx context.Context, tmplCtx *template.Context) e
 		Version: version.Get(),
 	}
 
-	_, err := c.Initialize(ctx, initRequest)
+	initCtx, cancel := p.bind(ctx)
+	_, err = c.Initialize(initCtx, initRequest)
+	cancel()
 	if err != nil {
-		_ = stdioTransport.Close()
+		select {
+		case <-p.exited:
+			err = t.exitError(p)
+		default:
+		}
+		// the server most likely told why it failed on its standard error, the error
+		// quoting it is only meant for the gateway logs and the admin endpoints
+		_ = p.stop(stopGracePeriod)
+		err = withStderr(err, t.stderrLog().tail(p.pid(), stderrErrorLines))
+		t.sup.lastError = err.Error()
 		return fmt.Errorf("failed to initialize stdio client: %w", err)
 	}
 
+	t.subs.watch(ctx, c, "")
+	c.OnNotification(t.logs.handleNotification)
 	t.client = c
+	t.process = p
+	t.sup.startedAt = time.Now()
+	t.setState(ServerStateRunning)
+
+	go t.watch(p)
+	go t.healthCheck(p, c)
 	return nil
 }
 
 func (t *StdioTransport) Stop(_ context.Context) error {
-	if !t.IsRunning() {
+	t.mu.Lock()
+	t.sup.cancelRestart()
+	p := t.process
+	t.client = nil
+	t.process = nil
+	if t.sup.state != ServerStateFailed {
+		t.setState(ServerStateStopped)
+	}
+	t.mu.Unlock()
+
+	if p == nil {
 		return nil
 	}
+	return p.stop(stopGracePeriod)
+}
 
-	if t.client != nil {
-		err := t.client.Close()
-		if err != nil {
-			return err
-		}
-		t.client = nil
+func (t *StdioTransport) IsRunning() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.client != nil
+}
+
+// Status implements Supervised.Status
+func (t *StdioTransport) Status() ServerStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.statusLocked()
+}
+
+func (t *StdioTransport) statusLocked() ServerStatus {
+	status := ServerStatus{
+		Name:      t.cfg.Name,
+		Command:   t.cfg.Command,
+		State:     t.sup.state,
+		Restarts:  t.sup.restarts,
+		LastError: t.sup.lastError,
+	}
+	if status.State == "" {
+		status.State = ServerStateStopped
+	}
+	if t.process != nil {
+		status.PID = t.process.pid()
+		startedAt := t.sup.startedAt
+		status.StartedAt = &startedAt
+	}
+	return status
+}
+
+// OnStatusChange implements Supervised.OnStatusChange
+func (t *StdioTransport) OnStatusChange(handler StatusHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sup.onStatus = handler
+	if handler != nil {
+		handler(t.statusLocked())
+	}
+}
+
+// OnViolation implements Sandboxed.OnViolation
+func (t *StdioTransport) OnViolation(handler ViolationHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onViolation = handler
+}
+
+// OnUpstreamLog implements UpstreamLogger.OnUpstreamLog
+func (t *StdioTransport) OnUpstreamLog(handler LogHandler) {
+	t.logs.setLocal(handler)
+}
+
+// OnStderr implements StderrCapturer.OnStderr
+func (t *StdioTransport) OnStderr(handler StderrHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stderrLog().setHandler(handler)
+}
+
+// Stderr implements StderrCapturer.Stderr
+func (t *StdioTransport) Stderr() (string, []StderrLine) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cfg.Name, t.stderrLog().recent()
+}
+
+// stderrLog returns the log of standard error, t.mu must be held
+func (t *StdioTransport) stderrLog() *stderrLog {
+	if t.stderr == nil {
+		t.stderr = newStderrLog(t.cfg.Name)
+	}
+	return t.stderr
+}
+
+// Restart implements Supervised.Restart
+func (t *StdioTransport) Restart(ctx context.Context) error {
+	t.mu.Lock()
+	t.sup.cancelRestart()
+	t.sup.crashes = nil
+	p := t.process
+	t.client = nil
+	t.process = nil
+	t.setState(ServerStateStopped)
+	t.mu.Unlock()
+
+	// the process may take the grace period to stop, requests are not held up meanwhile
+	if p != nil {
+		_ = p.stop(stopGracePeriod)
 	}
 
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.client != nil || !t.keepRunning() {
+		// started by a request meanwhile, or the next request starts the server
+		return nil
+	}
+	t.sup.restarts++
+	if err := t.startLocked(ctx); err != nil {
+		t.crashed(err)
+		return err
+	}
 	return nil
 }
 
-func (t *StdioTransport) IsRunning() bool {
-	return t.client != nil
+// setState changes the state of the server and reports it, t.mu must be held
+func (t *StdioTransport) setState(state ServerState) {
+	if t.sup.state == state && state != ServerStateRunning {
+		return
+	}
+	t.sup.state = state
+	if t.sup.onStatus != nil {
+		t.sup.onStatus(t.statusLocked())
+	}
+}
+
+// keepRunning reports whether the server must run without requests in flight, only
+// on-demand servers stop after their requests
+func (t *StdioTransport) keepRunning() bool {
+	return t.cfg.Policy != cnst.PolicyOnDemand || t.subs.active()
+}
+
+// watch waits for the process to exit and handles the exits nobody asked for
+func (t *StdioTransport) watch(p *stdioProcess) {
+	<-p.exited
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// a violation is reported even when the process is being stopped, an on-demand server
+	// is stopped as soon as the request it was killed in failed
+	err := t.exitError(p)
+	if t.process != p {
+		// stopped on purpose
+		return
+	}
+	_ = p.stop(0)
+	t.client = nil
+	t.process = nil
+	t.crashed(err)
+}
+
+// exitError returns why the process exited and reports sandbox violations, t.mu must be held
+func (t *StdioTransport) exitError(p *stdioProcess) error {
+	err := p.exitError()
+	var violation *SandboxViolation
+	if errors.As(err, &violation) && t.onViolation != nil {
+		v := *violation
+		v.Server = t.cfg.Name
+		t.onViolation(v)
+	}
+	return err
+}
+
+// crashed records a crash and schedules the restart of a server that must keep running,
+// unless it crashed too often within the restart window. t.mu must be held.
+func (t *StdioTransport) crashed(err error) {
+	settings := newSupervisorSettings(t.cfg.Supervisor)
+	t.sup.lastError = err.Error()
+	crashes := t.sup.recordCrash(time.Now(), settings.restartWindow)
+
+	switch {
+	case !t.keepRunning():
+		// the next request starts the server again
+		t.setState(ServerStateStopped)
+	case crashes > settings.maxRestarts:
+		t.setState(ServerStateFailed)
+	default:
+		t.setState(ServerStateRestarting)
+		t.sup.restartTimer = time.AfterFunc(settings.backoff(crashes), t.restart)
+	}
+}
+
+// restart starts a crashed server once the backoff elapsed
+func (t *StdioTransport) restart() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sup.state != ServerStateRestarting || t.client != nil {
+		return
+	}
+	t.sup.restartTimer = nil
+	t.sup.restarts++
+
+	ctx, cancel := context.WithTimeout(context.Background(), restartTimeout)
+	defer cancel()
+	if err := t.startLocked(ctx); err != nil {
+		t.crashed(err)
+	}
+}
+
+// healthCheck pings the server periodically and kills it when it stops answering, which
+// makes watch restart it
+func (t *StdioTransport) healthCheck(p *stdioProcess, c *client.Client) {
+	settings := newSupervisorSettings(t.cfg.Supervisor)
+	if settings.healthCheckInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(settings.healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.exited:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), settings.healthCheckTimeout)
+			err := c.Ping(ctx)
+			cancel()
+			if err != nil {
+				p.killFor(fmt.Errorf("health check failed: %w", err))
+				return
+			}
+		}
+	}
+}
+
+// acquire starts the server unless it is running and returns its client. The returned
+// context fails the requests in flight as soon as the process exits. done must be called
+// once the requests completed, it stops an on-demand server after the last request.
+func (t *StdioTransport) acquire(ctx context.Context, tmplCtx *template.Context) (context.Context, *client.Client, func(), error) {
+	if tmplCtx == nil {
+		tmplCtx = template.NewContext()
+	}
+	if err := t.Start(ctx, tmplCtx); err != nil {
+		return nil, nil, nil, err
+	}
+
+	t.mu.Lock()
+	c, p := t.client, t.process
+	if c != nil {
+		t.active++
+	}
+	t.mu.Unlock()
+	if c == nil {
+		return nil, nil, nil, ErrServerExited
+	}
+
+	ctx, cancel := p.bind(ctx)
+	return ctx, c, func() {
+		cancel()
+		t.release()
+	}, nil
+}
+
+// acquireFor acquires the server, rendering its environment from the request when it
+// has to start
+func (t *StdioTransport) acquireFor(ctx context.Context, req *template.RequestWrapper) (context.Context, *client.Client, func(), error) {
+	var tmplCtx *template.Context
+	if !t.IsRunning() {
+		var err error
+		tmplCtx, err = template.AssembleTemplateContext(req, nil, nil)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return t.acquire(ctx, tmplCtx)
+}
+
+// release stops the transport after the last request unless it must keep running
+func (t *StdioTransport) release() {
+	t.mu.Lock()
+	t.active--
+	idle := t.active == 0
+	t.mu.Unlock()
+	if idle && t.cfg.Policy == cnst.PolicyOnDemand && !t.subs.active() {
+		_ = t.Stop(context.Background())
+	}
 }
 
 func (t *StdioTransport) FetchTools(ctx context.Context) ([]mcp.ToolSchema, error) {
@@ -102,19 +440,14 @@ func (t *StdioTransport) FetchTools(ctx context.Context) ([]mcp.ToolSchema, erro
 		WithAttrs(attribute.String(cnst.AttrTransportType, "stdio"))
 	ctx = scope.Ctx
 	defer scope.End()
-	if !t.IsRunning() {
-		if err := t.Start(ctx, template.NewContext()); err != nil {
-			return nil, err
-		}
+	ctx, cli, done, err := t.acquire(ctx, nil)
+	if err != nil {
+		return nil, err
 	}
-	defer func() {
-		if t.cfg.Policy == cnst.PolicyOnDemand {
-			_ = t.Stop(ctx)
-		}
-	}()
+	defer done()
 
 	// List available tools
-	toolsResult, err := t.client.ListTools(ctx, mcpgo.ListToolsRequest{})
+	toolsResult, err := cli.ListTools(ctx, mcpgo.ListToolsRequest{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list tools: %w", err)
 	}
@@ -171,25 +504,25 @@ func (t *StdioTransport) CallTool(ctx context.Context, params mcp.CallToolParams
 		)
 	ctx = scope.Ctx
 	defer scope.End()
+	logToken, stopRelay := t.logs.relay(ctx)
+	defer stopRelay()
+	var tmplCtx *template.Context
 	if !t.IsRunning() {
 		var args map[string]any
 		if err := json.Unmarshal(params.Arguments, &args); err != nil {
 			return nil, fmt.Errorf("invalid tool arguments: %w", err)
 		}
-		tmplCtx, err := template.AssembleTemplateContext(req, args, nil)
+		var err error
+		tmplCtx, err = template.AssembleTemplateContext(req, args, nil)
 		if err != nil {
 			return nil, err
 		}
-
-		if err := t.Start(ctx, tmplCtx); err != nil {
-			return nil, err
-		}
 	}
-	defer func() {
-		if t.cfg.Policy == cnst.PolicyOnDemand {
-			_ = t.Stop(ctx)
-		}
-	}()
+	ctx, cli, done, err := t.acquire(ctx, tmplCtx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
 
 	toolCallRequestParams := make(map[string]any)
 	if err := json.Unmarshal(params.Arguments, &toolCallRequestParams); err != nil {
@@ -200,95 +533,13 @@ func (t *StdioTransport) CallTool(ctx context.Context, params mcp.CallToolParams
 	callRequest := mcpgo.CallToolRequest{}
 	callRequest.Params.Name = params.Name
 	callRequest.Params.Arguments = toolCallRequestParams
+	callRequest.Params.Meta = progressMeta(logToken)
 
-	mcpResult, err := t.client.CallTool(ctx, callRequest)
+	result, err := callTool(ctx, cli, callRequest)
 	if err != nil {
 		return nil, fmt.Errorf("failed to call tool: %w", err)
 	}
 
-	// Convert mcp-go result to local mcp format
-	result := &mcp.CallToolResult{
-		IsError: mcpResult.IsError,
-	}
-
-	// Process content items
-	if len(mcpResult.Content) > 0 {
-		var validContents []mcp.Content
-
-		for _, content := range mcpResult.Content {
-			// Skip null content
-			if content == nil {
-				continue
-			}
-
-			// Try to get content type
-			contentType := ""
-			switch c := content.(type) {
-			case *mcpgo.TextContent:
-				contentType = "text"
-				validContents = append(validContents, &mcp.TextContent{
-					Type: "text",
-					Text: c.Text,
-				})
-			case *mcpgo.ImageContent:
-				contentType = "image"
-				validContents = append(validContents, &mcp.ImageContent{
-					Type:     "image",
-					Data:     c.Data,
-					MimeType: c.MIMEType,
-				})
-			case *mcpgo.AudioContent:
-				contentType = "audio"
-				validContents = append(validContents, &mcp.AudioContent{
-					Type:     "audio",
-					Data:     c.Data,
-					MimeType: c.MIMEType,
-				})
-			default:
-				// Try to parse from raw content
-				rawContent, err := json.Marshal(content)
-				if err == nil {
-					var contentMap map[string]interface{}
-					if json.Unmarshal(rawContent, &contentMap) == nil {
-						if typ, ok := contentMap["type"].(string); ok {
-							contentType = typ
-
-							switch contentType {
-							case "text":
-								if text, ok := contentMap["text"].(string); ok {
-									validContents = append(validContents, &mcp.TextContent{
-										Type: "text",
-										Text: text,
-									})
-								}
-							case "image":
-								data, _ := contentMap["data"].(string)
-								mimeType, _ := contentMap["mimeType"].(string)
-								validContents = append(validContents, &mcp.ImageContent{
-									Type:     "image",
-									Data:     data,
-									MimeType: mimeType,
-								})
-							case "audio":
-								data, _ := contentMap["data"].(string)
-								mimeType, _ := contentMap["mimeType"].(string)
-								validContents = append(validContents, &mcp.AudioContent{
-									Type:     "audio",
-									Data:     data,
-									MimeType: mimeType,
-								})
-							}
-						}
-					}
-				}
-			}
-		}
-
-		if len(validContents) > 0 {
-			result.Content = validContents
-		}
-	}
-
 	return result, nil
 }
 
@@ -304,3 +555,105 @@ func (t *StdioTransport) FetchPrompt(ctx context.Context, name string) (*mcp.Pro
 		Message:    "Prompt not found",
 	}
 }
+
+// FetchResources returns all resources of the upstream server
+func (t *StdioTransport) FetchResources(ctx context.Context) ([]mcp.ResourceSchema, error) {
+	ctx, cli, done, err := t.acquire(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	resources, err := listResources(ctx, cli)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resources: %w", err)
+	}
+	return resources, nil
+}
+
+// FetchResourceTemplates returns all resource templates of the upstream server
+func (t *StdioTransport) FetchResourceTemplates(ctx context.Context) ([]mcp.ResourceTemplateSchema, error) {
+	ctx, cli, done, err := t.acquire(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	templates, err := listResourceTemplates(ctx, cli)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resource templates: %w", err)
+	}
+	return templates, nil
+}
+
+// ReadResource reads a resource of the upstream server
+func (t *StdioTransport) ReadResource(ctx context.Context, uri string, req *template.RequestWrapper) (*mcp.ReadResourceResult, error) {
+	scope := apptrace.Tracer(cnst.TraceMCPProxy).
+		Start(ctx, cnst.SpanTransportStdIOReadResource, oteltrace.WithSpanKind(oteltrace.SpanKindClient)).
+		WithAttrs(
+			attribute.String(cnst.AttrTransportType, "stdio"),
+			attribute.String(cnst.AttrMCPResourceURI, uri),
+		)
+	ctx = scope.Ctx
+	defer scope.End()
+	ctx, cli, done, err := t.acquireFor(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	result, err := readResource(ctx, cli, uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resource: %w", err)
+	}
+	return result, nil
+}
+
+// SubscribeResource subscribes to updates of a resource of the upstream server. The
+// transport keeps running while any resource is subscribed.
+func (t *StdioTransport) SubscribeResource(ctx context.Context, uri string, handler ResourceUpdateHandler) error {
+	ctx, cli, done, err := t.acquire(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	if err := subscribeResource(ctx, cli, uri); err != nil {
+		return fmt.Errorf("failed to subscribe to resource: %w", err)
+	}
+	t.subs.add(uri, "", handler)
+	return nil
+}
+
+// UnsubscribeResource cancels a subscription made with SubscribeResource
+func (t *StdioTransport) UnsubscribeResource(ctx context.Context, uri string) error {
+	t.subs.remove(uri)
+	if !t.IsRunning() {
+		return nil
+	}
+	ctx, cli, done, err := t.acquire(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	if err := unsubscribeResource(ctx, cli, uri); err != nil {
+		return fmt.Errorf("failed to unsubscribe from resource: %w", err)
+	}
+	return nil
+}
+
+// Complete fetches completion values of an argument from the upstream server
+func (t *StdioTransport) Complete(ctx context.Context, params mcp.CompleteParams, req *template.RequestWrapper) (*mcp.CompleteResult, error) {
+	ctx, cli, done, err := t.acquireFor(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	result, err := complete(ctx, cli, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete argument: %w", err)
+	}
+	return result, nil
+}
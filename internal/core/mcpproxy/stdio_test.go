@@ -0,0 +1,263 @@
+package mcpproxy
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/template"
+	"github.com/amoylab/unla/pkg/mcp"
+)
+
+// testServerEnv makes the test binary serve MCP over stdio instead of running the tests,
+// its value is the behavior of the server
+const testServerEnv = "UNLA_TEST_STDIO_SERVER"
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(testServerEnv); mode != "" {
+		serveStdio(mode)
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// serveStdio is a minimal MCP server. In "fail" mode it exits right after complaining on
+// its standard error, in "hang" mode it stops answering pings. A call of the "crash" tool
+// makes it exit, the "whoami" tool returns $UNLA_TEST_USER and the arguments of the
+// process, the "environ" tool its working directory and environment, and the "spin" tool
+// burns CPU forever. The "limits" tool returns the resource limits the process started
+// with, the "spawn" tool starts a child in "sleep" mode and returns its pid. In "linger"
+// mode it keeps running a while after its standard input was closed.
+func serveStdio(mode string) {
+	switch mode {
+	case "fail":
+		fmt.Fprintln(os.Stderr, "starting test server")
+		fmt.Fprintln(os.Stderr, "error: missing API key")
+		os.Exit(1)
+	case "sleep":
+		time.Sleep(time.Minute)
+		return
+	}
+	limits, _ := os.ReadFile("/proc/self/limits")
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params struct {
+				Name string `json:"name"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil || req.ID == nil {
+			continue
+		}
+		var result string
+		switch req.Method {
+		case "initialize":
+			result = `{"protocolVersion":"2025-03-26","capabilities":{"tools":{}},"serverInfo":{"name":"test","version":"1.0.0"}}`
+		case "ping":
+			if mode == "hang" {
+				continue
+			}
+			result = `{}`
+		case "tools/list":
+			result = `{"tools":[{"name":"crash","inputSchema":{"type":"object"}}]}`
+		case "tools/call":
+			switch req.Params.Name {
+			case "crash":
+				os.Exit(1)
+			case "whoami":
+				text, _ := json.Marshal(strings.Join(append([]string{os.Getenv("UNLA_TEST_USER")}, os.Args[1:]...), " "))
+				result = `{"content":[{"type":"text","text":` + string(text) + `}]}`
+			case "environ":
+				wd, _ := os.Getwd()
+				text, _ := json.Marshal(strings.Join(append([]string{wd}, os.Environ()...), "\n"))
+				result = `{"content":[{"type":"text","text":` + string(text) + `}]}`
+			case "spin":
+				for {
+				}
+			case "limits":
+				text, _ := json.Marshal(string(limits))
+				result = `{"content":[{"type":"text","text":` + string(text) + `}]}`
+			case "spawn":
+				child := exec.Command(os.Args[0])
+				child.Env = append(os.Environ(), testServerEnv+"=sleep")
+				_ = child.Start()
+				result = fmt.Sprintf(`{"content":[{"type":"text","text":"%d"}]}`, child.Process.Pid)
+			default:
+				result = `{"content":[]}`
+			}
+		default:
+			continue
+		}
+		fmt.Printf(`{"jsonrpc":"2.0","id":%s,"result":%s}`+"\n", req.ID, result)
+	}
+	if mode == "linger" {
+		time.Sleep(time.Minute)
+	}
+}
+
+func newTestStdioTransport(t *testing.T, mode string, policy cnst.MCPStartupPolicy, supervisor *config.SupervisorConfig) *StdioTransport {
+	t.Helper()
+	tr := &StdioTransport{cfg: config.MCPServerConfig{
+		Name:       "test",
+		Type:       cnst.BackendProtoStdio.String(),
+		Command:    os.Args[0],
+		Env:        map[string]string{testServerEnv: mode},
+		Policy:     policy,
+		Supervisor: supervisor,
+	}}
+	t.Cleanup(func() { _ = tr.Stop(context.Background()) })
+	return tr
+}
+
+func waitForState(t *testing.T, tr *StdioTransport, state ServerState) ServerStatus {
+	t.Helper()
+	var status ServerStatus
+	require.Eventually(t, func() bool {
+		status = tr.Status()
+		return status.State == state
+	}, 5*time.Second, 5*time.Millisecond, "server never reached state %s", state)
+	return status
+}
+
+func TestStdioTransport_OnDemandStopsAfterRequest(t *testing.T) {
+	tr := newTestStdioTransport(t, "serve", cnst.PolicyOnDemand, nil)
+
+	tools, err := tr.FetchTools(t.Context())
+	require.NoError(t, err)
+	require.Len(t, tools, 1)
+	assert.Equal(t, "crash", tools[0].Name)
+
+	assert.False(t, tr.IsRunning())
+	assert.Equal(t, ServerStateStopped, tr.Status().State)
+}
+
+func TestStdioTransport_RestartsCrashedServer(t *testing.T) {
+	tr := newTestStdioTransport(t, "serve", cnst.PolicyOnStart, &config.SupervisorConfig{
+		HealthCheckInterval: -1,
+		InitialBackoff:      config.Duration(10 * time.Millisecond),
+	})
+	var states []ServerState
+	statuses := make(chan ServerStatus, 16)
+	tr.OnStatusChange(func(status ServerStatus) { statuses <- status })
+
+	require.NoError(t, tr.Start(t.Context(), template.NewContext()))
+	first := waitForState(t, tr, ServerStateRunning)
+	require.NotZero(t, first.PID)
+
+	// the call in flight fails as soon as the server dies
+	_, err := tr.CallTool(t.Context(), mcp.CallToolParams{Name: "crash", Arguments: []byte(`{}`)}, nil)
+	require.Error(t, err)
+
+	require.Eventually(t, func() bool {
+		status := tr.Status()
+		return status.State == ServerStateRunning && status.Restarts == 1
+	}, 5*time.Second, 5*time.Millisecond)
+	second := tr.Status()
+	assert.NotEqual(t, first.PID, second.PID)
+	assert.Contains(t, second.LastError, "process exited")
+
+	for len(statuses) > 0 {
+		states = append(states, (<-statuses).State)
+	}
+	assert.Equal(t, []ServerState{ServerStateStopped, ServerStateRunning, ServerStateRestarting, ServerStateRunning}, states)
+
+	tools, err := tr.FetchTools(t.Context())
+	require.NoError(t, err)
+	assert.Len(t, tools, 1)
+}
+
+func TestStdioTransport_FailsAfterCrashLoop(t *testing.T) {
+	tr := newTestStdioTransport(t, "fail", cnst.PolicyOnStart, &config.SupervisorConfig{
+		InitialBackoff: config.Duration(time.Millisecond),
+		MaxRestarts:    2,
+	})
+
+	require.Error(t, tr.Start(t.Context(), template.NewContext()))
+	status := waitForState(t, tr, ServerStateFailed)
+	assert.Equal(t, 2, status.Restarts)
+	assert.NotEmpty(t, status.LastError)
+
+	// failed servers are not started again by requests
+	err := tr.Start(t.Context(), template.NewContext())
+	assert.True(t, errors.Is(err, ErrServerFailed))
+
+	// a restart by hand lifts the crash loop protection
+	tr.mu.Lock()
+	tr.env = []string{testServerEnv + "=serve"}
+	tr.mu.Unlock()
+	require.NoError(t, tr.Restart(t.Context()))
+	status = tr.Status()
+	assert.Equal(t, ServerStateRunning, status.State)
+	assert.Equal(t, 3, status.Restarts)
+}
+
+func TestStdioTransport_RestartDoesNotBlockWhileStopping(t *testing.T) {
+	tr := newTestStdioTransport(t, "linger", cnst.PolicyOnStart, nil)
+	require.NoError(t, tr.Start(t.Context(), template.NewContext()))
+
+	restarted := make(chan error, 1)
+	go func() { restarted <- tr.Restart(t.Context()) }()
+	waitForState(t, tr, ServerStateStopped)
+
+	// the lingering process is stopped without holding up the transport
+	start := time.Now()
+	tools, err := tr.FetchTools(t.Context())
+	require.NoError(t, err)
+	assert.Len(t, tools, 1)
+	assert.Less(t, time.Since(start), stopGracePeriod)
+
+	require.NoError(t, <-restarted)
+	assert.Equal(t, ServerStateRunning, tr.Status().State)
+	assert.Equal(t, 0, tr.Status().Restarts)
+}
+
+func TestStdioTransport_HealthCheckKillsHungServer(t *testing.T) {
+	tr := newTestStdioTransport(t, "hang", cnst.PolicyOnStart, &config.SupervisorConfig{
+		HealthCheckInterval: config.Duration(20 * time.Millisecond),
+		HealthCheckTimeout:  config.Duration(20 * time.Millisecond),
+		InitialBackoff:      config.Duration(time.Hour),
+	})
+
+	require.NoError(t, tr.Start(t.Context(), template.NewContext()))
+	status := waitForState(t, tr, ServerStateRestarting)
+	assert.Contains(t, status.LastError, "health check failed")
+	assert.False(t, tr.IsRunning())
+}
+
+func TestSupervisorSettings(t *testing.T) {
+	s := newSupervisorSettings(nil)
+	assert.Equal(t, defaultHealthCheckInterval, s.healthCheckInterval)
+	assert.Equal(t, defaultMaxRestarts, s.maxRestarts)
+
+	s = newSupervisorSettings(&config.SupervisorConfig{
+		InitialBackoff: config.Duration(time.Second),
+		MaxBackoff:     config.Duration(5 * time.Second),
+	})
+	assert.Equal(t, time.Second, s.backoff(1))
+	assert.Equal(t, 2*time.Second, s.backoff(2))
+	assert.Equal(t, 4*time.Second, s.backoff(3))
+	assert.Equal(t, 5*time.Second, s.backoff(4))
+	assert.Equal(t, 5*time.Second, s.backoff(50))
+}
+
+func TestSupervision_RecordCrash(t *testing.T) {
+	var sup supervision
+	now := time.Now()
+	assert.Equal(t, 1, sup.recordCrash(now.Add(-10*time.Minute), 5*time.Minute))
+	assert.Equal(t, 1, sup.recordCrash(now.Add(-time.Minute), 5*time.Minute))
+	assert.Equal(t, 2, sup.recordCrash(now, 5*time.Minute))
+}
@@ -22,26 +22,48 @@ import (
 
 // StreamableTransport implements Transport using Streamable HTTP
 type StreamableTransport struct {
-	client *client.Client
-	cfg    config.MCPServerConfig
+	remotes remoteClients
+	cfg     config.MCPServerConfig
+	subs    resourceSubscriptions
+	logs    logRelay
+	upstreamOAuth
 }
 
-var _ Transport = (*StreamableTransport)(nil)
+var (
+	_ Transport      = (*StreamableTransport)(nil)
+	_ OAuthClient    = (*StreamableTransport)(nil)
+	_ UpstreamLogger = (*StreamableTransport)(nil)
+)
 
-func (t *StreamableTransport) Start(ctx context.Context, tmplCtx *template.Context) error {
-	if t.IsRunning() {
-		return nil
+// Start connects to the server. The connection is shared by the requests of every user, it
+// only carries the headers rendered without request information. Servers protected by OAuth
+// are connected to once per user instead.
+func (t *StreamableTransport) Start(ctx context.Context, _ *template.Context) error {
+	return t.remotes.start(ctx, clientSubject(ctx, t.cfg), t.connect)
+}
+
+// connect starts and initializes a client. The client outlives the request starting it,
+// its transport is started with a context that is not cancelled with the request.
+func (t *StreamableTransport) connect(ctx context.Context) (*client.Client, error) {
+	headers, err := connectHeaders(t.cfg.Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []transport.StreamableHTTPCOption{transport.WithHTTPHeaders(headers), transport.WithHTTPHeaderFunc(requestHeaders)}
+	if t.cfg.OAuth != nil {
+		options = append(options, transport.WithHTTPBasicClient(oauthHTTPClient()))
 	}
 
 	// Create streamable transport
-	streamableTransport, err := transport.NewStreamableHTTP(t.cfg.URL)
+	streamableTransport, err := transport.NewStreamableHTTP(t.cfg.URL, options...)
 	if err != nil {
-		return fmt.Errorf("failed to create Streamable HTTP transport: %w", err)
+		return nil, fmt.Errorf("failed to create Streamable HTTP transport: %w", err)
 	}
 
-	// Start the transport
-	if err := streamableTransport.Start(ctx); err != nil {
-		return fmt.Errorf("failed to start Streamable HTTP transport: %w", err)
+	// Start the transport, it outlives the request starting it
+	if err := streamableTransport.Start(context.WithoutCancel(ctx)); err != nil {
+		return nil, fmt.Errorf("failed to start Streamable HTTP transport: %w", err)
 	}
 
 	// Create client with the transport
@@ -58,31 +80,25 @@ func (t *StreamableTransport) Start(ctx context.Context, tmplCtx *template.Conte
 	_, err = c.Initialize(ctx, initRequest)
 	if err != nil {
 		_ = streamableTransport.Close()
-		return fmt.Errorf("failed to initialize streamable client: %w", err)
+		return nil, fmt.Errorf("failed to initialize streamable client: %w", err)
 	}
 
-	t.client = c
-	return nil
+	t.subs.watch(ctx, c, clientSubject(ctx, t.cfg))
+	c.OnNotification(t.logs.handleNotification)
+	return c, nil
 }
 
 func (t *StreamableTransport) Stop(_ context.Context) error {
-	if !t.IsRunning() {
-		return nil
-	}
-
-	if t.client != nil {
-		err := t.client.Close()
-		if err != nil {
-			return err
-		}
-		t.client = nil
-	}
-
-	return nil
+	return t.remotes.stop()
 }
 
 func (t *StreamableTransport) IsRunning() bool {
-	return t.client != nil
+	return t.remotes.running()
+}
+
+// OnUpstreamLog implements UpstreamLogger.OnUpstreamLog
+func (t *StreamableTransport) OnUpstreamLog(handler LogHandler) {
+	t.logs.setLocal(handler)
 }
 
 func (t *StreamableTransport) FetchTools(ctx context.Context) ([]mcp.ToolSchema, error) {
@@ -91,14 +107,18 @@ func (t *StreamableTransport) FetchTools(ctx context.Context) ([]mcp.ToolSchema,
 		WithAttrs(attribute.String(cnst.AttrTransportType, "streamable-http"))
 	ctx = scope.Ctx
 	defer scope.End()
-	if !t.IsRunning() {
-		if err := t.Start(ctx, nil); err != nil {
-			return nil, err
-		}
+	ctx, err := t.authorize(ctx, t.cfg)
+	if err != nil {
+		return nil, err
 	}
+	cli, done, err := t.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
 
 	// List available tools
-	toolsResult, err := t.client.ListTools(ctx, mcpgo.ListToolsRequest{})
+	toolsResult, err := cli.ListTools(ctx, mcpgo.ListToolsRequest{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list tools: %w", err)
 	}
@@ -155,25 +175,34 @@ func (t *StreamableTransport) CallTool(ctx context.Context, params mcp.CallToolP
 		)
 	ctx = scope.Ctx
 	defer scope.End()
-	if !t.IsRunning() {
-		var args map[string]any
-		if err := json.Unmarshal(params.Arguments, &args); err != nil {
-			return nil, fmt.Errorf("invalid tool arguments: %w", err)
-		}
-		tmplCtx, err := template.AssembleTemplateContext(req, args, nil)
-		if err != nil {
-			return nil, err
-		}
+	logToken, stopRelay := t.logs.relay(ctx)
+	defer stopRelay()
+	var args map[string]any
+	if err := json.Unmarshal(params.Arguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid tool arguments: %w", err)
+	}
+	tmplCtx, err := template.AssembleTemplateContext(req, args, nil)
+	if err != nil {
+		return nil, err
+	}
 
-		if err := t.Start(ctx, tmplCtx); err != nil {
-			return nil, err
-		}
+	// Users who have not authorized the gateway at the server are told where to do so
+	ctx, err = t.authorize(ctx, t.cfg)
+	if err != nil {
+		return t.toolCallError(ctx, t.cfg, err)
+	}
+
+	cli, done, err := t.acquire(ctx)
+	if err != nil {
+		return t.toolCallError(ctx, t.cfg, err)
+	}
+	defer done()
+
+	// The call carries the headers rendered for its request
+	ctx, err = withRequestHeaders(ctx, t.cfg.Headers, tmplCtx)
+	if err != nil {
+		return nil, err
 	}
-	defer func() {
-		if t.cfg.Policy == cnst.PolicyOnDemand {
-			_ = t.Stop(ctx)
-		}
-	}()
 
 	// Prepare tool call request parameters
 	toolCallRequestParams := make(map[string]interface{})
@@ -185,13 +214,14 @@ func (t *StreamableTransport) CallTool(ctx context.Context, params mcp.CallToolP
 	callRequest := mcpgo.CallToolRequest{}
 	callRequest.Params.Name = params.Name
 	callRequest.Params.Arguments = toolCallRequestParams
+	callRequest.Params.Meta = progressMeta(logToken)
 
-	res, err := t.client.CallTool(ctx, callRequest)
+	result, err := callTool(ctx, cli, callRequest)
 	if err != nil {
-		return nil, fmt.Errorf("failed to call tool: %w", err)
+		return t.toolCallError(ctx, t.cfg, fmt.Errorf("failed to call tool: %w", err))
 	}
 
-	return convertMCPGoResult(res), nil
+	return result, nil
 }
 
 // FetchPrompts returns all prompts
@@ -206,3 +236,163 @@ func (t *StreamableTransport) FetchPrompt(ctx context.Context, name string) (*mc
 		Message:    "Prompt not found",
 	}
 }
+
+// acquire returns the client of the server, connecting unless it is running. Requests to
+// servers protected by OAuth get the client of their user. done must be called once the
+// request completed. The context must not carry the headers of a request
+// yet, a connection started with it would keep sending them.
+func (t *StreamableTransport) acquire(ctx context.Context) (*client.Client, func(), error) {
+	subject := clientSubject(ctx, t.cfg)
+	return t.remotes.acquire(ctx, subject, t.connect, func() bool { return t.keepRunning(subject) })
+}
+
+// keepRunning reports whether the client of the user keeps running without requests in
+// flight. Sessions with servers protected by OAuth belong to the user whose token opened
+// them, they are only kept while they hold resource subscriptions.
+func (t *StreamableTransport) keepRunning(subject string) bool {
+	return (t.cfg.Policy != cnst.PolicyOnDemand && t.cfg.OAuth == nil) || t.subs.held(subject)
+}
+
+// FetchResources returns all resources of the upstream server
+func (t *StreamableTransport) FetchResources(ctx context.Context) ([]mcp.ResourceSchema, error) {
+	ctx, err := t.authorize(ctx, t.cfg)
+	if err != nil {
+		return nil, err
+	}
+	cli, done, err := t.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	resources, err := listResources(ctx, cli)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resources: %w", err)
+	}
+	return resources, nil
+}
+
+// FetchResourceTemplates returns all resource templates of the upstream server
+func (t *StreamableTransport) FetchResourceTemplates(ctx context.Context) ([]mcp.ResourceTemplateSchema, error) {
+	ctx, err := t.authorize(ctx, t.cfg)
+	if err != nil {
+		return nil, err
+	}
+	cli, done, err := t.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	templates, err := listResourceTemplates(ctx, cli)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resource templates: %w", err)
+	}
+	return templates, nil
+}
+
+// ReadResource reads a resource of the upstream server
+func (t *StreamableTransport) ReadResource(ctx context.Context, uri string, req *template.RequestWrapper) (*mcp.ReadResourceResult, error) {
+	scope := apptrace.Tracer(cnst.TraceMCPProxy).
+		Start(ctx, cnst.SpanTransportStreamableReadResource, oteltrace.WithSpanKind(oteltrace.SpanKindClient)).
+		WithAttrs(
+			attribute.String(cnst.AttrTransportType, "streamable-http"),
+			attribute.String(cnst.AttrMCPResourceURI, uri),
+		)
+	ctx = scope.Ctx
+	defer scope.End()
+	ctx, err := t.authorize(ctx, t.cfg)
+	if err != nil {
+		return nil, err
+	}
+	tmplCtx, err := template.AssembleTemplateContext(req, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	cli, done, err := t.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	ctx, err = withRequestHeaders(ctx, t.cfg.Headers, tmplCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := readResource(ctx, cli, uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resource: %w", err)
+	}
+	return result, nil
+}
+
+// SubscribeResource subscribes to updates of a resource of the upstream server. The
+// transport keeps running while any resource is subscribed.
+func (t *StreamableTransport) SubscribeResource(ctx context.Context, uri string, handler ResourceUpdateHandler) error {
+	ctx, err := t.authorize(ctx, t.cfg)
+	if err != nil {
+		return err
+	}
+	cli, done, err := t.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+	if err := subscribeResource(ctx, cli, uri); err != nil {
+		return fmt.Errorf("failed to subscribe to resource: %w", err)
+	}
+	t.subs.add(uri, clientSubject(ctx, t.cfg), handler)
+	return nil
+}
+
+// UnsubscribeResource cancels a subscription made with SubscribeResource
+func (t *StreamableTransport) UnsubscribeResource(ctx context.Context, uri string) error {
+	// the subscription is held by the client of the user who made it
+	subject := t.subs.remove(uri)
+	if !t.IsRunning() {
+		return nil
+	}
+	if t.cfg.OAuth != nil {
+		ctx = WithSubject(ctx, subject)
+	}
+	ctx, err := t.authorize(ctx, t.cfg)
+	if err != nil {
+		return err
+	}
+	cli, done, err := t.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+	if err := unsubscribeResource(ctx, cli, uri); err != nil {
+		return fmt.Errorf("failed to unsubscribe from resource: %w", err)
+	}
+	return nil
+}
+
+// Complete fetches completion values of an argument from the upstream server
+func (t *StreamableTransport) Complete(ctx context.Context, params mcp.CompleteParams, req *template.RequestWrapper) (*mcp.CompleteResult, error) {
+	ctx, err := t.authorize(ctx, t.cfg)
+	if err != nil {
+		return nil, err
+	}
+	tmplCtx, err := template.AssembleTemplateContext(req, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	cli, done, err := t.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	ctx, err = withRequestHeaders(ctx, t.cfg.Headers, tmplCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := complete(ctx, cli, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete argument: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,158 @@
+package mcpproxy
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/amoylab/unla/internal/common/config"
+)
+
+// ServerState is the state of a supervised stdio MCP server
+type ServerState string
+
+const (
+	// ServerStateStopped is a server that is not running and not expected to run
+	ServerStateStopped ServerState = "stopped"
+	// ServerStateRunning is a server whose process is up and initialized
+	ServerStateRunning ServerState = "running"
+	// ServerStateRestarting is a crashed server waiting out the backoff before it restarts
+	ServerStateRestarting ServerState = "restarting"
+	// ServerStateFailed is a server that crashed too often, it stays down until it is
+	// restarted by hand
+	ServerStateFailed ServerState = "failed"
+)
+
+// ServerStates lists every state a supervised server can be in
+var ServerStates = []ServerState{ServerStateStopped, ServerStateRunning, ServerStateRestarting, ServerStateFailed}
+
+var (
+	// ErrServerExited fails the requests in flight when the process of the server exits
+	ErrServerExited = errors.New("stdio server exited")
+	// ErrServerFailed is returned while a server is down for crashing too often
+	ErrServerFailed = errors.New("stdio server is down after crashing repeatedly")
+)
+
+// ServerStatus reports the supervision of a stdio MCP server
+type ServerStatus struct {
+	Name      string      `json:"name"`
+	Command   string      `json:"command"`
+	State     ServerState `json:"state"`
+	PID       int         `json:"pid,omitempty"`
+	Restarts  int         `json:"restarts"`
+	LastError string      `json:"lastError,omitempty"`
+	StartedAt *time.Time  `json:"startedAt,omitempty"`
+}
+
+// StatusHandler is called with the status of a supervised server whenever its state
+// changes. It must not call back into the transport.
+type StatusHandler func(status ServerStatus)
+
+// Supervised is implemented by the transports supervising a local server process
+type Supervised interface {
+	// Status returns the current status of the server
+	Status() ServerStatus
+	// OnStatusChange sets the handler of state changes, it is called with the current
+	// status right away
+	OnStatusChange(handler StatusHandler)
+	// Restart restarts the server, lifting the crash loop protection of a failed server
+	Restart(ctx context.Context) error
+}
+
+const (
+	defaultHealthCheckInterval = 30 * time.Second
+	defaultHealthCheckTimeout  = 5 * time.Second
+	defaultInitialBackoff      = time.Second
+	defaultMaxBackoff          = time.Minute
+	defaultMaxRestarts         = 5
+	defaultRestartWindow       = 5 * time.Minute
+
+	// stopGracePeriod is how long a server may take to exit once its input is closed
+	stopGracePeriod = 5 * time.Second
+	// restartTimeout bounds the start and initialization of a restarted server
+	restartTimeout = 30 * time.Second
+)
+
+// supervisorSettings is a SupervisorConfig with the defaults applied
+type supervisorSettings struct {
+	healthCheckInterval time.Duration
+	healthCheckTimeout  time.Duration
+	initialBackoff      time.Duration
+	maxBackoff          time.Duration
+	maxRestarts         int
+	restartWindow       time.Duration
+}
+
+func newSupervisorSettings(cfg *config.SupervisorConfig) supervisorSettings {
+	s := supervisorSettings{
+		healthCheckInterval: defaultHealthCheckInterval,
+		healthCheckTimeout:  defaultHealthCheckTimeout,
+		initialBackoff:      defaultInitialBackoff,
+		maxBackoff:          defaultMaxBackoff,
+		maxRestarts:         defaultMaxRestarts,
+		restartWindow:       defaultRestartWindow,
+	}
+	if cfg == nil {
+		return s
+	}
+	if cfg.HealthCheckInterval != 0 {
+		s.healthCheckInterval = cfg.HealthCheckInterval.Std()
+	}
+	if cfg.HealthCheckTimeout > 0 {
+		s.healthCheckTimeout = cfg.HealthCheckTimeout.Std()
+	}
+	if cfg.InitialBackoff > 0 {
+		s.initialBackoff = cfg.InitialBackoff.Std()
+	}
+	if cfg.MaxBackoff > 0 {
+		s.maxBackoff = cfg.MaxBackoff.Std()
+	}
+	if cfg.MaxRestarts > 0 {
+		s.maxRestarts = cfg.MaxRestarts
+	}
+	if cfg.RestartWindow > 0 {
+		s.restartWindow = cfg.RestartWindow.Std()
+	}
+	return s
+}
+
+// backoff returns the delay before the restart following the given number of recent crashes
+func (s supervisorSettings) backoff(crashes int) time.Duration {
+	backoff := s.initialBackoff
+	for i := 1; i < crashes && backoff < s.maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, s.maxBackoff)
+}
+
+// supervision is the restart bookkeeping of a stdio transport
+type supervision struct {
+	state     ServerState
+	restarts  int
+	lastError string
+	startedAt time.Time
+	// crashes are the times of the crashes within the restart window
+	crashes []time.Time
+	// restartTimer is the pending restart of a crashed server
+	restartTimer *time.Timer
+	onStatus     StatusHandler
+}
+
+// recordCrash adds a crash and returns the number of crashes within the window
+func (s *supervision) recordCrash(now time.Time, window time.Duration) int {
+	recent := s.crashes[:0]
+	for _, at := range s.crashes {
+		if now.Sub(at) < window {
+			recent = append(recent, at)
+		}
+	}
+	s.crashes = append(recent, now)
+	return len(s.crashes)
+}
+
+func (s *supervision) cancelRestart() {
+	if s.restartTimer != nil {
+		s.restartTimer.Stop()
+		s.restartTimer = nil
+	}
+}
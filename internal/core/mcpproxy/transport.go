@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/amoylab/unla/internal/common/cnst"
 	"github.com/amoylab/unla/internal/common/config"
 	"github.com/amoylab/unla/internal/template"
 	"github.com/amoylab/unla/pkg/mcp"
@@ -42,6 +43,20 @@ type Transport interface {
 	FetchPrompts(ctx context.Context) ([]mcp.PromptSchema, error)
 	// FetchPrompt fetches a specific prompt by name
 	FetchPrompt(ctx context.Context, name string) (*mcp.PromptSchema, error)
+
+	// FetchResources fetches the list of available resources
+	FetchResources(ctx context.Context) ([]mcp.ResourceSchema, error)
+	// FetchResourceTemplates fetches the list of available resource templates
+	FetchResourceTemplates(ctx context.Context) ([]mcp.ResourceTemplateSchema, error)
+	// ReadResource reads the contents of a resource
+	ReadResource(ctx context.Context, uri string, req *template.RequestWrapper) (*mcp.ReadResourceResult, error)
+	// SubscribeResource subscribes to updates of a resource, the handler is called on every update
+	SubscribeResource(ctx context.Context, uri string, handler ResourceUpdateHandler) error
+	// UnsubscribeResource cancels a subscription made with SubscribeResource
+	UnsubscribeResource(ctx context.Context, uri string) error
+
+	// Complete fetches completion values of a prompt or resource template argument
+	Complete(ctx context.Context, params mcp.CompleteParams, req *template.RequestWrapper) (*mcp.CompleteResult, error)
 }
 
 // NewTransport creates transport based on the configuration
@@ -50,6 +65,9 @@ func NewTransport(cfg config.MCPServerConfig) (Transport, error) {
 	case TypeSSE:
 		return &SSETransport{cfg: cfg}, nil
 	case TypeStdio:
+		if cfg.Policy == cnst.PolicyPerSession {
+			return &PerSessionTransport{cfg: cfg}, nil
+		}
 		return &StdioTransport{cfg: cfg}, nil
 	case TypeStreamable:
 		return &StreamableTransport{cfg: cfg}, nil
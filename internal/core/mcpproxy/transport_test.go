@@ -3,6 +3,7 @@ package mcpproxy
 import (
 	"testing"
 
+	"github.com/amoylab/unla/internal/common/cnst"
 	"github.com/amoylab/unla/internal/common/config"
 	"github.com/stretchr/testify/assert"
 )
@@ -22,6 +23,13 @@ func TestNewTransportVariantsAndUnknown(t *testing.T) {
 		assert.True(t, ok)
 	}
 
+	perSession, err := NewTransport(config.MCPServerConfig{Type: string(TypeStdio), Command: "echo", Policy: cnst.PolicyPerSession})
+	assert.NoError(t, err)
+	if assert.NotNil(t, perSession) {
+		_, ok := perSession.(*PerSessionTransport)
+		assert.True(t, ok)
+	}
+
 	stream, err := NewTransport(config.MCPServerConfig{Type: string(TypeStreamable), URL: "http://localhost"})
 	assert.NoError(t, err)
 	if assert.NotNil(t, stream) {
@@ -0,0 +1,183 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/i18n"
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/internal/template"
+	"github.com/amoylab/unla/pkg/mcp"
+)
+
+// handlePromptsGet answers prompts/get. Prompts of HTTP servers are rendered from their
+// configuration, prompts of proxied MCP servers are fetched from the upstream server.
+func (s *Server) handlePromptsGet(c *gin.Context, conn session.Connection, req mcp.JSONRPCRequest, isSSE bool) {
+	prefix := conn.Meta().Prefix
+	protoType := s.state.GetProtoType(prefix)
+	if protoType == "" {
+		s.sendProtocolError(c, req.Id, "Server configuration not found", http.StatusInternalServerError, mcp.ErrorCodeInternalError)
+		return
+	}
+
+	var params struct {
+		Name      string            `json:"name"`
+		Arguments map[string]string `json:"arguments"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.Name == "" {
+		s.sendProtocolError(c, req.Id, "Invalid prompt get parameters", http.StatusBadRequest, mcp.ErrorCodeInvalidParams)
+		return
+	}
+
+	logger := s.getLogger(c)
+	switch protoType {
+	case cnst.BackendProtoHttp:
+		prompt := s.state.GetPrompt(prefix, params.Name)
+		if prompt == nil {
+			s.sendProtocolError(c, req.Id, "Prompt not found", http.StatusNotFound, mcp.ErrorCodeMethodNotFound)
+			return
+		}
+		if missing := missingPromptArgs(prompt.Arguments, params.Arguments); len(missing) > 0 {
+			message := i18n.NewWithMessage("ErrorPromptArgsMissing", "Missing required arguments for prompt {{.Prompt}}: {{.Args}}").
+				WithParam("Prompt", prompt.Name).
+				WithParam("Args", strings.Join(missing, ", ")).
+				TranslateByRequest(c.Request)
+			s.sendJSONRPCErrorWithData(c, conn, req, message, mcp.ErrorCodeInvalidParams,
+				map[string]any{"missing": missing}, isSSE)
+			return
+		}
+
+		var serverCfg map[string]string
+		if server := s.state.GetServerConfig(prefix); server != nil {
+			serverCfg = server.Config
+		}
+		args := make(map[string]any, len(params.Arguments))
+		for k, v := range params.Arguments {
+			args[k] = v
+		}
+		var result *mcp.GetPromptResult
+		tmplCtx, err := template.PrepareTemplateContext(conn.Meta().Request, args, c.Request, serverCfg)
+		if err == nil {
+			result, err = renderPrompt(prompt, params.Arguments, tmplCtx)
+		}
+		if err != nil {
+			logger.Error("failed to render prompt",
+				zap.String("prompt", params.Name),
+				zap.String("session_id", conn.Meta().ID),
+				zap.Error(err))
+			s.sendJSONRPCError(c, conn, req, fmt.Sprintf("Failed to render prompt: %v", err), mcp.ErrorCodeInternalError, isSSE)
+			return
+		}
+		s.sendSuccessResponse(c, conn, req, result, isSSE)
+	case cnst.BackendProtoStdio, cnst.BackendProtoSSE, cnst.BackendProtoStreamable:
+		transport := s.state.GetTransport(prefix)
+		if transport == nil {
+			s.sendProtocolError(c, req.Id, "Failed to fetch prompt", http.StatusInternalServerError, mcp.ErrorCodeInternalError)
+			return
+		}
+		prompt, err := transport.FetchPrompt(s.upstreamContext(c, conn), params.Name)
+		if err != nil {
+			logger.Error("failed to fetch prompt",
+				zap.String("prompt", params.Name),
+				zap.String("session_id", conn.Meta().ID),
+				zap.Error(err))
+			s.sendProtocolError(c, req.Id, "Failed to fetch prompt", http.StatusInternalServerError, mcp.ErrorCodeInternalError)
+			return
+		}
+		if prompt == nil {
+			s.sendProtocolError(c, req.Id, "Prompt not found", http.StatusNotFound, mcp.ErrorCodeMethodNotFound)
+			return
+		}
+		result := &mcp.GetPromptResult{Description: prompt.Description, Messages: make([]mcp.PromptResponseSchema, 0, len(prompt.PromptResponse))}
+		for _, msg := range prompt.PromptResponse {
+			msg.Content.Text = substitutePromptArgs(msg.Content.Text, params.Arguments)
+			result.Messages = append(result.Messages, msg)
+		}
+		s.sendSuccessResponse(c, conn, req, result, isSSE)
+	default:
+		s.sendProtocolError(c, req.Id, "Unsupported protocol type", http.StatusBadRequest, mcp.ErrorCodeInvalidParams)
+	}
+}
+
+// missingPromptArgs returns the required arguments of a prompt that were not supplied
+func missingPromptArgs(declared []config.PromptArgument, args map[string]string) []string {
+	var missing []string
+	for _, arg := range declared {
+		if _, ok := args[arg.Name]; arg.Required && !ok {
+			missing = append(missing, arg.Name)
+		}
+	}
+	return missing
+}
+
+// renderPrompt renders the messages of a configured prompt. Text, data and embedded
+// resources are templates with access to the arguments, the server config and env.
+func renderPrompt(prompt *config.PromptConfig, args map[string]string, tmplCtx *template.Context) (*mcp.GetPromptResult, error) {
+	render := func(text string) (string, error) {
+		if text == "" {
+			return "", nil
+		}
+		rendered, err := template.RenderTemplate(text, tmplCtx)
+		if err != nil {
+			return "", err
+		}
+		// Substitute legacy {name} placeholders after rendering so that argument values
+		// are never parsed as templates
+		return substitutePromptArgs(rendered, args), nil
+	}
+
+	result := &mcp.GetPromptResult{
+		Description: prompt.Description,
+		Messages:    make([]mcp.PromptResponseSchema, 0, len(prompt.PromptResponse)),
+	}
+	for i, msg := range prompt.PromptResponse {
+		content := mcp.PromptResponseContentSchema{Type: msg.Content.Type, MimeType: msg.Content.MimeType}
+		if content.Type == "" {
+			content.Type = mcp.TextContentType
+		}
+		var err error
+		if content.Text, err = render(msg.Content.Text); err != nil {
+			return nil, fmt.Errorf("message #%d: %w", i+1, err)
+		}
+		if content.Data, err = render(msg.Content.Data); err != nil {
+			return nil, fmt.Errorf("message #%d: %w", i+1, err)
+		}
+		if res := msg.Content.Resource; res != nil {
+			content.Resource = &mcp.ResourceContents{MimeType: res.MimeType}
+			for _, field := range []struct {
+				dst *string
+				src string
+			}{
+				{&content.Resource.URI, res.URI},
+				{&content.Resource.Text, res.Text},
+				{&content.Resource.Blob, res.Blob},
+			} {
+				if *field.dst, err = render(field.src); err != nil {
+					return nil, fmt.Errorf("message #%d: %w", i+1, err)
+				}
+			}
+		}
+		result.Messages = append(result.Messages, mcp.PromptResponseSchema{Role: msg.Role, Content: content})
+	}
+	return result, nil
+}
+
+// substitutePromptArgs replaces {name} placeholders with the supplied arguments in a
+// single pass, so a value containing a placeholder is left as is
+func substitutePromptArgs(text string, args map[string]string) string {
+	if len(args) == 0 {
+		return text
+	}
+	pairs := make([]string, 0, 2*len(args))
+	for name, value := range args {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/core/state"
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/pkg/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newPromptTestServer(t *testing.T) *Server {
+	t.Helper()
+	cfg := &config.MCPConfig{
+		Name:    "prompts",
+		Routers: []config.RouterConfig{{Prefix: "/p", Server: "srv"}},
+		Servers: []config.ServerConfig{{Name: "srv", Config: map[string]string{"style": "concise"}}},
+		Prompts: []config.PromptConfig{{
+			Name:        "review",
+			Description: "Review code",
+			Arguments: []config.PromptArgument{
+				{Name: "code", Required: true},
+				{Name: "language", Required: true},
+				{Name: "screenshot"},
+			},
+			PromptResponse: []config.PromptResponse{{
+				Role:    "user",
+				Content: config.PromptResponseContent{Type: "text", Text: "Review this {{.Args.language}} code, be {{.Config.style}}: {code}"},
+			}, {
+				Role:    "user",
+				Content: config.PromptResponseContent{Type: "image", Data: "{{.Args.screenshot}}", MimeType: "image/png"},
+			}, {
+				Role: "user",
+				Content: config.PromptResponseContent{Type: "resource", Resource: &config.PromptResourceContent{
+					URI:      "docs://style/{{.Args.language}}",
+					MimeType: "text/markdown",
+					Text:     "Follow the {{.Args.language}} style guide",
+				}},
+			}},
+		}},
+	}
+	st, err := state.BuildStateFromConfig(context.Background(), []*config.MCPConfig{cfg}, nil, zap.NewNop())
+	require.NoError(t, err)
+	return &Server{logger: zap.NewNop(), state: st}
+}
+
+func getPrompt(t *testing.T, s *Server, name string, args map[string]string) map[string]any {
+	t.Helper()
+	raw, err := json.Marshal(map[string]any{"name": name, "arguments": args})
+	require.NoError(t, err)
+	conn := &fakeConn{meta: &session.Meta{ID: "sid", Prefix: "/p"}}
+	c, w := newGin()
+	s.handlePromptsGet(c, conn, mcp.JSONRPCRequest{JSONRPC: mcp.JSPNRPCVersion, Id: 1, Method: mcp.PromptsGet, Params: raw}, false)
+
+	data := w.Body.String()
+	if event := sseData(data); event != "" {
+		data = event
+	}
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal([]byte(data), &resp))
+	return resp
+}
+
+func TestPromptsGet_RendersTemplates(t *testing.T) {
+	s := newPromptTestServer(t)
+
+	resp := getPrompt(t, s, "review", map[string]string{
+		"code":       "fmt.Println({{.Config.style}})",
+		"language":   "Go",
+		"screenshot": "iVBORw==",
+	})
+	require.Nil(t, resp["error"])
+	result := resp["result"].(map[string]any)
+	assert.Equal(t, "Review code", result["description"])
+	messages := result["messages"].([]any)
+	require.Len(t, messages, 3)
+
+	text := messages[0].(map[string]any)["content"].(map[string]any)
+	assert.Equal(t, "text", text["type"])
+	// argument values are substituted verbatim, never rendered as templates
+	assert.Equal(t, "Review this Go code, be concise: fmt.Println({{.Config.style}})", text["text"])
+
+	image := messages[1].(map[string]any)["content"].(map[string]any)
+	assert.Equal(t, map[string]any{"type": "image", "data": "iVBORw==", "mimeType": "image/png"}, image)
+
+	resource := messages[2].(map[string]any)["content"].(map[string]any)
+	assert.Equal(t, "resource", resource["type"])
+	assert.Equal(t, map[string]any{
+		"uri":      "docs://style/Go",
+		"mimeType": "text/markdown",
+		"text":     "Follow the Go style guide",
+	}, resource["resource"])
+}
+
+func TestPromptsGet_MissingRequiredArgs(t *testing.T) {
+	s := newPromptTestServer(t)
+
+	resp := getPrompt(t, s, "review", map[string]string{"code": "x"})
+	rpcErr := resp["error"].(map[string]any)
+	assert.Equal(t, float64(mcp.ErrorCodeInvalidParams), rpcErr["code"])
+	assert.Contains(t, rpcErr["message"], "language")
+	assert.Equal(t, []any{"language"}, rpcErr["data"].(map[string]any)["missing"])
+
+	resp = getPrompt(t, s, "unknown", nil)
+	assert.Equal(t, float64(mcp.ErrorCodeMethodNotFound), resp["error"].(map[string]any)["code"])
+}
+
+func TestSubstitutePromptArgs(t *testing.T) {
+	assert.Equal(t, "a={b} b=2", substitutePromptArgs("a={a} b={b}", map[string]string{"a": "{b}", "b": "2"}))
+	assert.Equal(t, "{a}", substitutePromptArgs("{a}", nil))
+}
@@ -0,0 +1,168 @@
+package core
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/core/ratelimit"
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/pkg/mcp"
+)
+
+const (
+	rateLimitLevelRouter = "router"
+	rateLimitLevelTool   = "tool"
+)
+
+// rateLimitRejection describes the limit a request exceeded
+type rateLimitRejection struct {
+	level  string
+	keyBy  string
+	limit  ratelimit.Limit
+	result ratelimit.Result
+}
+
+// checkRateLimits takes a token from the bucket of every limit and returns the first
+// rejection, tool is empty for router limits. Limiter errors are logged and let the
+// request through, an unavailable Redis should not take the gateway down.
+func (s *Server) checkRateLimits(c *gin.Context, conn session.Connection, level, tool string, limits []config.RateLimitConfig) *rateLimitRejection {
+	if s.rateLimiter == nil || len(limits) == 0 {
+		return nil
+	}
+
+	logger := s.getLogger(c)
+	ctx := c.Request.Context()
+	prefix := conn.Meta().Prefix
+	for _, cfg := range limits {
+		keyBy := cfg.KeyBy
+		if keyBy == "" {
+			keyBy = config.RateLimitKeyByPrefix
+		}
+		key := s.rateLimitKey(c, conn, keyBy)
+		if tool != "" {
+			key += ":tool:" + tool
+		}
+
+		limit, err := ratelimit.NewLimit(cfg)
+		if err != nil {
+			logger.Warn("ignoring invalid rate limit",
+				zap.String("prefix", prefix),
+				zap.String("level", level),
+				zap.Int("requests", cfg.Requests),
+				zap.Error(err))
+			continue
+		}
+		result, err := s.rateLimiter.Allow(ctx, key, limit)
+		if err != nil {
+			logger.Warn("failed to check rate limit",
+				zap.String("key", key),
+				zap.Error(err))
+			continue
+		}
+		if result.Allowed {
+			continue
+		}
+
+		logger.Info("rate limit exceeded",
+			zap.String("prefix", prefix),
+			zap.String("level", level),
+			zap.String("key_by", keyBy),
+			zap.String("tool", tool),
+			zap.Duration("retry_after", result.RetryAfter))
+		oteltrace.SpanFromContext(ctx).AddEvent(cnst.EventRateLimitRejected,
+			oteltrace.WithAttributes(
+				attribute.String(cnst.AttrRateLimitLevel, level),
+				attribute.String(cnst.AttrRateLimitKeyBy, keyBy),
+			))
+		if s.metrics != nil {
+			s.metrics.RateLimitRejected(prefix, level, keyBy)
+		}
+		return &rateLimitRejection{level: level, keyBy: keyBy, limit: limit, result: result}
+	}
+	return nil
+}
+
+// rateLimitKey returns the bucket key shared by requests of the same tenant, prefix,
+// client or session. Requests without an OAuth client fall back to their session.
+func (s *Server) rateLimitKey(c *gin.Context, conn session.Connection, keyBy string) string {
+	prefix := conn.Meta().Prefix
+	switch keyBy {
+	case config.RateLimitKeyByTenant:
+		return "tenant:" + s.state.GetTenant(prefix)
+	case config.RateLimitKeyByClient:
+		if clientID := s.requestClientID(c, conn); clientID != "" {
+			return "prefix:" + prefix + ":client:" + clientID
+		}
+		return "session:" + conn.Meta().ID
+	case config.RateLimitKeyBySession:
+		return "session:" + conn.Meta().ID
+	default:
+		return "prefix:" + prefix
+	}
+}
+
+// requestClientID resolves the OAuth client of the request's access token, SSE
+// messages may omit the token and rely on the one the session was opened with
+func (s *Server) requestClientID(c *gin.Context, conn session.Connection) string {
+	if s.auth == nil {
+		return ""
+	}
+	token := bearerToken(c.Request.Header.Get("Authorization"))
+	if token == "" {
+		if req := conn.Meta().Request; req != nil {
+			token = bearerToken(req.Headers["Authorization"])
+		}
+	}
+	if token == "" {
+		return ""
+	}
+	clientID, err := s.auth.TokenClientID(c.Request.Context(), token)
+	if err != nil {
+		return ""
+	}
+	return clientID
+}
+
+// sendRateLimitError sends a rate limited JSON-RPC error with retry hints in the
+// Retry-After header and the error data
+func (s *Server) sendRateLimitError(c *gin.Context, conn session.Connection, req mcp.JSONRPCRequest, rejection *rateLimitRejection, isSSE bool) {
+	retryAfter := rejection.result.RetryAfter
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+
+	response := mcp.JSONRPCErrorSchema{
+		JSONRPCBaseResult: mcp.JSONRPCBaseResult{
+			JSONRPC: mcp.JSPNRPCVersion,
+			ID:      req.Id,
+		},
+		Error: mcp.JSONRPCError{
+			Code:    mcp.ErrorCodeRateLimited,
+			Message: "Rate limit exceeded",
+			Data: map[string]any{
+				"retryAfterMs": retryAfter.Milliseconds(),
+				"limit":        rejection.limit.Requests,
+				"burst":        rejection.limit.Burst,
+				"per":          rejection.limit.Per.String(),
+				"level":        rejection.level,
+				"keyBy":        rejection.keyBy,
+			},
+		},
+	}
+	if isSSE || getBatch(c) != nil {
+		s.sendResponse(c, req.Id, conn, response, isSSE)
+		return
+	}
+	c.Header(mcp.HeaderMcpSessionID, conn.Meta().ID)
+	c.JSON(http.StatusTooManyRequests, response)
+}
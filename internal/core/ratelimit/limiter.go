@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/common/config"
+)
+
+// Type represents the type of rate limit store
+type Type string
+
+const (
+	// TypeMemory keeps token buckets in memory, limits apply per replica
+	TypeMemory Type = "memory"
+	// TypeRedis keeps token buckets in Redis, limits are shared between replicas
+	TypeRedis Type = "redis"
+)
+
+// ErrInvalidLimit is returned for limits that do not allow a positive number of requests
+var ErrInvalidLimit = errors.New("rate limit requests must be positive")
+
+// Limit describes a token bucket holding Burst tokens, refilled with Requests tokens every Per
+type Limit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+// NewLimit converts a configured rate limit, applying the defaults of Per and Burst.
+// Configs are not validated when loaded from the store, a limit without requests is
+// rejected with ErrInvalidLimit.
+func NewLimit(cfg config.RateLimitConfig) (Limit, error) {
+	if cfg.Requests <= 0 {
+		return Limit{}, ErrInvalidLimit
+	}
+	limit := Limit{
+		Requests: cfg.Requests,
+		Per:      cfg.Per.Std(),
+		Burst:    cfg.Burst,
+	}
+	if limit.Per <= 0 {
+		limit.Per = time.Second
+	}
+	if limit.Burst <= 0 {
+		limit.Burst = limit.Requests
+	}
+	return limit, nil
+}
+
+// valid reports whether buckets can be refilled at the rate of the limit
+func (l Limit) valid() bool {
+	return l.Requests > 0 && l.Per > 0 && l.Burst > 0
+}
+
+// refillInterval returns the time it takes to refill a single token, limits must be valid
+func (l Limit) refillInterval() time.Duration {
+	return max(l.Per/time.Duration(l.Requests), time.Nanosecond)
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed bool
+	// Remaining is the number of whole tokens left in the bucket
+	Remaining int
+	// RetryAfter is the time until the next token is available, zero when allowed
+	RetryAfter time.Duration
+}
+
+// Limiter defines the interface for token bucket stores
+type Limiter interface {
+	// Allow takes a token from the bucket of key, creating a full bucket on first use
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// NewLimiter creates a new limiter based on configuration. The Redis limiter
+// connects with the session store's Redis settings.
+func NewLimiter(logger *zap.Logger, cfg *config.RateLimitStoreConfig, redisCfg config.SessionRedisConfig) (Limiter, error) {
+	logger.Info("Initializing rate limiter", zap.String("type", cfg.Type))
+	switch Type(cfg.Type) {
+	case TypeMemory:
+		return NewMemoryLimiter(logger), nil
+	case TypeRedis:
+		return NewRedisLimiter(logger, redisCfg, cfg.Prefix)
+	default:
+		return nil, fmt.Errorf("unsupported rate limit type: %s", cfg.Type)
+	}
+}
@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestNewLimit_Defaults(t *testing.T) {
+	limit, err := NewLimit(config.RateLimitConfig{Requests: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, Limit{Requests: 5, Per: time.Second, Burst: 5}, limit)
+
+	limit, err = NewLimit(config.RateLimitConfig{Requests: 5, Per: config.Duration(time.Minute), Burst: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, Limit{Requests: 5, Per: time.Minute, Burst: 10}, limit)
+
+	_, err = NewLimit(config.RateLimitConfig{Requests: 0})
+	assert.ErrorIs(t, err, ErrInvalidLimit)
+	_, err = NewLimit(config.RateLimitConfig{Requests: -1})
+	assert.ErrorIs(t, err, ErrInvalidLimit)
+}
+
+func TestMemoryLimiter_InvalidLimit(t *testing.T) {
+	l := NewMemoryLimiter(zap.NewNop())
+	_, err := l.Allow(context.Background(), "k", Limit{Requests: 0, Per: time.Second, Burst: 1})
+	assert.ErrorIs(t, err, ErrInvalidLimit)
+}
+
+func TestMemoryLimiter_Allow(t *testing.T) {
+	ctx := context.Background()
+	l := NewMemoryLimiter(zap.NewNop())
+	now := time.Unix(1000, 0)
+	l.now = func() time.Time { return now }
+	limit := Limit{Requests: 2, Per: time.Second, Burst: 2}
+
+	res, err := l.Allow(ctx, "k", limit)
+	assert.NoError(t, err)
+	assert.Equal(t, Result{Allowed: true, Remaining: 1}, res)
+	res, _ = l.Allow(ctx, "k", limit)
+	assert.True(t, res.Allowed)
+
+	res, _ = l.Allow(ctx, "k", limit)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+
+	// other keys have their own bucket
+	res, _ = l.Allow(ctx, "other", limit)
+	assert.True(t, res.Allowed)
+
+	now = now.Add(250 * time.Millisecond)
+	res, _ = l.Allow(ctx, "k", limit)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 250*time.Millisecond, res.RetryAfter)
+
+	now = now.Add(250 * time.Millisecond)
+	res, _ = l.Allow(ctx, "k", limit)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+}
+
+func TestMemoryLimiter_ChangedLimitResetsBucket(t *testing.T) {
+	ctx := context.Background()
+	l := NewMemoryLimiter(zap.NewNop())
+	limit := Limit{Requests: 1, Per: time.Hour, Burst: 1}
+
+	res, _ := l.Allow(ctx, "k", limit)
+	assert.True(t, res.Allowed)
+	res, _ = l.Allow(ctx, "k", limit)
+	assert.False(t, res.Allowed)
+
+	limit.Burst = 2
+	res, _ = l.Allow(ctx, "k", limit)
+	assert.True(t, res.Allowed)
+}
+
+func TestMemoryLimiter_SweepDropsFullBuckets(t *testing.T) {
+	ctx := context.Background()
+	l := NewMemoryLimiter(zap.NewNop())
+	now := time.Unix(1000, 0)
+	l.now = func() time.Time { return now }
+
+	_, _ = l.Allow(ctx, "fast", Limit{Requests: 1, Per: time.Second, Burst: 1})
+	_, _ = l.Allow(ctx, "slow", Limit{Requests: 1, Per: time.Hour, Burst: 1})
+	assert.Len(t, l.buckets, 2)
+
+	now = now.Add(2 * sweepInterval)
+	_, _ = l.Allow(ctx, "new", Limit{Requests: 1, Per: time.Second, Burst: 1})
+	assert.Len(t, l.buckets, 2)
+	assert.Contains(t, l.buckets, bucketKey{key: "slow", limit: Limit{Requests: 1, Per: time.Hour, Burst: 1}})
+	assert.NotContains(t, l.buckets, bucketKey{key: "fast", limit: Limit{Requests: 1, Per: time.Second, Burst: 1}})
+}
@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/pkg/utils"
+)
+
+// tokenBucketScript refills and takes a token atomically. The bucket is a hash of the
+// token count and the time of the last update, it expires once it would be full again.
+// The time is the one of the Redis server, the clocks of the replicas may differ.
+//
+// KEYS[1] bucket key
+// ARGV[1] milliseconds to refill one token, ARGV[2] burst
+// Returns {allowed, remaining, retry after in milliseconds}
+var tokenBucketScript = redis.NewScript(`
+redis.replicate_commands()
+local interval = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+if now > ts then
+	tokens = math.min(burst, tokens + (now - ts) / interval)
+	ts = now
+end
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) * interval)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(ts))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) * interval) + 1000)
+return {allowed, math.floor(tokens), retry}
+`)
+
+// RedisLimiter implements Limiter with Redis so buckets are shared between replicas
+type RedisLimiter struct {
+	logger *zap.Logger
+	client redis.UniversalClient
+	prefix string
+}
+
+var _ Limiter = (*RedisLimiter)(nil)
+
+// NewRedisLimiter creates a new Redis-based limiter
+func NewRedisLimiter(logger *zap.Logger, cfg config.SessionRedisConfig, prefix string) (*RedisLimiter, error) {
+	addrs := utils.SplitByMultipleDelimiters(cfg.Addr, ";", ",")
+	redisOptions := &redis.UniversalOptions{
+		Addrs:    addrs,
+		Username: cfg.Username,
+		Password: cfg.Password,
+	}
+	if cfg.ClusterType == cnst.RedisClusterTypeSentinel {
+		redisOptions.MasterName = cfg.MasterName
+	}
+	if cfg.ClusterType != cnst.RedisClusterTypeCluster {
+		// can not set db in cluster mode
+		redisOptions.DB = cfg.DB
+	}
+	client := redis.NewUniversalClient(redisOptions)
+
+	// Test connection
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	if prefix == "" {
+		prefix = "ratelimit"
+	}
+	return &RedisLimiter{
+		logger: logger.Named("ratelimit.redis"),
+		client: client,
+		prefix: prefix + ":",
+	}, nil
+}
+
+// Allow implements Limiter.Allow
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	if !limit.valid() {
+		return Result{}, ErrInvalidLimit
+	}
+	interval := float64(limit.refillInterval()) / float64(time.Millisecond)
+	// the limit is part of the key so a changed limit starts over with a full bucket
+	bucketKey := fmt.Sprintf("%s%s:%d:%d:%d", l.prefix, key, limit.Requests, limit.Per.Milliseconds(), limit.Burst)
+
+	vals, err := tokenBucketScript.Run(ctx, l.client, []string{bucketKey},
+		interval, limit.Burst).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(vals) != 3 {
+		return Result{}, fmt.Errorf("unexpected token bucket reply: %v", vals)
+	}
+	return Result{
+		Allowed:    vals[0] == 1,
+		Remaining:  int(vals[1]),
+		RetryAfter: time.Duration(vals[2]) * time.Millisecond,
+	}, nil
+}
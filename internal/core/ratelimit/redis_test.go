@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	miniredis "github.com/alicebob/miniredis/v2"
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newTestRedisLimiter(t *testing.T) (*RedisLimiter, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	l, err := NewRedisLimiter(zap.NewNop(), config.SessionRedisConfig{
+		ClusterType: cnst.RedisClusterTypeSingle,
+		Addr:        mr.Addr(),
+	}, "testlimit")
+	if err != nil {
+		t.Fatalf("failed to create RedisLimiter: %v", err)
+	}
+	return l, mr
+}
+
+func TestNewRedisLimiter_ConnectionError(t *testing.T) {
+	l, err := NewRedisLimiter(zap.NewNop(), config.SessionRedisConfig{
+		ClusterType: cnst.RedisClusterTypeSingle,
+		Addr:        "127.0.0.1:0",
+	}, "")
+	assert.Nil(t, l)
+	assert.Error(t, err)
+}
+
+func TestRedisLimiter_Allow(t *testing.T) {
+	ctx := context.Background()
+	l, mr := newTestRedisLimiter(t)
+	now := time.Unix(1000, 0)
+	mr.SetTime(now)
+	limit := Limit{Requests: 2, Per: time.Second, Burst: 2}
+
+	res, err := l.Allow(ctx, "k", limit)
+	assert.NoError(t, err)
+	assert.Equal(t, Result{Allowed: true, Remaining: 1}, res)
+	res, _ = l.Allow(ctx, "k", limit)
+	assert.True(t, res.Allowed)
+
+	res, err = l.Allow(ctx, "k", limit)
+	assert.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+
+	res, _ = l.Allow(ctx, "other", limit)
+	assert.True(t, res.Allowed)
+
+	mr.SetTime(now.Add(500 * time.Millisecond))
+	res, _ = l.Allow(ctx, "k", limit)
+	assert.True(t, res.Allowed)
+}
+
+func TestRedisLimiter_SharedBetweenReplicas(t *testing.T) {
+	ctx := context.Background()
+	a, mr := newTestRedisLimiter(t)
+	b, err := NewRedisLimiter(zap.NewNop(), config.SessionRedisConfig{
+		ClusterType: cnst.RedisClusterTypeSingle,
+		Addr:        mr.Addr(),
+	}, "testlimit")
+	assert.NoError(t, err)
+	limit := Limit{Requests: 1, Per: time.Hour, Burst: 1}
+
+	res, _ := a.Allow(ctx, "k", limit)
+	assert.True(t, res.Allowed)
+	res, _ = b.Allow(ctx, "k", limit)
+	assert.False(t, res.Allowed)
+}
+
+func TestRedisLimiter_BucketExpires(t *testing.T) {
+	ctx := context.Background()
+	l, mr := newTestRedisLimiter(t)
+
+	_, err := l.Allow(ctx, "k", Limit{Requests: 1, Per: time.Second, Burst: 1})
+	assert.NoError(t, err)
+	keys := mr.Keys()
+	assert.Len(t, keys, 1)
+	assert.Greater(t, mr.TTL(keys[0]), time.Duration(0))
+
+	mr.FastForward(5 * time.Second)
+	assert.Empty(t, mr.Keys())
+}
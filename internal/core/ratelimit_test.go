@@ -0,0 +1,160 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/core/ratelimit"
+	"github.com/amoylab/unla/internal/core/state"
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/pkg/mcp"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newRateLimitTestServer(t *testing.T, endpoint string) *Server {
+	t.Helper()
+	cfg := &config.MCPConfig{
+		Name:   "rl",
+		Tenant: "acme",
+		Routers: []config.RouterConfig{{
+			Prefix:     "/rl",
+			Server:     "srv",
+			RateLimits: []config.RateLimitConfig{{Requests: 2, Per: config.Duration(time.Hour)}},
+		}},
+		Servers: []config.ServerConfig{{Name: "srv", AllowedTools: []string{"limited"}}},
+		Tools: []config.ToolConfig{{
+			Name:       "limited",
+			Method:     http.MethodGet,
+			Endpoint:   endpoint,
+			RateLimits: []config.RateLimitConfig{{Requests: 1, Per: config.Duration(time.Minute), KeyBy: config.RateLimitKeyBySession}},
+		}},
+	}
+	st, err := state.BuildStateFromConfig(context.Background(), []*config.MCPConfig{cfg}, nil, zap.NewNop())
+	require.NoError(t, err)
+
+	allowlist, _ := parseInternalNetworkAllowlist([]string{"127.0.0.0/8", "::1/128"})
+	return &Server{
+		logger:          zap.NewNop(),
+		state:           st,
+		toolRespHandler: CreateResponseHandlerChain(),
+		internalNetACL:  allowlist,
+		rateLimiter:     ratelimit.NewMemoryLimiter(zap.NewNop()),
+		inflight:        newInflightRequests(),
+	}
+}
+
+func newRateLimitRequest(t *testing.T, method string, params any) (*gin.Context, *httptest.ResponseRecorder, mcp.JSONRPCRequest) {
+	t.Helper()
+	c, w := newGin()
+	c.Request = httptest.NewRequest(http.MethodPost, "/rl/mcp", nil)
+	req := mcp.JSONRPCRequest{JSONRPC: mcp.JSPNRPCVersion, Method: method, Id: 1}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		require.NoError(t, err)
+		req.Params = raw
+	}
+	return c, w, req
+}
+
+func TestHandleMCPRequest_RouterRateLimit(t *testing.T) {
+	s := newRateLimitTestServer(t, "http://127.0.0.1")
+	conn := &fakeConn{meta: &session.Meta{ID: "sid", Prefix: "/rl"}}
+
+	for i := 0; i < 2; i++ {
+		c, w, req := newRateLimitRequest(t, mcp.Ping, nil)
+		s.handleMCPRequest(c, req, conn)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	// notifications are never limited
+	c, _, req := newRateLimitRequest(t, mcp.NotificationInitialized, nil)
+	req.Id = nil
+	s.handleMCPRequest(c, req, conn)
+	assert.Equal(t, http.StatusAccepted, c.Writer.Status())
+
+	c, w, req := newRateLimitRequest(t, mcp.Ping, nil)
+	s.handleMCPRequest(c, req, conn)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1800", w.Header().Get("Retry-After"))
+
+	var body mcp.JSONRPCErrorSchema
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, mcp.ErrorCodeRateLimited, body.Error.Code)
+	data, ok := body.Error.Data.(map[string]any)
+	require.True(t, ok)
+	assert.InDelta(t, float64(30*time.Minute/time.Millisecond), data["retryAfterMs"], 1000)
+	assert.Equal(t, float64(2), data["limit"])
+	assert.Equal(t, "1h0m0s", data["per"])
+	assert.Equal(t, rateLimitLevelRouter, data["level"])
+	assert.Equal(t, config.RateLimitKeyByPrefix, data["keyBy"])
+}
+
+func TestCallHTTPTool_ToolRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	s := newRateLimitTestServer(t, srv.URL)
+	params := mcp.CallToolParams{Name: "limited", Arguments: json.RawMessage(`{}`)}
+
+	// SSE sessions receive the error on the stream
+	conn := &fakeConn{meta: &session.Meta{ID: "sid", Prefix: "/rl", Request: &session.RequestInfo{}}}
+	c, _, req := newRateLimitRequest(t, mcp.ToolsCall, params)
+	assert.NotNil(t, s.callHTTPTool(c, req, conn, params, true))
+	c, w, req := newRateLimitRequest(t, mcp.ToolsCall, params)
+	assert.Nil(t, s.callHTTPTool(c, req, conn, params, true))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	require.Len(t, conn.sent, 1)
+	assert.True(t, strings.Contains(string(conn.sent[0].Data), `"code":-32029`))
+
+	// the tool limit is keyed by session, other sessions have their own bucket
+	other := &fakeConn{meta: &session.Meta{ID: "other", Prefix: "/rl", Request: &session.RequestInfo{}}}
+	c, _, req = newRateLimitRequest(t, mcp.ToolsCall, params)
+	assert.NotNil(t, s.callHTTPTool(c, req, other, params, true))
+}
+
+func TestRateLimitKey(t *testing.T) {
+	s := newRateLimitTestServer(t, "http://127.0.0.1")
+	conn := &fakeConn{meta: &session.Meta{ID: "sid", Prefix: "/rl", Request: &session.RequestInfo{
+		Headers: map[string]string{"Authorization": "Bearer valid_token"},
+	}}}
+	c, _ := newGin()
+
+	assert.Equal(t, "tenant:acme", s.rateLimitKey(c, conn, config.RateLimitKeyByTenant))
+	assert.Equal(t, "prefix:/rl", s.rateLimitKey(c, conn, config.RateLimitKeyByPrefix))
+	assert.Equal(t, "session:sid", s.rateLimitKey(c, conn, config.RateLimitKeyBySession))
+
+	// without OAuth the client falls back to the session
+	assert.Equal(t, "session:sid", s.rateLimitKey(c, conn, config.RateLimitKeyByClient))
+
+	// the token the session was opened with identifies the client
+	s.auth = &mockAuthService{}
+	assert.Equal(t, "prefix:/rl:client:test_client", s.rateLimitKey(c, conn, config.RateLimitKeyByClient))
+
+	// the token of the request itself takes precedence
+	c.Request.Header.Set("Authorization", "Bearer bad_token")
+	assert.Equal(t, "session:sid", s.rateLimitKey(c, conn, config.RateLimitKeyByClient))
+}
+
+func TestCheckRateLimits_InvalidLimit(t *testing.T) {
+	s := newRateLimitTestServer(t, "http://127.0.0.1")
+	conn := &fakeConn{meta: &session.Meta{ID: "sid", Prefix: "/rl"}}
+	c, _ := newGin()
+	c.Request = httptest.NewRequest(http.MethodPost, "/rl/mcp", nil)
+
+	// stored configs are not validated, limits without requests are ignored
+	limits := []config.RateLimitConfig{{Requests: 0}, {Requests: -1, Per: config.Duration(time.Second)}}
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, s.checkRateLimits(c, conn, rateLimitLevelRouter, "", limits))
+	}
+}
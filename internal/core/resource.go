@@ -0,0 +1,321 @@
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/internal/template"
+	"github.com/amoylab/unla/pkg/mcp"
+	apptrace "github.com/amoylab/unla/pkg/trace"
+)
+
+// errResourceNotFound is returned when no resource of the prefix serves the requested URI
+var errResourceNotFound = errors.New("resource not found")
+
+// handleResourcesList answers resources/list with the resources of the session prefix
+func (s *Server) handleResourcesList(c *gin.Context, conn session.Connection, req mcp.JSONRPCRequest, isSSE bool) {
+	resources := []mcp.ResourceSchema{}
+	prefix := conn.Meta().Prefix
+	switch s.state.GetProtoType(prefix) {
+	case cnst.BackendProtoHttp:
+		if schemas := s.state.GetResourceSchemas(prefix); len(schemas) > 0 {
+			resources = schemas
+		}
+	case cnst.BackendProtoStdio, cnst.BackendProtoSSE, cnst.BackendProtoStreamable:
+		transport := s.state.GetTransport(prefix)
+		if transport == nil {
+			s.sendProtocolError(c, req.Id, "Failed to fetch resources", http.StatusInternalServerError, mcp.ErrorCodeInternalError)
+			return
+		}
+		fetched, err := transport.FetchResources(s.upstreamContext(c, conn))
+		if err != nil {
+			s.getLogger(c).Error("failed to fetch resources",
+				zap.String("prefix", prefix),
+				zap.String("session_id", conn.Meta().ID),
+				zap.Error(err))
+			s.sendProtocolError(c, req.Id, "Failed to fetch resources", http.StatusInternalServerError, mcp.ErrorCodeInternalError)
+			return
+		}
+		resources = fetched
+	}
+	s.sendSuccessResponse(c, conn, req, mcp.ListResourcesResult{Resources: resources}, isSSE)
+}
+
+// handleResourceTemplatesList answers resources/templates/list with the resource templates
+// of the session prefix
+func (s *Server) handleResourceTemplatesList(c *gin.Context, conn session.Connection, req mcp.JSONRPCRequest, isSSE bool) {
+	templates := []mcp.ResourceTemplateSchema{}
+	prefix := conn.Meta().Prefix
+	switch s.state.GetProtoType(prefix) {
+	case cnst.BackendProtoHttp:
+		if schemas := s.state.GetResourceTemplateSchemas(prefix); len(schemas) > 0 {
+			templates = schemas
+		}
+	case cnst.BackendProtoStdio, cnst.BackendProtoSSE, cnst.BackendProtoStreamable:
+		transport := s.state.GetTransport(prefix)
+		if transport == nil {
+			s.sendProtocolError(c, req.Id, "Failed to fetch resource templates", http.StatusInternalServerError, mcp.ErrorCodeInternalError)
+			return
+		}
+		fetched, err := transport.FetchResourceTemplates(s.upstreamContext(c, conn))
+		if err != nil {
+			s.getLogger(c).Error("failed to fetch resource templates",
+				zap.String("prefix", prefix),
+				zap.String("session_id", conn.Meta().ID),
+				zap.Error(err))
+			s.sendProtocolError(c, req.Id, "Failed to fetch resource templates", http.StatusInternalServerError, mcp.ErrorCodeInternalError)
+			return
+		}
+		templates = fetched
+	}
+	s.sendSuccessResponse(c, conn, req, mcp.ListResourceTemplatesResult{ResourceTemplates: templates}, isSSE)
+}
+
+// handleResourcesRead answers resources/read by calling the HTTP endpoint of the resource
+// or reading it from the proxied MCP server
+func (s *Server) handleResourcesRead(c *gin.Context, conn session.Connection, req mcp.JSONRPCRequest, isSSE bool) {
+	var params mcp.ReadResourceParams
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.URI == "" {
+		s.sendProtocolError(c, req.Id, "Invalid resource read parameters", http.StatusBadRequest, mcp.ErrorCodeInvalidParams)
+		return
+	}
+
+	var (
+		result *mcp.ReadResourceResult
+		err    error
+	)
+	prefix := conn.Meta().Prefix
+	switch s.state.GetProtoType(prefix) {
+	case cnst.BackendProtoHttp:
+		result, err = s.readHTTPResource(c, conn, params.URI)
+	case cnst.BackendProtoStdio, cnst.BackendProtoSSE, cnst.BackendProtoStreamable:
+		transport := s.state.GetTransport(prefix)
+		if transport == nil {
+			err = fmt.Errorf("transport not found")
+			break
+		}
+		result, err = transport.ReadResource(s.upstreamContext(c, conn), params.URI, mergeRequestInfo(conn.Meta().Request, c.Request))
+	default:
+		err = errResourceNotFound
+	}
+	if err != nil {
+		if errors.Is(err, errResourceNotFound) {
+			s.sendJSONRPCErrorWithData(c, conn, req, "Resource not found", mcp.ErrorCodeResourceNotFound,
+				map[string]any{"uri": params.URI}, isSSE)
+			return
+		}
+		s.getLogger(c).Error("failed to read resource",
+			zap.String("uri", params.URI),
+			zap.String("session_id", conn.Meta().ID),
+			zap.Error(err))
+		// the cause stays in the logs, it may describe the upstream
+		if isTimeoutError(err) {
+			s.sendJSONRPCErrorWithData(c, conn, req, "Resource read timed out", mcp.ErrorCodeRequestTimeout,
+				map[string]any{"uri": params.URI}, isSSE)
+			return
+		}
+		s.sendJSONRPCErrorWithData(c, conn, req, "Failed to read resource", mcp.ErrorCodeInternalError,
+			map[string]any{"uri": params.URI}, isSSE)
+		return
+	}
+	s.sendSuccessResponse(c, conn, req, result, isSSE)
+}
+
+// readHTTPResource reads a resource of an HTTP server. The variables of a URI template are
+// passed to the request and response templates as args.
+func (s *Server) readHTTPResource(c *gin.Context, conn session.Connection, uri string) (*mcp.ReadResourceResult, error) {
+	prefix := conn.Meta().Prefix
+	res, vars := s.state.GetResource(prefix, uri)
+	if res == nil {
+		return nil, errResourceNotFound
+	}
+	serverCfg := s.state.GetServerConfig(prefix)
+	if serverCfg == nil {
+		return nil, fmt.Errorf("server configuration not found")
+	}
+
+	scope := apptrace.Tracer(cnst.TraceCore).
+		Start(c.Request.Context(), cnst.SpanHTTPResourceRead, oteltrace.WithSpanKind(oteltrace.SpanKindInternal)).
+		WithAttrs(
+			attribute.String(cnst.AttrMCPSessionID, conn.Meta().ID),
+			attribute.String(cnst.AttrMCPPrefix, prefix),
+			attribute.String(cnst.AttrMCPResourceURI, uri),
+		)
+	ctx := scope.Ctx
+	defer scope.End()
+
+	logger := s.getLogger(c)
+	logger.Info("reading HTTP resource",
+		zap.String("resource", res.Name),
+		zap.String("uri", uri),
+		zap.String("session_id", conn.Meta().ID))
+
+	tool := res.ToToolConfig()
+	args := make(map[string]any, len(vars))
+	for k, v := range vars {
+		// the variables are decoded, dot segments would leave their place in the endpoint
+		if v == "." || v == ".." {
+			return nil, errResourceNotFound
+		}
+		args[k] = v
+		tool.Args = append(tool.Args, config.ArgConfig{Name: k, Position: "path"})
+	}
+	tmplCtx, err := template.PrepareTemplateContext(conn.Meta().Request, args, c.Request, serverCfg.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	req, _, err := s.prepareRequest(tool, tmplCtx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateToolEndpoint(ctx, req.URL); err != nil {
+		logger.Warn("blocked resource endpoint",
+			zap.String("resource", res.Name),
+			zap.String("endpoint", req.URL.String()),
+			zap.Error(err))
+		return nil, err
+	}
+
+	timeouts := s.toolTimeouts(serverCfg, tool)
+	var cli *http.Client
+	if s.state != nil {
+		cli = s.state.GetResourceHTTPClient(prefix, res.Name)
+	}
+	if cli == nil {
+		if cli, err = createHTTPClient(tool, timeouts); err != nil {
+			return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+		}
+	}
+
+	breaker := s.circuitBreakerFor(serverCfg, req.URL.Host)
+	if breaker != nil {
+		if err := breaker.allow(time.Now()); err != nil {
+			scope.Span.AddEvent(cnst.EventCircuitBreakerRejected,
+				oteltrace.WithAttributes(attribute.String(cnst.AttrCircuitBreakerKey, breaker.key)))
+			if s.metrics != nil {
+				s.metrics.CircuitBreakerRejected(breaker.key)
+			}
+			return nil, err
+		}
+	}
+
+	reqCtx := ctx
+	if timeouts.Total > 0 {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeout(ctx, timeouts.Total.Std())
+		defer cancel()
+	}
+	req = req.WithContext(reqCtx)
+	resp, err := s.doHTTPRequest(reqCtx, cli, req, tool, scope.Span, logger)
+	if breaker != nil {
+		if err != nil && ctx.Err() != nil {
+			breaker.release()
+		} else {
+			breaker.done(err == nil && resp.StatusCode < http.StatusInternalServerError, time.Now())
+		}
+	}
+	if err != nil {
+		logger.Error("failed to read HTTP resource",
+			zap.String("resource", res.Name),
+			zap.String("url", req.URL.String()),
+			zap.Error(err))
+		if isTimeoutError(err) {
+			return nil, &TimeoutError{Tool: res.Name, Err: err}
+		}
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		if isTimeoutError(err) {
+			return nil, &TimeoutError{Tool: res.Name, Err: err}
+		}
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	scope.Span.SetAttributes(attribute.Int(cnst.AttrHTTPStatusCode, resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		scope.Span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", resp.StatusCode))
+		return nil, fmt.Errorf("upstream returned HTTP %d", resp.StatusCode)
+	}
+
+	contents, err := resourceContents(uri, res, resp.Header.Get("Content-Type"), body, tmplCtx)
+	if err != nil {
+		return nil, err
+	}
+	return &mcp.ReadResourceResult{Contents: []mcp.ResourceContents{contents}}, nil
+}
+
+// resourceContents converts an HTTP response to resource contents. A response template
+// always yields text, otherwise textual responses are returned as text and anything else
+// as a base64 blob.
+func resourceContents(uri string, res *config.ResourceConfig, contentType string, body []byte,
+	tmplCtx *template.Context) (mcp.ResourceContents, error) {
+	mimeType := res.MimeType
+	if mimeType == "" {
+		mimeType = contentType
+	}
+	contents := mcp.ResourceContents{URI: uri, MimeType: mimeType}
+
+	if res.ResponseBody != "" {
+		var data map[string]any
+		_ = json.Unmarshal(body, &data)
+		tmplCtx.Response.Data = preprocessResponseData(data)
+		tmplCtx.Response.Body = string(body)
+		rendered, err := template.RenderTemplate(res.ResponseBody, tmplCtx)
+		if err != nil {
+			return contents, fmt.Errorf("failed to render response body template: %w", err)
+		}
+		contents.Text = rendered
+		return contents, nil
+	}
+
+	if isTextMimeType(mimeType) {
+		contents.Text = string(body)
+	} else {
+		contents.Blob = base64.StdEncoding.EncodeToString(body)
+	}
+	return contents, nil
+}
+
+// isTextMimeType reports whether content of the MIME type can be returned as text.
+// An unknown type is treated as text.
+func isTextMimeType(mimeType string) bool {
+	if mimeType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		mediaType = strings.ToLower(mimeType)
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/yaml", "application/x-yaml",
+		"application/javascript", "application/x-www-form-urlencoded":
+		return true
+	}
+	return false
+}
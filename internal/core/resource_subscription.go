@@ -0,0 +1,189 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/pkg/mcp"
+)
+
+type resourceSubscriptionKey struct {
+	prefix string
+	uri    string
+}
+
+// resourceSubscriptions tracks the sessions subscribed to resources of proxied MCP
+// servers. The upstream server is subscribed once per prefix and URI, its updates are
+// sent to every subscribed session. Each instance tracks the subscriptions it made,
+// unsubscriptions are published to the session store to reach the instance holding them.
+type resourceSubscriptions struct {
+	mu       sync.Mutex
+	sessions map[resourceSubscriptionKey]map[string]struct{}
+}
+
+func newResourceSubscriptions() *resourceSubscriptions {
+	return &resourceSubscriptions{sessions: make(map[resourceSubscriptionKey]map[string]struct{})}
+}
+
+// add subscribes the session and reports whether it is the first subscriber of the resource
+func (r *resourceSubscriptions) add(prefix, uri, sessionID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := resourceSubscriptionKey{prefix: prefix, uri: uri}
+	sessions, ok := r.sessions[key]
+	if !ok {
+		sessions = make(map[string]struct{})
+		r.sessions[key] = sessions
+	}
+	sessions[sessionID] = struct{}{}
+	return !ok
+}
+
+// remove unsubscribes the session and reports whether the resource has no subscribers left
+func (r *resourceSubscriptions) remove(prefix, uri, sessionID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := resourceSubscriptionKey{prefix: prefix, uri: uri}
+	sessions, ok := r.sessions[key]
+	if !ok {
+		return false
+	}
+	delete(sessions, sessionID)
+	if len(sessions) > 0 {
+		return false
+	}
+	delete(r.sessions, key)
+	return true
+}
+
+// subscribers returns the sessions subscribed to the resource
+func (r *resourceSubscriptions) subscribers(prefix, uri string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sessions := r.sessions[resourceSubscriptionKey{prefix: prefix, uri: uri}]
+	ids := make([]string, 0, len(sessions))
+	for id := range sessions {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// supportsResourceSubscriptions reports whether resources of the prefix can be subscribed to,
+// which is the case for proxied MCP servers only
+func (s *Server) supportsResourceSubscriptions(prefix string) bool {
+	switch s.state.GetProtoType(prefix) {
+	case cnst.BackendProtoStdio, cnst.BackendProtoSSE, cnst.BackendProtoStreamable:
+		return true
+	}
+	return false
+}
+
+// handleResourcesSubscribe answers resources/subscribe, subscribing the upstream server
+// for the first session interested in the resource
+func (s *Server) handleResourcesSubscribe(c *gin.Context, conn session.Connection, req mcp.JSONRPCRequest, isSSE bool) {
+	var params mcp.SubscribeResourceParams
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.URI == "" {
+		s.sendProtocolError(c, req.Id, "Invalid resource subscribe parameters", http.StatusBadRequest, mcp.ErrorCodeInvalidParams)
+		return
+	}
+	prefix := conn.Meta().Prefix
+	transport := s.state.GetTransport(prefix)
+	if !s.supportsResourceSubscriptions(prefix) || transport == nil {
+		s.sendJSONRPCError(c, conn, req, "Resource subscriptions are not supported", mcp.ErrorCodeMethodNotFound, isSSE)
+		return
+	}
+
+	if s.resourceSubs.add(prefix, params.URI, conn.Meta().ID) {
+		err := transport.SubscribeResource(s.upstreamContext(c, conn), params.URI, func(uri string) {
+			s.notifyResourceUpdated(prefix, uri)
+		})
+		if err != nil {
+			s.resourceSubs.remove(prefix, params.URI, conn.Meta().ID)
+			s.getLogger(c).Error("failed to subscribe to upstream resource",
+				zap.String("prefix", prefix),
+				zap.String("uri", params.URI),
+				zap.Error(err))
+			s.sendJSONRPCError(c, conn, req, "Failed to subscribe to resource", mcp.ErrorCodeInternalError, isSSE)
+			return
+		}
+	}
+	s.sendSuccessResponse(c, conn, req, struct{}{}, isSSE)
+}
+
+// handleResourcesUnsubscribe answers resources/unsubscribe, unsubscribing the upstream
+// server once no session is interested in the resource anymore. The subscription may
+// have been made on another instance, the unsubscription is published to all of them.
+func (s *Server) handleResourcesUnsubscribe(c *gin.Context, conn session.Connection, req mcp.JSONRPCRequest, isSSE bool) {
+	var params mcp.SubscribeResourceParams
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.URI == "" {
+		s.sendProtocolError(c, req.Id, "Invalid resource unsubscribe parameters", http.StatusBadRequest, mcp.ErrorCodeInvalidParams)
+		return
+	}
+	unsubscription := &session.ResourceUnsubscription{
+		SessionID: conn.Meta().ID,
+		Prefix:    conn.Meta().Prefix,
+		URI:       params.URI,
+	}
+	if s.resourceSubs.remove(unsubscription.Prefix, unsubscription.URI, unsubscription.SessionID) {
+		s.unsubscribeUpstream(s.upstreamContext(c, conn), unsubscription.Prefix, unsubscription.URI)
+	}
+	if s.sessions != nil {
+		if err := s.sessions.PublishResourceUnsubscribe(c.Request.Context(), unsubscription); err != nil {
+			s.getLogger(c).Warn("failed to publish resource unsubscription",
+				zap.String("session_id", unsubscription.SessionID),
+				zap.String("uri", unsubscription.URI),
+				zap.Error(err))
+		}
+	}
+	s.sendSuccessResponse(c, conn, req, struct{}{}, isSSE)
+}
+
+// onResourceUnsubscribe drops the subscription of a session made on this instance, if any
+func (s *Server) onResourceUnsubscribe(unsubscription *session.ResourceUnsubscription) {
+	if s.resourceSubs.remove(unsubscription.Prefix, unsubscription.URI, unsubscription.SessionID) {
+		s.unsubscribeUpstream(context.Background(), unsubscription.Prefix, unsubscription.URI)
+	}
+}
+
+func (s *Server) unsubscribeUpstream(ctx context.Context, prefix, uri string) {
+	transport := s.state.GetTransport(prefix)
+	if transport == nil {
+		return
+	}
+	if err := transport.UnsubscribeResource(ctx, uri); err != nil {
+		s.logger.Warn("failed to unsubscribe from upstream resource",
+			zap.String("prefix", prefix),
+			zap.String("uri", uri),
+			zap.Error(err))
+	}
+}
+
+// notifyResourceUpdated relays notifications/resources/updated of an upstream server to the
+// subscribed sessions. Sessions that are gone are unsubscribed.
+func (s *Server) notifyResourceUpdated(prefix, uri string) {
+	ctx := context.Background()
+	for _, sessionID := range s.resourceSubs.subscribers(prefix, uri) {
+		conn, err := s.sessions.Get(ctx, sessionID)
+		if err == nil {
+			err = s.sendNotification(ctx, conn, mcp.NotificationResourceUpdated,
+				mcp.ResourceUpdatedNotificationParams{URI: uri})
+		}
+		if err != nil {
+			s.logger.Debug("dropping resource subscription of unavailable session",
+				zap.String("prefix", prefix),
+				zap.String("uri", uri),
+				zap.String("session_id", sessionID),
+				zap.Error(err))
+			if s.resourceSubs.remove(prefix, uri, sessionID) {
+				s.unsubscribeUpstream(ctx, prefix, uri)
+			}
+		}
+	}
+}
@@ -0,0 +1,376 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/amoylab/unla/internal/auth/storage"
+	"github.com/amoylab/unla/internal/auth/upstream"
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/core/state"
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/pkg/mcp"
+	mcpgo "github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newResourceTestServer(t *testing.T, upstream string) *Server {
+	t.Helper()
+	cfg := &config.MCPConfig{
+		Name:    "res",
+		Routers: []config.RouterConfig{{Prefix: "/res", Server: "srv"}},
+		Servers: []config.ServerConfig{{
+			Name:             "srv",
+			AllowedResources: []string{"readme", "city", "logo"},
+			Config:           map[string]string{"unit": "metric"},
+		}},
+		Resources: []config.ResourceConfig{{
+			Name:     "readme",
+			URI:      "docs://readme",
+			MimeType: "text/markdown",
+			Method:   http.MethodGet,
+			Endpoint: upstream + "/readme",
+		}, {
+			Name:         "city",
+			URITemplate:  "weather://cities/{city}",
+			MimeType:     "text/plain",
+			Method:       http.MethodGet,
+			Endpoint:     upstream + "/cities/{{.Args.city}}?unit={{.Config.unit}}",
+			ResponseBody: "{{.Response.Data.city}}: {{.Response.Data.temp}}",
+		}, {
+			Name:     "logo",
+			URI:      "assets://logo",
+			Method:   http.MethodGet,
+			Endpoint: upstream + "/logo",
+		}},
+	}
+	st, err := state.BuildStateFromConfig(context.Background(), []*config.MCPConfig{cfg}, nil, zap.NewNop())
+	require.NoError(t, err)
+
+	allowlist, _ := parseInternalNetworkAllowlist([]string{"127.0.0.0/8", "::1/128"})
+	return &Server{
+		logger:          zap.NewNop(),
+		state:           st,
+		toolRespHandler: CreateResponseHandlerChain(),
+		internalNetACL:  allowlist,
+	}
+}
+
+func readResource(t *testing.T, s *Server, method string, params any) map[string]any {
+	t.Helper()
+	conn := &fakeConn{meta: &session.Meta{ID: "sid", Prefix: "/res"}}
+	c, w := newGin()
+	req := mcp.JSONRPCRequest{JSONRPC: mcp.JSPNRPCVersion, Method: method, Id: 1}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		require.NoError(t, err)
+		req.Params = raw
+	}
+	switch method {
+	case mcp.ResourcesList:
+		s.handleResourcesList(c, conn, req, false)
+	case mcp.ResourcesTemplatesList:
+		s.handleResourceTemplatesList(c, conn, req, false)
+	default:
+		s.handleResourcesRead(c, conn, req, false)
+	}
+
+	data := w.Body.String()
+	if event := sseData(data); event != "" {
+		data = event
+	}
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal([]byte(data), &resp))
+	return resp
+}
+
+func TestResources_List(t *testing.T) {
+	s := newResourceTestServer(t, "http://127.0.0.1")
+
+	resp := readResource(t, s, mcp.ResourcesList, nil)
+	resources := resp["result"].(map[string]any)["resources"].([]any)
+	require.Len(t, resources, 2)
+	assert.Equal(t, "docs://readme", resources[0].(map[string]any)["uri"])
+	assert.Equal(t, "assets://logo", resources[1].(map[string]any)["uri"])
+
+	resp = readResource(t, s, mcp.ResourcesTemplatesList, nil)
+	templates := resp["result"].(map[string]any)["resourceTemplates"].([]any)
+	require.Len(t, templates, 1)
+	assert.Equal(t, "weather://cities/{city}", templates[0].(map[string]any)["uriTemplate"])
+}
+
+func TestResources_Read(t *testing.T) {
+	var paths []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/cities/") && r.URL.Path != "/cities/amoy" {
+			// the path as sent, the query is not touched
+			paths = append(paths, r.URL.EscapedPath())
+			assert.Equal(t, "metric", r.URL.Query().Get("unit"))
+		}
+		switch r.URL.Path {
+		case "/readme":
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("# Unla"))
+		case "/cities/amoy":
+			assert.Equal(t, "metric", r.URL.Query().Get("unit"))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"city":"Amoy","temp":26}`))
+		case "/logo":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte{0x89, 'P', 'N', 'G'})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+	s := newResourceTestServer(t, upstream.URL)
+
+	contents := func(resp map[string]any) map[string]any {
+		require.Nil(t, resp["error"])
+		items := resp["result"].(map[string]any)["contents"].([]any)
+		require.Len(t, items, 1)
+		return items[0].(map[string]any)
+	}
+
+	readme := contents(readResource(t, s, mcp.ResourcesRead, mcp.ReadResourceParams{URI: "docs://readme"}))
+	assert.Equal(t, "docs://readme", readme["uri"])
+	assert.Equal(t, "text/markdown", readme["mimeType"])
+	assert.Equal(t, "# Unla", readme["text"])
+
+	city := contents(readResource(t, s, mcp.ResourcesRead, mcp.ReadResourceParams{URI: "weather://cities/amoy"}))
+	assert.Equal(t, "weather://cities/amoy", city["uri"])
+	assert.Equal(t, "Amoy: 26", city["text"])
+
+	logo := contents(readResource(t, s, mcp.ResourcesRead, mcp.ReadResourceParams{URI: "assets://logo"}))
+	assert.Equal(t, "image/png", logo["mimeType"])
+	assert.Equal(t, "iVBORw==", logo["blob"])
+	assert.NotContains(t, logo, "text")
+
+	resp := readResource(t, s, mcp.ResourcesRead, mcp.ReadResourceParams{URI: "docs://missing"})
+	assert.Equal(t, float64(mcp.ErrorCodeResourceNotFound), resp["error"].(map[string]any)["code"])
+
+	// upstream errors are not reported as missing resources, nor passed to the client
+	resp = readResource(t, s, mcp.ResourcesRead, mcp.ReadResourceParams{URI: "weather://cities/nowhere"})
+	assert.Equal(t, float64(mcp.ErrorCodeInternalError), resp["error"].(map[string]any)["code"])
+	assert.Equal(t, "Failed to read resource", resp["error"].(map[string]any)["message"])
+
+	// variables stay within their path segment
+	resp = readResource(t, s, mcp.ResourcesRead, mcp.ReadResourceParams{URI: "weather://cities/..%2F..%2Freadme%3Fx"})
+	assert.Equal(t, float64(mcp.ErrorCodeInternalError), resp["error"].(map[string]any)["code"])
+	resp = readResource(t, s, mcp.ResourcesRead, mcp.ReadResourceParams{URI: "weather://cities/.."})
+	assert.Equal(t, float64(mcp.ErrorCodeResourceNotFound), resp["error"].(map[string]any)["code"])
+	assert.Equal(t, []string{"/cities/nowhere", "/cities/..%2F..%2Freadme%3Fx"}, paths)
+}
+
+func TestResources_ReadTimeout(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer upstream.Close()
+	s := newResourceTestServer(t, upstream.URL)
+	s.timeout = config.TimeoutConfig{Total: config.Duration(50 * time.Millisecond)}
+
+	resp := readResource(t, s, mcp.ResourcesRead, mcp.ReadResourceParams{URI: "docs://readme"})
+	assert.Equal(t, float64(mcp.ErrorCodeRequestTimeout), resp["error"].(map[string]any)["code"])
+}
+
+func TestIsTextMimeType(t *testing.T) {
+	for mimeType, expected := range map[string]bool{
+		"":                          true,
+		"text/plain; charset=utf-8": true,
+		"application/json":          true,
+		"application/ld+json":       true,
+		"image/png":                 false,
+		"application/octet-stream":  false,
+	} {
+		assert.Equal(t, expected, isTextMimeType(mimeType), mimeType)
+	}
+}
+
+func TestResources_Proxied(t *testing.T) {
+	upstream := server.NewMCPServer("upstream", "1.0.0")
+	upstream.AddResource(mcpgo.NewResource("docs://readme", "readme", mcpgo.WithMIMEType("text/plain")),
+		func(_ context.Context, req mcpgo.ReadResourceRequest) ([]mcpgo.ResourceContents, error) {
+			return []mcpgo.ResourceContents{mcpgo.TextResourceContents{URI: req.Params.URI, MIMEType: "text/plain", Text: "# Upstream"}}, nil
+		})
+	upstream.AddResourceTemplate(mcpgo.NewResourceTemplate("docs://{name}", "doc"),
+		func(context.Context, mcpgo.ReadResourceRequest) ([]mcpgo.ResourceContents, error) {
+			return nil, nil
+		})
+	ts := server.NewTestStreamableHTTPServer(upstream)
+	defer ts.Close()
+
+	cfg := &config.MCPConfig{
+		Name:    "proxy",
+		Routers: []config.RouterConfig{{Prefix: "/res", Server: "up"}},
+		McpServers: []config.MCPServerConfig{{
+			Name:   "up",
+			Type:   cnst.BackendProtoStreamable.String(),
+			URL:    ts.URL + "/mcp",
+			Policy: cnst.PolicyOnDemand,
+		}},
+	}
+	st, err := state.BuildStateFromConfig(context.Background(), []*config.MCPConfig{cfg}, nil, zap.NewNop())
+	require.NoError(t, err)
+	s := &Server{logger: zap.NewNop(), state: st, resourceSubs: newResourceSubscriptions()}
+
+	resp := readResource(t, s, mcp.ResourcesList, nil)
+	resources := resp["result"].(map[string]any)["resources"].([]any)
+	require.Len(t, resources, 1)
+	assert.Equal(t, "docs://readme", resources[0].(map[string]any)["uri"])
+
+	resp = readResource(t, s, mcp.ResourcesTemplatesList, nil)
+	templates := resp["result"].(map[string]any)["resourceTemplates"].([]any)
+	require.Len(t, templates, 1)
+	assert.Equal(t, "docs://{name}", templates[0].(map[string]any)["uriTemplate"])
+
+	resp = readResource(t, s, mcp.ResourcesRead, mcp.ReadResourceParams{URI: "docs://readme"})
+	contents := resp["result"].(map[string]any)["contents"].([]any)
+	require.Len(t, contents, 1)
+	assert.Equal(t, "# Upstream", contents[0].(map[string]any)["text"])
+}
+
+func TestResources_SubscribeUnsupportedForHTTP(t *testing.T) {
+	s := newResourceTestServer(t, "http://127.0.0.1")
+	s.resourceSubs = newResourceSubscriptions()
+	conn := &fakeConn{meta: &session.Meta{ID: "sid", Prefix: "/res"}}
+
+	c, w := newGin()
+	raw, _ := json.Marshal(mcp.SubscribeResourceParams{URI: "docs://readme"})
+	s.handleResourcesSubscribe(c, conn, mcp.JSONRPCRequest{JSONRPC: mcp.JSPNRPCVersion, Id: 1,
+		Method: mcp.ResourcesSubscribe, Params: raw}, false)
+
+	var resp mcp.JSONRPCErrorSchema
+	require.NoError(t, json.Unmarshal([]byte(sseData(w.Body.String())), &resp))
+	assert.Equal(t, mcp.ErrorCodeMethodNotFound, resp.Error.Code)
+	assert.False(t, s.supportsResourceSubscriptions("/res"))
+}
+
+func TestNotifyResourceUpdated(t *testing.T) {
+	ctx := context.Background()
+	store := session.NewMemoryStore(zap.NewNop())
+	conn, err := store.Register(ctx, &session.Meta{ID: "s1", Prefix: "/res"})
+	require.NoError(t, err)
+
+	s := &Server{logger: zap.NewNop(), state: state.NewState(), sessions: store, resourceSubs: newResourceSubscriptions()}
+	assert.True(t, s.resourceSubs.add("/res", "docs://readme", "s1"))
+	assert.False(t, s.resourceSubs.add("/res", "docs://readme", "gone"))
+
+	s.notifyResourceUpdated("/res", "docs://readme")
+	select {
+	case msg := <-conn.EventQueue():
+		assert.JSONEq(t, `{"jsonrpc":"2.0","method":"notifications/resources/updated","params":{"uri":"docs://readme"}}`,
+			string(msg.Data))
+	default:
+		t.Fatal("expected a resource updated notification")
+	}
+
+	// the session that no longer exists was unsubscribed
+	assert.Equal(t, []string{"s1"}, s.resourceSubs.subscribers("/res", "docs://readme"))
+	assert.True(t, s.resourceSubs.remove("/res", "docs://readme", "s1"))
+}
+
+func TestHandleResourcesUnsubscribe_PublishesToOtherInstances(t *testing.T) {
+	store := session.NewMemoryStore(zap.NewNop())
+	s := &Server{logger: zap.NewNop(), state: state.NewState(), sessions: store, resourceSubs: newResourceSubscriptions()}
+
+	// the subscription was made on another instance sharing the store
+	remote := &Server{logger: zap.NewNop(), state: state.NewState(), resourceSubs: newResourceSubscriptions()}
+	store.OnResourceUnsubscribe(remote.onResourceUnsubscribe)
+	remote.resourceSubs.add("/res", "docs://readme", "sid")
+
+	conn := &fakeConn{meta: &session.Meta{ID: "sid", Prefix: "/res"}}
+	c, w := newGin()
+	raw, _ := json.Marshal(mcp.SubscribeResourceParams{URI: "docs://readme"})
+	s.handleResourcesUnsubscribe(c, conn, mcp.JSONRPCRequest{JSONRPC: mcp.JSPNRPCVersion, Id: 1,
+		Method: mcp.ResourcesUnsubscribe, Params: raw}, false)
+
+	assert.Contains(t, w.Body.String(), `"result":{}`)
+	assert.Empty(t, remote.resourceSubs.subscribers("/res", "docs://readme"))
+}
+
+func TestResources_SubscribeOAuthUpstream(t *testing.T) {
+	// a streamable HTTP server only accepting the token the user authorized
+	var mu sync.Mutex
+	var methods []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		mu.Lock()
+		methods = append(methods, req.Method)
+		mu.Unlock()
+		result := `{}`
+		if req.Method == "initialize" {
+			result = `{"protocolVersion":"2025-03-26","capabilities":{"resources":{"subscribe":true}},"serverInfo":{"name":"test","version":"1.0.0"}}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, result)
+	}))
+	defer srv.Close()
+
+	cfg := &config.MCPConfig{
+		Name:    "res",
+		Routers: []config.RouterConfig{{Prefix: "/res", Server: "remote"}},
+		McpServers: []config.MCPServerConfig{{
+			Type:  cnst.BackendProtoStreamable.String(),
+			Name:  "remote",
+			URL:   srv.URL,
+			OAuth: &config.MCPServerOAuthConfig{},
+		}},
+	}
+	st, err := state.BuildStateFromConfig(context.Background(), []*config.MCPConfig{cfg}, nil, zap.NewNop())
+	require.NoError(t, err)
+	defer func() { _ = st.GetTransport("/res").Stop(context.Background()) }()
+
+	store := storage.NewMemoryStorage()
+	require.NoError(t, store.SaveUpstreamToken(context.Background(), &storage.UpstreamToken{
+		Subject: "session:sid", Resource: srv.URL, AccessToken: "good", CreatedAt: time.Now().Unix(),
+	}))
+	s := &Server{
+		logger:        zap.NewNop(),
+		state:         st,
+		resourceSubs:  newResourceSubscriptions(),
+		upstreamOAuth: upstream.NewClient(zap.NewNop(), store, "http://gateway/oauth/upstream/callback"),
+	}
+	s.authorizeUpstreamServers(st)
+
+	conn := &fakeConn{meta: &session.Meta{ID: "sid", Prefix: "/res"}}
+	raw, _ := json.Marshal(mcp.SubscribeResourceParams{URI: "docs://readme"})
+	c, w := newGin()
+	s.handleResourcesSubscribe(c, conn, mcp.JSONRPCRequest{JSONRPC: mcp.JSPNRPCVersion, Id: 1,
+		Method: mcp.ResourcesSubscribe, Params: raw}, false)
+	assert.Contains(t, w.Body.String(), `"result":{}`)
+
+	c, w = newGin()
+	s.handleResourcesUnsubscribe(c, conn, mcp.JSONRPCRequest{JSONRPC: mcp.JSPNRPCVersion, Id: 2,
+		Method: mcp.ResourcesUnsubscribe, Params: raw}, false)
+	assert.Contains(t, w.Body.String(), `"result":{}`)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"initialize", mcp.ResourcesSubscribe, mcp.ResourcesUnsubscribe}, methods)
+}
@@ -1,11 +1,14 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/core/mcpproxy"
 	"github.com/amoylab/unla/internal/mcp/session"
 	"github.com/amoylab/unla/pkg/mcp"
 	"github.com/gin-gonic/gin"
@@ -17,6 +20,10 @@ import (
 
 // sendProtocolError sends a protocol-level error response
 func (s *Server) sendProtocolError(c *gin.Context, id any, message string, statusCode int, bizCode int) {
+	if isRequestCancelled(c) {
+		s.sendAcceptedResponse(c)
+		return
+	}
 	logger := s.getLogger(c)
 	logger.Warn("sending protocol error",
 		zap.Any("id", id),
@@ -45,10 +52,38 @@ func (s *Server) sendProtocolError(c *gin.Context, id any, message string, statu
 			Message: message,
 		},
 	}
+	if batch := getBatch(c); batch != nil {
+		data, _ := json.Marshal(response)
+		batch.add(data)
+		return
+	}
 	c.JSON(statusCode, response)
 }
 
-// sendToolExecutionError sends a tool execution error response
+// UpstreamError is returned when a call proxied to an MCP server fails, its text may quote
+// the server and is not sent to clients
+type UpstreamError struct {
+	Err error
+}
+
+func (e *UpstreamError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+// quotesUpstream reports whether the text of err may quote an upstream server or the
+// standard error of its process, which may hold secrets
+func quotesUpstream(err error) bool {
+	var upstreamErr *UpstreamError
+	var stderrErr *mcpproxy.StderrError
+	return errors.As(err, &upstreamErr) || errors.As(err, &stderrErr)
+}
+
+// sendToolExecutionError sends a tool execution error response. Errors quoting the upstream
+// are only logged, the client then only learns that the tool failed.
 func (s *Server) sendToolExecutionError(c *gin.Context, conn session.Connection, req mcp.JSONRPCRequest, err error, isSSE bool) {
 	logger := s.getLogger(c)
 	logger.Error("tool execution error",
@@ -71,12 +106,55 @@ func (s *Server) sendToolExecutionError(c *gin.Context, conn session.Connection,
 		)
 	}
 
+	message := fmt.Sprintf("Error: %s", err.Error())
+	if quotesUpstream(err) {
+		message = "Error: tool execution failed"
+	}
 	response := mcp.JSONRPCResponse{
 		JSONRPCBaseResult: mcp.JSONRPCBaseResult{
 			JSONRPC: mcp.JSPNRPCVersion,
 			ID:      req.Id,
 		},
-		Result: mcp.NewCallToolResultError(fmt.Sprintf("Error: %s", err.Error())),
+		Result: mcp.NewCallToolResultError(message),
+	}
+	s.sendResponse(c, req.Id, conn, response, isSSE)
+}
+
+// sendJSONRPCError sends a JSON-RPC error for a request through SSE or direct HTTP
+func (s *Server) sendJSONRPCError(c *gin.Context, conn session.Connection, req mcp.JSONRPCRequest, message string, bizCode int, isSSE bool) {
+	s.sendJSONRPCErrorWithData(c, conn, req, message, bizCode, nil, isSSE)
+}
+
+// sendJSONRPCErrorWithData sends a JSON-RPC error carrying additional data about the failure
+func (s *Server) sendJSONRPCErrorWithData(c *gin.Context, conn session.Connection, req mcp.JSONRPCRequest, message string, bizCode int, data any, isSSE bool) {
+	logger := s.getLogger(c)
+	logger.Warn("sending JSON-RPC error",
+		zap.Any("request_id", req.Id),
+		zap.String("method", req.Method),
+		zap.String("session_id", conn.Meta().ID),
+		zap.String("message", message),
+		zap.Int("error_code", bizCode),
+		zap.Bool("is_sse", isSSE),
+	)
+
+	if span := oteltrace.SpanFromContext(c.Request.Context()); span != nil {
+		span.SetStatus(codes.Error, message)
+		span.SetAttributes(
+			attribute.String(cnst.AttrErrorReason, message),
+			attribute.Int(cnst.AttrMCPErrorCode, bizCode),
+		)
+	}
+
+	response := mcp.JSONRPCErrorSchema{
+		JSONRPCBaseResult: mcp.JSONRPCBaseResult{
+			JSONRPC: mcp.JSPNRPCVersion,
+			ID:      req.Id,
+		},
+		Error: mcp.JSONRPCError{
+			Code:    bizCode,
+			Message: message,
+			Data:    data,
+		},
 	}
 	s.sendResponse(c, req.Id, conn, response, isSSE)
 }
@@ -107,6 +185,16 @@ func (s *Server) sendSuccessResponse(c *gin.Context, conn session.Connection, re
 // sendResponse handles sending the response through SSE or direct HTTP
 func (s *Server) sendResponse(c *gin.Context, id any, conn session.Connection, response interface{}, isSSE bool) {
 	logger := s.getLogger(c)
+	if isRequestCancelled(c) {
+		// The client does not expect a response for a request it cancelled
+		logger.Info("request cancelled, dropping response",
+			zap.Any("id", id),
+			zap.String("session_id", conn.Meta().ID),
+		)
+		s.sendAcceptedResponse(c)
+		return
+	}
+
 	eventData, err := json.Marshal(response)
 	if err != nil {
 		logger.Error("failed to marshal response",
@@ -118,6 +206,11 @@ func (s *Server) sendResponse(c *gin.Context, id any, conn session.Connection, r
 		return
 	}
 
+	if batch := getBatch(c); batch != nil {
+		batch.add(eventData)
+		return
+	}
+
 	if isSSE {
 		if logger.Core().Enabled(zap.DebugLevel) {
 			logger.Debug("sending SSE response",
@@ -154,12 +247,22 @@ func (s *Server) sendResponse(c *gin.Context, id any, conn session.Connection, r
 		c.Header("Cache-Control", "no-cache")
 		c.Header("Connection", "keep-alive")
 		c.Header(mcp.HeaderMcpSessionID, conn.Meta().ID)
-		c.String(http.StatusOK, fmt.Sprintf("event: message\ndata: %s\n\n", eventData))
+		c.Status(http.StatusOK)
+		if err := s.writeEvent(c, conn, requestStream(c), eventData); err != nil {
+			logger.Error("failed to write HTTP response",
+				zap.Any("id", id),
+				zap.String("session_id", conn.Meta().ID),
+				zap.Error(err),
+			)
+		}
 	}
 }
 
 // sendAcceptedResponse sends an accepted response
 func (s *Server) sendAcceptedResponse(c *gin.Context) {
+	if getBatch(c) != nil {
+		return
+	}
 	logger := s.getLogger(c)
 	if logger.Core().Enabled(zap.DebugLevel) {
 		logger.Debug("sending accepted response",
@@ -169,3 +272,46 @@ func (s *Server) sendAcceptedResponse(c *gin.Context) {
 
 	c.String(http.StatusAccepted, mcp.Accepted)
 }
+
+// notificationMessage is a JSON-RPC notification sent to the client, it carries no id
+type notificationMessage struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+// sendNotification sends a JSON-RPC notification through the event stream of the session
+func (s *Server) sendNotification(ctx context.Context, conn session.Connection, method string, params any) error {
+	data, err := json.Marshal(notificationMessage{
+		JSONRPC: mcp.JSPNRPCVersion,
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+	return conn.Send(ctx, &session.Message{Event: "message", Data: data})
+}
+
+// sendRequestNotification sends a notification related to the request being handled. Over
+// SSE it goes through the event stream of the session, over streamable HTTP it is written
+// to the response stream of the request ahead of the response.
+func (s *Server) sendRequestNotification(c *gin.Context, conn session.Connection, method string, params any, isSSE bool) error {
+	if isSSE || getBatch(c) != nil {
+		return s.sendNotification(c.Request.Context(), conn, method, params)
+	}
+	data, err := json.Marshal(notificationMessage{
+		JSONRPC: mcp.JSPNRPCVersion,
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header(mcp.HeaderMcpSessionID, conn.Meta().ID)
+	c.Status(http.StatusOK)
+	return s.writeEvent(c, conn, requestStream(c), data)
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/amoylab/unla/internal/core/mcpproxy"
 	"github.com/amoylab/unla/internal/mcp/session"
 	"github.com/amoylab/unla/pkg/mcp"
 	"github.com/gin-gonic/gin"
@@ -101,12 +104,30 @@ func TestSendResponseSSESendError(t *testing.T) {
 
 func TestSendToolExecutionError_HTTP(t *testing.T) {
 	s := &Server{logger: zap.NewNop()}
-	c, w := newGin()
-	conn := &fakeConn{meta: &session.Meta{ID: "sid"}}
 	req := mcp.JSONRPCRequest{Id: 6, Method: "tools/call", JSONRPC: mcp.JSPNRPCVersion}
-	s.sendToolExecutionError(c, conn, req, errors.New("x"), false)
-	assert.Equal(t, http.StatusOK, w.Code)
-	assert.Contains(t, w.Body.String(), "event: message")
+	send := func(err error) string {
+		c, w := newGin()
+		conn := &fakeConn{meta: &session.Meta{ID: "sid"}}
+		s.sendToolExecutionError(c, conn, req, err, false)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "event: message")
+		return w.Body.String()
+	}
+
+	// errors of the gateway are sent as they are
+	body := send(errors.New("failed to render endpoint template"))
+	assert.Contains(t, body, "Error: failed to render endpoint template")
+	body = send(&CircuitOpenError{Key: "api.example.com", RetryIn: 10 * time.Second})
+	assert.Contains(t, body, "upstream api.example.com is unavailable (circuit open), retry in 10s")
+
+	// errors quoting the upstream or its standard error are not
+	body = send(&UpstreamError{Err: errors.New("upstream: invalid password for admin")})
+	assert.Contains(t, body, "tool execution failed")
+	assert.NotContains(t, body, "invalid password")
+	body = send(fmt.Errorf("failed to initialize stdio client: %w",
+		&mcpproxy.StderrError{Err: errors.New("EOF"), Stderr: []string{"error: missing API key"}}))
+	assert.Contains(t, body, "tool execution failed")
+	assert.NotContains(t, body, "missing API key")
 }
 
 func TestSendAcceptedResponse(t *testing.T) {
@@ -0,0 +1,133 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/mcp/session"
+)
+
+const (
+	// standaloneStream is the id of the stream opened by GET, every POST request
+	// streams its response on a stream of its own
+	standaloneStream = "get"
+	// streamKey is the gin context key of the id of the response stream of a POST request
+	streamKey = "mcp.stream"
+	// resumePollInterval is how often a resumed POST stream looks for new messages
+	resumePollInterval = 500 * time.Millisecond
+)
+
+// requestStream returns the id of the response stream of the POST request being handled
+func requestStream(c *gin.Context) string {
+	if id := c.GetString(streamKey); id != "" {
+		return id
+	}
+	id := uuid.New().String()
+	c.Set(streamKey, id)
+	return id
+}
+
+// writeEvent writes a message to the event stream of the response. With an event store
+// the message is stored first and carries an event id, so a client that lost the
+// connection can resume the stream with Last-Event-ID.
+func (s *Server) writeEvent(c *gin.Context, conn session.Connection, stream string, data []byte) error {
+	event := &session.StoredEvent{Data: data}
+	if s.events != nil {
+		// store the message even if the client is already gone, it may come back for it
+		id, err := s.events.Append(context.WithoutCancel(c.Request.Context()), conn.Meta().ID, stream, data)
+		if err != nil {
+			s.getLogger(c).Warn("failed to store event for replay",
+				zap.String("session_id", conn.Meta().ID),
+				zap.Error(err))
+		}
+		event.ID = id
+	}
+	return writeStoredEvent(c, event)
+}
+
+func writeStoredEvent(c *gin.Context, event *session.StoredEvent) error {
+	if event.ID != "" {
+		if _, err := fmt.Fprintf(c.Writer, "id: %s\n", event.ID); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(c.Writer, "event: message\ndata: %s\n\n", event.Data); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
+
+// replayEvents writes the messages of the stream of lastEventID the client missed. It
+// returns them with the id of the last one, or lastEventID when there were none.
+func (s *Server) replayEvents(c *gin.Context, conn session.Connection, lastEventID string) (string, []*session.StoredEvent, error) {
+	events, err := s.events.Replay(c.Request.Context(), conn.Meta().ID, lastEventID)
+	if err != nil {
+		return lastEventID, nil, err
+	}
+	for _, event := range events {
+		if err := writeStoredEvent(c, event); err != nil {
+			return lastEventID, nil, err
+		}
+		lastEventID = event.ID
+	}
+	return lastEventID, events, nil
+}
+
+// resumeRequestStream replays the response stream of a POST request and keeps following
+// it until the response was sent, the client is gone or the events expired
+func (s *Server) resumeRequestStream(c *gin.Context, conn session.Connection, lastEventID string) {
+	logger := s.getLogger(c)
+	ticker := time.NewTicker(resumePollInterval)
+	defer ticker.Stop()
+
+	for {
+		var (
+			events []*session.StoredEvent
+			err    error
+		)
+		lastEventID, events, err = s.replayEvents(c, conn, lastEventID)
+		if err != nil {
+			if !errors.Is(err, session.ErrEventNotFound) && c.Request.Context().Err() == nil {
+				logger.Warn("failed to replay events",
+					zap.String("session_id", conn.Meta().ID),
+					zap.String("last_event_id", lastEventID),
+					zap.Error(err))
+			}
+			return
+		}
+		for _, event := range events {
+			if isResponseMessage(event.Data) {
+				return
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-c.Request.Context().Done():
+			return
+		case <-s.shutdownCh:
+			return
+		}
+	}
+}
+
+// isResponseMessage reports whether data is a JSON-RPC response, which ends the stream
+// of a request, rather than a notification sent ahead of it
+func isResponseMessage(data []byte) bool {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		return true
+	}
+	var msg struct {
+		Method string `json:"method"`
+	}
+	return json.Unmarshal(data, &msg) == nil && msg.Method == ""
+}
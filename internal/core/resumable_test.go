@@ -0,0 +1,96 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/pkg/mcp"
+)
+
+// sseEventIDs returns the ids of the events of a streamed response
+func sseEventIDs(body string) []string {
+	var ids []string
+	for _, line := range strings.Split(body, "\n") {
+		if id, ok := strings.CutPrefix(line, "id: "); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func getMCP(ctx context.Context, s *Server, sessionID, lastEventID string) *httptest.ResponseRecorder {
+	c, w := newGin()
+	c.Request = httptest.NewRequest(http.MethodGet, "/rl/mcp", nil).WithContext(ctx)
+	c.Request.Header.Set("Accept", "text/event-stream")
+	c.Request.Header.Set(mcp.HeaderMcpSessionID, sessionID)
+	c.Request.Header.Set(mcp.HeaderLastEventID, lastEventID)
+	s.handleGet(c)
+	return w
+}
+
+func TestHandleGet_ResumesRequestStream(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer upstream.Close()
+	s := newVersionTestServer(t)
+	s.events = session.NewMemoryEventStore(100, time.Minute)
+	s.state.GetTool("/rl", "limited").Endpoint = upstream.URL
+	sessionID, _ := initializeSession(t, s, mcp.LatestProtocolVersion)
+
+	w := postMCP(s, sessionID, nil, `{"jsonrpc":"2.0","id":2,"method":"logging/setLevel","params":{"level":"warning"}}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// the client receives the log message, then loses the connection
+	w = postMCP(s, sessionID, nil, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"limited","arguments":{}}}`)
+	ids := sseEventIDs(w.Body.String())
+	require.Len(t, ids, 2)
+	assert.Equal(t, session.StreamOf(ids[0]), session.StreamOf(ids[1]))
+
+	// resuming replays the response and ends the stream
+	w = getMCP(t.Context(), s, sessionID, ids[0])
+	assert.Equal(t, []string{ids[1]}, sseEventIDs(w.Body.String()))
+	messages := sseMessages(t, w.Body.String())
+	require.Len(t, messages, 1)
+	assert.Equal(t, float64(3), messages[0]["id"])
+}
+
+func TestHandleGet_ResumesStandaloneStream(t *testing.T) {
+	s := newVersionTestServer(t)
+	s.events = session.NewMemoryEventStore(100, time.Minute)
+	sessionID, _ := initializeSession(t, s, mcp.LatestProtocolVersion)
+	conn, err := s.sessions.Get(t.Context(), sessionID)
+	require.NoError(t, err)
+
+	first, err := s.events.Append(t.Context(), sessionID, standaloneStream, []byte(`{"jsonrpc":"2.0","method":"a"}`))
+	require.NoError(t, err)
+	missed, err := s.events.Append(t.Context(), sessionID, standaloneStream, []byte(`{"jsonrpc":"2.0","method":"b"}`))
+	require.NoError(t, err)
+	require.NoError(t, conn.Send(t.Context(), &session.Message{Event: "message", Data: []byte(`{"jsonrpc":"2.0","method":"c"}`)}))
+
+	ctx, cancel := context.WithTimeout(t.Context(), 200*time.Millisecond)
+	defer cancel()
+	w := getMCP(ctx, s, sessionID, first)
+
+	ids := sseEventIDs(w.Body.String())
+	require.Len(t, ids, 2)
+	assert.Equal(t, missed, ids[0])
+	messages := sseMessages(t, w.Body.String())
+	require.Len(t, messages, 2)
+	assert.Equal(t, "b", messages[0]["method"])
+	assert.Equal(t, "c", messages[1]["method"])
+
+	// live messages are stored as well
+	replayed, err := s.events.Replay(t.Context(), sessionID, missed)
+	require.NoError(t, err)
+	require.Len(t, replayed, 1)
+	assert.Equal(t, ids[1], replayed[0].ID)
+}
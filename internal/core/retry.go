@@ -0,0 +1,221 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/common/config"
+)
+
+// Defaults applied to a tool retry block when a field is left empty
+const (
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = 200 * time.Millisecond
+	defaultRetryMaxBackoff     = 5 * time.Second
+	defaultRetryMultiplier     = 2.0
+	defaultRetryJitter         = 0.2
+)
+
+var defaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// retryPolicy is the resolved retry behavior of a single tool
+type retryPolicy struct {
+	maxAttempts     int
+	initialBackoff  time.Duration
+	maxBackoff      time.Duration
+	multiplier      float64
+	jitter          float64
+	retryOn         []int
+	honorRetryAfter bool
+}
+
+// newRetryPolicy resolves the retry block of a tool. It returns nil when the tool
+// has no retry block or is not annotated as safe to call more than once.
+func newRetryPolicy(tool *config.ToolConfig) *retryPolicy {
+	if tool == nil || tool.Retry == nil || !tool.IsRetrySafe() {
+		return nil
+	}
+	cfg := tool.Retry
+	p := &retryPolicy{
+		maxAttempts:     cfg.MaxAttempts,
+		initialBackoff:  cfg.InitialBackoff.Std(),
+		maxBackoff:      cfg.MaxBackoff.Std(),
+		multiplier:      cfg.Multiplier,
+		jitter:          cfg.Jitter,
+		retryOn:         cfg.RetryOn,
+		honorRetryAfter: !cfg.IgnoreRetryAfter,
+	}
+	if p.maxAttempts <= 0 {
+		p.maxAttempts = defaultRetryMaxAttempts
+	}
+	if p.maxAttempts == 1 {
+		return nil
+	}
+	if p.initialBackoff <= 0 {
+		p.initialBackoff = defaultRetryInitialBackoff
+	}
+	if p.maxBackoff <= 0 {
+		p.maxBackoff = defaultRetryMaxBackoff
+	}
+	if p.multiplier < 1 {
+		p.multiplier = defaultRetryMultiplier
+	}
+	if p.jitter <= 0 || p.jitter > 1 {
+		p.jitter = defaultRetryJitter
+	}
+	if len(p.retryOn) == 0 {
+		p.retryOn = defaultRetryStatusCodes
+	}
+	return p
+}
+
+// isRetryableStatus checks whether the downstream status code should be retried
+func (p *retryPolicy) isRetryableStatus(code int) bool {
+	return slices.Contains(p.retryOn, code)
+}
+
+// backoff returns the delay after the given number of failed attempts
+func (p *retryPolicy) backoff(attempt int) time.Duration {
+	d := float64(p.initialBackoff)
+	for i := 1; i < attempt; i++ {
+		d *= p.multiplier
+		if d >= float64(p.maxBackoff) {
+			d = float64(p.maxBackoff)
+			break
+		}
+	}
+	d *= 1 + p.jitter*(2*rand.Float64()-1)
+	if d > float64(p.maxBackoff) {
+		d = float64(p.maxBackoff)
+	}
+	return time.Duration(d)
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		d := at.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// doHTTPRequest sends the tool request, retrying transient failures according to the
+// tool's retry policy. Every attempt is recorded as an event on the given span.
+func (s *Server) doHTTPRequest(ctx context.Context, cli *http.Client, req *http.Request, tool *config.ToolConfig,
+	span oteltrace.Span, logger *zap.Logger) (*http.Response, error) {
+	policy := newRetryPolicy(tool)
+
+	// Buffer bodies that cannot be replayed so that they can be sent again
+	if policy != nil && req.Body != nil && req.GetBody == nil {
+		body, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to buffer request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to rewind request body: %w", err)
+			}
+			req.Body = body
+		}
+
+		start := time.Now()
+		resp, err := cli.Do(req)
+		attrs := []attribute.KeyValue{
+			attribute.Int(cnst.AttrHTTPAttempt, attempt),
+			attribute.Int64(cnst.AttrHTTPAttemptDuration, time.Since(start).Milliseconds()),
+		}
+		if err != nil {
+			attrs = append(attrs, attribute.String(cnst.AttrErrorReason, err.Error()))
+		} else {
+			attrs = append(attrs, attribute.Int(cnst.AttrHTTPStatusCode, resp.StatusCode))
+		}
+
+		if policy == nil || attempt >= policy.maxAttempts || ctx.Err() != nil {
+			span.AddEvent(cnst.EventHTTPToolAttempt, oteltrace.WithAttributes(attrs...))
+			return resp, err
+		}
+
+		retry := err != nil
+		wait := policy.backoff(attempt)
+		if err == nil && policy.isRetryableStatus(resp.StatusCode) {
+			retry = true
+			if policy.honorRetryAfter {
+				if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+					// Give up when the upstream asks us to wait longer than we are willing to
+					retry = retryAfter <= policy.maxBackoff
+					wait = retryAfter
+				}
+			}
+		}
+		if !retry {
+			span.AddEvent(cnst.EventHTTPToolAttempt, oteltrace.WithAttributes(attrs...))
+			return resp, err
+		}
+
+		attrs = append(attrs, attribute.Int64(cnst.AttrHTTPRetryBackoff, wait.Milliseconds()))
+		span.AddEvent(cnst.EventHTTPToolAttempt, oteltrace.WithAttributes(attrs...))
+
+		fields := []zap.Field{
+			zap.String("tool", tool.Name),
+			zap.String("url", req.URL.String()),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", wait),
+		}
+		if err != nil {
+			fields = append(fields, zap.Error(err))
+		} else {
+			fields = append(fields, zap.Int("status", resp.StatusCode))
+			// Drain the body so the connection can be reused
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+		logger.Warn("retrying HTTP tool request", fields...)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("retry aborted: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
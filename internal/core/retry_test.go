@@ -0,0 +1,123 @@
+package core
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/pkg/mcp"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestNewRetryPolicy(t *testing.T) {
+	// no retry block
+	assert.Nil(t, newRetryPolicy(&config.ToolConfig{}))
+
+	// retry block but tool is not annotated as safe
+	assert.Nil(t, newRetryPolicy(&config.ToolConfig{Retry: &config.RetryConfig{MaxAttempts: 3}}))
+
+	// single attempt disables retries
+	assert.Nil(t, newRetryPolicy(&config.ToolConfig{
+		Annotations: map[string]any{"readOnlyHint": true},
+		Retry:       &config.RetryConfig{MaxAttempts: 1},
+	}))
+
+	p := newRetryPolicy(&config.ToolConfig{
+		Annotations: map[string]any{"idempotentHint": true},
+		Retry:       &config.RetryConfig{},
+	})
+	if assert.NotNil(t, p) {
+		assert.Equal(t, defaultRetryMaxAttempts, p.maxAttempts)
+		assert.Equal(t, defaultRetryInitialBackoff, p.initialBackoff)
+		assert.True(t, p.honorRetryAfter)
+		assert.True(t, p.isRetryableStatus(http.StatusServiceUnavailable))
+		assert.False(t, p.isRetryableStatus(http.StatusBadRequest))
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := &retryPolicy{
+		initialBackoff: 100 * time.Millisecond,
+		maxBackoff:     time.Second,
+		multiplier:     2,
+		jitter:         0.1,
+	}
+	d1 := p.backoff(1)
+	assert.InDelta(t, float64(100*time.Millisecond), float64(d1), float64(10*time.Millisecond))
+	d3 := p.backoff(3)
+	assert.InDelta(t, float64(400*time.Millisecond), float64(d3), float64(40*time.Millisecond))
+	// capped by max backoff
+	assert.LessOrEqual(t, p.backoff(10), time.Second)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	d, ok := parseRetryAfter("3", now)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, d)
+
+	d, ok = parseRetryAfter(now.Add(2*time.Second).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, d)
+
+	_, ok = parseRetryAfter("", now)
+	assert.False(t, ok)
+	_, ok = parseRetryAfter("soon", now)
+	assert.False(t, ok)
+}
+
+func TestExecuteHTTPTool_Retry(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"q":"v"}`, string(body))
+		if n < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	allowlist, _ := parseInternalNetworkAllowlist([]string{"127.0.0.0/8", "::1/128"})
+	s := &Server{logger: zap.NewNop(), toolRespHandler: CreateResponseHandlerChain(), internalNetACL: allowlist}
+	tool := &config.ToolConfig{
+		Name:        "t",
+		Method:      http.MethodPost,
+		Endpoint:    srv.URL,
+		RequestBody: `{"q":"v"}`,
+		Annotations: map[string]any{"readOnlyHint": true},
+		Retry: &config.RetryConfig{
+			MaxAttempts:    3,
+			InitialBackoff: config.Duration(time.Millisecond),
+		},
+	}
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, nil)
+	conn := &fakeConnExec{meta: &session.Meta{ID: "sid", Request: &session.RequestInfo{}}}
+	c, _ := gin.CreateTestContext(nil)
+	c.Request = req
+
+	res, err := s.executeHTTPTool(c, conn, tool, map[string]any{}, &config.ServerConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+	if assert.NotNil(t, res) {
+		assert.Equal(t, "ok", res.Content[0].(*mcp.TextContent).Text)
+	}
+
+	// not retried when the tool is not annotated as safe
+	calls.Store(0)
+	tool.Annotations = nil
+	_, err = s.executeHTTPTool(c, conn, tool, map[string]any{}, &config.ServerConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
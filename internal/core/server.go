@@ -10,10 +10,13 @@ import (
 	"time"
 
 	"github.com/amoylab/unla/internal/auth"
+	"github.com/amoylab/unla/internal/auth/upstream"
 	"github.com/amoylab/unla/internal/common/cnst"
 	"github.com/amoylab/unla/internal/common/config"
 	"github.com/amoylab/unla/internal/core/mcpproxy"
+	"github.com/amoylab/unla/internal/core/ratelimit"
 	"github.com/amoylab/unla/internal/core/state"
+	"github.com/amoylab/unla/internal/core/toolcache"
 	"github.com/amoylab/unla/internal/mcp/session"
 	"github.com/amoylab/unla/internal/mcp/storage"
 	"github.com/amoylab/unla/pkg/mcp"
@@ -71,6 +74,28 @@ type (
 		metricsPath     string
 		toolAccess      config.ToolAccessConfig
 		internalNetACL  internalNetworkAllowlist
+		// circuitBreaker is the gateway-wide default, servers may override it
+		circuitBreaker config.CircuitBreakerConfig
+		breakers       *breakerRegistry
+		// timeout is the gateway-wide default for HTTP tools
+		timeout       config.TimeoutConfig
+		httpTransport config.HTTPTransportConfig
+		// toolCache caches results of read-only tools, nil disables caching
+		toolCache toolcache.Store
+		// rateLimiter holds the token buckets of rate limits, nil disables rate limiting
+		rateLimiter ratelimit.Limiter
+		// resourceSubs tracks the sessions subscribed to resources of proxied MCP servers
+		resourceSubs *resourceSubscriptions
+		// events keeps the messages of streamable HTTP streams for replay, nil disables resumption
+		events session.EventStore
+		// heartbeat is written to idle event streams
+		heartbeat config.SessionHeartbeatConfig
+		// sessionIdleTimeout is how long sessions may go without activity, open streams keep them alive
+		sessionIdleTimeout time.Duration
+		// inflight tracks the in-flight tool calls of local sessions for cancellation
+		inflight *inflightRequests
+		// upstreamOAuth authorizes the users at upstream MCP servers, nil disables upstream OAuth
+		upstreamOAuth *upstream.Client
 		// Pre-parsed header lists for efficient lookup
 		ignoreHeaders   []string
 		allowHeaders    []string
@@ -96,6 +121,45 @@ func WithToolAccessConfig(cfg config.ToolAccessConfig) ServerOption {
 	return func(s *Server) { s.applyToolAccessConfig(cfg) }
 }
 
+// WithCircuitBreakerConfig sets the default circuit breaker configuration for HTTP tools.
+func WithCircuitBreakerConfig(cfg config.CircuitBreakerConfig) ServerOption {
+	return func(s *Server) { s.circuitBreaker = cfg }
+}
+
+// WithTimeoutConfig sets the default timeouts for HTTP tools.
+func WithTimeoutConfig(cfg config.TimeoutConfig) ServerOption {
+	return func(s *Server) { s.timeout = cfg }
+}
+
+// WithHTTPTransportConfig sets the connection pool settings of the shared HTTP transports.
+func WithHTTPTransportConfig(cfg config.HTTPTransportConfig) ServerOption {
+	return func(s *Server) { s.httpTransport = cfg }
+}
+
+// WithToolCache sets the store used to cache results of read-only tools.
+func WithToolCache(store toolcache.Store) ServerOption {
+	return func(s *Server) { s.toolCache = store }
+}
+
+// WithRateLimiter sets the limiter enforcing the rate limits of routers and tools.
+func WithRateLimiter(limiter ratelimit.Limiter) ServerOption {
+	return func(s *Server) { s.rateLimiter = limiter }
+}
+
+// WithEventStore sets the store of the messages replayed to resuming streamable HTTP clients.
+func WithEventStore(store session.EventStore) ServerOption {
+	return func(s *Server) { s.events = store }
+}
+
+// WithSessionKeepAlive sets the heartbeats of idle event streams and the idle timeout of
+// sessions, which open streams renew.
+func WithSessionKeepAlive(heartbeat config.SessionHeartbeatConfig, idleTimeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.heartbeat = heartbeat
+		s.sessionIdleTimeout = idleTimeout
+	}
+}
+
 // WithTracing sets the tracing service name for OpenTelemetry.
 func WithTracing(serviceName string) ServerOption {
 	return func(s *Server) { s.tracingService = serviceName }
@@ -114,7 +178,16 @@ func NewServer(logger *zap.Logger, port int, store storage.Store, sessionStore s
 		shutdownCh:      make(chan struct{}),
 		toolRespHandler: CreateResponseHandlerChain(),
 		auth:            a,
+		resourceSubs:    newResourceSubscriptions(),
+		inflight:        newInflightRequests(),
+	}
+	if sessionStore != nil {
+		sessionStore.OnCancel(func(cancellation *session.Cancellation) { s.inflight.cancel(cancellation) })
+		sessionStore.OnResourceUnsubscribe(s.onResourceUnsubscribe)
+		sessionStore.OnSweep(s.onSessionSweep)
+		sessionStore.OnUnregister(s.closeSessionTransports)
 	}
+	s.breakers = newBreakerRegistry(s.onBreakerStateChange)
 
 	// Apply options
 	for _, opt := range opts {
@@ -122,6 +195,7 @@ func NewServer(logger *zap.Logger, port int, store storage.Store, sessionStore s
 			opt(s)
 		}
 	}
+	s.state.SetHTTPTransports(state.NewHTTPTransports(s.httpTransport, s.timeout))
 
 	// Load HTML templates
 	s.router.LoadHTMLGlob("assets/templates/*")
@@ -149,6 +223,16 @@ func (s *Server) getLogger(c *gin.Context) *zap.Logger {
 	return s.logger
 }
 
+// onBreakerStateChange logs circuit breaker transitions and exports them as metrics
+func (s *Server) onBreakerStateChange(key string, state breakerState) {
+	s.logger.Warn("circuit breaker state changed",
+		zap.String("key", key),
+		zap.String("state", state.String()))
+	if s.metrics != nil {
+		s.metrics.CircuitBreakerState(key, int(state))
+	}
+}
+
 // applyForwardConfig sets forward config and recomputes derived fields.
 func (s *Server) applyForwardConfig(cfg config.ForwardConfig) {
 	s.forwardConfig = cfg
@@ -201,6 +285,9 @@ func (s *Server) RegisterRoutes(ctx context.Context) error {
 		oauthGroup.POST("/register", s.handleOAuthRegister)
 		oauthGroup.POST("/revoke", s.handleOAuthRevoke)
 	}
+	if s.upstreamOAuth != nil {
+		s.router.GET("/oauth/upstream/callback", s.handleUpstreamOAuthCallback)
+	}
 
 	newState, err := s.updateConfigs(ctx)
 	if err != nil {
@@ -215,6 +302,8 @@ func (s *Server) RegisterRoutes(ctx context.Context) error {
 
 	// Atomically replace the state
 	s.state = newState
+	s.superviseStdioServers(newState)
+	s.authorizeUpstreamServers(newState)
 
 	// Register all routes under root path
 	s.logger.Debug("registering root handler")
@@ -337,6 +426,7 @@ func (s *Server) Shutdown(_ context.Context) error {
 		}
 	}
 	wg.Wait()
+	s.state.CloseHTTPTransports()
 
 	return nil
 }
@@ -423,8 +513,13 @@ func (s *Server) ReloadConfigs(ctx context.Context) {
 	if newState == nil {
 		return
 	}
+	oldState := s.state
 	// Atomically replace the state
 	s.state = newState
+	s.superviseStdioServers(newState)
+	s.authorizeUpstreamServers(newState)
+	s.invalidateToolCache(ctx, changedConfigs(oldState.GetRawConfigs(), newState.GetRawConfigs())...)
+	s.notifyListChanged(ctx, oldState, newState)
 
 	s.logger.Info("Configuration reloaded successfully")
 }
@@ -472,4 +567,16 @@ func (s *Server) UpdateConfig(ctx context.Context, cfg *config.MCPConfig) {
 
 	// Atomically replace the state
 	s.state = updatedState
+	s.superviseStdioServers(updatedState)
+	s.authorizeUpstreamServers(updatedState)
+
+	// Drop cached results of both the previous and the new version
+	changed := []*config.MCPConfig{cfg}
+	for _, old := range currentState.GetRawConfigs() {
+		if old.Tenant == cfg.Tenant && old.Name == cfg.Name {
+			changed = append(changed, old)
+		}
+	}
+	s.invalidateToolCache(ctx, changed...)
+	s.notifyListChanged(ctx, currentState, updatedState)
 }
@@ -0,0 +1,38 @@
+package core
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/core/mcpproxy"
+	"github.com/amoylab/unla/internal/mcp/session"
+)
+
+// upstreamContext returns the request context, carrying the session and user the requests
+// to the upstream MCP server are made for. Per-session servers run a process for each
+// session, servers protected by OAuth are requested with the token of the user.
+func (s *Server) upstreamContext(c *gin.Context, conn session.Connection) context.Context {
+	ctx := mcpproxy.WithSession(c.Request.Context(), conn.Meta().ID, mergeRequestInfo(conn.Meta().Request, c.Request))
+	if s.upstreamOAuth != nil {
+		ctx = mcpproxy.WithSubject(ctx, s.upstreamSubject(conn))
+	}
+	return ctx
+}
+
+// closeSessionTransports releases what the transports hold for a session that ended
+func (s *Server) closeSessionTransports(sessionID string) {
+	for prefix, transport := range s.state.GetTransports() {
+		scoped, ok := transport.(mcpproxy.SessionScoped)
+		if !ok {
+			continue
+		}
+		if err := scoped.CloseSession(context.Background(), sessionID); err != nil {
+			s.logger.Warn("failed to close session of transport",
+				zap.String("prefix", prefix),
+				zap.String("session_id", sessionID),
+				zap.Error(err))
+		}
+	}
+}
@@ -43,37 +43,13 @@ func (s *Server) handleSSE(c *gin.Context) {
 		prefix = "/"
 	}
 
-	requestInfo := &session.RequestInfo{
-		Headers: make(map[string]string),
-		Query:   make(map[string]string),
-		Cookies: make(map[string]string),
-	}
-	// Process request headers
-	for k, v := range c.Request.Header {
-		if len(v) > 0 {
-			requestInfo.Headers[k] = v[0]
-		}
-	}
-	// Process request querystring
-	for k, v := range c.Request.URL.Query() {
-		if len(v) > 0 {
-			requestInfo.Query[k] = v[0]
-		}
-	}
-	// Process request cookies
-	for _, cookie := range c.Request.Cookies() {
-		if cookie != nil && cookie.Name != "" {
-			requestInfo.Cookies[cookie.Name] = cookie.Value
-		}
-	}
-
 	sessionID := uuid.New().String()
 	meta := &session.Meta{
 		ID:        sessionID,
 		CreatedAt: time.Now(),
 		Prefix:    prefix,
 		Type:      "sse",
-		Request:   requestInfo,
+		Request:   newRequestInfo(c.Request),
 		Extra:     nil,
 	}
 
@@ -138,21 +114,30 @@ func (s *Server) handleSSE(c *gin.Context) {
 		zap.String("remote_addr", c.Request.RemoteAddr),
 	)
 
+	keepAlive := s.newKeepAlive()
+	defer keepAlive.stop()
+
 	// Main event loop
 	for {
 		select {
-		case event := <-conn.EventQueue():
-			if event == nil {
-				logger.Warn("received nil event for session",
+		case event, ok := <-conn.EventQueue():
+			if !ok {
+				logger.Info("SSE connection closing, session was closed",
 					zap.String("session_id", sessionID),
 				)
-			} else {
-				logger.Debug("sending event to SSE client",
+				return
+			}
+			if event == nil {
+				logger.Warn("received nil event for session",
 					zap.String("session_id", sessionID),
-					zap.String("event_type", event.Event),
-					zap.Int("data_size", len(event.Data)),
 				)
+				continue
 			}
+			logger.Debug("sending event to SSE client",
+				zap.String("session_id", sessionID),
+				zap.String("event_type", event.Event),
+				zap.Int("data_size", len(event.Data)),
+			)
 
 			switch event.Event {
 			case "message":
@@ -183,6 +168,11 @@ func (s *Server) handleSSE(c *gin.Context) {
 				}
 			}
 			c.Writer.Flush()
+			keepAlive.reset()
+		case <-keepAlive.C():
+			if !s.keepSessionAlive(c, conn) {
+				return
+			}
 		case <-ctx.Done():
 			logger.Info("SSE client disconnected",
 				zap.String("session_id", sessionID),
@@ -198,6 +188,34 @@ func (s *Server) handleSSE(c *gin.Context) {
 	}
 }
 
+// newRequestInfo captures the headers, query and cookies of the request creating a session
+func newRequestInfo(r *http.Request) *session.RequestInfo {
+	requestInfo := &session.RequestInfo{
+		Headers: make(map[string]string),
+		Query:   make(map[string]string),
+		Cookies: make(map[string]string),
+	}
+	// Process request headers
+	for k, v := range r.Header {
+		if len(v) > 0 {
+			requestInfo.Headers[k] = v[0]
+		}
+	}
+	// Process request querystring
+	for k, v := range r.URL.Query() {
+		if len(v) > 0 {
+			requestInfo.Query[k] = v[0]
+		}
+	}
+	// Process request cookies
+	for _, cookie := range r.Cookies() {
+		if cookie != nil && cookie.Name != "" {
+			requestInfo.Cookies[cookie.Name] = cookie.Value
+		}
+	}
+	return requestInfo
+}
+
 // sendErrorResponse sends an error response through SSE channel and returns Accepted status
 func (s *Server) sendErrorResponse(c *gin.Context, conn session.Connection, req mcp.JSONRPCRequest, errorMsg string) {
 	s.logger.Error("sending error response via SSE",
@@ -313,8 +331,16 @@ func (s *Server) handlePostMessage(c *gin.Context, conn session.Connection) {
 	// TODO: support auth
 
 	// Parse the JSON-RPC message
+	body, err := c.GetRawData()
+	if err == nil && isBatch(body) {
+		s.handleBatch(c, conn, body, s.handleSSERequest, true)
+		return
+	}
 	var req mcp.JSONRPCRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err == nil {
+		err = json.Unmarshal(body, &req)
+	}
+	if err != nil {
 		s.logger.Error("failed to parse JSON-RPC request",
 			zap.Error(err),
 			zap.String("session_id", conn.Meta().ID),
@@ -324,6 +350,12 @@ func (s *Server) handlePostMessage(c *gin.Context, conn session.Connection) {
 		return
 	}
 
+	s.handleSSERequest(c, req, conn)
+}
+
+// handleSSERequest handles a JSON-RPC message received for an SSE session, responses
+// are sent through the event stream
+func (s *Server) handleSSERequest(c *gin.Context, req mcp.JSONRPCRequest, conn session.Connection) {
 	s.logger.Debug("received JSON-RPC request",
 		zap.String("method", req.Method),
 		zap.Any("id", req.Id),
@@ -336,9 +368,20 @@ func (s *Server) handlePostMessage(c *gin.Context, conn session.Connection) {
 		defer s.metrics.McpReqDone(req.Method, reqStartTime)
 	}
 
+	// Notifications carry no id to answer with, only requests are rate limited
+	if req.Id != nil {
+		limits := s.state.GetRateLimits(conn.Meta().Prefix)
+		if rejection := s.checkRateLimits(c, conn, rateLimitLevelRouter, "", limits); rejection != nil {
+			s.sendRateLimitError(c, conn, req, rejection, true)
+			return
+		}
+	}
+
 	switch req.Method {
 	case mcp.NotificationInitialized:
 		s.sendAcceptedResponse(c)
+	case mcp.NotificationCancelled:
+		s.handleCancelled(c, conn, req)
 	case mcp.Initialize:
 		var params mcp.InitializeRequestParams
 		if err := json.Unmarshal(req.Params, &params); err != nil {
@@ -347,7 +390,7 @@ func (s *Server) handlePostMessage(c *gin.Context, conn session.Connection) {
 		}
 
 		result := mcp.InitializedResult{
-			ProtocolVersion: mcp.LatestProtocolVersion,
+			ProtocolVersion: s.negotiateProtocolVersion(c, conn, params.ProtocolVersion),
 			ServerInfo: mcp.ImplementationSchema{
 				Name:    cnst.AppName,
 				Version: version.Get(),
@@ -358,12 +401,13 @@ func (s *Server) handlePostMessage(c *gin.Context, conn session.Connection) {
 					ListChanged: true,
 				},
 				Prompts: mcp.PromptsCapabilitySchema{
-					ListChanged: false,
+					ListChanged: true,
 				},
 				Resources: mcp.ResourcesCapabilitySchema{
-					Subscribe:   false,
+					Subscribe:   s.supportsResourceSubscriptions(conn.Meta().Prefix),
 					ListChanged: false,
 				},
+				Completions: mcp.CompletionsCapabilitySchema{},
 			},
 		}
 		s.sendSuccessResponse(c, conn, req, result, true)
@@ -393,9 +437,9 @@ func (s *Server) handlePostMessage(c *gin.Context, conn session.Connection) {
 				return
 			}
 
-			tools, err = transport.FetchTools(c.Request.Context())
+			tools, err = transport.FetchTools(s.upstreamContext(c, conn))
 			if err != nil {
-				s.sendProtocolError(c, req.Id, "Failed to fetch tools", http.StatusInternalServerError, mcp.ErrorCodeInternalError)
+				s.sendProtocolError(c, req.Id, fetchToolsErrorMessage(err), http.StatusInternalServerError, mcp.ErrorCodeInternalError)
 				return
 			}
 		default:
@@ -403,19 +447,8 @@ func (s *Server) handlePostMessage(c *gin.Context, conn session.Connection) {
 			return
 		}
 
-		toolSchemas := make([]mcp.ToolSchema, len(tools))
-		for i, tool := range tools {
-			toolSchemas[i] = mcp.ToolSchema{
-				Name:        tool.Name,
-				Description: tool.Description,
-				InputSchema: tool.InputSchema,
-				Annotations: tool.Annotations,
-				Meta:        tool.Meta,
-			}
-		}
-
 		result := mcp.ListToolsResult{
-			Tools: toolSchemas,
+			Tools: toolsForVersion(tools, protocolVersion(conn)),
 		}
 		s.sendSuccessResponse(c, conn, req, result, true)
 	case mcp.ToolsCall:
@@ -432,6 +465,10 @@ func (s *Server) handlePostMessage(c *gin.Context, conn session.Connection) {
 			return
 		}
 
+		ctx, done := s.inflight.track(c.Request.Context(), conn.Meta().ID, req.Id)
+		defer done()
+		c.Request = c.Request.WithContext(ctx)
+
 		var (
 			result *mcp.CallToolResult
 			err    error
@@ -463,9 +500,11 @@ func (s *Server) handlePostMessage(c *gin.Context, conn session.Connection) {
 				return
 			}
 
-			result, err = transport.CallTool(c.Request.Context(), params, mergeRequestInfo(conn.Meta().Request, c.Request))
+			result, err = transport.CallTool(s.relayUpstreamLogs(c, conn), params, mergeRequestInfo(conn.Meta().Request, c.Request))
 			if err != nil {
-				s.sendToolExecutionError(c, conn, req, err, true)
+				s.logToSession(c, conn, mcp.LoggingLevelError, logSourceUpstream,
+					toolLogData(params.Name, "tool call failed", nil))
+				s.sendToolExecutionError(c, conn, req, &UpstreamError{Err: err}, true)
 				status = "error"
 				return
 			}
@@ -499,7 +538,7 @@ func (s *Server) handlePostMessage(c *gin.Context, conn session.Connection) {
 				return
 			}
 
-			prompts, err = transport.FetchPrompts(c.Request.Context())
+			prompts, err = transport.FetchPrompts(s.upstreamContext(c, conn))
 			if err != nil {
 				s.sendProtocolError(c, req.Id, "Failed to fetch prompts", http.StatusInternalServerError, mcp.ErrorCodeInternalError)
 				return
@@ -512,132 +551,29 @@ func (s *Server) handlePostMessage(c *gin.Context, conn session.Connection) {
 		result := struct {
 			Prompts []mcp.PromptSchema `json:"prompts"`
 		}{
-			Prompts: prompts,
+			Prompts: promptsForVersion(prompts, protocolVersion(conn)),
 		}
 		s.sendSuccessResponse(c, conn, req, result, true)
 	case mcp.PromptsGet:
-		protoType := s.state.GetProtoType(conn.Meta().Prefix)
-		if protoType == "" {
-			s.sendProtocolError(c, req.Id, "Server configuration not found", http.StatusInternalServerError, mcp.ErrorCodeInternalError)
-			return
-		}
-
-		var params struct {
-			Name      string            `json:"name"`
-			Arguments map[string]string `json:"arguments"`
-		}
-
-		if err := json.Unmarshal(req.Params, &params); err != nil || params.Name == "" {
-			s.sendProtocolError(c, req.Id, "Invalid prompt get parameters", http.StatusBadRequest, mcp.ErrorCodeInvalidParams)
-			return
-		}
-
-		var prompt *mcp.PromptSchema
-		var err error
-		switch protoType {
-		case cnst.BackendProtoHttp:
-			prompts := s.state.GetPromptSchemas(conn.Meta().Prefix)
-			for i := range prompts {
-				if prompts[i].Name == params.Name {
-					prompt = &prompts[i]
-					break
-				}
-			}
-		case cnst.BackendProtoStdio, cnst.BackendProtoSSE, cnst.BackendProtoStreamable:
-			transport := s.state.GetTransport(conn.Meta().Prefix)
-			if transport == nil {
-				s.sendProtocolError(c, req.Id, "Failed to fetch prompt", http.StatusInternalServerError, mcp.ErrorCodeInternalError)
-				return
-			}
-			prompt, err = transport.FetchPrompt(c.Request.Context(), params.Name)
-			if err != nil {
-				s.sendProtocolError(c, req.Id, "Failed to fetch prompt", http.StatusInternalServerError, mcp.ErrorCodeInternalError)
-				return
-			}
-		default:
-			s.sendProtocolError(c, req.Id, "Unsupported protocol type", http.StatusBadRequest, mcp.ErrorCodeInvalidParams)
-			return
-		}
-
-		if prompt == nil {
-			s.sendProtocolError(c, req.Id, "Prompt not found", http.StatusNotFound, mcp.ErrorCodeMethodNotFound)
-			return
-		}
-
-		// Build the response with argument substitution
-		var argsMap map[string]string
-		if req.Params != nil {
-			_ = json.Unmarshal(req.Params, &argsMap)
-		}
-		argsMap = params.Arguments
-
-		resp := struct {
-			Description string `json:"description"`
-			Messages    []struct {
-				Role    string `json:"role"`
-				Content struct {
-					Type string `json:"type"`
-					Text string `json:"text"`
-				} `json:"content"`
-			} `json:"messages"`
-		}{
-			Description: prompt.Description,
-		}
-
-		for _, msg := range prompt.PromptResponse {
-			text := msg.Content.Text
-			// Replace {argument} placeholders with values from argsMap
-			for _, arg := range prompt.Arguments {
-				if val, ok := argsMap[arg.Name]; ok {
-					text = strings.ReplaceAll(text, "{"+arg.Name+"}", val)
-				}
-			}
-			resp.Messages = append(resp.Messages, struct {
-				Role    string `json:"role"`
-				Content struct {
-					Type string `json:"type"`
-					Text string `json:"text"`
-				} `json:"content"`
-			}{
-				Role: msg.Role,
-				Content: struct {
-					Type string `json:"type"`
-					Text string `json:"text"`
-				}{
-					Type: msg.Content.Type,
-					Text: text,
-				},
-			})
-		}
+		s.handlePromptsGet(c, conn, req, true)
+		return
 
-		s.sendSuccessResponse(c, conn, req, resp, true)
+	case mcp.CompletionComplete:
+		s.handleCompletionComplete(c, conn, req, true)
 		return
 
 	case mcp.LoggingSetLevel:
-		// Minimal stub: accept requested level and return empty object
-		type params struct {
-			Level string `json:"level"`
-		}
-		var p params
-		_ = json.Unmarshal(req.Params, &p)
-		s.sendSuccessResponse(c, conn, req, struct{}{}, true)
+		s.handleLoggingSetLevel(c, conn, req, true)
 	case mcp.ResourcesList:
-		s.sendSuccessResponse(c, conn, req, struct {
-			Resources []struct{} `json:"resources"`
-		}{Resources: []struct{}{}}, true)
+		s.handleResourcesList(c, conn, req, true)
 	case mcp.ResourcesTemplatesList:
-		s.sendSuccessResponse(c, conn, req, struct {
-			ResourceTemplates []struct{} `json:"resourceTemplates"`
-		}{ResourceTemplates: []struct{}{}}, true)
+		s.handleResourceTemplatesList(c, conn, req, true)
 	case mcp.ResourcesRead:
-		type params struct {
-			URI string `json:"uri"`
-		}
-		var p params
-		_ = json.Unmarshal(req.Params, &p)
-		s.sendSuccessResponse(c, conn, req, struct {
-			Contents []struct{} `json:"contents"`
-		}{Contents: []struct{}{}}, true)
+		s.handleResourcesRead(c, conn, req, true)
+	case mcp.ResourcesSubscribe:
+		s.handleResourcesSubscribe(c, conn, req, true)
+	case mcp.ResourcesUnsubscribe:
+		s.handleResourcesUnsubscribe(c, conn, req, true)
 	default:
 		s.sendProtocolError(c, req.Id, "Unknown method", http.StatusNotFound, mcp.ErrorCodeMethodNotFound)
 	}
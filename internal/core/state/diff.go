@@ -0,0 +1,59 @@
+package state
+
+import (
+	"reflect"
+	"sort"
+)
+
+// ListChanges holds the prefixes whose tool or prompt lists differ between two states
+type ListChanges struct {
+	Tools   []string
+	Prompts []string
+}
+
+// DiffLists compares the tools and prompts served under every prefix of the previous and
+// the next state. Added and removed prefixes count as changed. The lists of a proxied MCP server
+// are considered changed whenever its server configuration changed, as they are only known
+// to the upstream server.
+func DiffLists(prev, next *State) ListChanges {
+	prefixes := make(map[uriPrefix]struct{})
+	for _, s := range []*State{prev, next} {
+		if s == nil {
+			continue
+		}
+		for prefix := range s.runtime {
+			prefixes[prefix] = struct{}{}
+		}
+	}
+
+	var changes ListChanges
+	for prefix := range prefixes {
+		before, after := runtimeOf(prev, prefix), runtimeOf(next, prefix)
+		upstreamChanged := before.protoType != after.protoType ||
+			!reflect.DeepEqual(before.mcpServer, after.mcpServer)
+		if upstreamChanged || !sameList(before.toolSchemas, after.toolSchemas) {
+			changes.Tools = append(changes.Tools, string(prefix))
+		}
+		if upstreamChanged || !sameList(before.promptSchemas, after.promptSchemas) {
+			changes.Prompts = append(changes.Prompts, string(prefix))
+		}
+	}
+	sort.Strings(changes.Tools)
+	sort.Strings(changes.Prompts)
+	return changes
+}
+
+func runtimeOf(s *State, prefix uriPrefix) runtimeUnit {
+	if s == nil {
+		return runtimeUnit{}
+	}
+	return s.runtime[prefix]
+}
+
+// sameList reports whether two lists hold the same items, an empty list equals a nil one
+func sameList[T any](a, b []T) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,50 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestDiffLists(t *testing.T) {
+	build := func(toolDesc, promptDesc, upstreamURL string, withRemoved bool) *State {
+		cfg := &config.MCPConfig{
+			Name:    "diff",
+			Tools:   []config.ToolConfig{{Name: "t", Description: toolDesc, Method: "GET", Endpoint: "http://localhost/t"}},
+			Prompts: []config.PromptConfig{{Name: "p", Description: promptDesc}},
+			Servers: []config.ServerConfig{{Name: "srv", AllowedTools: []string{"t"}}},
+			Routers: []config.RouterConfig{{Server: "srv", Prefix: "/http"}, {Server: "up", Prefix: "/proxy"}},
+			McpServers: []config.MCPServerConfig{{
+				Type:   cnst.BackendProtoSSE.String(),
+				Name:   "up",
+				URL:    upstreamURL,
+				Policy: cnst.PolicyOnDemand,
+			}},
+		}
+		if withRemoved {
+			cfg.Routers = append(cfg.Routers, config.RouterConfig{Server: "srv", Prefix: "/removed"})
+		}
+		s, err := BuildStateFromConfig(context.Background(), []*config.MCPConfig{cfg}, nil, zap.NewNop())
+		require.NoError(t, err)
+		return s
+	}
+
+	base := build("v1", "v1", "http://127.0.0.1:9/a", false)
+	assert.Equal(t, ListChanges{}, DiffLists(base, build("v1", "v1", "http://127.0.0.1:9/a", false)))
+
+	assert.Equal(t, ListChanges{Tools: []string{"/http"}},
+		DiffLists(base, build("v2", "v1", "http://127.0.0.1:9/a", false)))
+	assert.Equal(t, ListChanges{Prompts: []string{"/http"}},
+		DiffLists(base, build("v1", "v2", "http://127.0.0.1:9/a", false)))
+	assert.Equal(t, ListChanges{Tools: []string{"/proxy"}, Prompts: []string{"/proxy"}},
+		DiffLists(base, build("v1", "v1", "http://127.0.0.1:9/b", false)))
+	assert.Equal(t, ListChanges{Tools: []string{"/removed"}, Prompts: []string{"/removed"}},
+		DiffLists(build("v1", "v1", "http://127.0.0.1:9/a", true), base))
+	assert.Equal(t, ListChanges{Tools: []string{"/http", "/proxy"}, Prompts: []string{"/http", "/proxy"}},
+		DiffLists(nil, base))
+}
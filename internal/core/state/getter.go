@@ -1,6 +1,8 @@
 package state
 
 import (
+	"net/http"
+
 	"github.com/amoylab/unla/internal/common/cnst"
 	"github.com/amoylab/unla/internal/common/config"
 	"github.com/amoylab/unla/internal/core/mcpproxy"
@@ -13,8 +15,14 @@ func (s *State) getRuntime(prefix string) runtimeUnit {
 		return runtimeUnit{
 			tools:         make(map[toolName]*config.ToolConfig),
 			toolSchemas:   make([]mcp.ToolSchema, 0),
+			httpClients:   make(map[toolName]*http.Client),
 			prompts:       make(map[promptName]*config.PromptConfig),
 			promptSchemas: make([]mcp.PromptSchema, 0),
+
+			resources:               make(map[resourceURI]*config.ResourceConfig),
+			resourceSchemas:         make([]mcp.ResourceSchema, 0),
+			resourceTemplateSchemas: make([]mcp.ResourceTemplateSchema, 0),
+			resourceClients:         make(map[string]*http.Client),
 		}
 	}
 	return runtime
@@ -26,6 +34,26 @@ func (s *State) setRouter(prefix string, router *config.RouterConfig) {
 	s.runtime[uriPrefix(prefix)] = runtime
 }
 
+func (s *State) setTenant(prefix, tenant string) {
+	runtime := s.getRuntime(prefix)
+	runtime.tenant = tenant
+	s.runtime[uriPrefix(prefix)] = runtime
+}
+
+// GetTenant returns the tenant of the config that declared the prefix
+func (s *State) GetTenant(prefix string) string {
+	return s.runtime[uriPrefix(prefix)].tenant
+}
+
+// GetRateLimits returns the rate limits declared on the router of the prefix
+func (s *State) GetRateLimits(prefix string) []config.RateLimitConfig {
+	runtime, ok := s.runtime[uriPrefix(prefix)]
+	if ok && runtime.router != nil {
+		return runtime.router.RateLimits
+	}
+	return nil
+}
+
 func (s *State) GetCORS(prefix string) *config.CORSConfig {
 	runtime, ok := s.runtime[uriPrefix(prefix)]
 	if ok && runtime.router != nil {
@@ -70,6 +98,22 @@ func (s *State) GetTool(prefix, name string) *config.ToolConfig {
 	return runtime.tools[toolName(name)]
 }
 
+// GetHTTPClient returns the pooled client of an HTTP tool, or nil if none was built
+func (s *State) GetHTTPClient(prefix, name string) *http.Client {
+	runtime, ok := s.runtime[uriPrefix(prefix)]
+	if !ok {
+		return nil
+	}
+	return runtime.httpClients[toolName(name)]
+}
+
+// CloseHTTPTransports closes the idle connections of all pooled HTTP transports
+func (s *State) CloseHTTPTransports() {
+	if s.httpTransports != nil {
+		s.httpTransports.Close()
+	}
+}
+
 func (s *State) GetToolSchemas(prefix string) []mcp.ToolSchema {
 	runtime, ok := s.runtime[uriPrefix(prefix)]
 	if !ok {
@@ -147,3 +191,70 @@ func (s *State) GetPromptSchemas(prefix string) []mcp.PromptSchema {
 	}
 	return runtime.promptSchemas
 }
+
+// GetResource returns the resource serving the URI and the values of its URI template
+// variables. Resources with a fixed URI take precedence over templates.
+func (s *State) GetResource(prefix, uri string) (*config.ResourceConfig, map[string]string) {
+	runtime, ok := s.runtime[uriPrefix(prefix)]
+	if !ok {
+		return nil, nil
+	}
+	if res, ok := runtime.resources[resourceURI(uri)]; ok {
+		return res, map[string]string{}
+	}
+	for _, rt := range runtime.resourceTemplates {
+		if values, ok := rt.tmpl.Match(uri); ok {
+			return rt.cfg, values
+		}
+	}
+	return nil, nil
+}
+
+// GetResourceTemplate returns the resource declaring the URI template as written
+func (s *State) GetResourceTemplate(prefix, uriTemplate string) *config.ResourceConfig {
+	runtime, ok := s.runtime[uriPrefix(prefix)]
+	if !ok {
+		return nil
+	}
+	for _, rt := range runtime.resourceTemplates {
+		if rt.tmpl.String() == uriTemplate {
+			return rt.cfg
+		}
+	}
+	return nil
+}
+
+func (s *State) GetResourceSchemas(prefix string) []mcp.ResourceSchema {
+	runtime, ok := s.runtime[uriPrefix(prefix)]
+	if !ok {
+		return nil
+	}
+	return runtime.resourceSchemas
+}
+
+func (s *State) GetResourceTemplateSchemas(prefix string) []mcp.ResourceTemplateSchema {
+	runtime, ok := s.runtime[uriPrefix(prefix)]
+	if !ok {
+		return nil
+	}
+	return runtime.resourceTemplateSchemas
+}
+
+// GetResourceHTTPClient returns the pooled client of an HTTP resource, or nil if none was built
+func (s *State) GetResourceHTTPClient(prefix, name string) *http.Client {
+	runtime, ok := s.runtime[uriPrefix(prefix)]
+	if !ok {
+		return nil
+	}
+	return runtime.resourceClients[name]
+}
+
+// GetCompletionHTTPClient returns the pooled client of the completion lookups of a server,
+// or nil if none was built
+func (s *State) GetCompletionHTTPClient(prefix string) *http.Client {
+	runtime, ok := s.runtime[uriPrefix(prefix)]
+	if !ok {
+		return nil
+	}
+	return runtime.completionClient
+}
@@ -0,0 +1,176 @@
+package state
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang.org/x/net/proxy"
+
+	"github.com/amoylab/unla/internal/common/config"
+)
+
+// transportKey identifies the settings that require a dedicated transport
+type transportKey struct {
+	proxyType      string
+	proxyHost      string
+	proxyPort      int
+	connect        config.Duration
+	tlsHandshake   config.Duration
+	responseHeader config.Duration
+}
+
+func newTransportKey(proxyCfg *config.ProxyConfig, timeouts config.TimeoutConfig) transportKey {
+	key := transportKey{
+		connect:        timeouts.Connect,
+		tlsHandshake:   timeouts.TLSHandshake,
+		responseHeader: timeouts.ResponseHeader,
+	}
+	if proxyCfg != nil {
+		key.proxyType = proxyCfg.Type
+		key.proxyHost = proxyCfg.Host
+		key.proxyPort = proxyCfg.Port
+	}
+	return key
+}
+
+type pooledTransport struct {
+	transport *http.Transport
+	client    *http.Client
+}
+
+// HTTPTransports is a registry of pooled transports shared by HTTP tools. Tools with
+// the same proxy and connection settings share a transport, so keep-alive connections
+// are reused across calls and across config reloads.
+type HTTPTransports struct {
+	mu         sync.Mutex
+	cfg        config.HTTPTransportConfig
+	timeouts   config.TimeoutConfig
+	transports map[transportKey]*pooledTransport
+}
+
+// NewHTTPTransports creates a registry using the given pool settings and default timeouts
+func NewHTTPTransports(cfg config.HTTPTransportConfig, timeouts config.TimeoutConfig) *HTTPTransports {
+	return &HTTPTransports{
+		cfg:        cfg,
+		timeouts:   timeouts,
+		transports: make(map[transportKey]*pooledTransport),
+	}
+}
+
+// client returns the shared client for a tool of the given server, creating the
+// transport on first use
+func (r *HTTPTransports) client(server *config.ServerConfig, tool *config.ToolConfig) (*http.Client, transportKey, error) {
+	timeouts := r.timeouts.Merge(server.Timeout).Merge(tool.Timeout)
+	key := newTransportKey(tool.Proxy, timeouts)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if pooled, ok := r.transports[key]; ok {
+		return pooled.client, key, nil
+	}
+	transport, err := NewHTTPTransport(r.cfg, tool.Proxy, timeouts)
+	if err != nil {
+		return nil, key, err
+	}
+	pooled := &pooledTransport{
+		transport: transport,
+		client:    &http.Client{Transport: otelhttp.NewTransport(transport)},
+	}
+	r.transports[key] = pooled
+	return pooled.client, key, nil
+}
+
+// retain drops every transport not in keys. In-flight requests on a dropped transport
+// complete normally, only its idle connections are closed.
+func (r *HTTPTransports) retain(keys map[transportKey]struct{}) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	removed := 0
+	for key, pooled := range r.transports {
+		if _, ok := keys[key]; ok {
+			continue
+		}
+		pooled.transport.CloseIdleConnections()
+		delete(r.transports, key)
+		removed++
+	}
+	return removed
+}
+
+// Close closes the idle connections of all transports and empties the registry
+func (r *HTTPTransports) Close() {
+	r.retain(nil)
+}
+
+// NewHTTPTransport creates a transport with the given pool settings, proxy and timeouts
+func NewHTTPTransport(cfg config.HTTPTransportConfig, proxyCfg *config.ProxyConfig, timeouts config.TimeoutConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.MaxIdleConns > 0 {
+		transport.MaxIdleConns = cfg.MaxIdleConns
+	}
+	if cfg.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	}
+	if cfg.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = cfg.MaxConnsPerHost
+	}
+	if cfg.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+	if cfg.HTTP2.Disabled {
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	} else if cfg.HTTP2.ReadIdleTimeout > 0 || cfg.HTTP2.PingTimeout > 0 || cfg.HTTP2.MaxReadFrameSize > 0 {
+		transport.HTTP2 = &http.HTTP2Config{
+			SendPingTimeout:  cfg.HTTP2.ReadIdleTimeout,
+			PingTimeout:      cfg.HTTP2.PingTimeout,
+			MaxReadFrameSize: cfg.HTTP2.MaxReadFrameSize,
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: timeouts.Connect.Std()}
+	if timeouts.Connect > 0 {
+		transport.DialContext = dialer.DialContext
+	}
+	if timeouts.TLSHandshake > 0 {
+		transport.TLSHandshakeTimeout = timeouts.TLSHandshake.Std()
+	}
+	if timeouts.ResponseHeader > 0 {
+		transport.ResponseHeaderTimeout = timeouts.ResponseHeader.Std()
+	}
+
+	if proxyCfg == nil {
+		return transport, nil
+	}
+	switch proxyCfg.Type {
+	case "http", "https":
+		proxyURLStr := fmt.Sprintf("%s://%s:%d", proxyCfg.Type, proxyCfg.Host, proxyCfg.Port)
+		proxyURL, err := url.Parse(proxyURLStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s proxy configuration: %w", proxyCfg.Type, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+
+	case "socks5":
+		socksDialer, err := proxy.SOCKS5("tcp", fmt.Sprintf("%s:%d", proxyCfg.Host, proxyCfg.Port), nil, dialer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create SOCKS5 dialer: %w", err)
+		}
+		transport.Proxy = nil
+		if contextDialer, ok := socksDialer.(proxy.ContextDialer); ok {
+			transport.DialContext = contextDialer.DialContext
+		} else {
+			transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return socksDialer.Dial(network, addr)
+			}
+		}
+	}
+	return transport, nil
+}
@@ -0,0 +1,101 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestNewHTTPTransport(t *testing.T) {
+	tr, err := NewHTTPTransport(config.HTTPTransportConfig{
+		MaxIdleConnsPerHost: 32,
+		IdleConnTimeout:     time.Minute,
+		HTTP2:               config.HTTP2Config{ReadIdleTimeout: 10 * time.Second},
+	}, nil, config.TimeoutConfig{ResponseHeader: config.Duration(3 * time.Second)})
+	assert.NoError(t, err)
+	assert.Equal(t, 32, tr.MaxIdleConnsPerHost)
+	assert.Equal(t, time.Minute, tr.IdleConnTimeout)
+	assert.Equal(t, 3*time.Second, tr.ResponseHeaderTimeout)
+	assert.True(t, tr.ForceAttemptHTTP2)
+	if assert.NotNil(t, tr.HTTP2) {
+		assert.Equal(t, 10*time.Second, tr.HTTP2.SendPingTimeout)
+	}
+
+	tr, err = NewHTTPTransport(config.HTTPTransportConfig{HTTP2: config.HTTP2Config{Disabled: true}},
+		&config.ProxyConfig{Type: "socks5", Host: "127.0.0.1", Port: 1080}, config.TimeoutConfig{})
+	assert.NoError(t, err)
+	assert.False(t, tr.ForceAttemptHTTP2)
+	assert.NotNil(t, tr.TLSNextProto)
+	assert.Nil(t, tr.Proxy)
+	assert.NotNil(t, tr.DialContext)
+
+	_, err = NewHTTPTransport(config.HTTPTransportConfig{},
+		&config.ProxyConfig{Type: "https", Host: "invalid host with space", Port: 1}, config.TimeoutConfig{})
+	assert.Error(t, err)
+}
+
+func TestHTTPTransports_Client(t *testing.T) {
+	r := NewHTTPTransports(config.HTTPTransportConfig{}, config.TimeoutConfig{Connect: config.Duration(time.Second)})
+	server := &config.ServerConfig{}
+
+	c1, k1, err := r.client(server, &config.ToolConfig{Name: "a"})
+	assert.NoError(t, err)
+	c2, k2, err := r.client(server, &config.ToolConfig{Name: "b"})
+	assert.NoError(t, err)
+	assert.Same(t, c1, c2)
+	assert.Equal(t, k1, k2)
+	assert.Equal(t, config.Duration(time.Second), k1.connect)
+
+	// different proxy or timeouts get a dedicated transport
+	c3, k3, err := r.client(server, &config.ToolConfig{Name: "c", Proxy: &config.ProxyConfig{Type: "http", Host: "127.0.0.1", Port: 8080}})
+	assert.NoError(t, err)
+	assert.NotSame(t, c1, c3)
+	_, k4, err := r.client(&config.ServerConfig{Timeout: &config.TimeoutConfig{Connect: config.Duration(2 * time.Second)}},
+		&config.ToolConfig{Name: "d"})
+	assert.NoError(t, err)
+	assert.NotEqual(t, k1, k4)
+	assert.Len(t, r.transports, 3)
+
+	assert.Equal(t, 2, r.retain(map[transportKey]struct{}{k3: {}}))
+	assert.Len(t, r.transports, 1)
+	r.Close()
+	assert.Empty(t, r.transports)
+}
+
+func TestBuildStateFromConfig_HTTPTransports(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+	newCfg := func(proxy *config.ProxyConfig) *config.MCPConfig {
+		return &config.MCPConfig{
+			Name:    "c1",
+			Tools:   []config.ToolConfig{{Name: "t1", Method: "GET", Endpoint: "/x", Proxy: proxy}, {Name: "t2", Method: "GET", Endpoint: "/y"}},
+			Servers: []config.ServerConfig{{Name: "srv1", AllowedTools: []string{"t1", "t2"}}},
+			Routers: []config.RouterConfig{{Server: "srv1", Prefix: "/h"}},
+		}
+	}
+
+	s1, err := BuildStateFromConfig(ctx, []*config.MCPConfig{newCfg(nil)}, nil, logger)
+	assert.NoError(t, err)
+	cli := s1.GetHTTPClient("/h", "t1")
+	assert.NotNil(t, cli)
+	assert.Same(t, cli, s1.GetHTTPClient("/h", "t2"))
+	assert.Nil(t, s1.GetHTTPClient("/h", "missing"))
+
+	// unchanged settings keep the pooled transport across reloads
+	s2, err := BuildStateFromConfig(ctx, []*config.MCPConfig{newCfg(nil)}, s1, logger)
+	assert.NoError(t, err)
+	assert.Same(t, cli, s2.GetHTTPClient("/h", "t1"))
+
+	// a transport no tool uses anymore is torn down
+	s3, err := BuildStateFromConfig(ctx, []*config.MCPConfig{newCfg(&config.ProxyConfig{Type: "http", Host: "127.0.0.1", Port: 8080})}, s2, logger)
+	assert.NoError(t, err)
+	assert.NotSame(t, cli, s3.GetHTTPClient("/h", "t1"))
+	assert.Len(t, s3.httpTransports.transports, 2)
+	_, err = BuildStateFromConfig(ctx, []*config.MCPConfig{}, s3, logger)
+	assert.NoError(t, err)
+	assert.Empty(t, s3.httpTransports.transports)
+}
@@ -3,6 +3,9 @@ package state
 import (
 	"context"
 	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
 	"time"
 
 	"github.com/amoylab/unla/internal/common/cnst"
@@ -15,19 +18,23 @@ import (
 )
 
 type (
-	uriPrefix  string
-	toolName   string
-	promptName string
+	uriPrefix   string
+	toolName    string
+	promptName  string
+	resourceURI string
 
 	// State contains all the read-only shared state
 	State struct {
 		rawConfigs []*config.MCPConfig
 		runtime    map[uriPrefix]runtimeUnit
 		metrics    metrics
+		// httpTransports is carried over from the previous state on reload
+		httpTransports *HTTPTransports
 	}
 
 	runtimeUnit struct {
 		protoType cnst.ProtoType
+		tenant    string
 		router    *config.RouterConfig
 		server    *config.ServerConfig
 		mcpServer *config.MCPServerConfig
@@ -35,9 +42,24 @@ type (
 
 		tools       map[toolName]*config.ToolConfig
 		toolSchemas []mcp.ToolSchema
+		httpClients map[toolName]*http.Client
 
 		prompts       map[promptName]*config.PromptConfig
 		promptSchemas []mcp.PromptSchema
+
+		resources               map[resourceURI]*config.ResourceConfig
+		resourceTemplates       []resourceTemplate
+		resourceSchemas         []mcp.ResourceSchema
+		resourceTemplateSchemas []mcp.ResourceTemplateSchema
+		resourceClients         map[string]*http.Client
+		// completionClient looks up completion values, which only have the server's settings
+		completionClient *http.Client
+	}
+
+	// resourceTemplate is a resource addressed by a compiled URI template
+	resourceTemplate struct {
+		cfg  *config.ResourceConfig
+		tmpl *mcp.URITemplate
 	}
 
 	metrics struct {
@@ -52,17 +74,27 @@ type (
 
 func NewState() *State {
 	return &State{
-		rawConfigs: make([]*config.MCPConfig, 0),
-		runtime:    make(map[uriPrefix]runtimeUnit),
-		metrics:    metrics{},
+		rawConfigs:     make([]*config.MCPConfig, 0),
+		runtime:        make(map[uriPrefix]runtimeUnit),
+		metrics:        metrics{},
+		httpTransports: NewHTTPTransports(config.HTTPTransportConfig{}, config.TimeoutConfig{}),
 	}
 }
 
+// SetHTTPTransports replaces the transport registry used when building from this state
+func (s *State) SetHTTPTransports(transports *HTTPTransports) {
+	s.httpTransports = transports
+}
+
 // BuildStateFromConfig creates a new State from the given configuration
 func BuildStateFromConfig(ctx context.Context, cfgs []*config.MCPConfig, oldState *State, logger *zap.Logger) (*State, error) {
 	// Create new state
 	newState := NewState()
 	newState.rawConfigs = cfgs
+	if oldState != nil && oldState.httpTransports != nil {
+		newState.httpTransports = oldState.httpTransports
+	}
+	usedTransports := make(map[transportKey]struct{})
 
 	for _, cfg := range cfgs {
 		toolMap := make(map[toolName]*config.ToolConfig)
@@ -71,6 +103,10 @@ func BuildStateFromConfig(ctx context.Context, cfgs []*config.MCPConfig, oldStat
 			newState.metrics.totalTools++
 			toolMap[toolName(tool.Name)] = &tool
 		}
+		resourceMap := make(map[string]*config.ResourceConfig)
+		for i := range cfg.Resources {
+			resourceMap[cfg.Resources[i].Name] = &cfg.Resources[i]
+		}
 
 		// Build prefix to tools mapping for MCP servers
 		prefixMap := make(map[string][]string)
@@ -78,6 +114,7 @@ func BuildStateFromConfig(ctx context.Context, cfgs []*config.MCPConfig, oldStat
 		for _, router := range cfg.Routers {
 			prefixMap[router.Server] = append(prefixMap[router.Server], router.Prefix)
 			newState.setRouter(router.Prefix, &router)
+			newState.setTenant(router.Prefix, cfg.Tenant)
 			logger.Info("registered router",
 				zap.String("tenant", cfg.Tenant),
 				zap.String("prefix", router.Prefix),
@@ -101,12 +138,22 @@ func BuildStateFromConfig(ctx context.Context, cfgs []*config.MCPConfig, oldStat
 			var (
 				allowedToolSchemas []mcp.ToolSchema
 				allowedTools       = make(map[toolName]*config.ToolConfig)
+				httpClients        = make(map[toolName]*http.Client)
 			)
 			for _, ss := range server.AllowedTools {
 				tool, ok := toolMap[toolName(ss)]
 				if ok {
 					allowedToolSchemas = append(allowedToolSchemas, tool.ToToolSchema())
 					allowedTools[toolName(ss)] = tool
+
+					cli, key, err := newState.httpTransports.client(&server, tool)
+					if err != nil {
+						logger.Warn("failed to create HTTP transport for tool", zap.String("server", server.Name),
+							zap.String("tool", ss), zap.Error(err))
+						continue
+					}
+					httpClients[toolName(ss)] = cli
+					usedTransports[key] = struct{}{}
 				} else {
 					newState.metrics.missingTools++
 					logger.Warn("failed to find allowed tool for server", zap.String("server", server.Name),
@@ -114,6 +161,52 @@ func BuildStateFromConfig(ctx context.Context, cfgs []*config.MCPConfig, oldStat
 				}
 			}
 
+			var (
+				resources               = make(map[resourceURI]*config.ResourceConfig)
+				resourceTemplates       []resourceTemplate
+				resourceSchemas         = make([]mcp.ResourceSchema, 0)
+				resourceTemplateSchemas = make([]mcp.ResourceTemplateSchema, 0)
+				resourceClients         = make(map[string]*http.Client)
+			)
+			for _, name := range server.AllowedResources {
+				res, ok := resourceMap[name]
+				if !ok {
+					logger.Warn("failed to find allowed resource for server", zap.String("server", server.Name),
+						zap.String("resource", name))
+					continue
+				}
+				if res.IsTemplate() {
+					tmpl, err := mcp.CompileURITemplate(res.URITemplate)
+					if err != nil {
+						logger.Warn("invalid resource URI template", zap.String("server", server.Name),
+							zap.String("resource", name), zap.Error(err))
+						continue
+					}
+					resourceTemplates = append(resourceTemplates, resourceTemplate{cfg: res, tmpl: tmpl})
+					resourceTemplateSchemas = append(resourceTemplateSchemas, res.ToResourceTemplateSchema())
+				} else {
+					resources[resourceURI(res.URI)] = res
+					resourceSchemas = append(resourceSchemas, res.ToResourceSchema())
+				}
+
+				cli, key, err := newState.httpTransports.client(&server, res.ToToolConfig())
+				if err != nil {
+					logger.Warn("failed to create HTTP transport for resource", zap.String("server", server.Name),
+						zap.String("resource", name), zap.Error(err))
+					continue
+				}
+				resourceClients[name] = cli
+				usedTransports[key] = struct{}{}
+			}
+
+			completionClient, key, err := newState.httpTransports.client(&server, &config.ToolConfig{})
+			if err != nil {
+				logger.Warn("failed to create HTTP transport for completions", zap.String("server", server.Name),
+					zap.Error(err))
+			} else {
+				usedTransports[key] = struct{}{}
+			}
+
 			// Process each prefix for this server
 			for _, prefix := range prefixes {
 				runtime := newState.getRuntime(prefix)
@@ -121,6 +214,13 @@ func BuildStateFromConfig(ctx context.Context, cfgs []*config.MCPConfig, oldStat
 				runtime.server = &server
 				runtime.tools = allowedTools
 				runtime.toolSchemas = allowedToolSchemas
+				runtime.httpClients = httpClients
+				runtime.resources = resources
+				runtime.resourceTemplates = resourceTemplates
+				runtime.resourceSchemas = resourceSchemas
+				runtime.resourceTemplateSchemas = resourceTemplateSchemas
+				runtime.resourceClients = resourceClients
+				runtime.completionClient = completionClient
 				//runtime.prompts = map[promptName]*cfg.Prompts
 				for i := range cfg.Prompts {
 					p := &cfg.Prompts[i]
@@ -157,8 +257,15 @@ func BuildStateFromConfig(ctx context.Context, cfgs []*config.MCPConfig, oldStat
 						// Compare configurations to see if we need to create a new transport
 						oldConfig := oldRuntime.mcpServer
 						if oldConfig != nil && oldConfig.Type == mcpServer.Type &&
+							oldConfig.Policy == mcpServer.Policy &&
 							oldConfig.Command == mcpServer.Command &&
 							oldConfig.URL == mcpServer.URL &&
+							maps.Equal(oldConfig.Headers, mcpServer.Headers) &&
+							maps.Equal(oldConfig.Env, mcpServer.Env) &&
+							reflect.DeepEqual(oldConfig.Sandbox, mcpServer.Sandbox) &&
+							reflect.DeepEqual(oldConfig.Supervisor, mcpServer.Supervisor) &&
+							reflect.DeepEqual(oldConfig.PerSession, mcpServer.PerSession) &&
+							reflect.DeepEqual(oldConfig.OAuth, mcpServer.OAuth) &&
 							len(oldConfig.Args) == len(mcpServer.Args) {
 							// Compare args
 							argsMatch := true
@@ -209,24 +316,32 @@ func BuildStateFromConfig(ctx context.Context, cfgs []*config.MCPConfig, oldStat
 		}
 	}
 
+	// Tear down transports no tool refers to anymore
+	if removed := newState.httpTransports.retain(usedTransports); removed > 0 {
+		logger.Info("closed unused HTTP transports", zap.Int("count", removed))
+	}
+
 	if oldState != nil {
 		for prefix, oldRuntime := range oldState.runtime {
-			if _, stillExists := newState.runtime[prefix]; !stillExists {
-				if oldRuntime.mcpServer == nil {
-					continue
-				}
-				if oldRuntime.transport == nil {
-					logger.Info("transport already stopped", zap.String("prefix", string(prefix)),
-						zap.String("command", oldRuntime.mcpServer.Command), zap.Strings("args", oldRuntime.mcpServer.Args))
-					continue
-				}
-				logger.Info("shutting down unused transport", zap.String("prefix", string(prefix)),
+			// transports replaced by a new one are shut down as well
+			newRuntime, stillExists := newState.runtime[prefix]
+			if stillExists && (newRuntime.transport == oldRuntime.transport || oldRuntime.transport == nil) {
+				continue
+			}
+			if oldRuntime.mcpServer == nil {
+				continue
+			}
+			if oldRuntime.transport == nil {
+				logger.Info("transport already stopped", zap.String("prefix", string(prefix)),
 					zap.String("command", oldRuntime.mcpServer.Command), zap.Strings("args", oldRuntime.mcpServer.Args))
-				if err := oldRuntime.transport.Stop(ctx); err != nil {
-					logger.Warn("failed to close old transport", zap.String("prefix", string(prefix)),
-						zap.Error(err), zap.String("command", oldRuntime.mcpServer.Command),
-						zap.Strings("args", oldRuntime.mcpServer.Args))
-				}
+				continue
+			}
+			logger.Info("shutting down unused transport", zap.String("prefix", string(prefix)),
+				zap.String("command", oldRuntime.mcpServer.Command), zap.Strings("args", oldRuntime.mcpServer.Args))
+			if err := oldRuntime.transport.Stop(ctx); err != nil {
+				logger.Warn("failed to close old transport", zap.String("prefix", string(prefix)),
+					zap.Error(err), zap.String("command", oldRuntime.mcpServer.Command),
+					zap.Strings("args", oldRuntime.mcpServer.Args))
 			}
 		}
 	}
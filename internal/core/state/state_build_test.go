@@ -55,3 +55,101 @@ func TestBuildStateFromConfig_MinimalHTTPAndMCP(t *testing.T) {
 		t.Fatalf("expected sse proto for /m")
 	}
 }
+
+func TestBuildStateFromConfig_Resources(t *testing.T) {
+	cfg := &config.MCPConfig{
+		Name: "c1",
+		Resources: []config.ResourceConfig{{
+			Name:     "readme",
+			URI:      "docs://readme",
+			Method:   "GET",
+			Endpoint: "http://127.0.0.1/readme",
+		}, {
+			Name:        "city",
+			URITemplate: "weather://cities/{city}",
+			Method:      "GET",
+			Endpoint:    "http://127.0.0.1/cities/{{.Args.city}}",
+		}, {
+			Name:     "unused",
+			URI:      "docs://unused",
+			Method:   "GET",
+			Endpoint: "http://127.0.0.1/unused",
+		}},
+		Servers: []config.ServerConfig{{
+			Name:             "srv1",
+			AllowedResources: []string{"readme", "city", "missing"},
+		}},
+		Routers: []config.RouterConfig{{Server: "srv1", Prefix: "/h"}},
+	}
+
+	ns, err := BuildStateFromConfig(context.Background(), []*config.MCPConfig{cfg}, nil, zap.NewNop())
+	if err != nil {
+		t.Fatalf("BuildStateFromConfig: %v", err)
+	}
+	if got := ns.GetResourceSchemas("/h"); len(got) != 1 || got[0].URI != "docs://readme" {
+		t.Fatalf("unexpected resources: %+v", got)
+	}
+	if got := ns.GetResourceTemplateSchemas("/h"); len(got) != 1 || got[0].URITemplate != "weather://cities/{city}" {
+		t.Fatalf("unexpected resource templates: %+v", got)
+	}
+
+	res, vars := ns.GetResource("/h", "docs://readme")
+	if res == nil || res.Name != "readme" || len(vars) != 0 {
+		t.Fatalf("expected readme resource, got %+v %v", res, vars)
+	}
+	res, vars = ns.GetResource("/h", "weather://cities/amoy")
+	if res == nil || res.Name != "city" || vars["city"] != "amoy" {
+		t.Fatalf("expected city resource, got %+v %v", res, vars)
+	}
+	if res, _ := ns.GetResource("/h", "docs://unused"); res != nil {
+		t.Fatalf("resource not allowed for the server should not be found")
+	}
+	if ns.GetResourceHTTPClient("/h", "readme") == nil {
+		t.Fatalf("expected pooled client for readme")
+	}
+}
+
+func TestBuildStateFromConfig_ReusesTransports(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+	build := func(oldState *State, mcpServer config.MCPServerConfig) *State {
+		t.Helper()
+		cfg := &config.MCPConfig{
+			Name:       "c1",
+			Routers:    []config.RouterConfig{{Server: "ms1", Prefix: "/m"}},
+			McpServers: []config.MCPServerConfig{mcpServer},
+		}
+		ns, err := BuildStateFromConfig(ctx, []*config.MCPConfig{cfg}, oldState, logger)
+		if err != nil {
+			t.Fatalf("BuildStateFromConfig: %v", err)
+		}
+		return ns
+	}
+	server := config.MCPServerConfig{
+		Type:    cnst.BackendProtoStdio.String(),
+		Name:    "ms1",
+		Command: "__nonexistent_command__",
+		Env:     map[string]string{"TOKEN": "a"},
+		Policy:  cnst.PolicyOnDemand,
+	}
+
+	s1 := build(nil, server)
+	s2 := build(s1, server)
+	if s2.GetTransport("/m") != s1.GetTransport("/m") {
+		t.Fatalf("expected the transport of an unchanged server to be reused")
+	}
+
+	changes := map[string]func(*config.MCPServerConfig){
+		"env":        func(c *config.MCPServerConfig) { c.Env = map[string]string{"TOKEN": "b"} },
+		"supervisor": func(c *config.MCPServerConfig) { c.Supervisor = &config.SupervisorConfig{MaxRestarts: 1} },
+		"perSession": func(c *config.MCPServerConfig) { c.PerSession = &config.PerSessionConfig{MaxProcesses: 1} },
+		"oauth":      func(c *config.MCPServerConfig) { c.OAuth = &config.MCPServerOAuthConfig{ClientID: "gw"} },
+	}
+	for name, change := range changes {
+		changed := server
+		change(&changed)
+		if build(s2, changed).GetTransport("/m") == s2.GetTransport("/m") {
+			t.Errorf("expected a new transport once the %s of the server changed", name)
+		}
+	}
+}
@@ -147,3 +147,15 @@ func TestSetRouter(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, router, runtime.router)
 }
+
+func TestGetTenantAndRateLimits(t *testing.T) {
+	s := NewState()
+	limits := []config.RateLimitConfig{{Requests: 10}}
+	s.setRouter("/test", &config.RouterConfig{Prefix: "/test", Server: "srv", RateLimits: limits})
+	s.setTenant("/test", "acme")
+
+	assert.Equal(t, "acme", s.GetTenant("/test"))
+	assert.Equal(t, limits, s.GetRateLimits("/test"))
+	assert.Empty(t, s.GetTenant("/missing"))
+	assert.Nil(t, s.GetRateLimits("/missing"))
+}
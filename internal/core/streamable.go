@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -59,8 +60,6 @@ func (s *Server) handleGet(c *gin.Context) {
 		return
 	}
 
-	// TODO: replay events according to the last-event-id in request headers.
-
 	// TODO: only support one sse stream per session, we can detect it when sub the redis topic
 
 	c.Writer.Header().Set("Content-Type", "text/event-stream")
@@ -69,18 +68,39 @@ func (s *Server) handleGet(c *gin.Context) {
 	c.Writer.Header().Set(mcp.HeaderMcpSessionID, conn.Meta().ID)
 	c.Writer.Flush()
 
+	// Replay the messages the client missed on the stream it reconnects to
+	if lastEventID := c.GetHeader(mcp.HeaderLastEventID); lastEventID != "" && s.events != nil {
+		if session.StreamOf(lastEventID) != standaloneStream {
+			s.resumeRequestStream(c, conn, lastEventID)
+			return
+		}
+		if _, _, err := s.replayEvents(c, conn, lastEventID); err != nil {
+			logger.Debug("no events to replay",
+				zap.String("session_id", conn.Meta().ID),
+				zap.String("last_event_id", lastEventID),
+				zap.Error(err))
+		}
+	}
+
+	keepAlive := s.newKeepAlive()
+	defer keepAlive.stop()
+
 	for {
 		select {
-		case event := <-conn.EventQueue():
-			switch event.Event {
-			case "message":
-				_, err := fmt.Fprintf(c.Writer, "event: message\ndata: %s\n\n", event.Data)
-				if err != nil {
+		case event, ok := <-conn.EventQueue():
+			if !ok {
+				return
+			}
+			if event != nil && event.Event == "message" {
+				if err := s.writeEvent(c, conn, standaloneStream, event.Data); err != nil {
 					logger.Error("failed to send SSE message", zap.Error(err))
 				}
+				keepAlive.reset()
+			}
+		case <-keepAlive.C():
+			if !s.keepSessionAlive(c, conn) {
+				return
 			}
-			_, _ = fmt.Fprint(c.Writer, event)
-			c.Writer.Flush()
 		case <-c.Request.Context().Done():
 			return
 		case <-s.shutdownCh:
@@ -109,9 +129,23 @@ func (s *Server) handlePost(c *gin.Context) {
 		return
 	}
 
-	// TODO: support batch messages
+	body, err := c.GetRawData()
+	if err != nil {
+		s.sendProtocolError(c, nil, "Invalid JSON-RPC request",
+			http.StatusBadRequest, mcp.ErrorCodeParseError)
+		return
+	}
+	if isBatch(body) {
+		conn := s.getSession(c)
+		if conn == nil {
+			return
+		}
+		s.handleBatch(c, conn, body, s.handleMCPRequest, false)
+		return
+	}
+
 	var req mcp.JSONRPCRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := json.Unmarshal(body, &req); err != nil {
 		s.sendProtocolError(c, nil, "Invalid JSON-RPC request",
 			http.StatusBadRequest, mcp.ErrorCodeParseError)
 		return
@@ -119,10 +153,7 @@ func (s *Server) handlePost(c *gin.Context) {
 
 	sessionID := c.GetHeader(mcp.HeaderMcpSessionID)
 
-	var (
-		conn session.Connection
-		err  error
-	)
+	var conn session.Connection
 	if req.Method == mcp.Initialize {
 		if sessionID != "" {
 			// confirm if it's registered
@@ -147,6 +178,7 @@ func (s *Server) handlePost(c *gin.Context) {
 				CreatedAt: time.Now(),
 				Prefix:    prefix,
 				Type:      "streamable",
+				Request:   newRequestInfo(c.Request),
 			}
 			conn, err = s.sessions.Register(c.Request.Context(), meta)
 			if err != nil {
@@ -162,8 +194,15 @@ func (s *Server) handlePost(c *gin.Context) {
 				http.StatusNotFound, mcp.ErrorCodeRequestTimeout)
 			return
 		}
+		if !s.checkProtocolVersionHeader(c, conn) {
+			return
+		}
 	}
 
+	if s.events != nil {
+		// the client may resume the response stream after a disconnect, keep handling the request
+		c.Request = c.Request.WithContext(context.WithoutCancel(c.Request.Context()))
+	}
 	s.handleMCPRequest(c, req, conn)
 }
 
@@ -179,12 +218,17 @@ func (s *Server) handleDelete(c *gin.Context) {
 		s.sendProtocolError(c, conn.Meta().ID, "Failed to terminate session", http.StatusInternalServerError, mcp.ErrorCodeInternalError)
 		return
 	}
+	if s.events != nil {
+		if err := s.events.Delete(c.Request.Context(), conn.Meta().ID); err != nil {
+			s.getLogger(c).Warn("failed to delete session events",
+				zap.String("session_id", conn.Meta().ID),
+				zap.Error(err))
+		}
+	}
 	c.Status(http.StatusOK)
 }
 
 func (s *Server) handleMCPRequest(c *gin.Context, req mcp.JSONRPCRequest, conn session.Connection) {
-	logger := s.getLogger(c)
-
 	// Create a span per MCP method to group downstream work
 	scope := apptrace.Tracer(cnst.TraceCore).
 		Start(c.Request.Context(), cnst.SpanMCPMethodPrefix+req.Method, oteltrace.WithSpanKind(oteltrace.SpanKindInternal)).
@@ -205,6 +249,15 @@ func (s *Server) handleMCPRequest(c *gin.Context, req mcp.JSONRPCRequest, conn s
 		defer s.metrics.McpReqDone(req.Method, reqStartTime)
 	}
 
+	// Notifications carry no id to answer with, only requests are rate limited
+	if req.Id != nil {
+		limits := s.state.GetRateLimits(conn.Meta().Prefix)
+		if rejection := s.checkRateLimits(c, conn, rateLimitLevelRouter, "", limits); rejection != nil {
+			s.sendRateLimitError(c, conn, req, rejection, false)
+			return
+		}
+	}
+
 	// Process the request based on its method
 	switch req.Method {
 	case mcp.Initialize:
@@ -216,19 +269,20 @@ func (s *Server) handleMCPRequest(c *gin.Context, req mcp.JSONRPCRequest, conn s
 		}
 
 		s.sendSuccessResponse(c, conn, req, mcp.InitializedResult{
-			ProtocolVersion: mcp.LatestProtocolVersion,
+			ProtocolVersion: s.negotiateProtocolVersion(c, conn, params.ProtocolVersion),
 			Capabilities: mcp.ServerCapabilitiesSchema{
 				Logging: mcp.LoggingCapabilitySchema{},
 				Tools: mcp.ToolsCapabilitySchema{
 					ListChanged: true,
 				},
 				Prompts: mcp.PromptsCapabilitySchema{
-					ListChanged: false,
+					ListChanged: true,
 				},
 				Resources: mcp.ResourcesCapabilitySchema{
-					Subscribe:   false,
+					Subscribe:   s.supportsResourceSubscriptions(conn.Meta().Prefix),
 					ListChanged: false,
 				},
+				Completions: mcp.CompletionsCapabilitySchema{},
 			},
 			ServerInfo: mcp.ImplementationSchema{
 				Name:    cnst.AppName,
@@ -240,6 +294,9 @@ func (s *Server) handleMCPRequest(c *gin.Context, req mcp.JSONRPCRequest, conn s
 	case mcp.NotificationInitialized:
 		c.Status(http.StatusAccepted)
 		return
+	case mcp.NotificationCancelled:
+		s.handleCancelled(c, conn, req)
+		return
 	case mcp.Ping:
 		// Handle ping request with an empty response
 		s.sendSuccessResponse(c, conn, req, struct{}{}, false)
@@ -267,9 +324,9 @@ func (s *Server) handleMCPRequest(c *gin.Context, req mcp.JSONRPCRequest, conn s
 				return
 			}
 
-			tools, err = transport.FetchTools(c.Request.Context())
+			tools, err = transport.FetchTools(s.upstreamContext(c, conn))
 			if err != nil {
-				s.sendProtocolError(c, req.Id, "Failed to fetch tools", http.StatusInternalServerError, mcp.ErrorCodeInternalError)
+				s.sendProtocolError(c, req.Id, fetchToolsErrorMessage(err), http.StatusInternalServerError, mcp.ErrorCodeInternalError)
 				return
 			}
 		default:
@@ -278,7 +335,7 @@ func (s *Server) handleMCPRequest(c *gin.Context, req mcp.JSONRPCRequest, conn s
 		}
 
 		s.sendSuccessResponse(c, conn, req, mcp.ListToolsResult{
-			Tools: tools,
+			Tools: toolsForVersion(tools, protocolVersion(conn)),
 		}, false)
 		return
 
@@ -296,6 +353,10 @@ func (s *Server) handleMCPRequest(c *gin.Context, req mcp.JSONRPCRequest, conn s
 			return
 		}
 
+		ctx, done := s.inflight.track(c.Request.Context(), conn.Meta().ID, req.Id)
+		defer done()
+		c.Request = c.Request.WithContext(ctx)
+
 		var (
 			result *mcp.CallToolResult
 			err    error
@@ -327,9 +388,11 @@ func (s *Server) handleMCPRequest(c *gin.Context, req mcp.JSONRPCRequest, conn s
 				return
 			}
 
-			result, err = transport.CallTool(c.Request.Context(), params, mergeRequestInfo(conn.Meta().Request, c.Request))
+			result, err = transport.CallTool(s.relayUpstreamLogs(c, conn), params, mergeRequestInfo(conn.Meta().Request, c.Request))
 			if err != nil {
-				s.sendToolExecutionError(c, conn, req, err, true)
+				s.logToSession(c, conn, mcp.LoggingLevelError, logSourceUpstream,
+					toolLogData(params.Name, "tool call failed", nil))
+				s.sendToolExecutionError(c, conn, req, &UpstreamError{Err: err}, true)
 				status = "error"
 				return
 			}
@@ -344,41 +407,27 @@ func (s *Server) handleMCPRequest(c *gin.Context, req mcp.JSONRPCRequest, conn s
 		return
 
 	case mcp.LoggingSetLevel:
-		// Minimal stub: accept requested level and return empty object
-		// Inspector may invoke this regardless of server support; avoid -32601
-		type params struct {
-			Level string `json:"level"`
-		}
-		var p params
-		// Ignore unmarshal errors; treat as best-effort no-op
-		_ = json.Unmarshal(req.Params, &p)
-		s.sendSuccessResponse(c, conn, req, struct{}{}, false)
+		s.handleLoggingSetLevel(c, conn, req, false)
 		return
 
 	case mcp.ResourcesList:
-		// Return an empty resources list by default
-		s.sendSuccessResponse(c, conn, req, struct {
-			Resources []struct{} `json:"resources"`
-		}{Resources: []struct{}{}}, false)
+		s.handleResourcesList(c, conn, req, false)
 		return
 
 	case mcp.ResourcesTemplatesList:
-		// Return an empty resourceTemplates list by default
-		s.sendSuccessResponse(c, conn, req, struct {
-			ResourceTemplates []struct{} `json:"resourceTemplates"`
-		}{ResourceTemplates: []struct{}{}}, false)
+		s.handleResourceTemplatesList(c, conn, req, false)
 		return
 
 	case mcp.ResourcesRead:
-		// Minimal stub: acknowledge read with empty contents to avoid -32601
-		type params struct {
-			URI string `json:"uri"`
-		}
-		var p params
-		_ = json.Unmarshal(req.Params, &p)
-		s.sendSuccessResponse(c, conn, req, struct {
-			Contents []struct{} `json:"contents"`
-		}{Contents: []struct{}{}}, false)
+		s.handleResourcesRead(c, conn, req, false)
+		return
+
+	case mcp.ResourcesSubscribe:
+		s.handleResourcesSubscribe(c, conn, req, false)
+		return
+
+	case mcp.ResourcesUnsubscribe:
+		s.handleResourcesUnsubscribe(c, conn, req, false)
 		return
 
 	case mcp.PromptsList:
@@ -403,7 +452,7 @@ func (s *Server) handleMCPRequest(c *gin.Context, req mcp.JSONRPCRequest, conn s
 				return
 			}
 
-			prompts, err = transport.FetchPrompts(c.Request.Context())
+			prompts, err = transport.FetchPrompts(s.upstreamContext(c, conn))
 			if err != nil {
 				s.sendProtocolError(c, req.Id, "Failed to fetch prompts", http.StatusInternalServerError, mcp.ErrorCodeInternalError)
 				return
@@ -416,98 +465,16 @@ func (s *Server) handleMCPRequest(c *gin.Context, req mcp.JSONRPCRequest, conn s
 		s.sendSuccessResponse(c, conn, req, struct {
 			Prompts []mcp.PromptSchema `json:"prompts"`
 		}{
-			Prompts: prompts,
+			Prompts: promptsForVersion(prompts, protocolVersion(conn)),
 		}, false)
 		return
 
 	case mcp.PromptsGet:
-		protoType := s.state.GetProtoType(conn.Meta().Prefix)
-		if protoType == "" {
-			s.sendProtocolError(c, req.Id, "Server configuration not found", http.StatusInternalServerError, mcp.ErrorCodeInternalError)
-			return
-		}
-
-		var params struct {
-			Name      string            `json:"name"`
-			Arguments map[string]string `json:"arguments"`
-		}
-		if err := json.Unmarshal(req.Params, &params); err != nil || params.Name == "" {
-			s.sendProtocolError(c, req.Id, "Invalid prompt get parameters", http.StatusBadRequest, mcp.ErrorCodeInvalidParams)
-			return
-		}
-
-		var prompt *mcp.PromptSchema
-		var err error
-		switch protoType {
-		case cnst.BackendProtoHttp:
-			prompts := s.state.GetPromptSchemas(conn.Meta().Prefix)
-			for i := range prompts {
-				if prompts[i].Name == params.Name {
-					prompt = &prompts[i]
-					break
-				}
-			}
-			logger.Info("PromptsGet-prompt found", zap.String("params.Name", params.Name), zap.String("promptname", prompt.Name))
-		case cnst.BackendProtoStdio, cnst.BackendProtoSSE, cnst.BackendProtoStreamable:
-			transport := s.state.GetTransport(conn.Meta().Prefix)
-			if transport == nil {
-				s.sendProtocolError(c, req.Id, "Failed to fetch prompt", http.StatusInternalServerError, mcp.ErrorCodeInternalError)
-				return
-			}
-			prompt, err = transport.FetchPrompt(c.Request.Context(), params.Name)
-			if err != nil {
-				s.sendProtocolError(c, req.Id, "Failed to fetch prompt", http.StatusInternalServerError, mcp.ErrorCodeInternalError)
-				return
-			}
-		default:
-			s.sendProtocolError(c, req.Id, "Unsupported protocol type", http.StatusBadRequest, mcp.ErrorCodeInvalidParams)
-			return
-		}
-
-		if prompt == nil {
-			s.sendProtocolError(c, req.Id, "Prompt not found", http.StatusNotFound, mcp.ErrorCodeMethodNotFound)
-			return
-		}
+		s.handlePromptsGet(c, conn, req, false)
+		return
 
-		// Build the response with argument substitution
-		argsMap := params.Arguments
-		resp := struct {
-			Description string `json:"description"`
-			Messages    []struct {
-				Role    string `json:"role"`
-				Content struct {
-					Type string `json:"type"`
-					Text string `json:"text"`
-				} `json:"content"`
-			} `json:"messages"`
-		}{
-			Description: prompt.Description,
-		}
-		for _, msg := range prompt.PromptResponse {
-			text := msg.Content.Text
-			for _, arg := range prompt.Arguments {
-				if val, ok := argsMap[arg.Name]; ok {
-					text = strings.ReplaceAll(text, "{"+arg.Name+"}", val)
-				}
-			}
-			resp.Messages = append(resp.Messages, struct {
-				Role    string `json:"role"`
-				Content struct {
-					Type string `json:"type"`
-					Text string `json:"text"`
-				} `json:"content"`
-			}{
-				Role: msg.Role,
-				Content: struct {
-					Type string `json:"type"`
-					Text string `json:"text"`
-				}{
-					Type: msg.Content.Type,
-					Text: text,
-				},
-			})
-		}
-		s.sendSuccessResponse(c, conn, req, resp, false)
+	case mcp.CompletionComplete:
+		s.handleCompletionComplete(c, conn, req, false)
 		return
 
 	default:
@@ -527,5 +494,8 @@ func (s *Server) getSession(c *gin.Context) session.Connection {
 		s.sendProtocolError(c, nil, "Session not found", http.StatusNotFound, mcp.ErrorCodeRequestTimeout)
 		return nil
 	}
+	if !s.checkProtocolVersionHeader(c, conn) {
+		return nil
+	}
 	return conn
 }
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/core/mcpproxy"
+	"github.com/amoylab/unla/internal/core/state"
+	"github.com/amoylab/unla/pkg/mcp"
+)
+
+// superviseStdioServers subscribes to the state changes, sandbox violations and standard
+// error of the stdio servers of the state, and to the upstream log messages no request
+// can be relayed. Transports reused from a previous state are subscribed again.
+func (s *Server) superviseStdioServers(st *state.State) {
+	for prefix, transport := range st.GetTransports() {
+		if supervised, ok := transport.(mcpproxy.Supervised); ok {
+			supervised.OnStatusChange(func(status mcpproxy.ServerStatus) {
+				s.onStdioStatusChange(prefix, status)
+			})
+		}
+		if sandboxed, ok := transport.(mcpproxy.Sandboxed); ok {
+			sandboxed.OnViolation(func(violation mcpproxy.SandboxViolation) {
+				s.onSandboxViolation(prefix, violation)
+			})
+		}
+		if capturer, ok := transport.(mcpproxy.StderrCapturer); ok {
+			capturer.OnStderr(func(server string, line mcpproxy.StderrLine) {
+				s.logger.Info("stdio server stderr",
+					zap.String("prefix", prefix),
+					zap.String("server", server),
+					zap.Int("pid", line.PID),
+					zap.String("line", line.Text))
+			})
+		}
+		if upstreamLogger, ok := transport.(mcpproxy.UpstreamLogger); ok {
+			upstreamLogger.OnUpstreamLog(func(params mcp.LoggingMessageNotificationParams) {
+				s.logger.Info("upstream log message",
+					zap.String("prefix", prefix),
+					zap.String("level", params.Level),
+					zap.String("logger", params.Logger),
+					zap.Any("data", params.Data))
+			})
+		}
+	}
+}
+
+// onStdioStatusChange logs the state changes of stdio servers and exports them as metrics
+func (s *Server) onStdioStatusChange(prefix string, status mcpproxy.ServerStatus) {
+	fields := []zap.Field{
+		zap.String("prefix", prefix),
+		zap.String("server", status.Name),
+		zap.String("state", string(status.State)),
+		zap.Int("restarts", status.Restarts),
+	}
+	switch status.State {
+	case mcpproxy.ServerStateRestarting, mcpproxy.ServerStateFailed:
+		s.logger.Warn("stdio server state changed", append(fields, zap.String("last_error", status.LastError))...)
+	default:
+		s.logger.Debug("stdio server state changed", append(fields, zap.Int("pid", status.PID))...)
+	}
+
+	if s.metrics == nil {
+		return
+	}
+	states := make([]string, len(mcpproxy.ServerStates))
+	for i, st := range mcpproxy.ServerStates {
+		states[i] = string(st)
+	}
+	s.metrics.StdioServerState(prefix, status.Name, string(status.State), states, status.Restarts)
+}
+
+// onSandboxViolation logs the stdio server processes killed for exceeding a limit of their
+// sandbox and counts them in the metrics
+func (s *Server) onSandboxViolation(prefix string, violation mcpproxy.SandboxViolation) {
+	s.logger.Warn("stdio server violated its sandbox",
+		zap.String("prefix", prefix),
+		zap.String("server", violation.Server),
+		zap.Int("pid", violation.PID),
+		zap.String("limit", string(violation.Limit)),
+		zap.Duration("max", violation.Max))
+
+	if s.metrics != nil {
+		s.metrics.StdioSandboxViolation(prefix, violation.Server, string(violation.Limit))
+	}
+}
@@ -0,0 +1,46 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/amoylab/unla/internal/common/config"
+)
+
+// TimeoutError is returned when an HTTP tool call exceeds one of its configured timeouts
+type TimeoutError struct {
+	Tool string
+	Err  error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("tool %s timed out: %v", e.Tool, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// toolTimeouts resolves the timeouts of a tool call, tool settings take precedence
+// over server settings which take precedence over the gateway default
+func (s *Server) toolTimeouts(serverCfg *config.ServerConfig, tool *config.ToolConfig) config.TimeoutConfig {
+	timeouts := s.timeout
+	if serverCfg != nil {
+		timeouts = timeouts.Merge(serverCfg.Timeout)
+	}
+	if tool != nil {
+		timeouts = timeouts.Merge(tool.Timeout)
+	}
+	return timeouts
+}
+
+// isTimeoutError checks whether err was caused by a deadline or a network timeout
+func isTimeoutError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
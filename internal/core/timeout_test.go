@@ -0,0 +1,96 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/pkg/mcp"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestToolTimeouts(t *testing.T) {
+	s := &Server{timeout: config.TimeoutConfig{
+		Connect: config.Duration(time.Second),
+		Total:   config.Duration(time.Minute),
+	}}
+	serverCfg := &config.ServerConfig{Timeout: &config.TimeoutConfig{Total: config.Duration(30 * time.Second)}}
+	tool := &config.ToolConfig{Timeout: &config.TimeoutConfig{Total: config.Duration(5 * time.Second)}}
+
+	assert.Equal(t, s.timeout, s.toolTimeouts(nil, nil))
+	assert.Equal(t, config.Duration(30*time.Second), s.toolTimeouts(serverCfg, &config.ToolConfig{}).Total)
+
+	timeouts := s.toolTimeouts(serverCfg, tool)
+	assert.Equal(t, config.Duration(time.Second), timeouts.Connect)
+	assert.Equal(t, config.Duration(5*time.Second), timeouts.Total)
+}
+
+func TestIsTimeoutError(t *testing.T) {
+	assert.True(t, isTimeoutError(context.DeadlineExceeded))
+	assert.True(t, isTimeoutError(&TimeoutError{Tool: "t", Err: context.DeadlineExceeded}))
+	assert.False(t, isTimeoutError(context.Canceled))
+	assert.False(t, isTimeoutError(errors.New("boom")))
+}
+
+func TestExecuteHTTPTool_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	allowlist, _ := parseInternalNetworkAllowlist([]string{"127.0.0.0/8", "::1/128"})
+	s := &Server{logger: zap.NewNop(), toolRespHandler: CreateResponseHandlerChain(), internalNetACL: allowlist}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	conn := &fakeConnExec{meta: &session.Meta{ID: "sid", Request: &session.RequestInfo{}}}
+	c, _ := gin.CreateTestContext(nil)
+	c.Request = req
+
+	cases := map[string]*config.TimeoutConfig{
+		"response header": {ResponseHeader: config.Duration(50 * time.Millisecond)},
+		"total":           {Total: config.Duration(50 * time.Millisecond)},
+	}
+	for name, timeout := range cases {
+		t.Run(name, func(t *testing.T) {
+			tool := &config.ToolConfig{Name: "slow", Method: http.MethodGet, Endpoint: srv.URL, Timeout: timeout}
+			_, err := s.executeHTTPTool(c, conn, tool, map[string]any{}, &config.ServerConfig{})
+			var timeoutErr *TimeoutError
+			if assert.ErrorAs(t, err, &timeoutErr) {
+				assert.Equal(t, "slow", timeoutErr.Tool)
+			}
+		})
+	}
+}
+
+func TestSendJSONRPCError(t *testing.T) {
+	s := &Server{logger: zap.NewNop()}
+	req := mcp.JSONRPCRequest{Id: 7, Method: "tools/call", JSONRPC: mcp.JSPNRPCVersion}
+
+	c, w := newGin()
+	conn := &fakeConn{meta: &session.Meta{ID: "sid"}}
+	s.sendJSONRPCError(c, conn, req, "timed out", mcp.ErrorCodeRequestTimeout, true)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	if assert.Len(t, conn.sent, 1) {
+		var body mcp.JSONRPCErrorSchema
+		assert.NoError(t, json.Unmarshal(conn.sent[0].Data, &body))
+		assert.Equal(t, mcp.ErrorCodeRequestTimeout, body.Error.Code)
+		assert.Equal(t, "timed out", body.Error.Message)
+	}
+
+	c, w = newGin()
+	s.sendJSONRPCError(c, conn, req, "timed out", mcp.ErrorCodeRequestTimeout, false)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":-32001`)
+}
@@ -4,18 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
-	"net"
 	"net/http"
 	"net/url"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	"golang.org/x/net/proxy"
 
 	apptrace "github.com/amoylab/unla/pkg/trace"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
@@ -25,6 +25,8 @@ import (
 
 	"github.com/amoylab/unla/internal/common/cnst"
 	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/core/state"
+	"github.com/amoylab/unla/internal/i18n"
 	"github.com/amoylab/unla/internal/mcp/session"
 	"github.com/amoylab/unla/internal/template"
 	"github.com/amoylab/unla/pkg/mcp"
@@ -172,39 +174,38 @@ func fillDefaultArgs(tool *config.ToolConfig, args map[string]any) {
 	}
 }
 
-// createHTTPClient creates an HTTP client with proxy support if configured
-func createHTTPClient(tool *config.ToolConfig) (*http.Client, error) {
-	if tool != nil && tool.Proxy != nil {
-		transport := &http.Transport{}
-
-		switch tool.Proxy.Type {
-		case "http", "https":
-			proxyURLStr := fmt.Sprintf("%s://%s:%d", tool.Proxy.Type, tool.Proxy.Host, tool.Proxy.Port)
-			proxyURL, err := url.Parse(proxyURLStr)
-			if err != nil {
-				return nil, fmt.Errorf("invalid %s proxy configuration: %w", tool.Proxy.Type, err)
-			}
-			transport.Proxy = http.ProxyURL(proxyURL)
-
-		case "socks5":
-			dialer, err := proxy.SOCKS5("tcp", fmt.Sprintf("%s:%d", tool.Proxy.Host, tool.Proxy.Port), nil, proxy.Direct)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create SOCKS5 dialer: %w", err)
-			}
-			transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
-				return dialer.Dial(network, addr)
-			}
-		}
+// createHTTPClient creates an unpooled HTTP client for a tool that has no pooled client in the state
+func createHTTPClient(tool *config.ToolConfig, timeouts config.TimeoutConfig) (*http.Client, error) {
+	hasProxy := tool != nil && tool.Proxy != nil
+	hasTimeouts := timeouts.Connect > 0 || timeouts.TLSHandshake > 0 || timeouts.ResponseHeader > 0
+	if !hasProxy && !hasTimeouts {
+		return &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}, nil
+	}
 
-		return &http.Client{Transport: otelhttp.NewTransport(transport)}, nil
+	var proxyCfg *config.ProxyConfig
+	if hasProxy {
+		proxyCfg = tool.Proxy
 	}
+	transport, err := state.NewHTTPTransport(config.HTTPTransportConfig{}, proxyCfg, timeouts)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: otelhttp.NewTransport(transport)}, nil
+}
 
-	return &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}, nil
+// httpClientFor returns the pooled client built for the tool, falling back to a new client
+func (s *Server) httpClientFor(prefix string, tool *config.ToolConfig, timeouts config.TimeoutConfig) (*http.Client, error) {
+	if s.state != nil {
+		if cli := s.state.GetHTTPClient(prefix, tool.Name); cli != nil {
+			return cli, nil
+		}
+	}
+	return createHTTPClient(tool, timeouts)
 }
 
 // executeHTTPTool executes a tool with the given arguments
 func (s *Server) executeHTTPTool(c *gin.Context, conn session.Connection, tool *config.ToolConfig,
-	args map[string]any, serverCfg map[string]string) (*mcp.CallToolResult, error) {
+	args map[string]any, serverCfg *config.ServerConfig) (*mcp.CallToolResult, error) {
 	// Create a span to represent the tool execution lifecycle
 	scope := apptrace.Tracer(cnst.TraceCore).
 		Start(c.Request.Context(), cnst.SpanHTTPToolExecute, oteltrace.WithSpanKind(oteltrace.SpanKindInternal)).
@@ -235,13 +236,16 @@ func (s *Server) executeHTTPTool(c *gin.Context, conn session.Connection, tool *
 		zap.String("session_id", conn.Meta().ID),
 		zap.String("remote_addr", c.Request.RemoteAddr))
 
+	s.logToSession(c, conn, mcp.LoggingLevelDebug, logSourceTool, toolLogData(tool.Name, "executing tool", nil))
+
 	// Prepare template context
-	tmplCtx, err := template.PrepareTemplateContext(conn.Meta().Request, args, c.Request, serverCfg)
+	tmplCtx, err := template.PrepareTemplateContext(conn.Meta().Request, args, c.Request, serverCfg.Config)
 	if err != nil {
 		logger.Error("failed to prepare template context",
 			zap.String("tool", tool.Name),
 			zap.String("session_id", conn.Meta().ID),
 			zap.Error(err))
+		s.logToSession(c, conn, mcp.LoggingLevelError, logSourceTemplate, toolLogData(tool.Name, "failed to prepare template context", err))
 		return nil, err
 	}
 
@@ -252,6 +256,7 @@ func (s *Server) executeHTTPTool(c *gin.Context, conn session.Connection, tool *
 			zap.String("tool", tool.Name),
 			zap.String("session_id", conn.Meta().ID),
 			zap.Error(err))
+		s.logToSession(c, conn, mcp.LoggingLevelError, logSourceTemplate, toolLogData(tool.Name, "failed to render request", err))
 		return nil, err
 	}
 
@@ -303,7 +308,8 @@ func (s *Server) executeHTTPTool(c *gin.Context, conn session.Connection, tool *
 	processArguments(req, tool, args)
 
 	// Execute request
-	cli, err := createHTTPClient(tool)
+	timeouts := s.toolTimeouts(serverCfg, tool)
+	cli, err := s.httpClientFor(conn.Meta().Prefix, tool, timeouts)
 	if err != nil {
 		logger.Error("failed to create HTTP client",
 			zap.String("tool", tool.Name),
@@ -317,15 +323,52 @@ func (s *Server) executeHTTPTool(c *gin.Context, conn session.Connection, tool *
 		zap.String("url", req.URL.String()),
 		zap.String("session_id", conn.Meta().ID))
 
+	// Fail fast while the upstream is known to be down
+	breaker := s.circuitBreakerFor(serverCfg, req.URL.Host)
+	if breaker != nil {
+		if err := breaker.allow(time.Now()); err != nil {
+			logger.Warn("circuit breaker rejected HTTP tool request",
+				zap.String("tool", tool.Name),
+				zap.String("breaker", breaker.key),
+				zap.String("session_id", conn.Meta().ID))
+			scope.Span.AddEvent(cnst.EventCircuitBreakerRejected,
+				oteltrace.WithAttributes(attribute.String(cnst.AttrCircuitBreakerKey, breaker.key)))
+			if s.metrics != nil {
+				s.metrics.CircuitBreakerRejected(breaker.key)
+			}
+			return nil, err
+		}
+	}
+
+	// The total timeout bounds all attempts as well as reading the response body
+	reqCtx := ctx
+	if timeouts.Total > 0 {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeout(ctx, timeouts.Total.Std())
+		defer cancel()
+	}
+
 	// Ensure downstream request carries current trace context
-	req = req.WithContext(ctx)
-	resp, err := cli.Do(req)
+	req = req.WithContext(reqCtx)
+	resp, err := s.doHTTPRequest(reqCtx, cli, req, tool, scope.Span, logger)
+	if breaker != nil {
+		if err != nil && ctx.Err() != nil {
+			// The caller went away, this says nothing about the upstream
+			breaker.release()
+		} else {
+			breaker.done(err == nil && resp.StatusCode < http.StatusInternalServerError, time.Now())
+		}
+	}
 	if err != nil {
 		logger.Error("failed to execute HTTP request",
 			zap.String("tool", tool.Name),
 			zap.String("url", req.URL.String()),
 			zap.String("session_id", conn.Meta().ID),
 			zap.Error(err))
+		s.logToSession(c, conn, mcp.LoggingLevelError, logSourceUpstream, toolLogData(tool.Name, "upstream request failed", err))
+		if isTimeoutError(err) {
+			return nil, &TimeoutError{Tool: tool.Name, Err: err}
+		}
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
 	defer resp.Body.Close()
@@ -338,6 +381,9 @@ func (s *Server) executeHTTPTool(c *gin.Context, conn session.Connection, tool *
 			zap.String("session_id", conn.Meta().ID),
 			zap.Int("status", resp.StatusCode),
 			zap.Error(err))
+		if isTimeoutError(err) {
+			return nil, &TimeoutError{Tool: tool.Name, Err: err}
+		}
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
@@ -351,6 +397,12 @@ func (s *Server) executeHTTPTool(c *gin.Context, conn session.Connection, tool *
 		zap.String("response_body", string(respBodyBytes)),
 		zap.Int("status", resp.StatusCode))
 
+	if resp.StatusCode >= http.StatusBadRequest {
+		data := toolLogData(tool.Name, "upstream returned an error status", nil)
+		data["status"] = resp.StatusCode
+		s.logToSession(c, conn, mcp.LoggingLevelWarning, logSourceUpstream, data)
+	}
+
 	// If downstream response indicates error, annotate the span with concise details per config
 	if resp.StatusCode >= 400 && s.traceCapture.DownstreamError.Enabled {
 		maxLen := s.traceCapture.DownstreamError.MaxBodyLength
@@ -383,21 +435,49 @@ func (s *Server) executeHTTPTool(c *gin.Context, conn session.Connection, tool *
 		)
 	}
 
+	// Async tools submit a job, their result is the status of the finished job
+	respTool := tool
+	if tool.Async != nil && resp.StatusCode < http.StatusBadRequest {
+		if resp, err = s.pollAsyncJob(ctx, c, tool, tmplCtx, cli, timeouts, respBodyBytes); err != nil {
+			logger.Error("async job did not complete",
+				zap.String("tool", tool.Name),
+				zap.String("session_id", conn.Meta().ID),
+				zap.Error(err))
+			s.logToSession(c, conn, mcp.LoggingLevelError, logSourceTool, toolLogData(tool.Name, "async job did not complete", err))
+			return nil, err
+		}
+		resultTool := *tool
+		resultTool.ResponseBody = tool.Async.ResultBody
+		respTool = &resultTool
+	}
+
 	// Process response
-	callToolResult, err := s.toolRespHandler.Handle(resp, tool, tmplCtx)
+	callToolResult, err := s.toolRespHandler.Handle(resp, respTool, tmplCtx)
 	if err != nil {
 		logger.Error("failed to process tool response",
 			zap.String("tool", tool.Name),
 			zap.String("session_id", conn.Meta().ID),
 			zap.Int("status", resp.StatusCode),
 			zap.Error(err))
+		s.logToSession(c, conn, mcp.LoggingLevelError, logSourceTemplate, toolLogData(tool.Name, "failed to render response", err))
 		return nil, err
 	}
 
+	if tool.OutputSchema != nil && resp.StatusCode < http.StatusBadRequest && !callToolResult.IsError {
+		if err := validateToolOutput(c.Request, tool, callToolResult); err != nil {
+			logger.Error("tool response does not match output schema",
+				zap.String("tool", tool.Name),
+				zap.String("session_id", conn.Meta().ID),
+				zap.Error(err))
+			return nil, err
+		}
+	}
+
 	logger.Info("tool execution completed successfully",
 		zap.String("tool", tool.Name),
 		zap.String("session_id", conn.Meta().ID),
 		zap.Int("status", resp.StatusCode))
+	s.logToSession(c, conn, mcp.LoggingLevelInfo, logSourceTool, toolLogData(tool.Name, "tool execution completed", nil))
 
 	return callToolResult, nil
 }
@@ -481,6 +561,11 @@ func (s *Server) callHTTPTool(c *gin.Context, req mcp.JSONRPCRequest, conn sessi
 		return nil
 	}
 
+	if rejection := s.checkRateLimits(c, conn, rateLimitLevelTool, tool.Name, tool.RateLimits); rejection != nil {
+		s.sendRateLimitError(c, conn, req, rejection, isSSE)
+		return nil
+	}
+
 	// Convert arguments to map[string]any
 	var args map[string]any
 	if err := json.Unmarshal(params.Arguments, &args); err != nil {
@@ -492,6 +577,28 @@ func (s *Server) callHTTPTool(c *gin.Context, req mcp.JSONRPCRequest, conn sessi
 		return nil
 	}
 
+	// Validate arguments against the input schema before they reach the upstream
+	if err := validateToolArgs(c.Request, tool, args); err != nil {
+		var validationErr *ArgsValidationError
+		if !errors.As(err, &validationErr) {
+			// A schema we fail to compile is a config problem, not the caller's
+			logger.Warn("failed to compile tool input schema",
+				zap.String("tool", params.Name),
+				zap.Error(err))
+		} else {
+			logger.Info("tool arguments do not match input schema",
+				zap.String("tool", params.Name),
+				zap.String("session_id", conn.Meta().ID),
+				zap.Error(err))
+			message := i18n.NewWithMessage("ErrorToolArgsInvalid", "Invalid arguments for tool {{.Tool}}").
+				WithParam("Tool", tool.Name).
+				TranslateByRequest(c.Request)
+			s.sendJSONRPCErrorWithData(c, conn, req, message, mcp.ErrorCodeInvalidParams,
+				map[string]any{"errors": validationErr.Fields}, isSSE)
+			return nil
+		}
+	}
+
 	// Log tool arguments at debug level
 	if logger.Core().Enabled(zap.DebugLevel) {
 		argsJSON, _ := json.Marshal(args)
@@ -513,12 +620,18 @@ func (s *Server) callHTTPTool(c *gin.Context, req mcp.JSONRPCRequest, conn sessi
 	}
 
 	// Execute the tool
-	result, err := s.executeHTTPTool(c, conn, tool, args, serverCfg.Config)
+	setProgressToken(c, conn, params.Meta.ProgressToken, isSSE)
+	result, err := s.executeHTTPToolCached(c, conn, tool, args, serverCfg)
 	if err != nil {
 		logger.Error("tool execution failed",
 			zap.String("tool", params.Name),
 			zap.String("session_id", conn.Meta().ID),
 			zap.Error(err))
+		var timeoutErr *TimeoutError
+		if errors.As(err, &timeoutErr) {
+			s.sendJSONRPCError(c, conn, req, err.Error(), mcp.ErrorCodeRequestTimeout, isSSE)
+			return nil
+		}
 		s.sendToolExecutionError(c, conn, req, err, isSSE)
 		return nil
 	}
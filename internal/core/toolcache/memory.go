@@ -0,0 +1,110 @@
+package toolcache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// MemoryStore implements Store with a per tool LRU list in memory
+type MemoryStore struct {
+	logger *zap.Logger
+	mu     sync.Mutex
+	// namespaces maps namespace -> tool -> entries
+	namespaces map[string]map[string]*lru
+	now        func() time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+type lru struct {
+	order   *list.List // front is the most recently used
+	entries map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates a new in-memory tool cache
+func NewMemoryStore(logger *zap.Logger) *MemoryStore {
+	return &MemoryStore{
+		logger:     logger.Named("toolcache.memory"),
+		namespaces: make(map[string]map[string]*lru),
+		now:        time.Now,
+	}
+}
+
+// Get implements Store.Get
+func (s *MemoryStore) Get(_ context.Context, key Key) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := s.namespaces[key.Namespace][key.Tool]
+	if l == nil {
+		return nil, false, nil
+	}
+	elem, ok := l.entries[key.Value]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := elem.Value.(*memoryEntry)
+	if !s.now().Before(entry.expiresAt) {
+		l.order.Remove(elem)
+		delete(l.entries, key.Value)
+		return nil, false, nil
+	}
+	l.order.MoveToFront(elem)
+	return entry.value, true, nil
+}
+
+// Set implements Store.Set
+func (s *MemoryStore) Set(_ context.Context, key Key, value []byte, ttl time.Duration, maxEntries int) error {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tools, ok := s.namespaces[key.Namespace]
+	if !ok {
+		tools = make(map[string]*lru)
+		s.namespaces[key.Namespace] = tools
+	}
+	l, ok := tools[key.Tool]
+	if !ok {
+		l = &lru{order: list.New(), entries: make(map[string]*list.Element)}
+		tools[key.Tool] = l
+	}
+
+	expiresAt := s.now().Add(ttl)
+	if elem, ok := l.entries[key.Value]; ok {
+		entry := elem.Value.(*memoryEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		l.order.MoveToFront(elem)
+		return nil
+	}
+	l.entries[key.Value] = l.order.PushFront(&memoryEntry{key: key.Value, value: value, expiresAt: expiresAt})
+
+	for l.order.Len() > maxEntries {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*memoryEntry).key)
+	}
+	return nil
+}
+
+// Invalidate implements Store.Invalidate
+func (s *MemoryStore) Invalidate(_ context.Context, namespace string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.namespaces, namespace)
+	return nil
+}
@@ -0,0 +1,73 @@
+package toolcache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestMemoryStore_GetSet(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(zap.NewNop())
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	key := Key{Namespace: "/p", Tool: "t", Value: `{"q":1}`}
+	_, ok, err := s.Get(ctx, key)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, s.Set(ctx, key, []byte("v1"), time.Minute, 0))
+	v, ok, err := s.Get(ctx, key)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "v1", string(v))
+
+	// expired entries are dropped
+	now = now.Add(time.Minute)
+	_, ok, _ = s.Get(ctx, key)
+	assert.False(t, ok)
+}
+
+func TestMemoryStore_Eviction(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(zap.NewNop())
+	k := func(v string) Key { return Key{Namespace: "/p", Tool: "t", Value: v} }
+
+	assert.NoError(t, s.Set(ctx, k("a"), []byte("a"), time.Minute, 2))
+	assert.NoError(t, s.Set(ctx, k("b"), []byte("b"), time.Minute, 2))
+	// touching a makes b the least recently used entry
+	_, ok, _ := s.Get(ctx, k("a"))
+	assert.True(t, ok)
+	assert.NoError(t, s.Set(ctx, k("c"), []byte("c"), time.Minute, 2))
+
+	_, ok, _ = s.Get(ctx, k("b"))
+	assert.False(t, ok)
+	_, ok, _ = s.Get(ctx, k("a"))
+	assert.True(t, ok)
+	_, ok, _ = s.Get(ctx, k("c"))
+	assert.True(t, ok)
+
+	// the limit applies per tool
+	assert.NoError(t, s.Set(ctx, Key{Namespace: "/p", Tool: "other", Value: "a"}, []byte("x"), time.Minute, 2))
+	_, ok, _ = s.Get(ctx, k("c"))
+	assert.True(t, ok)
+}
+
+func TestMemoryStore_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(zap.NewNop())
+	k1 := Key{Namespace: "/p1", Tool: "t", Value: "a"}
+	k2 := Key{Namespace: "/p2", Tool: "t", Value: "a"}
+	assert.NoError(t, s.Set(ctx, k1, []byte("1"), time.Minute, 0))
+	assert.NoError(t, s.Set(ctx, k2, []byte("2"), time.Minute, 0))
+
+	assert.NoError(t, s.Invalidate(ctx, "/p1"))
+	_, ok, _ := s.Get(ctx, k1)
+	assert.False(t, ok)
+	_, ok, _ = s.Get(ctx, k2)
+	assert.True(t, ok)
+}
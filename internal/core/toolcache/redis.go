@@ -0,0 +1,137 @@
+package toolcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/pkg/utils"
+)
+
+// RedisStore implements Store with Redis so cached results are shared between replicas.
+// Invalidation bumps a generation counter of the namespace instead of deleting keys,
+// entries of older generations are never read again and expire with their TTL.
+type RedisStore struct {
+	logger *zap.Logger
+	client redis.UniversalClient
+	prefix string
+}
+
+var _ Store = (*RedisStore)(nil)
+
+// NewRedisStore creates a new Redis-based tool cache
+func NewRedisStore(logger *zap.Logger, cfg config.SessionRedisConfig, prefix string) (*RedisStore, error) {
+	addrs := utils.SplitByMultipleDelimiters(cfg.Addr, ";", ",")
+	redisOptions := &redis.UniversalOptions{
+		Addrs:    addrs,
+		Username: cfg.Username,
+		Password: cfg.Password,
+	}
+	if cfg.ClusterType == cnst.RedisClusterTypeSentinel {
+		redisOptions.MasterName = cfg.MasterName
+	}
+	if cfg.ClusterType != cnst.RedisClusterTypeCluster {
+		// can not set db in cluster mode
+		redisOptions.DB = cfg.DB
+	}
+	client := redis.NewUniversalClient(redisOptions)
+
+	// Test connection
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	if prefix == "" {
+		prefix = "toolcache"
+	}
+	return &RedisStore{
+		logger: logger.Named("toolcache.redis"),
+		client: client,
+		prefix: prefix + ":",
+	}, nil
+}
+
+func (s *RedisStore) generationKey(namespace string) string {
+	return s.prefix + "gen:" + namespace
+}
+
+// keys returns the entry key and the key of the tool's LRU index
+func (s *RedisStore) keys(ctx context.Context, key Key) (string, string, error) {
+	gen, err := s.client.Get(ctx, s.generationKey(key.Namespace)).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			return "", "", err
+		}
+		gen = "0"
+	}
+	sum := sha256.Sum256([]byte(key.Value))
+	base := fmt.Sprintf("%s%s:%s:%s", s.prefix, key.Namespace, gen, key.Tool)
+	return base + ":" + hex.EncodeToString(sum[:]), base + ":index", nil
+}
+
+// Get implements Store.Get
+func (s *RedisStore) Get(ctx context.Context, key Key) ([]byte, bool, error) {
+	entryKey, _, err := s.keys(ctx, key)
+	if err != nil {
+		return nil, false, err
+	}
+	value, err := s.client.Get(ctx, entryKey).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set implements Store.Set
+func (s *RedisStore) Set(ctx context.Context, key Key, value []byte, ttl time.Duration, maxEntries int) error {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	entryKey, indexKey, err := s.keys(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	pipe := s.client.Pipeline()
+	pipe.Set(ctx, entryKey, value, ttl)
+	pipe.ZAdd(ctx, indexKey, redis.Z{Score: float64(time.Now().UnixNano()), Member: entryKey})
+	pipe.PExpire(ctx, indexKey, ttl)
+	card := pipe.ZCard(ctx, indexKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	// Evict the oldest entries beyond the limit
+	excess := card.Val() - int64(maxEntries)
+	if excess <= 0 {
+		return nil
+	}
+	evicted, err := s.client.ZPopMin(ctx, indexKey, excess).Result()
+	if err != nil {
+		return err
+	}
+	pipe = s.client.Pipeline()
+	for _, z := range evicted {
+		if member, ok := z.Member.(string); ok {
+			pipe.Del(ctx, member)
+		}
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// Invalidate implements Store.Invalidate
+func (s *RedisStore) Invalidate(ctx context.Context, namespace string) error {
+	return s.client.Incr(ctx, s.generationKey(namespace)).Err()
+}
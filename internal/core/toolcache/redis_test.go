@@ -0,0 +1,97 @@
+package toolcache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	miniredis "github.com/alicebob/miniredis/v2"
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newTestRedisStore(t *testing.T) (*RedisStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	s, err := NewRedisStore(zap.NewNop(), config.SessionRedisConfig{
+		ClusterType: cnst.RedisClusterTypeSingle,
+		Addr:        mr.Addr(),
+	}, "testcache")
+	if err != nil {
+		t.Fatalf("failed to create RedisStore: %v", err)
+	}
+	return s, mr
+}
+
+func TestNewRedisStore_ConnectionError(t *testing.T) {
+	s, err := NewRedisStore(zap.NewNop(), config.SessionRedisConfig{
+		ClusterType: cnst.RedisClusterTypeSingle,
+		Addr:        "127.0.0.1:0",
+	}, "")
+	assert.Nil(t, s)
+	assert.Error(t, err)
+}
+
+func TestRedisStore_GetSetInvalidate(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newTestRedisStore(t)
+	key := Key{Namespace: "/p", Tool: "t", Value: `{"q":1}`}
+
+	_, ok, err := s.Get(ctx, key)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, s.Set(ctx, key, []byte("v1"), time.Minute, 0))
+	v, ok, err := s.Get(ctx, key)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "v1", string(v))
+
+	// entries expire with their TTL
+	mr.FastForward(time.Minute)
+	_, ok, _ = s.Get(ctx, key)
+	assert.False(t, ok)
+
+	// invalidation hides entries of older generations
+	assert.NoError(t, s.Set(ctx, key, []byte("v2"), time.Minute, 0))
+	assert.NoError(t, s.Invalidate(ctx, "/p"))
+	_, ok, _ = s.Get(ctx, key)
+	assert.False(t, ok)
+	assert.NoError(t, s.Set(ctx, key, []byte("v3"), time.Minute, 0))
+	v, ok, _ = s.Get(ctx, key)
+	assert.True(t, ok)
+	assert.Equal(t, "v3", string(v))
+}
+
+func TestRedisStore_Eviction(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestRedisStore(t)
+	k := func(v string) Key { return Key{Namespace: "/p", Tool: "t", Value: v} }
+
+	assert.NoError(t, s.Set(ctx, k("a"), []byte("a"), time.Minute, 2))
+	assert.NoError(t, s.Set(ctx, k("b"), []byte("b"), time.Minute, 2))
+	assert.NoError(t, s.Set(ctx, k("c"), []byte("c"), time.Minute, 2))
+
+	_, ok, _ := s.Get(ctx, k("a"))
+	assert.False(t, ok)
+	_, ok, _ = s.Get(ctx, k("b"))
+	assert.True(t, ok)
+	_, ok, _ = s.Get(ctx, k("c"))
+	assert.True(t, ok)
+}
+
+func TestNewStore(t *testing.T) {
+	logger := zap.NewNop()
+	s, err := NewStore(logger, &config.ToolCacheConfig{Type: "memory"}, config.SessionRedisConfig{})
+	assert.NoError(t, err)
+	assert.IsType(t, &MemoryStore{}, s)
+
+	_, err = NewStore(logger, &config.ToolCacheConfig{Type: "bogus"}, config.SessionRedisConfig{})
+	assert.Error(t, err)
+}
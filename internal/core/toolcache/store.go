@@ -0,0 +1,57 @@
+package toolcache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/common/config"
+)
+
+// Type represents the type of tool cache store
+type Type string
+
+const (
+	// TypeMemory represents in-memory tool cache
+	TypeMemory Type = "memory"
+	// TypeRedis represents Redis-based tool cache
+	TypeRedis Type = "redis"
+)
+
+// DefaultMaxEntries bounds the entries of a tool when its cache block sets no limit
+const DefaultMaxEntries = 1000
+
+// Key identifies a cached tool result
+type Key struct {
+	// Namespace is the unit of invalidation, the router prefix the tool is served under
+	Namespace string
+	Tool      string
+	// Value is the rendered cache key of the call
+	Value string
+}
+
+// Store defines the interface for tool result caches
+type Store interface {
+	// Get returns the cached value of key, the bool reports whether it was found
+	Get(ctx context.Context, key Key) ([]byte, bool, error)
+	// Set stores value for ttl, evicting the oldest entries of the tool beyond maxEntries
+	Set(ctx context.Context, key Key, value []byte, ttl time.Duration, maxEntries int) error
+	// Invalidate drops all entries of the namespace
+	Invalidate(ctx context.Context, namespace string) error
+}
+
+// NewStore creates a new tool cache store based on configuration. The Redis store
+// connects with the session store's Redis settings.
+func NewStore(logger *zap.Logger, cfg *config.ToolCacheConfig, redisCfg config.SessionRedisConfig) (Store, error) {
+	logger.Info("Initializing tool cache", zap.String("type", cfg.Type))
+	switch Type(cfg.Type) {
+	case TypeMemory:
+		return NewMemoryStore(logger), nil
+	case TypeRedis:
+		return NewRedisStore(logger, redisCfg, cfg.Prefix)
+	default:
+		return nil, fmt.Errorf("unsupported tool cache type: %s", cfg.Type)
+	}
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/auth/upstream"
+	"github.com/amoylab/unla/internal/core/mcpproxy"
+	"github.com/amoylab/unla/internal/core/state"
+	"github.com/amoylab/unla/internal/mcp/session"
+)
+
+// WithUpstreamOAuth sets the client authorizing the gateway users at the upstream MCP
+// servers with an oauth block.
+func WithUpstreamOAuth(client *upstream.Client) ServerOption {
+	return func(s *Server) { s.upstreamOAuth = client }
+}
+
+// authorizeUpstreamServers hands the upstream OAuth client to the transports of the state,
+// transports reused from a previous state get it again
+func (s *Server) authorizeUpstreamServers(st *state.State) {
+	if s.upstreamOAuth == nil {
+		return
+	}
+	for _, transport := range st.GetTransports() {
+		if client, ok := transport.(mcpproxy.OAuthClient); ok {
+			client.SetAuthorizer(s.upstreamOAuth)
+		}
+	}
+}
+
+// upstreamSubject is the gateway user upstream tokens are bound to. Access tokens of the
+// gateway only identify the OAuth client they were issued to, which may be shared by many
+// users, so tokens are bound to the session and users authorize again in every session.
+func (s *Server) upstreamSubject(conn session.Connection) string {
+	return "session:" + conn.Meta().ID
+}
+
+// upstreamServer returns the upstream server with an oauth block at url
+func (s *Server) upstreamServer(url string) (upstream.Server, bool) {
+	for _, cfg := range s.state.GetRawConfigs() {
+		for _, server := range cfg.McpServers {
+			if server.OAuth != nil && server.URL == url {
+				return upstream.Server{Name: server.Name, URL: server.URL, OAuth: *server.OAuth}, true
+			}
+		}
+	}
+	return upstream.Server{}, false
+}
+
+// fetchToolsErrorMessage is the message of a failed tool listing, users who have not
+// authorized the gateway at the upstream server are told where to do so
+func fetchToolsErrorMessage(err error) string {
+	var authErr *upstream.AuthorizationRequiredError
+	if errors.As(err, &authErr) {
+		return authErr.Error()
+	}
+	return "Failed to fetch tools"
+}
+
+// handleUpstreamOAuthCallback completes the authorization of an upstream MCP server, the
+// authorization server redirects the user here
+func (s *Server) handleUpstreamOAuthCallback(c *gin.Context) {
+	logger := s.getLogger(c)
+	if errCode := c.Query("error"); errCode != "" {
+		logger.Info("upstream authorization denied",
+			zap.String("error", errCode),
+			zap.String("description", c.Query("error_description")))
+		c.String(http.StatusBadRequest, "Authorization failed: %s %s", errCode, c.Query("error_description"))
+		return
+	}
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		c.String(http.StatusBadRequest, "Authorization failed: missing state or code")
+		return
+	}
+
+	token, err := s.upstreamOAuth.Callback(c.Request.Context(), state, code, s.upstreamServer)
+	if err != nil {
+		logger.Warn("failed to complete upstream authorization", zap.Error(err))
+		c.String(http.StatusBadRequest, "Authorization failed: %s", err.Error())
+		return
+	}
+	c.String(http.StatusOK, "Authorization of %s completed, you can close this window and call the tool again.", token.Resource)
+}
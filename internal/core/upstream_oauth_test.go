@@ -0,0 +1,22 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/mcp/session"
+)
+
+func TestUpstreamSubject_NotSharedByClient(t *testing.T) {
+	// both sessions use access tokens of the same OAuth client of the gateway
+	s := &Server{logger: zap.NewNop(), auth: &fakeAuth{OAuth2: fakeOAuth2{}}}
+	alice := &fakeConn{meta: &session.Meta{ID: "sid1", Request: &session.RequestInfo{
+		Headers: map[string]string{"Authorization": "Bearer alice"}}}}
+	bob := &fakeConn{meta: &session.Meta{ID: "sid2", Request: &session.RequestInfo{
+		Headers: map[string]string{"Authorization": "Bearer bob"}}}}
+
+	assert.Equal(t, "session:sid1", s.upstreamSubject(alice))
+	assert.Equal(t, "session:sid2", s.upstreamSubject(bob))
+}
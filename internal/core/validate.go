@@ -0,0 +1,255 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/i18n"
+	"github.com/amoylab/unla/pkg/mcp"
+)
+
+// FieldError is the validation failure of a single field of arguments or results
+type FieldError struct {
+	// Field is the dotted path of the field, e.g. "filters.0.name"
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ArgsValidationError reports the arguments of a tool call not matching its input schema
+type ArgsValidationError struct {
+	Tool   string
+	Fields []FieldError
+}
+
+func (e *ArgsValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Message
+	}
+	return fmt.Sprintf("invalid arguments for tool %s: %s", e.Tool, strings.Join(msgs, "; "))
+}
+
+// OutputValidationError reports the structured content of a tool result not matching
+// the output schema of the tool
+type OutputValidationError struct {
+	Tool   string
+	Fields []FieldError
+}
+
+func (e *OutputValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Message
+	}
+	return fmt.Sprintf("structured content of tool %s does not match its output schema: %s", e.Tool, strings.Join(msgs, "; "))
+}
+
+// toolArgsSchema converts the input schema of a tool into a schema kin-openapi can
+// validate against. Required arguments with a default are optional to the caller.
+func toolArgsSchema(tool *config.ToolConfig) (*openapi3.Schema, error) {
+	input := tool.ToToolSchema().InputSchema
+
+	defaults := make(map[string]bool)
+	for _, arg := range tool.Args {
+		if arg.Default != "" {
+			defaults[arg.Name] = true
+		}
+	}
+	required := make([]string, 0, len(input.Required))
+	for _, name := range input.Required {
+		if !defaults[name] {
+			required = append(required, name)
+		}
+	}
+
+	return compileSchema(map[string]any{
+		"type":       "object",
+		"properties": input.Properties,
+		"required":   required,
+	})
+}
+
+// compileSchema converts a JSON Schema document into a schema kin-openapi can validate against
+func compileSchema(doc map[string]any) (*openapi3.Schema, error) {
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var normalized any
+	if err := json.Unmarshal(raw, &normalized); err != nil {
+		return nil, err
+	}
+	raw, err = json.Marshal(pruneEmptySchemaKeys(normalized))
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &openapi3.Schema{}
+	if err := json.Unmarshal(raw, schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// pruneEmptySchemaKeys drops the empty "type" and "description" keys ToToolSchema
+// emits for args that leave them unset, an empty type would match nothing
+func pruneEmptySchemaKeys(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			if s, ok := item.(string); ok && s == "" && (k == "type" || k == "description") {
+				delete(val, k)
+				continue
+			}
+			val[k] = pruneEmptySchemaKeys(item)
+		}
+	case []any:
+		for i, item := range val {
+			val[i] = pruneEmptySchemaKeys(item)
+		}
+	}
+	return v
+}
+
+// validateToolArgs validates args against the input schema of the tool, coercing
+// string values into the declared scalar type first when the tool enables it. Messages
+// are localized for the language of the request.
+func validateToolArgs(r *http.Request, tool *config.ToolConfig, args map[string]any) error {
+	schema, err := toolArgsSchema(tool)
+	if err != nil {
+		return err
+	}
+	if tool.CoerceArgs {
+		coerceArgs(schema, args)
+	}
+
+	err = schema.VisitJSON(map[string]any(args), openapi3.MultiErrors())
+	if err == nil {
+		return nil
+	}
+
+	var fields []FieldError
+	collectSchemaErrors(r, err, &fields)
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+	return &ArgsValidationError{Tool: tool.Name, Fields: fields}
+}
+
+// validateToolOutput validates the structured content of a result against the output
+// schema of the tool, a result without structured content never matches
+func validateToolOutput(r *http.Request, tool *config.ToolConfig, result *mcp.CallToolResult) error {
+	schema, err := compileSchema(tool.OutputSchema)
+	if err != nil {
+		return fmt.Errorf("invalid output schema of tool %s: %w", tool.Name, err)
+	}
+	if result.StructuredContent == nil {
+		return &OutputValidationError{Tool: tool.Name, Fields: []FieldError{{
+			Field: "structuredContent",
+			Message: i18n.NewWithMessage("ErrorToolOutputNotObject", "the response is not a JSON object").
+				TranslateByRequest(r),
+		}}}
+	}
+
+	err = schema.VisitJSON(result.StructuredContent, openapi3.MultiErrors())
+	if err == nil {
+		return nil
+	}
+	var fields []FieldError
+	collectSchemaErrors(r, err, &fields)
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+	return &OutputValidationError{Tool: tool.Name, Fields: fields}
+}
+
+func collectSchemaErrors(r *http.Request, err error, fields *[]FieldError) {
+	var multi openapi3.MultiError
+	if errors.As(err, &multi) {
+		for _, e := range multi {
+			collectSchemaErrors(r, e, fields)
+		}
+		return
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if !errors.As(err, &schemaErr) {
+		*fields = append(*fields, FieldError{Field: "arguments", Message: err.Error()})
+		return
+	}
+
+	field := strings.Join(schemaErr.JSONPointer(), ".")
+	if field == "" {
+		field = "arguments"
+	}
+	var msg *i18n.I18nError
+	switch schemaErr.SchemaField {
+	case "required":
+		msg = i18n.NewWithMessage("ErrorToolArgRequired", "{{.Field}} is required")
+	case "type":
+		msg = i18n.NewWithMessage("ErrorToolArgType", "{{.Field}} must be of type {{.Type}}")
+		if schemaErr.Schema != nil && schemaErr.Schema.Type != nil {
+			msg.WithParam("Type", strings.Join(schemaErr.Schema.Type.Slice(), " or "))
+		}
+	case "enum":
+		msg = i18n.NewWithMessage("ErrorToolArgEnum", "{{.Field}} must be one of {{.Values}}")
+		if schemaErr.Schema != nil {
+			values, _ := json.Marshal(schemaErr.Schema.Enum)
+			msg.WithParam("Values", string(values))
+		}
+	default:
+		msg = i18n.NewWithMessage("ErrorToolArgInvalid", "{{.Field}} is invalid: {{.Reason}}").
+			WithParam("Reason", schemaErr.Reason)
+	}
+	msg.WithParam("Field", field)
+	*fields = append(*fields, FieldError{Field: field, Message: msg.TranslateByRequest(r)})
+}
+
+// coerceArgs converts string values into the integer, number or boolean type their
+// schema declares when the conversion is lossless, e.g. "42" for an integer
+func coerceArgs(schema *openapi3.Schema, args map[string]any) {
+	for name, ref := range schema.Properties {
+		if ref == nil || ref.Value == nil {
+			continue
+		}
+		value, ok := args[name]
+		if !ok {
+			continue
+		}
+		prop := ref.Value
+		if items, ok := value.([]any); ok && prop.Type.Is(openapi3.TypeArray) && prop.Items != nil && prop.Items.Value != nil {
+			for i, item := range items {
+				items[i] = coerceValue(prop.Items.Value, item)
+			}
+			continue
+		}
+		args[name] = coerceValue(prop, value)
+	}
+}
+
+func coerceValue(schema *openapi3.Schema, value any) any {
+	s, ok := value.(string)
+	if !ok || schema.Type == nil {
+		return value
+	}
+	s = strings.TrimSpace(s)
+	switch {
+	case schema.Type.Is(openapi3.TypeInteger):
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return float64(n)
+		}
+	case schema.Type.Is(openapi3.TypeNumber):
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			return n
+		}
+	case schema.Type.Is(openapi3.TypeBoolean):
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+	return value
+}
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/amoylab/unla/internal/common/config"
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/pkg/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newValidationTool() *config.ToolConfig {
+	return &config.ToolConfig{
+		Name: "search",
+		Args: []config.ArgConfig{
+			{Name: "q", Type: "string", Required: true},
+			{Name: "limit", Type: "integer"},
+			{Name: "page", Type: "integer", Required: true, Default: "1"},
+			{Name: "exact", Type: "boolean"},
+			{Name: "tags", Type: "array", Items: config.ItemsConfig{Enum: []string{"a", "b"}}},
+			{Name: "raw"},
+		},
+		InputSchema: map[string]any{
+			"filter": map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"field": map[string]any{"type": "string"}},
+				"required":   []any{"field"},
+			},
+		},
+	}
+}
+
+func TestValidateToolArgs(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	tool := newValidationTool()
+
+	// required args with a default may be omitted, untyped args accept anything
+	assert.NoError(t, validateToolArgs(r, tool, map[string]any{"q": "x", "raw": 1.0}))
+
+	err := validateToolArgs(r, tool, map[string]any{
+		"limit":  "10",
+		"tags":   []any{"c"},
+		"filter": map[string]any{},
+	})
+	var validationErr *ArgsValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "search", validationErr.Tool)
+	assert.Equal(t, []FieldError{
+		{Field: "filter.field", Message: "filter.field is required"},
+		{Field: "limit", Message: "limit must be of type integer"},
+		{Field: "q", Message: "q is required"},
+		{Field: "tags.0", Message: `tags.0 must be one of ["a","b"]`},
+	}, validationErr.Fields)
+}
+
+func TestValidateToolArgs_Coercion(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	tool := newValidationTool()
+	tool.Args = append(tool.Args, config.ArgConfig{Name: "ids", Type: "array", Items: config.ItemsConfig{Type: "number"}})
+
+	args := map[string]any{"q": "x", "limit": " 42 ", "exact": "true", "ids": []any{"1.5", 2.0}}
+	require.Error(t, validateToolArgs(r, tool, args))
+
+	tool.CoerceArgs = true
+	require.NoError(t, validateToolArgs(r, tool, args))
+	assert.Equal(t, 42.0, args["limit"])
+	assert.Equal(t, true, args["exact"])
+	assert.Equal(t, []any{1.5, 2.0}, args["ids"])
+
+	// strings that do not convert losslessly are left alone and still rejected
+	args = map[string]any{"q": "x", "limit": "4.2"}
+	require.Error(t, validateToolArgs(r, tool, args))
+	assert.Equal(t, "4.2", args["limit"])
+}
+
+func TestCallHTTPTool_InvalidArgs(t *testing.T) {
+	s := newRateLimitTestServer(t, "http://127.0.0.1")
+	tool := s.state.GetTool("/rl", "limited")
+	tool.RateLimits = nil
+	tool.Args = []config.ArgConfig{{Name: "q", Type: "string", Required: true}}
+
+	conn := &fakeConn{meta: &session.Meta{ID: "sid", Prefix: "/rl", Request: &session.RequestInfo{}}}
+	params := mcp.CallToolParams{Name: "limited", Arguments: json.RawMessage(`{"q": 1}`)}
+	c, w, req := newRateLimitRequest(t, mcp.ToolsCall, params)
+	assert.Nil(t, s.callHTTPTool(c, req, conn, params, false))
+
+	var body mcp.JSONRPCErrorSchema
+	require.NoError(t, json.Unmarshal([]byte(sseData(w.Body.String())), &body))
+	assert.Equal(t, mcp.ErrorCodeInvalidParams, body.Error.Code)
+	assert.Equal(t, "Invalid arguments for tool limited", body.Error.Message)
+	assert.Equal(t, map[string]any{"errors": []any{
+		map[string]any{"field": "q", "message": "q must be of type string"},
+	}}, body.Error.Data)
+}
+
+func TestValidateToolOutput(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	tool := &config.ToolConfig{
+		Name: "weather",
+		OutputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"temp": map[string]any{"type": "number"},
+			},
+			"required": []any{"temp"},
+		},
+	}
+
+	result := mcp.NewCallToolResultText(`{"temp":21}`)
+	result.StructuredContent = map[string]any{"temp": 21.0}
+	assert.NoError(t, validateToolOutput(r, tool, result))
+
+	result.StructuredContent = map[string]any{"temp": "warm"}
+	var validationErr *OutputValidationError
+	require.ErrorAs(t, validateToolOutput(r, tool, result), &validationErr)
+	assert.Equal(t, []FieldError{{Field: "temp", Message: "temp must be of type number"}}, validationErr.Fields)
+
+	result.StructuredContent = nil
+	require.ErrorAs(t, validateToolOutput(r, tool, result), &validationErr)
+	assert.Equal(t, []FieldError{{Field: "structuredContent", Message: "the response is not a JSON object"}}, validationErr.Fields)
+}
+
+// sseData returns the data of a single SSE event
+func sseData(event string) string {
+	for _, line := range strings.Split(event, "\n") {
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			return data
+		}
+	}
+	return ""
+}
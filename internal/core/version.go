@@ -0,0 +1,91 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/pkg/mcp"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// protocolVersion returns the protocol version negotiated for the session. Sessions
+// created before the version was recorded are treated as 2025-03-26, the version the
+// specification assumes for clients that do not send the MCP-Protocol-Version header.
+func protocolVersion(conn session.Connection) string {
+	if version := conn.Meta().ProtocolVersion; version != "" {
+		return version
+	}
+	return mcp.ProtocolVersion20250326
+}
+
+// negotiateProtocolVersion picks the protocol version answered to an initialize request
+// and records it in the session metadata
+func (s *Server) negotiateProtocolVersion(c *gin.Context, conn session.Connection, requested string) string {
+	version := mcp.NegotiateProtocolVersion(requested)
+
+	meta := *conn.Meta()
+	meta.ProtocolVersion = version
+	if err := s.sessions.Update(c.Request.Context(), &meta); err != nil {
+		s.getLogger(c).Warn("failed to store negotiated protocol version",
+			zap.String("session_id", meta.ID),
+			zap.String("protocol_version", version),
+			zap.Error(err))
+	}
+	if requested != version {
+		s.getLogger(c).Info("client requested an unsupported protocol version",
+			zap.String("session_id", meta.ID),
+			zap.String("requested", requested),
+			zap.String("protocol_version", version))
+	}
+	return version
+}
+
+// checkProtocolVersionHeader rejects streamable HTTP requests whose MCP-Protocol-Version
+// header names an unsupported version or one other than negotiated for the session.
+// Clients before 2025-06-18 do not send the header, requests without it are accepted.
+func (s *Server) checkProtocolVersionHeader(c *gin.Context, conn session.Connection) bool {
+	header := c.GetHeader(mcp.HeaderMcpProtocolVersion)
+	if header == "" {
+		return true
+	}
+	negotiated := conn.Meta().ProtocolVersion
+	if mcp.IsSupportedProtocolVersion(header) && (negotiated == "" || header == negotiated) {
+		return true
+	}
+	s.sendProtocolError(c, nil, fmt.Sprintf("Bad Request: unsupported protocol version %q", header),
+		http.StatusBadRequest, mcp.ErrorCodeInvalidRequest)
+	return false
+}
+
+// toolsForVersion returns the tools as described to clients of the given protocol version,
+// dropping the fields introduced in 2025-06-18 for older clients
+func toolsForVersion(tools []mcp.ToolSchema, version string) []mcp.ToolSchema {
+	current := mcp.ProtocolVersionAtLeast(version, mcp.ProtocolVersion20250618)
+	result := make([]mcp.ToolSchema, len(tools))
+	for i, tool := range tools {
+		if !current {
+			tool.Title = ""
+			tool.Icons = nil
+			tool.OutputSchema = nil
+		}
+		result[i] = tool
+	}
+	return result
+}
+
+// promptsForVersion returns the prompts as described to clients of the given protocol
+// version, dropping the fields introduced in 2025-06-18 for older clients
+func promptsForVersion(prompts []mcp.PromptSchema, version string) []mcp.PromptSchema {
+	current := mcp.ProtocolVersionAtLeast(version, mcp.ProtocolVersion20250618)
+	result := make([]mcp.PromptSchema, len(prompts))
+	for i, prompt := range prompts {
+		if !current {
+			prompt.Title = ""
+			prompt.Icons = nil
+		}
+		result[i] = prompt
+	}
+	return result
+}
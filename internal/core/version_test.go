@@ -0,0 +1,145 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/amoylab/unla/internal/mcp/session"
+	"github.com/amoylab/unla/pkg/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newVersionTestServer(t *testing.T) *Server {
+	t.Helper()
+	s := newRateLimitTestServer(t, "http://127.0.0.1")
+	s.rateLimiter = nil
+	s.sessions = session.NewMemoryStore(zap.NewNop())
+	return s
+}
+
+func postMCP(s *Server, sessionID string, header map[string]string, body string) *httptest.ResponseRecorder {
+	c, w := newGin()
+	c.Request = httptest.NewRequest(http.MethodPost, "/rl/mcp", bytes.NewBufferString(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set("Accept", "application/json, text/event-stream")
+	if sessionID != "" {
+		c.Request.Header.Set(mcp.HeaderMcpSessionID, sessionID)
+	}
+	for k, v := range header {
+		c.Request.Header.Set(k, v)
+	}
+	s.handlePost(c)
+	return w
+}
+
+func initializeSession(t *testing.T, s *Server, version string) (string, mcp.InitializedResult) {
+	t.Helper()
+	w := postMCP(s, "", nil, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"`+version+`"}}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Result mcp.InitializedResult `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(sseData(w.Body.String())), &resp))
+	return w.Header().Get(mcp.HeaderMcpSessionID), resp.Result
+}
+
+func TestInitialize_NegotiatesProtocolVersion(t *testing.T) {
+	s := newVersionTestServer(t)
+
+	for requested, expected := range map[string]string{
+		mcp.ProtocolVersion20250618: mcp.ProtocolVersion20250618,
+		mcp.ProtocolVersion20241105: mcp.ProtocolVersion20241105,
+		"1999-01-01":                mcp.LatestProtocolVersion,
+	} {
+		sessionID, result := initializeSession(t, s, requested)
+		assert.Equal(t, expected, result.ProtocolVersion)
+
+		conn, err := s.sessions.Get(context.Background(), sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, expected, conn.Meta().ProtocolVersion)
+	}
+}
+
+func TestHandlePost_ProtocolVersionHeader(t *testing.T) {
+	s := newVersionTestServer(t)
+	sessionID, _ := initializeSession(t, s, mcp.ProtocolVersion20250618)
+	ping := `{"jsonrpc":"2.0","id":2,"method":"ping"}`
+
+	w := postMCP(s, sessionID, map[string]string{mcp.HeaderMcpProtocolVersion: mcp.ProtocolVersion20250618}, ping)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// clients that do not send the header are accepted
+	w = postMCP(s, sessionID, nil, ping)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = postMCP(s, sessionID, map[string]string{mcp.HeaderMcpProtocolVersion: mcp.ProtocolVersion20250326}, ping)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = postMCP(s, sessionID, map[string]string{mcp.HeaderMcpProtocolVersion: "1999-01-01"}, ping)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandlePost_Batch(t *testing.T) {
+	s := newVersionTestServer(t)
+	batch := `[{"jsonrpc":"2.0","id":2,"method":"ping"},{"jsonrpc":"2.0","method":"notifications/initialized"},{"jsonrpc":"2.0","id":3,"method":"nope"}]`
+
+	sessionID, _ := initializeSession(t, s, mcp.ProtocolVersion20250326)
+	w := postMCP(s, sessionID, nil, batch)
+	require.Equal(t, http.StatusOK, w.Code)
+	var responses []map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &responses))
+	require.Len(t, responses, 2)
+	assert.Equal(t, 2.0, responses[0]["id"])
+	assert.Equal(t, map[string]any{}, responses[0]["result"])
+	assert.Equal(t, 3.0, responses[1]["id"])
+	assert.Equal(t, float64(mcp.ErrorCodeMethodNotFound), responses[1]["error"].(map[string]any)["code"])
+
+	// batching was removed in 2025-06-18
+	sessionID, _ = initializeSession(t, s, mcp.ProtocolVersion20250618)
+	w = postMCP(s, sessionID, nil, batch)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var errResp mcp.JSONRPCErrorSchema
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+	assert.Equal(t, mcp.ErrorCodeInvalidRequest, errResp.Error.Code)
+}
+
+func TestHandlePostMessage_Batch(t *testing.T) {
+	s := newVersionTestServer(t)
+	conn := &fakeConn{meta: &session.Meta{ID: "sid", Prefix: "/rl", ProtocolVersion: mcp.ProtocolVersion20241105}}
+
+	c, w := newGin()
+	c.Request = httptest.NewRequest(http.MethodPost, "/rl/message?sessionId=sid",
+		bytes.NewBufferString(`[{"jsonrpc":"2.0","id":1,"method":"ping"},{"jsonrpc":"2.0","id":2,"method":"ping"}]`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	s.handlePostMessage(c, conn)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	require.Len(t, conn.sent, 1)
+	var responses []mcp.JSONRPCResponse
+	require.NoError(t, json.Unmarshal(conn.sent[0].Data, &responses))
+	assert.Len(t, responses, 2)
+}
+
+func TestToolsForVersion(t *testing.T) {
+	tools := []mcp.ToolSchema{{
+		Name:         "weather",
+		Title:        "Weather",
+		Icons:        []mcp.Icon{{Src: "https://example.com/w.png"}},
+		OutputSchema: &mcp.ToolOutputSchema{Type: "object"},
+	}}
+
+	assert.Equal(t, tools, toolsForVersion(tools, mcp.ProtocolVersion20250618))
+	assert.Equal(t, []mcp.ToolSchema{{Name: "weather"}}, toolsForVersion(tools, mcp.ProtocolVersion20250326))
+	assert.Equal(t, "Weather", tools[0].Title)
+	assert.Equal(t, []mcp.ToolSchema{}, toolsForVersion(nil, mcp.ProtocolVersion20250618))
+
+	prompts := []mcp.PromptSchema{{Name: "greet", Title: "Greet", Icons: []mcp.Icon{{Src: "data:image/png;base64,"}}}}
+	assert.Equal(t, prompts, promptsForVersion(prompts, mcp.ProtocolVersion20250618))
+	assert.Equal(t, []mcp.PromptSchema{{Name: "greet"}}, promptsForVersion(prompts, mcp.ProtocolVersion20241105))
+}
@@ -0,0 +1,83 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/common/config"
+)
+
+// ErrEventNotFound is returned when the event to resume from is unknown or no longer kept
+var ErrEventNotFound = fmt.Errorf("event not found")
+
+// defaultEventTTL is how long events are kept when the configuration does not say
+const defaultEventTTL = 10 * time.Minute
+
+// StoredEvent is a message of a session stream kept for replay
+type StoredEvent struct {
+	ID   string // Event id, "<stream id>/<position>"
+	Data []byte // Payload
+}
+
+// EventStore keeps the recent messages sent on the streams of each session, so a
+// client reconnecting with Last-Event-ID receives the messages it missed. Event ids
+// are unique within a session and name the stream they were sent on.
+type EventStore interface {
+	// Append stores a message sent on a stream of the session and returns its event id.
+	Append(ctx context.Context, sessionID, streamID string, data []byte) (string, error)
+
+	// Replay returns the messages of the stream of lastEventID sent after it. It returns
+	// ErrEventNotFound when the event is unknown or was already evicted.
+	Replay(ctx context.Context, sessionID, lastEventID string) ([]*StoredEvent, error)
+
+	// Delete drops the messages of the session.
+	Delete(ctx context.Context, sessionID string) error
+}
+
+// NewEventStore creates the event store of the session store type. It returns nil when
+// resumption is disabled.
+func NewEventStore(logger *zap.Logger, cfg *config.SessionConfig) (EventStore, error) {
+	if cfg.Events.MaxEvents <= 0 {
+		return nil, nil
+	}
+	ttl := cfg.Events.TTL
+	if ttl <= 0 {
+		ttl = defaultEventTTL
+	}
+	switch Type(cfg.Type) {
+	case TypeMemory:
+		return NewMemoryEventStore(cfg.Events.MaxEvents, ttl), nil
+	case TypeRedis:
+		store, err := NewRedisEventStore(logger, cfg.Redis, cfg.Events.MaxEvents, ttl)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unsupported session store type: %s", cfg.Type)
+	}
+}
+
+// StreamOf returns the id of the stream an event was sent on
+func StreamOf(eventID string) string {
+	streamID, _, _ := splitEventID(eventID)
+	return streamID
+}
+
+func eventID(streamID, position string) string {
+	return streamID + "/" + position
+}
+
+// splitEventID splits an event id into its stream id and position. Stream ids may
+// contain "/", positions do not.
+func splitEventID(eventID string) (streamID, position string, ok bool) {
+	i := strings.LastIndex(eventID, "/")
+	if i <= 0 || i == len(eventID)-1 {
+		return "", "", false
+	}
+	return eventID[:i], eventID[i+1:], true
+}
@@ -0,0 +1,109 @@
+package session
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MemoryEventStore implements EventStore with a bounded buffer per session
+type MemoryEventStore struct {
+	mu        sync.Mutex
+	maxEvents int
+	ttl       time.Duration
+	sessions  map[string]*memoryEvents
+	lastSweep time.Time
+}
+
+type memoryEvents struct {
+	events  []*StoredEvent
+	seq     uint64
+	updated time.Time
+}
+
+var _ EventStore = (*MemoryEventStore)(nil)
+
+// NewMemoryEventStore creates an event store keeping the last maxEvents messages of
+// each session, for ttl after the last one
+func NewMemoryEventStore(maxEvents int, ttl time.Duration) *MemoryEventStore {
+	return &MemoryEventStore{
+		maxEvents: maxEvents,
+		ttl:       ttl,
+		sessions:  make(map[string]*memoryEvents),
+		lastSweep: time.Now(),
+	}
+}
+
+// Append implements EventStore.Append
+func (s *MemoryEventStore) Append(_ context.Context, sessionID, streamID string, data []byte) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	buf, ok := s.sessions[sessionID]
+	if !ok {
+		buf = &memoryEvents{}
+		s.sessions[sessionID] = buf
+	}
+	buf.seq++
+	event := &StoredEvent{ID: eventID(streamID, strconv.FormatUint(buf.seq, 10)), Data: data}
+	buf.events = append(buf.events, event)
+	if len(buf.events) > s.maxEvents {
+		buf.events = append(buf.events[:0:0], buf.events[len(buf.events)-s.maxEvents:]...)
+	}
+	buf.updated = now
+	return event.ID, nil
+}
+
+// Replay implements EventStore.Replay
+func (s *MemoryEventStore) Replay(_ context.Context, sessionID, lastEventID string) ([]*StoredEvent, error) {
+	streamID, _, ok := splitEventID(lastEventID)
+	if !ok {
+		return nil, ErrEventNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buf, ok := s.sessions[sessionID]
+	if !ok || time.Since(buf.updated) > s.ttl {
+		return nil, ErrEventNotFound
+	}
+	for i, event := range buf.events {
+		if event.ID != lastEventID {
+			continue
+		}
+		var events []*StoredEvent
+		for _, next := range buf.events[i+1:] {
+			if StreamOf(next.ID) == streamID {
+				events = append(events, next)
+			}
+		}
+		return events, nil
+	}
+	return nil, ErrEventNotFound
+}
+
+// Delete implements EventStore.Delete
+func (s *MemoryEventStore) Delete(_ context.Context, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, sessionID)
+	return nil
+}
+
+// sweep drops the sessions without messages for longer than the ttl, at most once per ttl
+func (s *MemoryEventStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+	s.lastSweep = now
+	for id, buf := range s.sessions {
+		if now.Sub(buf.updated) > s.ttl {
+			delete(s.sessions, id)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/common/config"
+)
+
+// RedisEventStore implements EventStore with a capped Redis stream per session, so a
+// client can resume on any gateway instance
+type RedisEventStore struct {
+	logger    *zap.Logger
+	client    redis.UniversalClient
+	prefix    string
+	maxEvents int
+	ttl       time.Duration
+}
+
+var _ EventStore = (*RedisEventStore)(nil)
+
+// NewRedisEventStore creates a Redis event store keeping the last maxEvents messages of
+// each session, for ttl after the last one
+func NewRedisEventStore(logger *zap.Logger, cfg config.SessionRedisConfig, maxEvents int, ttl time.Duration) (*RedisEventStore, error) {
+	client, err := newRedisClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisEventStore{
+		logger:    logger.Named("session.events.redis"),
+		client:    client,
+		prefix:    redisPrefix(cfg) + "events:",
+		maxEvents: maxEvents,
+		ttl:       ttl,
+	}, nil
+}
+
+// Append implements EventStore.Append
+func (s *RedisEventStore) Append(ctx context.Context, sessionID, streamID string, data []byte) (string, error) {
+	key := s.prefix + sessionID
+	id, err := s.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: key,
+		MaxLen: int64(s.maxEvents),
+		Approx: true,
+		Values: map[string]any{"stream": streamID, "data": data},
+	}).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to store event: %w", err)
+	}
+	if err := s.client.Expire(ctx, key, s.ttl).Err(); err != nil {
+		s.logger.Warn("failed to set event ttl",
+			zap.String("session_id", sessionID),
+			zap.Error(err))
+	}
+	return eventID(streamID, id), nil
+}
+
+// Replay implements EventStore.Replay
+func (s *RedisEventStore) Replay(ctx context.Context, sessionID, lastEventID string) ([]*StoredEvent, error) {
+	streamID, position, ok := splitEventID(lastEventID)
+	if !ok || !isStreamEntryID(position) {
+		return nil, ErrEventNotFound
+	}
+
+	// The range starts at the last event itself to tell whether it is still kept
+	messages, err := s.client.XRange(ctx, s.prefix+sessionID, position, "+").Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read events: %w", err)
+	}
+	if len(messages) == 0 || messages[0].ID != position {
+		return nil, ErrEventNotFound
+	}
+
+	var events []*StoredEvent
+	for _, message := range messages[1:] {
+		if stream, _ := message.Values["stream"].(string); stream != streamID {
+			continue
+		}
+		data, _ := message.Values["data"].(string)
+		events = append(events, &StoredEvent{ID: eventID(streamID, message.ID), Data: []byte(data)})
+	}
+	return events, nil
+}
+
+// Delete implements EventStore.Delete
+func (s *RedisEventStore) Delete(ctx context.Context, sessionID string) error {
+	return s.client.Del(ctx, s.prefix+sessionID).Err()
+}
+
+// Close closes the Redis connection
+func (s *RedisEventStore) Close() error {
+	return s.client.Close()
+}
+
+// isStreamEntryID reports whether id is a Redis stream entry id, "<ms>-<seq>"
+func isStreamEntryID(id string) bool {
+	ms, seq, ok := strings.Cut(id, "-")
+	if !ok {
+		return false
+	}
+	_, err1 := strconv.ParseUint(ms, 10, 64)
+	_, err2 := strconv.ParseUint(seq, 10, 64)
+	return err1 == nil && err2 == nil
+}
@@ -0,0 +1,100 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	miniredis "github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/amoylab/unla/internal/common/cnst"
+	"github.com/amoylab/unla/internal/common/config"
+)
+
+// testEventStore checks the behaviour shared by the event store implementations
+func testEventStore(t *testing.T, s EventStore, maxEvents int) {
+	t.Helper()
+	ctx := context.Background()
+
+	first, err := s.Append(ctx, "sid", "get", []byte("a"))
+	require.NoError(t, err)
+	assert.Equal(t, "get", StreamOf(first))
+	post, err := s.Append(ctx, "sid", "post/1", []byte("b"))
+	require.NoError(t, err)
+	assert.Equal(t, "post/1", StreamOf(post))
+	_, err = s.Append(ctx, "sid", "get", []byte("c"))
+	require.NoError(t, err)
+	_, err = s.Append(ctx, "other", "get", []byte("x"))
+	require.NoError(t, err)
+
+	// only the messages of the stream of the last event are replayed
+	events, err := s.Replay(ctx, "sid", first)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, []byte("c"), events[0].Data)
+	assert.Equal(t, "get", StreamOf(events[0].ID))
+
+	events, err = s.Replay(ctx, "sid", post)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	_, err = s.Replay(ctx, "sid", "get/unknown")
+	assert.ErrorIs(t, err, ErrEventNotFound)
+	_, err = s.Replay(ctx, "sid", "garbage")
+	assert.ErrorIs(t, err, ErrEventNotFound)
+
+	// the oldest events are evicted past the bound
+	for i := 0; i < maxEvents; i++ {
+		_, err = s.Append(ctx, "sid", "get", []byte("d"))
+		require.NoError(t, err)
+	}
+	_, err = s.Replay(ctx, "sid", first)
+	assert.ErrorIs(t, err, ErrEventNotFound)
+
+	require.NoError(t, s.Delete(ctx, "sid"))
+	_, err = s.Replay(ctx, "sid", post)
+	assert.ErrorIs(t, err, ErrEventNotFound)
+}
+
+func TestMemoryEventStore(t *testing.T) {
+	testEventStore(t, NewMemoryEventStore(4, time.Minute), 4)
+}
+
+func TestMemoryEventStore_TTL(t *testing.T) {
+	s := NewMemoryEventStore(4, time.Millisecond)
+	id, err := s.Append(context.Background(), "sid", "get", []byte("a"))
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	_, err = s.Replay(context.Background(), "sid", id)
+	assert.ErrorIs(t, err, ErrEventNotFound)
+}
+
+func TestRedisEventStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cfg := config.SessionRedisConfig{ClusterType: cnst.RedisClusterTypeSingle, Addr: mr.Addr(), Prefix: "testsess"}
+	s, err := NewRedisEventStore(zap.NewNop(), cfg, 4, time.Minute)
+	require.NoError(t, err)
+	defer func() { _ = s.Close() }()
+
+	testEventStore(t, s, 4)
+
+	_, err = s.Append(context.Background(), "sid", "get", []byte("a"))
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, mr.TTL("testsess:events:sid"))
+}
+
+func TestNewEventStore(t *testing.T) {
+	s, err := NewEventStore(zap.NewNop(), &config.SessionConfig{Type: "memory"})
+	assert.NoError(t, err)
+	assert.Nil(t, s)
+
+	s, err = NewEventStore(zap.NewNop(), &config.SessionConfig{Type: "memory", Events: config.SessionEventsConfig{MaxEvents: 10}})
+	assert.NoError(t, err)
+	assert.IsType(t, &MemoryEventStore{}, s)
+
+	_, err = NewEventStore(zap.NewNop(), &config.SessionConfig{Type: "etcd", Events: config.SessionEventsConfig{MaxEvents: 10}})
+	assert.Error(t, err)
+}
@@ -24,9 +24,21 @@ func NewStore(ctx context.Context, logger *zap.Logger, cfg *config.SessionConfig
 	logger.Info("Initializing session store", zap.String("type", cfg.Type))
 	switch Type(cfg.Type) {
 	case TypeMemory:
-		return NewMemoryStore(logger), nil
+		store := NewMemoryStore(logger)
+		store.idleTimeout = cfg.IdleTimeout
+		go runJanitor(ctx, janitorInterval(cfg.IdleTimeout), store.sweep)
+		return store, nil
 	case TypeRedis:
-		return NewRedisStore(ctx, logger, cfg.Redis)
+		store, err := NewRedisStore(ctx, logger, cfg.Redis)
+		if err != nil {
+			return nil, err
+		}
+		// Redis expires sessions without activity for the TTL, the idle timeout may shorten it
+		if cfg.IdleTimeout > 0 && (store.ttl <= 0 || cfg.IdleTimeout < store.ttl) {
+			store.ttl = cfg.IdleTimeout
+		}
+		go runJanitor(ctx, janitorInterval(cfg.IdleTimeout), store.sweep)
+		return store, nil
 	default:
 		return nil, fmt.Errorf("unsupported session store type: %s", cfg.Type)
 	}
@@ -0,0 +1,36 @@
+package session
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// defaultJanitorInterval is how often the janitor sweeps without an idle timeout
+	defaultJanitorInterval = time.Minute
+	// minJanitorInterval bounds the sweeps of short idle timeouts
+	minJanitorInterval = time.Second
+)
+
+// janitorInterval is how often the janitor sweeps, often enough to reap idle sessions
+// soon after the idle timeout
+func janitorInterval(idleTimeout time.Duration) time.Duration {
+	if idleTimeout <= 0 {
+		return defaultJanitorInterval
+	}
+	return min(max(idleTimeout/2, minJanitorInterval), defaultJanitorInterval)
+}
+
+// runJanitor calls sweep at every interval until the context is done
+func runJanitor(ctx context.Context, interval time.Duration, sweep func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sweep(ctx)
+		}
+	}
+}
@@ -4,15 +4,25 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 // MemoryStore implements Store using in-memory storage
 type MemoryStore struct {
-	logger *zap.Logger
-	mu     sync.RWMutex
-	conns  map[string]Connection
+	logger   *zap.Logger
+	mu       sync.RWMutex
+	conns    map[string]Connection
+	onCancel CancelHandler
+	onSweep  SweepHandler
+	// onResourceUnsubscribe is called for every published resource unsubscription
+	onResourceUnsubscribe ResourceUnsubscribeHandler
+	// onUnregister is called after a session was unregistered
+	onUnregister UnregisterHandler
+	// idleTimeout is how long a session may go without activity before it is reaped, 0 keeps them
+	idleTimeout time.Duration
 }
 
 var _ Store = (*MemoryStore)(nil)
@@ -40,6 +50,7 @@ func (s *MemoryStore) Register(_ context.Context, meta *Meta) (Connection, error
 		meta:  meta,
 		queue: make(chan *Message, 100),
 	}
+	conn.touch()
 
 	// Store connection
 	s.conns[meta.ID] = conn
@@ -56,16 +67,32 @@ func (s *MemoryStore) Get(_ context.Context, id string) (Connection, error) {
 	if !ok {
 		return nil, ErrSessionNotFound
 	}
+	conn.(*MemoryConnection).touch()
 	return conn, nil
 }
 
+// Update implements Store.Update
+func (s *MemoryStore) Update(_ context.Context, meta *Meta) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	conn, ok := s.conns[meta.ID]
+	if !ok {
+		return ErrSessionNotFound
+	}
+	memConn := conn.(*MemoryConnection)
+	memConn.mu.Lock()
+	memConn.meta = meta
+	memConn.mu.Unlock()
+	return nil
+}
+
 // Unregister implements Store.Unregister
 func (s *MemoryStore) Unregister(_ context.Context, id string) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	conn, ok := s.conns[id]
 	if !ok {
+		s.mu.Unlock()
 		return ErrSessionNotFound
 	}
 
@@ -78,6 +105,12 @@ func (s *MemoryStore) Unregister(_ context.Context, id string) error {
 
 	// Remove connection
 	delete(s.conns, id)
+	handler := s.onUnregister
+	s.mu.Unlock()
+
+	if handler != nil {
+		handler(id)
+	}
 	return nil
 }
 
@@ -93,10 +126,117 @@ func (s *MemoryStore) List(_ context.Context) ([]Connection, error) {
 	return conns, nil
 }
 
+// ListLocal implements Store.ListLocal, every session of a memory store is local
+func (s *MemoryStore) ListLocal(ctx context.Context) ([]Connection, error) {
+	return s.List(ctx)
+}
+
+// PublishCancel implements Store.PublishCancel
+func (s *MemoryStore) PublishCancel(_ context.Context, cancellation *Cancellation) error {
+	s.mu.RLock()
+	handler := s.onCancel
+	s.mu.RUnlock()
+	if handler != nil {
+		handler(cancellation)
+	}
+	return nil
+}
+
+// OnCancel implements Store.OnCancel
+func (s *MemoryStore) OnCancel(handler CancelHandler) {
+	s.mu.Lock()
+	s.onCancel = handler
+	s.mu.Unlock()
+}
+
+// PublishResourceUnsubscribe implements Store.PublishResourceUnsubscribe
+func (s *MemoryStore) PublishResourceUnsubscribe(_ context.Context, unsubscription *ResourceUnsubscription) error {
+	s.mu.RLock()
+	handler := s.onResourceUnsubscribe
+	s.mu.RUnlock()
+	if handler != nil {
+		handler(unsubscription)
+	}
+	return nil
+}
+
+// OnResourceUnsubscribe implements Store.OnResourceUnsubscribe
+func (s *MemoryStore) OnResourceUnsubscribe(handler ResourceUnsubscribeHandler) {
+	s.mu.Lock()
+	s.onResourceUnsubscribe = handler
+	s.mu.Unlock()
+}
+
+// Touch implements Store.Touch
+func (s *MemoryStore) Touch(_ context.Context, id string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	conn, ok := s.conns[id]
+	if !ok {
+		return ErrSessionNotFound
+	}
+	conn.(*MemoryConnection).touch()
+	return nil
+}
+
+// OnSweep implements Store.OnSweep
+func (s *MemoryStore) OnSweep(handler SweepHandler) {
+	s.mu.Lock()
+	s.onSweep = handler
+	s.mu.Unlock()
+}
+
+// OnUnregister implements Store.OnUnregister
+func (s *MemoryStore) OnUnregister(handler UnregisterHandler) {
+	s.mu.Lock()
+	s.onUnregister = handler
+	s.mu.Unlock()
+}
+
+// sweep reaps the sessions idle for longer than the idle timeout
+func (s *MemoryStore) sweep(ctx context.Context) {
+	now := time.Now()
+	var reaped []*Meta
+
+	s.mu.Lock()
+	if s.idleTimeout > 0 {
+		for id, conn := range s.conns {
+			memConn := conn.(*MemoryConnection)
+			if now.Sub(memConn.lastActiveAt()) <= s.idleTimeout {
+				continue
+			}
+			// Closing the queue ends the event stream of the session, if any is still open
+			if err := conn.Close(ctx); err != nil {
+				s.logger.Error("failed to close connection",
+					zap.String("id", id),
+					zap.Error(err))
+			}
+			delete(s.conns, id)
+			reaped = append(reaped, memConn.Meta())
+		}
+	}
+	active := len(s.conns)
+	handler := s.onSweep
+	s.mu.Unlock()
+
+	for _, meta := range reaped {
+		s.logger.Info("reaped idle session",
+			zap.String("id", meta.ID),
+			zap.String("type", meta.Type))
+	}
+	if handler != nil {
+		handler(active, reaped)
+	}
+}
+
 // MemoryConnection implements Connection using in-memory storage
 type MemoryConnection struct {
+	mu    sync.RWMutex
 	meta  *Meta
 	queue chan *Message
+	// lastActive is the unix nano time of the last activity of the session
+	lastActive atomic.Int64
 }
 
 var _ Connection = (*MemoryConnection)(nil)
@@ -124,8 +264,18 @@ func (c *MemoryConnection) Close(_ context.Context) error {
 
 // Meta implements Connection.Meta
 func (c *MemoryConnection) Meta() *Meta {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.meta
 }
 
+func (c *MemoryConnection) touch() {
+	c.lastActive.Store(time.Now().UnixNano())
+}
+
+func (c *MemoryConnection) lastActiveAt() time.Time {
+	return time.Unix(0, c.lastActive.Load())
+}
+
 // ErrSessionNotFound is returned when a session is not found
 var ErrSessionNotFound = fmt.Errorf("session not found")
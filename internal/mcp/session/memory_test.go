@@ -3,14 +3,18 @@ package session
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
 func TestMemoryStore_RegisterGetListUnregister(t *testing.T) {
 	s := NewMemoryStore(zap.NewNop())
 	meta := &Meta{ID: "sid"}
+	var unregistered []string
+	s.OnUnregister(func(id string) { unregistered = append(unregistered, id) })
 
 	// register
 	conn, err := s.Register(context.Background(), meta)
@@ -40,6 +44,7 @@ func TestMemoryStore_RegisterGetListUnregister(t *testing.T) {
 
 	// unregister unknown id
 	assert.ErrorIs(t, s.Unregister(context.Background(), "nope"), ErrSessionNotFound)
+	assert.Equal(t, []string{"sid"}, unregistered)
 }
 
 func TestMemoryConnection_SendQueueFull(t *testing.T) {
@@ -49,3 +54,49 @@ func TestMemoryConnection_SendQueueFull(t *testing.T) {
 	// now should be full
 	assert.Error(t, c.Send(context.Background(), &Message{Event: "e3"}))
 }
+
+func TestMemoryStore_Update(t *testing.T) {
+	s := NewMemoryStore(zap.NewNop())
+	conn, err := s.Register(context.Background(), &Meta{ID: "sid"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Update(context.Background(), &Meta{ID: "sid", ProtocolVersion: "2025-06-18"}))
+	assert.Equal(t, "2025-06-18", conn.Meta().ProtocolVersion)
+
+	assert.ErrorIs(t, s.Update(context.Background(), &Meta{ID: "nope"}), ErrSessionNotFound)
+}
+
+func TestMemoryStore_SweepReapsIdleSessions(t *testing.T) {
+	s := NewMemoryStore(zap.NewNop())
+	s.idleTimeout = 50 * time.Millisecond
+	var (
+		active int
+		reaped []*Meta
+	)
+	s.OnSweep(func(n int, metas []*Meta) { active, reaped = n, metas })
+
+	idle, err := s.Register(context.Background(), &Meta{ID: "idle", Type: "sse"})
+	require.NoError(t, err)
+	_, err = s.Register(context.Background(), &Meta{ID: "busy"})
+	require.NoError(t, err)
+
+	time.Sleep(60 * time.Millisecond)
+	require.NoError(t, s.Touch(context.Background(), "busy"))
+	s.sweep(context.Background())
+
+	assert.Equal(t, 1, active)
+	require.Len(t, reaped, 1)
+	assert.Equal(t, "idle", reaped[0].ID)
+	_, open := <-idle.EventQueue()
+	assert.False(t, open)
+	_, err = s.Get(context.Background(), "idle")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+	assert.ErrorIs(t, s.Touch(context.Background(), "idle"), ErrSessionNotFound)
+}
+
+func TestJanitorInterval(t *testing.T) {
+	assert.Equal(t, time.Minute, janitorInterval(0))
+	assert.Equal(t, time.Second, janitorInterval(100*time.Millisecond))
+	assert.Equal(t, 30*time.Second, janitorInterval(time.Minute))
+	assert.Equal(t, time.Minute, janitorInterval(time.Hour))
+}
@@ -27,12 +27,42 @@ type RedisStore struct {
 	mu          sync.RWMutex
 	ttl         time.Duration // TTL for session data
 	streamCtx   context.Context
+	onCancel    CancelHandler
+	onSweep     SweepHandler
+	// onResourceUnsubscribe is called for the unsubscriptions read from the stream, including our own
+	onResourceUnsubscribe ResourceUnsubscribeHandler
+	// onUnregister is called for the deletes read from the stream, including our own
+	onUnregister UnregisterHandler
 }
 
 var _ Store = (*RedisStore)(nil)
 
 // NewRedisStore creates a new Redis-based session store
 func NewRedisStore(ctx context.Context, logger *zap.Logger, cfg config.SessionRedisConfig) (*RedisStore, error) {
+	client, err := newRedisClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	prefix := redisPrefix(cfg)
+
+	store := &RedisStore{
+		logger:      logger.Named("session.store.redis"),
+		client:      client,
+		prefix:      prefix,
+		topic:       cfg.Topic,
+		connections: make(map[string]*RedisConnection),
+		ttl:         cfg.TTL,
+		streamCtx:   ctx,
+	}
+
+	// Start handling session updates via streams
+	go store.handleUpdates()
+
+	return store, nil
+}
+
+// newRedisClient connects to the Redis of the session configuration
+func newRedisClient(cfg config.SessionRedisConfig) (redis.UniversalClient, error) {
 	addrs := utils.SplitByMultipleDelimiters(cfg.Addr, ";", ",")
 	redisOptions := &redis.UniversalOptions{
 		Addrs:    addrs,
@@ -52,29 +82,16 @@ func NewRedisStore(ctx context.Context, logger *zap.Logger, cfg config.SessionRe
 	if err := client.Ping(context.Background()).Err(); err != nil {
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
+	return client, nil
+}
 
-	prefix := cfg.Prefix
-	if prefix == "" {
+// redisPrefix returns the prefix of the session keys
+func redisPrefix(cfg config.SessionRedisConfig) string {
+	if cfg.Prefix == "" {
 		// Adapt to historical versions
-		prefix = "session:"
-	} else {
-		prefix = prefix + ":"
+		return "session:"
 	}
-
-	store := &RedisStore{
-		logger:      logger.Named("session.store.redis"),
-		client:      client,
-		prefix:      prefix,
-		topic:       cfg.Topic,
-		connections: make(map[string]*RedisConnection),
-		ttl:         cfg.TTL,
-		streamCtx:   ctx,
-	}
-
-	// Start handling session updates via streams
-	go store.handleUpdates()
-
-	return store, nil
+	return cfg.Prefix + ":"
 }
 
 // handleUpdates handles session update notifications via streams
@@ -117,9 +134,11 @@ func (s *RedisStore) handleUpdates() {
 // processSessionMessage processes a single session stream message
 func (s *RedisStore) processSessionMessage(message redis.XMessage) {
 	var update struct {
-		Action  string   `json:"action"` // "create", "update", "delete", "event"
-		Meta    *Meta    `json:"meta"`
-		Message *Message `json:"message,omitempty"`
+		Action         string                  `json:"action"` // "create", "update", "delete", "event", "cancel", "unsubscribe"
+		Meta           *Meta                   `json:"meta"`
+		Message        *Message                `json:"message,omitempty"`
+		Cancellation   *Cancellation           `json:"cancellation,omitempty"`
+		Unsubscription *ResourceUnsubscription `json:"unsubscription,omitempty"`
 	}
 
 	if configData, exists := message.Values["config"]; exists {
@@ -140,19 +159,33 @@ func (s *RedisStore) processSessionMessage(message redis.XMessage) {
 			// Remove from local cache
 			s.logger.Debug("received session delete",
 				zap.String("id", update.Meta.ID))
+			s.mu.RLock()
+			handler := s.onUnregister
+			s.mu.RUnlock()
+			if handler != nil {
+				handler(update.Meta.ID)
+			}
 		case "event":
 			// Handle event and send to appropriate connection
+			// The queue is sent to under the lock, it is closed once the connection is dropped
 			s.mu.RLock()
 			conn, exists := s.connections[update.Meta.ID]
-			s.mu.RUnlock()
-
+			sent := false
 			if exists {
 				select {
 				case conn.queue <- update.Message:
+					sent = true
+				default:
+				}
+			}
+			s.mu.RUnlock()
+
+			if exists {
+				if sent {
 					s.logger.Info("sent message to connection queue",
 						zap.String("id", update.Meta.ID),
 						zap.String("event", update.Message.Event))
-				default:
+				} else {
 					s.logger.Warn("connection queue is full, dropping message",
 						zap.String("id", update.Meta.ID),
 						zap.String("event", update.Message.Event))
@@ -162,13 +195,27 @@ func (s *RedisStore) processSessionMessage(message redis.XMessage) {
 					zap.String("id", update.Meta.ID),
 					zap.String("event", update.Message.Event))
 			}
+		case "cancel":
+			s.mu.RLock()
+			handler := s.onCancel
+			s.mu.RUnlock()
+			if handler != nil && update.Cancellation != nil {
+				handler(update.Cancellation)
+			}
+		case "unsubscribe":
+			s.mu.RLock()
+			handler := s.onResourceUnsubscribe
+			s.mu.RUnlock()
+			if handler != nil && update.Unsubscription != nil {
+				handler(update.Unsubscription)
+			}
 		}
 	}
 }
 
 // publishUpdate publishes a session update to the stream
 func (s *RedisStore) publishUpdate(ctx context.Context, action string, meta *Meta, msg *Message) error {
-	update := struct {
+	return s.publish(ctx, struct {
 		Action  string   `json:"action"`
 		Meta    *Meta    `json:"meta"`
 		Message *Message `json:"message,omitempty"`
@@ -176,8 +223,10 @@ func (s *RedisStore) publishUpdate(ctx context.Context, action string, meta *Met
 		Action:  action,
 		Meta:    meta,
 		Message: msg,
-	}
+	})
+}
 
+func (s *RedisStore) publish(ctx context.Context, update any) error {
 	data, err := json.Marshal(update)
 	if err != nil {
 		return fmt.Errorf("failed to marshal session update: %w", err)
@@ -287,12 +336,36 @@ func (s *RedisStore) Get(ctx context.Context, id string) (Connection, error) {
 	}, nil
 }
 
+// Update implements Store.Update
+func (s *RedisStore) Update(ctx context.Context, meta *Meta) error {
+	exists, err := s.client.SIsMember(ctx, s.prefix+"ids", meta.ID).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check session ID: %w", err)
+	}
+	if !exists {
+		return ErrSessionNotFound
+	}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session metadata: %w", err)
+	}
+	if err := s.client.Set(ctx, s.prefix+meta.ID, data, s.ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store session metadata in Redis: %w", err)
+	}
+
+	s.mu.Lock()
+	if conn, ok := s.connections[meta.ID]; ok {
+		conn.setMeta(meta)
+	}
+	s.mu.Unlock()
+	return nil
+}
+
 // Unregister implements Store.Unregister
 func (s *RedisStore) Unregister(ctx context.Context, id string) error {
 	// Remove from active connections
-	s.mu.Lock()
-	delete(s.connections, id)
-	s.mu.Unlock()
+	s.dropLocal(id)
 
 	// Check if session ID is valid
 	exists, err := s.client.SIsMember(ctx, s.prefix+"ids", id).Result()
@@ -355,14 +428,181 @@ func (s *RedisStore) List(ctx context.Context) ([]Connection, error) {
 	return connections, nil
 }
 
+// ListLocal implements Store.ListLocal with the sessions registered on this instance
+// that are still valid
+func (s *RedisStore) ListLocal(ctx context.Context) ([]Connection, error) {
+	s.mu.RLock()
+	local := make([]*RedisConnection, 0, len(s.connections))
+	for _, conn := range s.connections {
+		local = append(local, conn)
+	}
+	s.mu.RUnlock()
+
+	connections := make([]Connection, 0, len(local))
+	for _, conn := range local {
+		exists, err := s.client.SIsMember(ctx, s.prefix+"ids", conn.Meta().ID).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to check session ID: %w", err)
+		}
+		if !exists {
+			// The session expired without being unregistered
+			s.dropLocal(conn.Meta().ID)
+			continue
+		}
+		connections = append(connections, conn)
+	}
+	return connections, nil
+}
+
+// PublishCancel implements Store.PublishCancel. Every instance reads the cancellation
+// from the stream, the handler of this instance is called by the stream reader as well.
+func (s *RedisStore) PublishCancel(ctx context.Context, cancellation *Cancellation) error {
+	return s.publish(ctx, struct {
+		Action       string        `json:"action"`
+		Meta         *Meta         `json:"meta"`
+		Cancellation *Cancellation `json:"cancellation"`
+	}{
+		Action:       "cancel",
+		Meta:         &Meta{ID: cancellation.SessionID},
+		Cancellation: cancellation,
+	})
+}
+
+// OnCancel implements Store.OnCancel
+func (s *RedisStore) OnCancel(handler CancelHandler) {
+	s.mu.Lock()
+	s.onCancel = handler
+	s.mu.Unlock()
+}
+
+// PublishResourceUnsubscribe implements Store.PublishResourceUnsubscribe. Every instance
+// reads the unsubscription from the stream, including this one.
+func (s *RedisStore) PublishResourceUnsubscribe(ctx context.Context, unsubscription *ResourceUnsubscription) error {
+	return s.publish(ctx, struct {
+		Action         string                  `json:"action"`
+		Meta           *Meta                   `json:"meta"`
+		Unsubscription *ResourceUnsubscription `json:"unsubscription"`
+	}{
+		Action:         "unsubscribe",
+		Meta:           &Meta{ID: unsubscription.SessionID},
+		Unsubscription: unsubscription,
+	})
+}
+
+// OnResourceUnsubscribe implements Store.OnResourceUnsubscribe
+func (s *RedisStore) OnResourceUnsubscribe(handler ResourceUnsubscribeHandler) {
+	s.mu.Lock()
+	s.onResourceUnsubscribe = handler
+	s.mu.Unlock()
+}
+
+// Touch implements Store.Touch by renewing the TTL of the session
+func (s *RedisStore) Touch(ctx context.Context, id string) error {
+	renewed, err := s.client.Expire(ctx, s.prefix+id, s.ttl).Result()
+	if err != nil {
+		return fmt.Errorf("failed to renew session TTL: %w", err)
+	}
+	if !renewed {
+		return ErrSessionNotFound
+	}
+	if err := s.client.Expire(ctx, s.prefix+"ids", s.ttl).Err(); err != nil {
+		s.logger.Warn("failed to renew session ID set TTL",
+			zap.String("id", id),
+			zap.Error(err))
+	}
+	return nil
+}
+
+// OnSweep implements Store.OnSweep
+func (s *RedisStore) OnSweep(handler SweepHandler) {
+	s.mu.Lock()
+	s.onSweep = handler
+	s.mu.Unlock()
+}
+
+// OnUnregister implements Store.OnUnregister
+func (s *RedisStore) OnUnregister(handler UnregisterHandler) {
+	s.mu.Lock()
+	s.onUnregister = handler
+	s.mu.Unlock()
+}
+
+// sweep drops the sessions Redis expired for being idle from the local connections and
+// from the session ID set
+func (s *RedisStore) sweep(ctx context.Context) {
+	s.mu.RLock()
+	local := make([]*RedisConnection, 0, len(s.connections))
+	for _, conn := range s.connections {
+		local = append(local, conn)
+	}
+	s.mu.RUnlock()
+
+	var reaped []*Meta
+	for _, conn := range local {
+		meta := conn.Meta()
+		n, err := s.client.Exists(ctx, s.prefix+meta.ID).Result()
+		if err != nil {
+			s.logger.Warn("failed to check session expiry",
+				zap.String("id", meta.ID),
+				zap.Error(err))
+			return
+		}
+		if n > 0 {
+			continue
+		}
+		s.dropLocal(meta.ID)
+		reaped = append(reaped, meta)
+		s.logger.Info("reaped idle session",
+			zap.String("id", meta.ID),
+			zap.String("type", meta.Type))
+	}
+
+	// Sessions of every instance expire, whoever sweeps first drops their IDs
+	ids, err := s.client.SMembers(ctx, s.prefix+"ids").Result()
+	if err != nil {
+		s.logger.Warn("failed to get session IDs", zap.Error(err))
+	}
+	for _, id := range ids {
+		n, err := s.client.Exists(ctx, s.prefix+id).Result()
+		if err != nil || n > 0 {
+			continue
+		}
+		if err := s.client.SRem(ctx, s.prefix+"ids", id).Err(); err != nil {
+			s.logger.Warn("failed to remove expired session ID",
+				zap.String("id", id),
+				zap.Error(err))
+		}
+	}
+
+	s.mu.RLock()
+	active := len(s.connections)
+	handler := s.onSweep
+	s.mu.RUnlock()
+	if handler != nil {
+		handler(active, reaped)
+	}
+}
+
 // Close closes the Redis store
 func (s *RedisStore) Close() error {
 	return s.client.Close()
 }
 
+// dropLocal forgets a connection registered on this instance. Closing its queue ends the
+// event stream of the session, if any is still open.
+func (s *RedisStore) dropLocal(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if conn, ok := s.connections[id]; ok {
+		delete(s.connections, id)
+		close(conn.queue)
+	}
+}
+
 // RedisConnection implements Connection using Redis
 type RedisConnection struct {
 	store *RedisStore
+	mu    sync.RWMutex
 	meta  *Meta
 	queue chan *Message
 }
@@ -376,28 +616,38 @@ func (c *RedisConnection) EventQueue() <-chan *Message {
 
 // Send implements Connection.Send
 func (c *RedisConnection) Send(ctx context.Context, msg *Message) error {
+	meta := c.Meta()
+
 	// Renew TTL for both the session data and the session ID in the set
-	key := c.store.prefix + c.meta.ID
+	key := c.store.prefix + meta.ID
 	if err := c.store.client.Expire(ctx, key, c.store.ttl).Err(); err != nil {
 		c.store.logger.Warn("failed to renew session TTL",
-			zap.String("id", c.meta.ID),
+			zap.String("id", meta.ID),
 			zap.Error(err))
 	}
 	if err := c.store.client.Expire(ctx, c.store.prefix+"ids", c.store.ttl).Err(); err != nil {
 		c.store.logger.Warn("failed to renew session ID set TTL",
-			zap.String("id", c.meta.ID),
+			zap.String("id", meta.ID),
 			zap.Error(err))
 	}
 
-	return c.store.publishUpdate(ctx, "event", c.meta, msg)
+	return c.store.publishUpdate(ctx, "event", meta, msg)
 }
 
 // Close implements Connection.Close
 func (c *RedisConnection) Close(ctx context.Context) error {
-	return c.store.Unregister(ctx, c.meta.ID)
+	return c.store.Unregister(ctx, c.Meta().ID)
 }
 
 // Meta implements Connection.Meta
 func (c *RedisConnection) Meta() *Meta {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.meta
 }
+
+func (c *RedisConnection) setMeta(meta *Meta) {
+	c.mu.Lock()
+	c.meta = meta
+	c.mu.Unlock()
+}
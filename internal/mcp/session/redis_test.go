@@ -9,6 +9,7 @@ import (
 	"github.com/amoylab/unla/internal/common/cnst"
 	"github.com/amoylab/unla/internal/common/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -96,3 +97,216 @@ func TestRedisStore_RegisterGetListSendUnregister(t *testing.T) {
 	// Unregister unknown
 	assert.ErrorIs(t, store.Unregister(ctx, "nope"), ErrSessionNotFound)
 }
+
+func TestRedisStore_Update(t *testing.T) {
+	store, mr, cancel := newTestRedisStore(t)
+	defer func() {
+		cancel()
+		_ = store.Close()
+		mr.Close()
+	}()
+
+	ctx := context.Background()
+	conn, err := store.Register(ctx, &Meta{ID: "sid", Prefix: "/p"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, store.Update(ctx, &Meta{ID: "sid", Prefix: "/p", ProtocolVersion: "2025-06-18"}))
+	assert.Equal(t, "2025-06-18", conn.Meta().ProtocolVersion)
+
+	got, err := store.Get(ctx, "sid")
+	assert.NoError(t, err)
+	assert.Equal(t, "2025-06-18", got.Meta().ProtocolVersion)
+
+	assert.ErrorIs(t, store.Update(ctx, &Meta{ID: "nope"}), ErrSessionNotFound)
+}
+
+func TestRedisStore_ListLocal(t *testing.T) {
+	store, mr, cancel := newTestRedisStore(t)
+	defer func() {
+		cancel()
+		_ = store.Close()
+		mr.Close()
+	}()
+
+	// a second replica sharing the same Redis
+	ctx, cancelOther := context.WithCancel(context.Background())
+	defer cancelOther()
+	other, err := NewRedisStore(ctx, zap.NewNop(), config.SessionRedisConfig{
+		ClusterType: cnst.RedisClusterTypeSingle,
+		Addr:        mr.Addr(),
+		Topic:       "unla:sessions",
+		Prefix:      "testsess",
+		TTL:         5 * time.Second,
+	})
+	assert.NoError(t, err)
+	defer other.Close()
+
+	_, err = store.Register(ctx, &Meta{ID: "local", Prefix: "/p"})
+	assert.NoError(t, err)
+	_, err = store.Register(ctx, &Meta{ID: "expired", Prefix: "/p"})
+	assert.NoError(t, err)
+	_, err = other.Register(ctx, &Meta{ID: "remote", Prefix: "/p"})
+	assert.NoError(t, err)
+	_, err = mr.SRem("testsess:ids", "expired")
+	assert.NoError(t, err)
+
+	all, err := store.List(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	local, err := store.ListLocal(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, local, 1) {
+		assert.Equal(t, "local", local[0].Meta().ID)
+	}
+}
+
+func TestRedisStore_PublishCancel(t *testing.T) {
+	store, mr, cancel := newTestRedisStore(t)
+	defer func() {
+		cancel()
+		_ = store.Close()
+		mr.Close()
+	}()
+
+	// a second replica sharing the same Redis serves the request
+	ctx, cancelOther := context.WithCancel(context.Background())
+	defer cancelOther()
+	other, err := NewRedisStore(ctx, zap.NewNop(), config.SessionRedisConfig{
+		ClusterType: cnst.RedisClusterTypeSingle,
+		Addr:        mr.Addr(),
+		Topic:       "unla:sessions",
+		Prefix:      "testsess",
+		TTL:         5 * time.Second,
+	})
+	assert.NoError(t, err)
+	defer other.Close()
+
+	received := make(chan *Cancellation, 10)
+	other.OnCancel(func(cancellation *Cancellation) { received <- cancellation })
+
+	want := &Cancellation{SessionID: "sid", RequestID: `"req-1"`, Reason: "user aborted"}
+	// the stream reader of the other replica starts at the latest entry, publish until it is reading
+	assert.Eventually(t, func() bool {
+		assert.NoError(t, store.PublishCancel(ctx, want))
+		select {
+		case got := <-received:
+			assert.Equal(t, want, got)
+			return true
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestRedisStore_PublishResourceUnsubscribe(t *testing.T) {
+	store, mr, cancel := newTestRedisStore(t)
+	defer func() {
+		cancel()
+		_ = store.Close()
+		mr.Close()
+	}()
+
+	// a second replica sharing the same Redis holds the subscription
+	ctx, cancelOther := context.WithCancel(context.Background())
+	defer cancelOther()
+	other, err := NewRedisStore(ctx, zap.NewNop(), config.SessionRedisConfig{
+		ClusterType: cnst.RedisClusterTypeSingle,
+		Addr:        mr.Addr(),
+		Topic:       "unla:sessions",
+		Prefix:      "testsess",
+		TTL:         5 * time.Second,
+	})
+	assert.NoError(t, err)
+	defer other.Close()
+
+	received := make(chan *ResourceUnsubscription, 10)
+	other.OnResourceUnsubscribe(func(unsubscription *ResourceUnsubscription) { received <- unsubscription })
+
+	want := &ResourceUnsubscription{SessionID: "sid", Prefix: "/res", URI: "docs://readme"}
+	assert.Eventually(t, func() bool {
+		assert.NoError(t, store.PublishResourceUnsubscribe(ctx, want))
+		select {
+		case got := <-received:
+			assert.Equal(t, want, got)
+			return true
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestRedisStore_OnUnregister(t *testing.T) {
+	store, mr, cancel := newTestRedisStore(t)
+	defer func() {
+		cancel()
+		_ = store.Close()
+		mr.Close()
+	}()
+
+	// every replica learns about sessions unregistered on another one
+	ctx, cancelOther := context.WithCancel(context.Background())
+	defer cancelOther()
+	other, err := NewRedisStore(ctx, zap.NewNop(), config.SessionRedisConfig{
+		ClusterType: cnst.RedisClusterTypeSingle,
+		Addr:        mr.Addr(),
+		Topic:       "unla:sessions",
+		Prefix:      "testsess",
+		TTL:         5 * time.Second,
+	})
+	assert.NoError(t, err)
+	defer other.Close()
+
+	unregistered := make(chan string, 10)
+	other.OnUnregister(func(id string) { unregistered <- id })
+
+	assert.Eventually(t, func() bool {
+		_, err := store.Register(ctx, &Meta{ID: "sid"})
+		assert.NoError(t, err)
+		assert.NoError(t, store.Unregister(ctx, "sid"))
+		select {
+		case id := <-unregistered:
+			assert.Equal(t, "sid", id)
+			return true
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestRedisStore_TouchAndSweep(t *testing.T) {
+	store, mr, cancel := newTestRedisStore(t)
+	defer func() {
+		cancel()
+		_ = store.Close()
+		mr.Close()
+	}()
+	var (
+		active int
+		reaped []*Meta
+	)
+	store.OnSweep(func(n int, metas []*Meta) { active, reaped = n, metas })
+
+	ctx := context.Background()
+	idle, err := store.Register(ctx, &Meta{ID: "idle", Type: "sse"})
+	require.NoError(t, err)
+	_, err = store.Register(ctx, &Meta{ID: "busy", Type: "streamable"})
+	require.NoError(t, err)
+
+	// Redis expires the session that was not renewed within the TTL
+	mr.FastForward(3 * time.Second)
+	require.NoError(t, store.Touch(ctx, "busy"))
+	mr.FastForward(3 * time.Second)
+	assert.ErrorIs(t, store.Touch(ctx, "idle"), ErrSessionNotFound)
+
+	store.sweep(ctx)
+	assert.Equal(t, 1, active)
+	require.Len(t, reaped, 1)
+	assert.Equal(t, "idle", reaped[0].ID)
+	// the event stream of the reaped session ends
+	_, open := <-idle.EventQueue()
+	assert.False(t, open)
+	ids, err := store.client.SMembers(ctx, store.prefix+"ids").Result()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"busy"}, ids)
+}
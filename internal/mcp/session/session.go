@@ -26,8 +26,39 @@ type Meta struct {
 	Type      string       `json:"type"`       // Connection type, e.g., "sse", "streamable"
 	Request   *RequestInfo `json:"request"`    // Request information
 	Extra     []byte       `json:"extra"`      // Optional serialized extra data
+
+	ProtocolVersion string `json:"protocol_version,omitempty"` // MCP protocol version negotiated at initialization
+	LogLevel        string `json:"log_level,omitempty"`        // Minimum level of notifications/message, none are sent while empty
+}
+
+// Cancellation asks for an in-flight request of a session to be aborted.
+type Cancellation struct {
+	SessionID string `json:"session_id"`
+	RequestID string `json:"request_id"`       // JSON encoding of the JSON-RPC request id
+	Reason    string `json:"reason,omitempty"` // Optional reason given by the client
 }
 
+// CancelHandler is called for every cancellation published to the store.
+type CancelHandler func(cancellation *Cancellation)
+
+// ResourceUnsubscription asks for the subscription of a session to a resource of a
+// proxied MCP server to be dropped by the instance holding it.
+type ResourceUnsubscription struct {
+	SessionID string `json:"session_id"`
+	Prefix    string `json:"prefix"`
+	URI       string `json:"uri"`
+}
+
+// ResourceUnsubscribeHandler is called for every resource unsubscription published to the store.
+type ResourceUnsubscribeHandler func(unsubscription *ResourceUnsubscription)
+
+// UnregisterHandler is called with the ID of every session unregistered from the store.
+type UnregisterHandler func(id string)
+
+// SweepHandler is called after every sweep of the janitor of a store with the number of
+// sessions left on this instance and the sessions reaped for being idle.
+type SweepHandler func(active int, reaped []*Meta)
+
 // Connection represents an active session connection capable of sending messages.
 type Connection interface {
 	// EventQueue returns a read-only channel where outbound messages are published.
@@ -51,9 +82,43 @@ type Store interface {
 	// Get retrieves an active session connection by ID.
 	Get(ctx context.Context, id string) (Connection, error)
 
+	// Update replaces the metadata of a registered session. Connections retrieved
+	// afterwards carry the new metadata.
+	Update(ctx context.Context, meta *Meta) error
+
 	// Unregister removes a session connection by ID.
 	Unregister(ctx context.Context, id string) error
 
 	// List returns all currently active session connections.
 	List(ctx context.Context) ([]Connection, error)
+
+	// ListLocal returns the session connections registered on this instance. With a store
+	// shared by several replicas, each replica delivers the events of its own sessions, so
+	// broadcasting to local sessions on every replica reaches each session once.
+	ListLocal(ctx context.Context) ([]Connection, error)
+
+	// PublishCancel delivers a cancellation to the handler of every instance sharing
+	// the store, including this one.
+	PublishCancel(ctx context.Context, cancellation *Cancellation) error
+
+	// OnCancel sets the handler receiving published cancellations.
+	OnCancel(handler CancelHandler)
+
+	// PublishResourceUnsubscribe delivers a resource unsubscription to the handler of
+	// every instance sharing the store, including this one.
+	PublishResourceUnsubscribe(ctx context.Context, unsubscription *ResourceUnsubscription) error
+
+	// OnResourceUnsubscribe sets the handler receiving published resource unsubscriptions.
+	OnResourceUnsubscribe(handler ResourceUnsubscribeHandler)
+
+	// Touch marks the session as active, sessions idle for longer than the idle timeout
+	// are reaped. It returns ErrSessionNotFound once the session is gone.
+	Touch(ctx context.Context, id string) error
+
+	// OnSweep sets the handler called after every sweep of the janitor.
+	OnSweep(handler SweepHandler)
+
+	// OnUnregister sets the handler called on every instance sharing the store when a
+	// session is unregistered. Sessions reaped for being idle are reported to OnSweep.
+	OnUnregister(handler UnregisterHandler)
 }
@@ -89,6 +89,7 @@ func (s *DBStore) Create(_ context.Context, server *config.MCPConfig) error {
 					"servers":     model.Servers,
 					"tools":       model.Tools,
 					"prompts":     model.Prompts,
+					"resources":   model.Resources,
 					"mcp_servers": model.McpServers,
 					"updated_at":  time.Now(),
 				}).Error; err != nil {
@@ -409,6 +410,7 @@ func (s *DBStore) ListVersions(ctx context.Context, tenant, name string) ([]*con
 			Servers:    v.Servers,
 			Tools:      v.Tools,
 			Prompts:    v.Prompts,
+			Resources:  v.Resources,
 			McpServers: v.McpServers,
 			IsActive:   v.Version == activeVersionMap[v.Name],
 			Hash:       v.Hash,
@@ -465,6 +467,7 @@ func (s *DBStore) SetActiveVersion(ctx context.Context, tenant, name string, ver
 			Servers:    versionModel.Servers,
 			Tools:      versionModel.Tools,
 			Prompts:    versionModel.Prompts,
+			Resources:  versionModel.Resources,
 			McpServers: versionModel.McpServers,
 			Hash:       versionModel.Hash,
 		}
@@ -482,12 +485,13 @@ func (s *DBStore) SetActiveVersion(ctx context.Context, tenant, name string, ver
 			Servers:    versionModel.Servers,
 			Tools:      versionModel.Tools,
 			Prompts:    versionModel.Prompts,
+			Resources:  versionModel.Resources,
 			McpServers: versionModel.McpServers,
 		}
 
 		if err := tx.Clauses(clause.OnConflict{
 			Columns:   []clause.Column{{Name: "name"}, {Name: "tenant"}},
-			DoUpdates: clause.AssignmentColumns([]string{"updated_at", "routers", "servers", "tools", "prompts", "mcp_servers", "deleted_at"}),
+			DoUpdates: clause.AssignmentColumns([]string{"updated_at", "routers", "servers", "tools", "prompts", "resources", "mcp_servers", "deleted_at"}),
 		}).Create(mcpConfig).Error; err != nil {
 			return err
 		}
@@ -23,6 +23,7 @@ type MCPConfig struct {
 	Servers    string         `gorm:"type:text; column:servers"`
 	Tools      string         `gorm:"type:text; column:tools"`
 	Prompts    string         `gorm:"type:text; column:prompts"`
+	Resources  string         `gorm:"type:text; column:resources"`
 	McpServers string         `gorm:"type:text; column:mcp_servers"`
 	DeletedAt  gorm.DeletedAt `gorm:"index"`
 }
@@ -56,6 +57,11 @@ func (m *MCPConfig) ToMCPConfig() (*config.MCPConfig, error) {
 			return nil, err
 		}
 	}
+	if len(m.Resources) > 0 {
+		if err := json.Unmarshal([]byte(m.Resources), &cfg.Resources); err != nil {
+			return nil, err
+		}
+	}
 	if len(m.McpServers) > 0 {
 		if err := json.Unmarshal([]byte(m.McpServers), &cfg.McpServers); err != nil {
 			return nil, err
@@ -87,6 +93,11 @@ func FromMCPConfig(cfg *config.MCPConfig) (*MCPConfig, error) {
 		return nil, err
 	}
 
+	resources, err := json.Marshal(cfg.Resources)
+	if err != nil {
+		return nil, err
+	}
+
 	mcpServers, err := json.Marshal(cfg.McpServers)
 	if err != nil {
 		return nil, err
@@ -101,6 +112,7 @@ func FromMCPConfig(cfg *config.MCPConfig) (*MCPConfig, error) {
 		Servers:    string(servers),
 		Tools:      string(tools),
 		Prompts:    string(prompts),
+		Resources:  string(resources),
 		McpServers: string(mcpServers),
 	}, nil
 }
@@ -146,6 +158,7 @@ type MCPConfigVersion struct {
 	Servers    string          `gorm:"type:text;column:servers"`
 	Tools      string          `gorm:"type:text;column:tools"`
 	Prompts    string          `gorm:"type:text; column:prompts"`
+	Resources  string          `gorm:"type:text;column:resources"`
 	McpServers string          `gorm:"type:text;column:mcp_servers"`
 	Hash       string          `gorm:"column:hash;not null"` // hash of the configuration content
 	DeletedAt  gorm.DeletedAt  `gorm:"index"`
@@ -180,6 +193,11 @@ func (m *MCPConfigVersion) ToMCPConfig() (*config.MCPConfig, error) {
 			return nil, err
 		}
 	}
+	if len(m.Resources) > 0 {
+		if err := json.Unmarshal([]byte(m.Resources), &cfg.Resources); err != nil {
+			return nil, err
+		}
+	}
 	if len(m.McpServers) > 0 {
 		if err := json.Unmarshal([]byte(m.McpServers), &cfg.McpServers); err != nil {
 			return nil, err
@@ -204,6 +222,9 @@ func FromMCPConfigVersion(cfg *config.MCPConfig, version int, createdBy string,
 	if cfg.Prompts == nil {
 		cfg.Prompts = []config.PromptConfig{}
 	}
+	if cfg.Resources == nil {
+		cfg.Resources = []config.ResourceConfig{}
+	}
 	if cfg.McpServers == nil {
 		cfg.McpServers = []config.MCPServerConfig{}
 	}
@@ -228,6 +249,11 @@ func FromMCPConfigVersion(cfg *config.MCPConfig, version int, createdBy string,
 		return nil, err
 	}
 
+	resources, err := json.Marshal(cfg.Resources)
+	if err != nil {
+		return nil, err
+	}
+
 	mcpServers, err := json.Marshal(cfg.McpServers)
 	if err != nil {
 		return nil, err
@@ -235,6 +261,10 @@ func FromMCPConfigVersion(cfg *config.MCPConfig, version int, createdBy string,
 
 	// Calculate hash of the configuration content
 	content := fmt.Sprintf("%s%s%s%s%s", routers, servers, tools, prompts, mcpServers)
+	if len(cfg.Resources) > 0 {
+		// keep the hashes of configurations without resources unchanged
+		content += string(resources)
+	}
 	hash := sha256.Sum256([]byte(content))
 
 	return &MCPConfigVersion{
@@ -248,6 +278,7 @@ func FromMCPConfigVersion(cfg *config.MCPConfig, version int, createdBy string,
 		Servers:    string(servers),
 		Tools:      string(tools),
 		Prompts:    string(prompts),
+		Resources:  string(resources),
 		McpServers: string(mcpServers),
 		Hash:       hex.EncodeToString(hash[:]),
 	}, nil
@@ -265,6 +296,7 @@ func (m *MCPConfigVersion) ToConfigVersion() *config.MCPConfigVersion {
 		Servers:    m.Servers,
 		Tools:      m.Tools,
 		Prompts:    m.Prompts,
+		Resources:  m.Resources,
 		McpServers: m.McpServers,
 		Hash:       m.Hash,
 	}
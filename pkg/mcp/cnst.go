@@ -2,9 +2,10 @@ package mcp
 
 // Protocol versions
 const (
+	ProtocolVersion20250618 = "2025-06-18"
 	ProtocolVersion20250326 = "2025-03-26"
 	ProtocolVersion20241105 = "2024-11-05"
-	LatestProtocolVersion   = ProtocolVersion20241105
+	LatestProtocolVersion   = ProtocolVersion20250618
 	JSPNRPCVersion          = "2.0"
 )
 
@@ -38,6 +39,19 @@ const (
 	ResourcesList          = "resources/list"
 	ResourcesTemplatesList = "resources/templates/list"
 	ResourcesRead          = "resources/read"
+	ResourcesSubscribe     = "resources/subscribe"
+	ResourcesUnsubscribe   = "resources/unsubscribe"
+
+	CompletionComplete = "completion/complete"
+)
+
+// Completion references
+const (
+	RefPrompt   = "ref/prompt"
+	RefResource = "ref/resource"
+
+	// MaxCompletionValues is the maximum number of values a completion result may contain
+	MaxCompletionValues = 100
 )
 
 // Error codes for MCP protocol
@@ -54,14 +68,19 @@ const (
 const (
 	ErrorCodeConnectionClosed = -32000
 	ErrorCodeRequestTimeout   = -32001
+	ErrorCodeResourceNotFound = -32002
+	ErrorCodeRateLimited      = -32029
 )
 
 const (
-	HeaderMcpSessionID = "Mcp-Session-Id"
+	HeaderMcpSessionID       = "Mcp-Session-Id"
+	HeaderMcpProtocolVersion = "MCP-Protocol-Version"
+	HeaderLastEventID        = "Last-Event-ID"
 )
 
 const (
-	TextContentType  = "text"
-	ImageContentType = "image"
-	AudioContentType = "audio"
+	TextContentType     = "text"
+	ImageContentType    = "image"
+	AudioContentType    = "audio"
+	ResourceContentType = "resource"
 )
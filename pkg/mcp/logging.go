@@ -0,0 +1,39 @@
+package mcp
+
+import "slices"
+
+// Logging levels of notifications/message, as defined by RFC 5424
+const (
+	LoggingLevelDebug     = "debug"
+	LoggingLevelInfo      = "info"
+	LoggingLevelNotice    = "notice"
+	LoggingLevelWarning   = "warning"
+	LoggingLevelError     = "error"
+	LoggingLevelCritical  = "critical"
+	LoggingLevelAlert     = "alert"
+	LoggingLevelEmergency = "emergency"
+)
+
+// loggingLevels lists the logging levels by increasing severity
+var loggingLevels = []string{
+	LoggingLevelDebug,
+	LoggingLevelInfo,
+	LoggingLevelNotice,
+	LoggingLevelWarning,
+	LoggingLevelError,
+	LoggingLevelCritical,
+	LoggingLevelAlert,
+	LoggingLevelEmergency,
+}
+
+// IsValidLoggingLevel reports whether level is one of the logging levels
+func IsValidLoggingLevel(level string) bool {
+	return slices.Contains(loggingLevels, level)
+}
+
+// LoggingLevelEnabled reports whether a message of the given level is sent to a client
+// that set the minimum level min
+func LoggingLevelEnabled(min, level string) bool {
+	minRank := slices.Index(loggingLevels, min)
+	return minRank >= 0 && slices.Index(loggingLevels, level) >= minRank
+}
@@ -0,0 +1,19 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggingLevelEnabled(t *testing.T) {
+	assert.True(t, LoggingLevelEnabled(LoggingLevelWarning, LoggingLevelError))
+	assert.True(t, LoggingLevelEnabled(LoggingLevelWarning, LoggingLevelWarning))
+	assert.False(t, LoggingLevelEnabled(LoggingLevelWarning, LoggingLevelInfo))
+	// nothing is sent before the client sets a level
+	assert.False(t, LoggingLevelEnabled("", LoggingLevelEmergency))
+	assert.False(t, LoggingLevelEnabled(LoggingLevelDebug, "verbose"))
+
+	assert.True(t, IsValidLoggingLevel(LoggingLevelNotice))
+	assert.False(t, IsValidLoggingLevel("trace"))
+}
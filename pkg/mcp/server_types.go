@@ -2,6 +2,7 @@ package mcp
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -53,10 +54,16 @@ type (
 	ToolSchema struct {
 		// The name of the tool
 		Name string `json:"name"`
+		// A human-readable name for display, since protocol version 2025-06-18
+		Title string `json:"title,omitempty"`
 		// A human-readable description of the tool
 		Description string `json:"description"`
+		// Icons clients may display for the tool
+		Icons []Icon `json:"icons,omitempty"`
 		// A JSON Schema object defining the expected parameters for the tool
 		InputSchema ToolInputSchema `json:"inputSchema"`
+		// An optional JSON Schema object defining the structure of the tool's structured output
+		OutputSchema *ToolOutputSchema `json:"outputSchema,omitempty"`
 		// Annotations for the tool
 		Annotations *ToolAnnotations `json:"annotations,omitempty"`
 		// Meta information for the tool
@@ -82,6 +89,13 @@ type (
 		Enum       []any          `json:"enum,omitempty"`
 	}
 
+	// ToolOutputSchema describes the structuredContent of a tool's results
+	ToolOutputSchema struct {
+		Type       string         `json:"type"`
+		Properties map[string]any `json:"properties,omitempty"`
+		Required   []string       `json:"required,omitempty"`
+	}
+
 	// ListToolsResult represents the result of a tools/list request
 	ListToolsResult struct {
 		Tools []ToolSchema `json:"tools"`
@@ -137,7 +151,9 @@ type (
 	// CallToolResult represents the result of a tools/call request
 	CallToolResult struct {
 		Content []Content `json:"content"`
-		IsError bool      `json:"isError"`
+		// An optional JSON object matching the outputSchema of the tool
+		StructuredContent map[string]any `json:"structuredContent,omitempty"`
+		IsError           bool           `json:"isError"`
 	}
 
 	// ImplementationSchema describes the name and version of an MCP implementation
@@ -181,6 +197,7 @@ type (
 		Prompts      PromptsCapabilitySchema      `json:"prompts"`
 		Resources    ResourcesCapabilitySchema    `json:"resources"`
 		Tools        ToolsCapabilitySchema        `json:"tools"`
+		Completions  CompletionsCapabilitySchema  `json:"completions"`
 	}
 
 	ExperimentalCapabilitySchema struct {
@@ -200,6 +217,10 @@ type (
 		ListChanged bool `json:"listChanged"`
 	}
 
+	// CompletionsCapabilitySchema represents argument completion capabilities
+	CompletionsCapabilitySchema struct {
+	}
+
 	// ToolsCapabilitySchema represents tools-related capabilities
 	ToolsCapabilitySchema struct {
 		ListChanged bool `json:"listChanged"`
@@ -249,11 +270,20 @@ type (
 
 	PromptSchema struct {
 		Name           string                 `json:"name" yaml:"name"`
+		Title          string                 `json:"title,omitempty" yaml:"title,omitempty"`
 		Description    string                 `json:"description" yaml:"description"`
+		Icons          []Icon                 `json:"icons,omitempty" yaml:"icons,omitempty"`
 		Arguments      []PromptArgumentSchema `json:"arguments" yaml:"arguments"`
 		PromptResponse []PromptResponseSchema `json:"promptResponse,omitempty" yaml:"promptResponse,omitempty"`
 	}
 
+	// Icon represents an icon a client may display for a tool or prompt
+	Icon struct {
+		Src      string   `json:"src" yaml:"src"`
+		MimeType string   `json:"mimeType,omitempty" yaml:"mimeType,omitempty"`
+		Sizes    []string `json:"sizes,omitempty" yaml:"sizes,omitempty"`
+	}
+
 	PromptArgumentSchema struct {
 		Name        string `json:"name" yaml:"name"`
 		Description string `json:"description" yaml:"description"`
@@ -265,9 +295,144 @@ type (
 		Content PromptResponseContentSchema `json:"content" yaml:"content"`
 	}
 
+	// PromptResponseContentSchema is the content of a prompt message. Text is set for
+	// text content, Data and MimeType for image and audio content and Resource for an
+	// embedded resource.
 	PromptResponseContentSchema struct {
-		Type string `json:"type" yaml:"type"`
-		Text string `json:"text" yaml:"text"`
+		Type     string            `json:"type" yaml:"type"`
+		Text     string            `json:"text,omitempty" yaml:"text,omitempty"`
+		Data     string            `json:"data,omitempty" yaml:"data,omitempty"`
+		MimeType string            `json:"mimeType,omitempty" yaml:"mimeType,omitempty"`
+		Resource *ResourceContents `json:"resource,omitempty" yaml:"resource,omitempty"`
+	}
+
+	// GetPromptResult is the result of prompts/get
+	GetPromptResult struct {
+		Description string                 `json:"description,omitempty"`
+		Messages    []PromptResponseSchema `json:"messages"`
+	}
+
+	// ResourceSchema represents a resource with a fixed URI
+	ResourceSchema struct {
+		URI         string `json:"uri"`
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+		MimeType    string `json:"mimeType,omitempty"`
+	}
+
+	// ResourceTemplateSchema represents resources addressed by an RFC 6570 URI template
+	ResourceTemplateSchema struct {
+		URITemplate string `json:"uriTemplate"`
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+		MimeType    string `json:"mimeType,omitempty"`
+	}
+
+	// ListResourcesResult represents the result of a resources/list request
+	ListResourcesResult struct {
+		Resources []ResourceSchema `json:"resources"`
+	}
+
+	// ListResourceTemplatesResult represents the result of a resources/templates/list request
+	ListResourceTemplatesResult struct {
+		ResourceTemplates []ResourceTemplateSchema `json:"resourceTemplates"`
+	}
+
+	// ReadResourceParams represents the parameters of a resources/read request
+	ReadResourceParams struct {
+		URI string `json:"uri"`
+	}
+
+	// SubscribeResourceParams represents the parameters of a resources/subscribe or
+	// resources/unsubscribe request
+	SubscribeResourceParams struct {
+		URI string `json:"uri"`
+	}
+
+	// ResourceUpdatedNotificationParams represents the parameters of a
+	// notifications/resources/updated notification
+	ResourceUpdatedNotificationParams struct {
+		URI string `json:"uri"`
+	}
+
+	// CancelledNotificationParams represents the parameters of a notifications/cancelled
+	// notification
+	CancelledNotificationParams struct {
+		RequestID any    `json:"requestId"`
+		Reason    string `json:"reason,omitempty"`
+	}
+
+	// ProgressNotificationParams represents the parameters of a notifications/progress
+	// notification. Progress increases with every notification of a request.
+	ProgressNotificationParams struct {
+		ProgressToken any     `json:"progressToken"`
+		Progress      float64 `json:"progress"`
+		Total         float64 `json:"total,omitempty"`
+		Message       string  `json:"message,omitempty"`
+	}
+
+	// SetLevelParams represents the parameters of a logging/setLevel request
+	SetLevelParams struct {
+		Level string `json:"level"`
+	}
+
+	// LoggingMessageNotificationParams represents the parameters of a notifications/message
+	// notification
+	LoggingMessageNotificationParams struct {
+		Level  string `json:"level"`
+		Logger string `json:"logger,omitempty"`
+		Data   any    `json:"data"`
+	}
+
+	// CompleteParams represents the parameters of a completion/complete request
+	CompleteParams struct {
+		Ref      CompleteReference `json:"ref"`
+		Argument CompleteArgument  `json:"argument"`
+		Context  *CompleteContext  `json:"context,omitempty"`
+	}
+
+	// CompleteReference identifies the prompt (ref/prompt) or resource template
+	// (ref/resource) whose argument is completed
+	CompleteReference struct {
+		Type string `json:"type"`
+		Name string `json:"name,omitempty"`
+		URI  string `json:"uri,omitempty"`
+	}
+
+	// CompleteArgument is the argument being completed and its partial value
+	CompleteArgument struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	// CompleteContext holds the arguments already resolved by the client
+	CompleteContext struct {
+		Arguments map[string]string `json:"arguments,omitempty"`
+	}
+
+	// CompleteResult represents the result of a completion/complete request
+	CompleteResult struct {
+		Completion CompletionValues `json:"completion"`
+	}
+
+	// CompletionValues are the suggested values of an argument
+	CompletionValues struct {
+		Values  []string `json:"values"`
+		Total   int      `json:"total,omitempty"`
+		HasMore bool     `json:"hasMore,omitempty"`
+	}
+
+	// ReadResourceResult represents the result of a resources/read request
+	ReadResourceResult struct {
+		Contents []ResourceContents `json:"contents"`
+	}
+
+	// ResourceContents represents the contents of a resource, either text or base64 encoded binary data
+	ResourceContents struct {
+		URI      string `json:"uri"`
+		MimeType string `json:"mimeType,omitempty"`
+		Text     string `json:"text,omitempty"`
+		Blob     string `json:"blob,omitempty"`
 	}
 
 	// CapabilitiesInfo represents the capabilities information for an MCP server
@@ -365,6 +530,59 @@ func (i *AudioContent) GetType() string {
 	return AudioContentType
 }
 
+// UnmarshalJSON decodes the content items into their concrete types
+func (r *CallToolResult) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Content           []json.RawMessage `json:"content"`
+		StructuredContent map[string]any    `json:"structuredContent"`
+		IsError           bool              `json:"isError"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	r.IsError = raw.IsError
+	r.StructuredContent = raw.StructuredContent
+	r.Content = make([]Content, 0, len(raw.Content))
+	for _, item := range raw.Content {
+		var typed struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(item, &typed); err != nil {
+			return err
+		}
+		var content Content
+		switch typed.Type {
+		case TextContentType:
+			content = &TextContent{}
+		case ImageContentType:
+			content = &ImageContent{}
+		case AudioContentType:
+			content = &AudioContent{}
+		default:
+			return fmt.Errorf("unknown content type: %s", typed.Type)
+		}
+		if err := json.Unmarshal(item, content); err != nil {
+			return err
+		}
+		r.Content = append(r.Content, content)
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, text contents keep the text field even when empty
+func (r ResourceContents) MarshalJSON() ([]byte, error) {
+	if r.Blob != "" {
+		type blobContents ResourceContents
+		return json.Marshal(blobContents(r))
+	}
+	return json.Marshal(struct {
+		URI      string `json:"uri"`
+		MimeType string `json:"mimeType,omitempty"`
+		Text     string `json:"text"`
+	}{URI: r.URI, MimeType: r.MimeType, Text: r.Text})
+}
+
 // NewCallToolResult creates a new CallToolResult
 // @param content the content of the result
 // @param isError indicates if the result is an error
@@ -98,3 +98,28 @@ func TestNewCallToolResultVariants(t *testing.T) {
 		assert.Equal(t, "boom", txt.Text)
 	}
 }
+
+func TestCallToolResult_UnmarshalJSON(t *testing.T) {
+	in := NewCallToolResult([]Content{
+		&TextContent{Type: TextContentType, Text: "hi"},
+		&ImageContent{Type: ImageContentType, Data: "BASE64", MimeType: "image/png"},
+		&AudioContent{Type: AudioContentType, Data: "AUD64", MimeType: "audio/mpeg"},
+	}, true)
+	data, err := json.Marshal(in)
+	assert.NoError(t, err)
+
+	var out CallToolResult
+	assert.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, in, &out)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"content":[{"type":"video"}]}`), &out))
+
+	in = NewCallToolResultText(`{"temp":21}`)
+	in.StructuredContent = map[string]any{"temp": 21.0}
+	data, err = json.Marshal(in)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"structuredContent":{"temp":21}`)
+	out = CallToolResult{}
+	assert.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, in, &out)
+}
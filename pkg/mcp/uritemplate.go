@@ -0,0 +1,96 @@
+package mcp
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// URITemplate matches URIs against an RFC 6570 URI template. Simple expressions like
+// {id} match a single path segment, reserved expressions like {+path} match the rest
+// of the URI including slashes. Other operators are not supported.
+type URITemplate struct {
+	raw      string
+	re       *regexp.Regexp
+	vars     []string
+	reserved []bool
+}
+
+var uriTemplateVarRe = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// CompileURITemplate parses a URI template
+func CompileURITemplate(tmpl string) (*URITemplate, error) {
+	t := &URITemplate{raw: tmpl}
+	var pattern strings.Builder
+	pattern.WriteString("^")
+
+	rest := tmpl
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			if strings.IndexByte(rest, '}') >= 0 {
+				return nil, fmt.Errorf("unexpected '}' in URI template %q", tmpl)
+			}
+			pattern.WriteString(regexp.QuoteMeta(rest))
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated expression in URI template %q", tmpl)
+		}
+		pattern.WriteString(regexp.QuoteMeta(rest[:start]))
+
+		expr := rest[start+1 : start+end]
+		reserved := strings.HasPrefix(expr, "+")
+		name := strings.TrimPrefix(expr, "+")
+		if !uriTemplateVarRe.MatchString(name) {
+			return nil, fmt.Errorf("unsupported expression {%s} in URI template %q", expr, tmpl)
+		}
+		if reserved {
+			pattern.WriteString("(.+)")
+		} else {
+			pattern.WriteString("([^/?#]+)")
+		}
+		t.vars = append(t.vars, name)
+		t.reserved = append(t.reserved, reserved)
+		rest = rest[start+end+1:]
+	}
+	pattern.WriteString("$")
+
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid URI template %q: %w", tmpl, err)
+	}
+	t.re = re
+	return t, nil
+}
+
+// String returns the template as written
+func (t *URITemplate) String() string {
+	return t.raw
+}
+
+// Variables returns the names of the template variables in order of appearance
+func (t *URITemplate) Variables() []string {
+	return t.vars
+}
+
+// Match reports whether the URI matches the template and returns the values of its variables
+func (t *URITemplate) Match(uri string) (map[string]string, bool) {
+	m := t.re.FindStringSubmatch(uri)
+	if m == nil {
+		return nil, false
+	}
+	values := make(map[string]string, len(t.vars))
+	for i, name := range t.vars {
+		value := m[i+1]
+		if !t.reserved[i] {
+			if unescaped, err := url.PathUnescape(value); err == nil {
+				value = unescaped
+			}
+		}
+		values[name] = value
+	}
+	return values, true
+}
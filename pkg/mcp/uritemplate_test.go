@@ -0,0 +1,34 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURITemplate(t *testing.T) {
+	tmpl, err := CompileURITemplate("repo://{owner}/{name}/blob/{+path}")
+	require.NoError(t, err)
+	assert.Equal(t, "repo://{owner}/{name}/blob/{+path}", tmpl.String())
+
+	values, ok := tmpl.Match("repo://amoy%20lab/unla/blob/docs/README.md")
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"owner": "amoy lab", "name": "unla", "path": "docs/README.md"}, values)
+
+	_, ok = tmpl.Match("repo://amoylab/unla/tree/main")
+	assert.False(t, ok)
+	_, ok = tmpl.Match("repo://a/b/c/blob/x")
+	assert.False(t, ok)
+
+	// literal parts are not regular expressions
+	tmpl, err = CompileURITemplate("weather://city.{id}")
+	require.NoError(t, err)
+	_, ok = tmpl.Match("weather://cityX42")
+	assert.False(t, ok)
+
+	for _, bad := range []string{"x://{id", "x://id}", "x://{?q}", "x://{}"} {
+		_, err := CompileURITemplate(bad)
+		assert.Error(t, err, bad)
+	}
+}
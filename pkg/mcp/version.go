@@ -0,0 +1,30 @@
+package mcp
+
+import "slices"
+
+// SupportedProtocolVersions lists the protocol versions the gateway negotiates, newest first
+var SupportedProtocolVersions = []string{
+	ProtocolVersion20250618,
+	ProtocolVersion20250326,
+	ProtocolVersion20241105,
+}
+
+// IsSupportedProtocolVersion reports whether the gateway speaks the given protocol version
+func IsSupportedProtocolVersion(version string) bool {
+	return slices.Contains(SupportedProtocolVersions, version)
+}
+
+// NegotiateProtocolVersion returns the version requested by the client when it is supported,
+// otherwise the latest version, leaving it to the client to disconnect if it cannot use it
+func NegotiateProtocolVersion(requested string) string {
+	if IsSupportedProtocolVersion(requested) {
+		return requested
+	}
+	return LatestProtocolVersion
+}
+
+// ProtocolVersionAtLeast reports whether version is the same as or newer than minimum.
+// Protocol versions are dates in YYYY-MM-DD form, so they order as plain strings.
+func ProtocolVersionAtLeast(version, minimum string) bool {
+	return version >= minimum
+}
@@ -0,0 +1,18 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateProtocolVersion(t *testing.T) {
+	assert.Equal(t, ProtocolVersion20250618, NegotiateProtocolVersion(ProtocolVersion20250618))
+	assert.Equal(t, ProtocolVersion20241105, NegotiateProtocolVersion(ProtocolVersion20241105))
+	assert.Equal(t, LatestProtocolVersion, NegotiateProtocolVersion("2023-01-01"))
+	assert.Equal(t, LatestProtocolVersion, NegotiateProtocolVersion(""))
+
+	assert.True(t, ProtocolVersionAtLeast(ProtocolVersion20250618, ProtocolVersion20250326))
+	assert.True(t, ProtocolVersionAtLeast(ProtocolVersion20250326, ProtocolVersion20250326))
+	assert.False(t, ProtocolVersionAtLeast(ProtocolVersion20241105, ProtocolVersion20250326))
+}
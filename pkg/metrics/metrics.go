@@ -25,6 +25,15 @@ type Metrics struct {
 	toolExecCnt  *prometheus.CounterVec
 	toolExecDur  *prometheus.HistogramVec
 	toolExecInfl *prometheus.GaugeVec
+	breakerState *prometheus.GaugeVec
+	breakerRej   *prometheus.CounterVec
+	toolCacheCnt *prometheus.CounterVec
+	rateLimitRej *prometheus.CounterVec
+	sessionsAct  prometheus.Gauge
+	sessionsReap *prometheus.CounterVec
+	stdioState   *prometheus.GaugeVec
+	stdioRestart *prometheus.GaugeVec
+	stdioViolate *prometheus.CounterVec
 }
 
 func New(cfg config.MetricsConfig) *Metrics {
@@ -50,6 +59,32 @@ func New(cfg config.MetricsConfig) *Metrics {
 	toolExecInfl := prometheus.NewGaugeVec(prometheus.GaugeOpts{Namespace: ns, Name: "tool_execution_inflight_requests"}, []string{"tool_name"})
 	r.MustRegister(toolExecCnt, toolExecDur, toolExecInfl)
 
+	// Circuit breaker state per upstream: 0 closed, 1 half-open, 2 open
+	breakerState := prometheus.NewGaugeVec(prometheus.GaugeOpts{Namespace: ns, Name: "circuit_breaker_state"}, []string{"key"})
+	breakerRej := prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: ns, Name: "circuit_breaker_rejections_total"}, []string{"key"})
+	r.MustRegister(breakerState, breakerRej)
+
+	toolCacheCnt := prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: ns, Name: "tool_cache_requests_total"}, []string{"tool_name", "result"})
+	r.MustRegister(toolCacheCnt)
+
+	// level is "router" or "tool", key_by is what the bucket is shared by
+	rateLimitRej := prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: ns, Name: "rate_limit_rejections_total"}, []string{"prefix", "level", "key_by"})
+	r.MustRegister(rateLimitRej)
+
+	// Sessions of this instance, reaped ones went idle for longer than the idle timeout
+	sessionsAct := prometheus.NewGauge(prometheus.GaugeOpts{Namespace: ns, Name: "sessions_active"})
+	sessionsReap := prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: ns, Name: "sessions_reaped_total"}, []string{"type"})
+	r.MustRegister(sessionsAct, sessionsReap)
+
+	// Supervised stdio servers: 1 for the current state of each server, 0 for the others
+	stdioState := prometheus.NewGaugeVec(prometheus.GaugeOpts{Namespace: ns, Name: "stdio_server_state"}, []string{"prefix", "server", "state"})
+	stdioRestart := prometheus.NewGaugeVec(prometheus.GaugeOpts{Namespace: ns, Name: "stdio_server_restarts"}, []string{"prefix", "server"})
+	r.MustRegister(stdioState, stdioRestart)
+
+	// Stdio server processes killed for exceeding a limit of their sandbox
+	stdioViolate := prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: ns, Name: "stdio_sandbox_violations_total"}, []string{"prefix", "server", "limit"})
+	r.MustRegister(stdioViolate)
+
 	return &Metrics{
 		registry:     r,
 		namespace:    ns,
@@ -62,6 +97,15 @@ func New(cfg config.MetricsConfig) *Metrics {
 		toolExecCnt:  toolExecCnt,
 		toolExecDur:  toolExecDur,
 		toolExecInfl: toolExecInfl,
+		breakerState: breakerState,
+		breakerRej:   breakerRej,
+		toolCacheCnt: toolCacheCnt,
+		rateLimitRej: rateLimitRej,
+		sessionsAct:  sessionsAct,
+		sessionsReap: sessionsReap,
+		stdioState:   stdioState,
+		stdioRestart: stdioRestart,
+		stdioViolate: stdioViolate,
 	}
 }
 
@@ -85,6 +129,49 @@ func (m *Metrics) ToolExecDone(toolName string, since time.Time, status *string)
 	m.toolExecInfl.WithLabelValues(toolName).Dec()
 }
 
+func (m *Metrics) CircuitBreakerState(key string, state int) {
+	m.breakerState.WithLabelValues(key).Set(float64(state))
+}
+
+func (m *Metrics) CircuitBreakerRejected(key string) {
+	m.breakerRej.WithLabelValues(key).Inc()
+}
+
+func (m *Metrics) ToolCacheRequest(toolName string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.toolCacheCnt.WithLabelValues(toolName, result).Inc()
+}
+
+func (m *Metrics) RateLimitRejected(prefix, level, keyBy string) {
+	m.rateLimitRej.WithLabelValues(prefix, level, keyBy).Inc()
+}
+
+func (m *Metrics) SessionsActive(n int) {
+	m.sessionsAct.Set(float64(n))
+}
+
+func (m *Metrics) SessionReaped(sessionType string) {
+	m.sessionsReap.WithLabelValues(sessionType).Inc()
+}
+
+func (m *Metrics) StdioServerState(prefix, server, state string, states []string, restarts int) {
+	for _, st := range states {
+		v := 0.0
+		if st == state {
+			v = 1
+		}
+		m.stdioState.WithLabelValues(prefix, server, st).Set(v)
+	}
+	m.stdioRestart.WithLabelValues(prefix, server).Set(float64(restarts))
+}
+
+func (m *Metrics) StdioSandboxViolation(prefix, server, limit string) {
+	m.stdioViolate.WithLabelValues(prefix, server, limit).Inc()
+}
+
 func (m *Metrics) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		route := c.FullPath()
@@ -169,6 +169,66 @@ export function MCPServersConfig({
     updateConfig({ mcpServers: updatedServers });
   };
 
+  const updateHeader = (serverIndex: number, headerIndex: number, field: 'key' | 'value', value: string) => {
+    const updatedServers = [...mcpServers];
+    const server = updatedServers[serverIndex];
+    const headers = { ...server.headers };
+    const key = Object.keys(headers)[headerIndex];
+
+    if (field === 'key') {
+      if (key !== value) {
+        headers[value] = headers[key];
+        delete headers[key];
+      }
+    } else {
+      headers[key] = value;
+    }
+
+    updatedServers[serverIndex] = {
+      ...server,
+      headers
+    };
+
+    updateConfig({ mcpServers: updatedServers });
+  };
+
+  const addHeader = (serverIndex: number) => {
+    const updatedServers = [...mcpServers];
+    const server = updatedServers[serverIndex];
+    const headers = { ...server.headers };
+    const existingKeys = Object.keys(headers);
+
+    let newKey = "Authorization";
+    let count = 1;
+    while (existingKeys.includes(newKey)) {
+      newKey = `X-Header-${count}`;
+      count++;
+    }
+    headers[newKey] = "";
+
+    updatedServers[serverIndex] = {
+      ...server,
+      headers
+    };
+
+    updateConfig({ mcpServers: updatedServers });
+  };
+
+  const removeHeader = (serverIndex: number, headerIndex: number) => {
+    const updatedServers = [...mcpServers];
+    const server = updatedServers[serverIndex];
+    const headers = { ...server.headers };
+    const key = Object.keys(headers)[headerIndex];
+    delete headers[key];
+
+    updatedServers[serverIndex] = {
+      ...server,
+      headers
+    };
+
+    updateConfig({ mcpServers: updatedServers });
+  };
+
   const addServer = () => {
     const newServer: MCPServerConfig = {
       type: "stdio",
@@ -238,6 +298,7 @@ export function MCPServersConfig({
               >
                 <SelectItem key="onDemand" textValue={t('gateway.policy_on_demand')}>{t('gateway.policy_on_demand')}</SelectItem>
                 <SelectItem key="onStart" textValue={t('gateway.policy_on_start')}>{t('gateway.policy_on_start')}</SelectItem>
+                <SelectItem key="perSession" textValue={t('gateway.policy_per_session')}>{t('gateway.policy_per_session')}</SelectItem>
               </Select>
 
               {(server.type === 'stdio' || !server.type) && (
@@ -312,6 +373,46 @@ export function MCPServersConfig({
                     value={server.url || ''}
                     onChange={(e) => updateServer(index, 'url', e.target.value)}
                   />
+
+                  <div className="mt-4">
+                    <h4 className="text-sm font-medium mb-2">{t('gateway.headers')}</h4>
+                    <div className="flex flex-col gap-2">
+                      {Object.entries(server.headers || {}).map(([key, value], headerIndex) => (
+                        <div key={headerIndex} className="flex items-center gap-2">
+                          <Input
+                            className="flex-1"
+                            value={key}
+                            onChange={(e) => updateHeader(index, headerIndex, 'key', e.target.value)}
+                            placeholder={t('gateway.header_name_placeholder')}
+                          />
+                          <Input
+                            className="flex-1"
+                            value={String(value)}
+                            onChange={(e) => updateHeader(index, headerIndex, 'value', e.target.value)}
+                            placeholder={t('gateway.header_value_placeholder')}
+                          />
+                          <Button
+                            color="danger"
+                            variant="flat"
+                            isIconOnly
+                            onPress={() => removeHeader(index, headerIndex)}
+                          >
+                            <LocalIcon icon="lucide:x" />
+                          </Button>
+                        </div>
+                      ))}
+
+                      <Button
+                        color="primary"
+                        variant="flat"
+                        size="sm"
+                        startContent={<LocalIcon icon="lucide:plus" />}
+                        onPress={() => addHeader(index)}
+                      >
+                        {t('gateway.add_header')}
+                      </Button>
+                    </div>
+                  </div>
                 </div>
               )}
 
@@ -3,11 +3,22 @@ export interface PromptArgument {
   name: string;
   description: string;
   required: boolean;
+  enum?: string[];
+}
+
+export interface PromptResourceContent {
+  uri: string;
+  mimeType?: string;
+  text?: string;
+  blob?: string;
 }
 
 export interface PromptResponseContent {
   type: string;
   text: string;
+  data?: string;
+  mimeType?: string;
+  resource?: PromptResourceContent;
 }
 
 export interface PromptResponse {